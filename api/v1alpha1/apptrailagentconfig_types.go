@@ -0,0 +1,94 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AgentFilterSpec holds the resource filter settings that can be changed at runtime.
+// Unset fields keep the value from the agent's flags and --config file.
+type AgentFilterSpec struct {
+	// WatchNamespaces lists the namespaces to watch, supporting glob patterns
+	// +optional
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+
+	// ExcludeNamespaces lists the namespaces to ignore, supporting glob patterns
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// InvertNamespaceFilter watches only the namespaces matching ExcludeNamespaces
+	// +optional
+	InvertNamespaceFilter *bool `json:"invertNamespaceFilter,omitempty"`
+
+	// WatchNamespaceLabels lists namespace label key=value pairs a namespace must match to be watched
+	// +optional
+	WatchNamespaceLabels []string `json:"watchNamespaceLabels,omitempty"`
+
+	// ExcludeNamespaceLabels lists namespace label key=value pairs that exclude a namespace
+	// +optional
+	ExcludeNamespaceLabels []string `json:"excludeNamespaceLabels,omitempty"`
+
+	// RequireLabels lists labels a resource must have to be tracked
+	// +optional
+	RequireLabels []string `json:"requireLabels,omitempty"`
+
+	// ExcludeLabels lists label key=value pairs that exclude a resource
+	// +optional
+	ExcludeLabels []string `json:"excludeLabels,omitempty"`
+
+	// DryRun logs resources that would be filtered out instead of filtering them
+	// +optional
+	DryRun *bool `json:"dryRun,omitempty"`
+}
+
+// AppTrailAgentConfigSpec defines the runtime configuration of the agent
+type AppTrailAgentConfigSpec struct {
+	// Filter overrides the resource filter settings
+	// +optional
+	Filter AgentFilterSpec `json:"filter,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// AppTrailAgentConfig is the Schema for the apptrailagentconfigs API
+// The agent watches the object named by its --agent-config flag and applies changes without a restart.
+type AppTrailAgentConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired configuration of the agent
+	// +optional
+	Spec AppTrailAgentConfigSpec `json:"spec,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// AppTrailAgentConfigList contains a list of AppTrailAgentConfig
+type AppTrailAgentConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []AppTrailAgentConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AppTrailAgentConfig{}, &AppTrailAgentConfigList{})
+}
@@ -20,6 +20,15 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Condition types reported in WorkloadRolloutStateStatus
+const (
+	// ConditionRolloutTimedOut is True once a rollout ran longer than its timeout. While the
+	// timeout is approaching it stays False with reason TimeoutApproaching.
+	ConditionRolloutTimedOut = "RolloutTimedOut"
+	// ConditionRolloutFailed is True while the workload's rollout phase is failed
+	ConditionRolloutFailed = "RolloutFailed"
+)
+
 // WorkloadRolloutStateSpec defines the desired state of WorkloadRolloutState
 type WorkloadRolloutStateSpec struct {
 	// WorkloadNamespace is the namespace of the workload being tracked
@@ -55,12 +64,35 @@ type WorkloadRolloutStateSpec struct {
 	// LastSentAt is the timestamp when the last event was sent
 	// +optional
 	LastSentAt *metav1.Time `json:"lastSentAt,omitempty"`
+
+	// OnDeleteNotified is set once the StatefulSet was reported as using the OnDelete
+	// update strategy, so the report is not repeated after agent restarts
+	// +optional
+	OnDeleteNotified bool `json:"onDeleteNotified,omitempty"`
+}
+
+// WorkloadRolloutStateStatus defines the observed state of WorkloadRolloutState
+type WorkloadRolloutStateStatus struct {
+	// Phase is the rollout phase last sent for the workload
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Conditions explain why a rollout is slow or has failed
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Workload",type=string,JSONPath=`.spec.workloadName`
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.workloadKind`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // WorkloadRolloutState is the Schema for the workloadrolloutstates API
-// This resource tracks rollout timing state for workloads (Deployments, StatefulSets, DaemonSets) across the cluster
+// This resource tracks rollout timing state for workloads (Deployments, StatefulSets, DaemonSets) across the cluster.
 type WorkloadRolloutState struct {
 	metav1.TypeMeta `json:",inline"`
 
@@ -71,6 +103,10 @@ type WorkloadRolloutState struct {
 	// spec defines the desired state of WorkloadRolloutState
 	// +required
 	Spec WorkloadRolloutStateSpec `json:"spec"`
+
+	// status reports the rollout phase and conditions
+	// +optional
+	Status WorkloadRolloutStateStatus `json:"status,omitzero"`
 }
 
 // +kubebuilder:object:root=true
@@ -21,15 +21,146 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentFilterSpec) DeepCopyInto(out *AgentFilterSpec) {
+	*out = *in
+	if in.WatchNamespaces != nil {
+		in, out := &in.WatchNamespaces, &out.WatchNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InvertNamespaceFilter != nil {
+		in, out := &in.InvertNamespaceFilter, &out.InvertNamespaceFilter
+		*out = new(bool)
+		**out = **in
+	}
+	if in.WatchNamespaceLabels != nil {
+		in, out := &in.WatchNamespaceLabels, &out.WatchNamespaceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeNamespaceLabels != nil {
+		in, out := &in.ExcludeNamespaceLabels, &out.ExcludeNamespaceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequireLabels != nil {
+		in, out := &in.RequireLabels, &out.RequireLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeLabels != nil {
+		in, out := &in.ExcludeLabels, &out.ExcludeLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentFilterSpec.
+func (in *AgentFilterSpec) DeepCopy() *AgentFilterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentFilterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppTrailAgentConfig) DeepCopyInto(out *AppTrailAgentConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppTrailAgentConfig.
+func (in *AppTrailAgentConfig) DeepCopy() *AppTrailAgentConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AppTrailAgentConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppTrailAgentConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppTrailAgentConfigList) DeepCopyInto(out *AppTrailAgentConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AppTrailAgentConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppTrailAgentConfigList.
+func (in *AppTrailAgentConfigList) DeepCopy() *AppTrailAgentConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(AppTrailAgentConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AppTrailAgentConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppTrailAgentConfigSpec) DeepCopyInto(out *AppTrailAgentConfigSpec) {
+	*out = *in
+	in.Filter.DeepCopyInto(&out.Filter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppTrailAgentConfigSpec.
+func (in *AppTrailAgentConfigSpec) DeepCopy() *AppTrailAgentConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AppTrailAgentConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkloadRolloutState) DeepCopyInto(out *WorkloadRolloutState) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadRolloutState.
@@ -101,3 +232,25 @@ func (in *WorkloadRolloutStateSpec) DeepCopy() *WorkloadRolloutStateSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadRolloutStateStatus) DeepCopyInto(out *WorkloadRolloutStateStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadRolloutStateStatus.
+func (in *WorkloadRolloutStateStatus) DeepCopy() *WorkloadRolloutStateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadRolloutStateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -17,11 +17,16 @@ limitations under the License.
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"errors"
 	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -30,18 +35,27 @@ import (
 	"github.com/apptrail-sh/agent/internal/filter"
 	"github.com/apptrail-sh/agent/internal/heartbeat"
 	"github.com/apptrail-sh/agent/internal/hooks"
+	"github.com/apptrail-sh/agent/internal/hooks/cloudevents"
+	"github.com/apptrail-sh/agent/internal/hooks/cloudlogging"
 	"github.com/apptrail-sh/agent/internal/hooks/controlplane"
+	"github.com/apptrail-sh/agent/internal/hooks/prometheus"
 	"github.com/apptrail-sh/agent/internal/hooks/pubsub"
+	"github.com/apptrail-sh/agent/internal/hooks/s3"
 	"github.com/apptrail-sh/agent/internal/hooks/slack"
+	"github.com/apptrail-sh/agent/internal/hooks/victorops"
+	apptrailwebhook "github.com/apptrail-sh/agent/internal/hooks/webhook"
 	"github.com/apptrail-sh/agent/internal/model"
 
+	"github.com/apptrail-sh/agent/internal/preflight"
 	"github.com/apptrail-sh/agent/internal/reconciler"
 	"github.com/apptrail-sh/agent/internal/reconciler/infrastructure"
+	"github.com/apptrail-sh/agent/internal/trigger"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"golang.org/x/net/http/httpproxy"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -61,26 +75,130 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+// preflightTimeout bounds the startup RBAC check so an unreachable API server fails fast
+const preflightTimeout = 30 * time.Second
+
 // config holds all command-line configuration
 type config struct {
-	metricsAddr          string
-	enableLeaderElection bool
-	probeAddr            string
-	secureMetrics        bool
-	enableHTTP2          bool
-	slackWebhookURL      string
-	controlPlaneURL      string
-	controlPlaneAPIKey   string
-	clusterID            string
-	pubsubTopic          string
-	trackNodes           bool
-	trackPods            bool
-	watchNamespaces      string
-	excludeNamespaces    string
-	requireLabels        string
-	excludeLabels        string
-	heartbeatEnabled     bool
-	heartbeatInterval    time.Duration
+	metricsAddr               string
+	enableLeaderElection      bool
+	leaseDuration             time.Duration
+	renewDeadline             time.Duration
+	retryPeriod               time.Duration
+	probeAddr                 string
+	triggerAddr               string
+	triggerToken              string
+	eventReplayBufferSize     int
+	filterClusterPrefix       string
+	excludeClusterPrefix      string
+	publisherRoutingFile      string
+	secureMetrics             bool
+	enableHTTP2               bool
+	slackWebhookURL           string
+	slackRateLimit            float64
+	slackBotToken             string
+	slackChannel              string
+	slackUpdateWindow         time.Duration
+	slackOnCallScheduleURL    string
+	slackDefaultOnCall        string
+	onCallCacheTTL            time.Duration
+	argoCDServerURL           string
+	fluxDashboardURL          string
+	webhookURL                string
+	webhookSigningSecret      string
+	webhookSigningSecretFile  string
+	cloudEventsFormat         string
+	victorOpsRoutingKey       string
+	victorOpsIntegrationKey   string
+	controlPlaneURL           string
+	controlPlaneURLs          string
+	controlPlaneAPIKey        string
+	controlPlaneTokenFile     string
+	controlPlaneOAuth2URL     string
+	controlPlaneOAuth2ID      string
+	controlPlaneOAuth2Secret  string
+	controlPlaneOAuth2Scopes  string
+	controlPlaneCACert        string
+	controlPlaneInsecure      bool
+	httpProxy                 string
+	httpsProxy                string
+	noProxy                   string
+	controlPlaneCloudEvents   bool
+	controlPlaneCompress      bool
+	controlPlaneCompressLevel int
+	controlPlaneTimeout       time.Duration
+	controlPlaneKeepAlive     time.Duration
+	controlPlaneMaxIdleConns  int
+	controlPlaneIdleTimeout   time.Duration
+	controlPlaneNoKeepAlive   bool
+	controlPlaneMaxRetries    int
+	controlPlaneRetryWait     time.Duration
+	controlPlaneRetryMaxWait  time.Duration
+	clusterID                 string
+	projectID                 string // Falls back to the project detected with the cluster ID
+	pubsubTopic               string
+	pubsubHeartbeatTopic      string
+	pubsubDeadLetterTopic     string
+	pubsubNodeTopic           string
+	pubsubPodTopic            string
+	pubsubMaxOutstandingMsgs  int
+	pubsubMaxOutstandingBytes int64
+	pubsubOrderingStrategy    string
+	s3Bucket                  string
+	s3Endpoint                string
+	s3Region                  string
+	s3BatchSize               int
+	s3FlushInterval           time.Duration
+	cloudLoggingProject       string
+	cloudLoggingLogName       string
+	pushgatewayURL            string
+	pushgatewayJobName        string
+	pushgatewayBatchSize      int
+	trackNodes                bool
+	trackPods                 bool
+	pendingAlertThreshold     time.Duration
+	restartAlertThreshold     int
+	nodePodCountThreshold     int
+	nodePodCountPercent       float64
+	restartAlertEscalations   string
+	pendingCheckInterval      time.Duration
+	trackNamespaces           bool
+	trackQuotas               bool
+	quotaWarningThreshold     float64
+	trackVirtualMachines      bool
+	watchNamespaces           string
+	excludeNamespaces         string
+	excludeNamespacesPreset   string
+	excludeNamespacesFile     string
+	excludedNamespaces        []string // Resolved from the preset, file and flag; not a flag
+	invertNamespaceFilter     bool
+	requireLabels             string
+	excludeLabels             string
+	watchNamespaceLabels      string
+	excludeNamespaceLabels    string
+	filterDryRun              bool
+	configFile                string
+	agentConfigName           string
+	resourceDropPolicy        string
+	aggregationThreshold      int
+	eventTimestampJitterMs    int
+	maxEventPayloadSize       int
+	rolloutTimeout            time.Duration
+	rateLimitBaseDelay        time.Duration
+	rateLimitMaxDelay         time.Duration
+	versionFromImage          string
+	versionLabels             string
+	passthroughAnnotations    string
+	warmUpTimeout             time.Duration
+	heartbeatEnabled          bool
+	heartbeatInterval         time.Duration
+	fullSyncInterval          time.Duration
+	skipPreflight             bool
+}
+
+// trackInfrastructure reports whether any infrastructure resource tracking is enabled
+func (c config) trackInfrastructure() bool {
+	return c.trackNodes || c.trackPods || c.trackNamespaces || c.trackQuotas
 }
 
 func init() {
@@ -95,26 +213,43 @@ func main() {
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zap.Options{Development: true})))
 
 	mgr := setupManager(cfg)
+	runPreflight(mgr, cfg)
 	agentVersion := buildinfo.AgentVersion()
 
-	// Resolve cluster ID (explicit flag takes priority, then auto-detection)
-	cfg.clusterID = resolveClusterID(cfg.clusterID)
+	// Resolve cluster and project ID (explicit flags take priority, then auto-detection)
+	cfg.clusterID, cfg.projectID = resolveClusterID(cfg.clusterID, cfg.projectID)
+
+	cfg.excludedNamespaces = resolveExcludedNamespaces(cfg)
+	model.SetTimestampJitter(model.JitterConfig{Max: time.Duration(cfg.eventTimestampJitterMs) * time.Millisecond})
 
 	// Setup channels for event publishing
 	publisherChan := make(chan model.WorkloadUpdate, 100)
 	resourceEventChan := make(chan model.ResourceEventPayload, 1000)
+	// Deletions skip the queue behind resourceEventChan
+	resourceDeletionChan := make(chan model.ResourceEventPayload, 1000)
 
 	// Setup publishers
-	publishers, resourcePublishers, heartbeatPublishers := setupPublishers(cfg, agentVersion)
-	startPublisherQueues(cfg, publisherChan, resourceEventChan, publishers, resourcePublishers)
+	publishers, resourcePublishers, heartbeatPublishers, healthChecks, closers := setupPublishers(cfg, agentVersion)
+
+	// Shared by routing and pod filtering; started only when one of them needs namespace labels
+	namespaceWatcher := infrastructure.NewNamespaceWatcher(mgr.GetClient())
+	router := setupPublisherRouter(mgr, cfg, publishers, namespaceWatcher)
+	// Full-state syncs bypass aggregation and publish to resourcePublishers directly
+	queueEventChan, closers := setupNamespaceAggregator(cfg, resourceEventChan, resourcePublishers, closers)
+	replayBuffer := startPublisherQueues(cfg, publisherChan, queueEventChan, resourceDeletionChan, publishers, router,
+		resourcePublishers, agentVersion)
 
 	// Setup heartbeat sender
-	setupHeartbeatSender(mgr, cfg, heartbeatPublishers, agentVersion)
+	setupHeartbeatSender(mgr, cfg, heartbeatPublishers, healthChecks, agentVersion)
 
 	// Setup reconcilers
+	reloader := setupFilterReloader(mgr, cfg)
 	controllerNamespace := getControllerNamespace()
-	setupWorkloadReconcilers(mgr, cfg, publisherChan, controllerNamespace)
-	setupInfrastructureReconcilers(mgr, cfg, resourceEventChan, agentVersion)
+	workloadReconcilers := setupWorkloadReconcilers(mgr, cfg, reloader, publisherChan, resourceEventChan,
+		controllerNamespace, agentVersion)
+	setupReconcileTrigger(mgr, cfg, workloadReconcilers, replayBuffer)
+	infrastructureSources := setupInfrastructureReconcilers(mgr, cfg, reloader, resourceEventChan, resourceDeletionChan, namespaceWatcher, agentVersion)
+	setupFullStateSync(mgr, cfg, workloadReconcilers, infrastructureSources, resourcePublishers, agentVersion)
 
 	// +kubebuilder:scaffold:builder
 
@@ -123,8 +258,11 @@ func main() {
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
+		closePublishers(closers)
 		os.Exit(1)
 	}
+
+	closePublishers(closers)
 }
 
 func parseFlags() config {
@@ -133,40 +271,234 @@ func parseFlags() config {
 	flag.StringVar(&cfg.metricsAddr, "metrics-bind-address", ":8080", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&cfg.probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&cfg.triggerAddr, "trigger-bind-address", ":8082",
+		"The address the full reconcile trigger (POST /api/v1/reconcile) binds to; only served with --trigger-token")
+	flag.StringVar(&cfg.triggerToken, "trigger-token", os.Getenv("APPTRAIL_TRIGGER_TOKEN"),
+		"Shared secret expected in the X-Trigger-Token header of full reconcile triggers (or APPTRAIL_TRIGGER_TOKEN env var)")
+	flag.IntVar(&cfg.eventReplayBufferSize, "event-replay-buffer-size", hooks.DefaultEventReplayBufferSize,
+		"Recent workload events served at GET /api/v1/events/recent with --trigger-token (0 disables)")
 	flag.BoolVar(&cfg.enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&cfg.leaseDuration, "leader-election-lease-duration", 15*time.Second,
+		"How long non-leaders wait before trying to acquire a lease that was not renewed")
+	flag.DurationVar(&cfg.renewDeadline, "leader-election-renew-deadline", 10*time.Second,
+		"How long the leader keeps retrying to renew its lease before giving it up (must be below the lease duration)")
+	flag.DurationVar(&cfg.retryPeriod, "leader-election-retry-period", 2*time.Second,
+		"How long leader election clients wait between attempts")
 	flag.BoolVar(&cfg.secureMetrics, "metrics-secure", false,
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&cfg.enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&cfg.filterClusterPrefix, "filter-cluster-prefix", "",
+		"Only publish workload events when the cluster ID starts with this prefix")
+	flag.StringVar(&cfg.excludeClusterPrefix, "exclude-cluster-prefix", "",
+		"Do not publish workload events when the cluster ID starts with this prefix")
+	flag.StringVar(&cfg.publisherRoutingFile, "publisher-routing-file", "",
+		"YAML file routing workload events to publishers by namespace pattern; namespaces can override it with the apptrail.sh/publisher label")
 	flag.StringVar(&cfg.slackWebhookURL, "slack-webhook-url", "", "The URL to send slack notifications to")
+	flag.Float64Var(&cfg.slackRateLimit, "slack-rate-limit", slack.DefaultRateLimit,
+		"Maximum Slack messages per second")
+	flag.StringVar(&cfg.slackBotToken, "slack-bot-token", os.Getenv("SLACK_BOT_TOKEN"),
+		"Slack Bot API token; enables editing rollout messages in place as phases change")
+	flag.StringVar(&cfg.slackChannel, "slack-channel", "",
+		"Slack channel to post rollout messages to (required with --slack-bot-token)")
+	flag.DurationVar(&cfg.slackUpdateWindow, "slack-update-window", slack.DefaultUpdateWindow,
+		"How long rollout messages are edited in place before a new message is posted")
+	flag.StringVar(&cfg.slackOnCallScheduleURL, "slack-oncall-schedule-url", "",
+		"URL returning the Slack user ID of the current on-call engineer as {\"slackUserId\": \"U...\"}; they are @mentioned in failed rollout messages")
+	flag.StringVar(&cfg.slackDefaultOnCall, "slack-default-oncall-mention", "",
+		"Mention used in failed rollout messages when the on-call lookup fails or no schedule URL is set, e.g. <!subteam^S0123ABCD>")
+	flag.DurationVar(&cfg.onCallCacheTTL, "oncall-cache-ttl", slack.DefaultOnCallCacheTTL,
+		"How long the on-call user looked up at --slack-oncall-schedule-url is cached")
+	flag.StringVar(&cfg.argoCDServerURL, "argocd-server-url", "",
+		"ArgoCD server URL; Slack messages link ArgoCD-managed workloads to their application")
+	flag.StringVar(&cfg.fluxDashboardURL, "flux-dashboard-url", "",
+		"Weave GitOps dashboard URL; Slack messages link Flux-managed workloads to their Kustomization or HelmRelease")
+	flag.StringVar(&cfg.webhookURL, "webhook-url", "", "The URL to POST workload events to as JSON")
+	flag.StringVar(&cfg.webhookSigningSecret, "webhook-signing-secret", os.Getenv("WEBHOOK_SIGNING_SECRET"),
+		"Secret used to sign webhook payloads with HMAC-SHA256 (X-AppTrail-Signature header)")
+	flag.StringVar(&cfg.webhookSigningSecretFile, "webhook-signing-secret-file", "",
+		"Path to a file containing the webhook signing secret (takes precedence over --webhook-signing-secret)")
+	flag.StringVar(&cfg.cloudEventsFormat, "cloudevents-format", "",
+		"Send webhook events as CloudEvents 1.0: 'structured' (JSON envelope) or 'binary' (ce-* headers); empty sends plain JSON")
+	flag.StringVar(&cfg.victorOpsRoutingKey, "victorops-routing-key", "",
+		"VictorOps (Splunk On-Call) routing key for failed rollout incidents")
+	flag.StringVar(&cfg.victorOpsIntegrationKey, "victorops-integration-key", os.Getenv("VICTOROPS_INTEGRATION_KEY"),
+		"VictorOps REST integration key (required with --victorops-routing-key)")
 	flag.StringVar(&cfg.controlPlaneURL, "controlplane-url", "",
 		"The URL of the AppTrail Control Plane (e.g., http://controlplane:3000/ingest/v1/agent/events)")
+	flag.StringVar(&cfg.controlPlaneURLs, "controlplane-urls", "",
+		"Comma-separated Control Plane URLs tried in round-robin order on failure; alternative to --controlplane-url")
 	flag.StringVar(&cfg.controlPlaneAPIKey, "api-key", os.Getenv("APPTRAIL_API_KEY"),
 		"API key for authenticating with the Control Plane")
+	flag.StringVar(&cfg.controlPlaneTokenFile, "controlplane-token-file", "",
+		"Path to a bearer token file (e.g. a projected ServiceAccount token) reloaded before it expires")
+	flag.StringVar(&cfg.controlPlaneOAuth2URL, "controlplane-oauth2-token-url", "",
+		"OAuth2 token endpoint for obtaining Control Plane access tokens with the client credentials flow")
+	flag.StringVar(&cfg.controlPlaneOAuth2ID, "controlplane-oauth2-client-id", "",
+		"OAuth2 client ID for the Control Plane")
+	flag.StringVar(&cfg.controlPlaneOAuth2Secret, "controlplane-oauth2-client-secret", os.Getenv("CONTROLPLANE_OAUTH2_CLIENT_SECRET"),
+		"OAuth2 client secret for the Control Plane (defaults to CONTROLPLANE_OAUTH2_CLIENT_SECRET)")
+	flag.StringVar(&cfg.controlPlaneOAuth2Scopes, "controlplane-oauth2-scopes", "",
+		"Comma-separated OAuth2 scopes requested for Control Plane access tokens")
+	flag.StringVar(&cfg.controlPlaneCACert, "controlplane-ca-cert", "",
+		"Path to a PEM CA bundle used to verify the Control Plane certificate")
+	flag.BoolVar(&cfg.controlPlaneInsecure, "controlplane-insecure-skip-verify", false,
+		"Skip Control Plane TLS certificate verification (development only)")
+	flag.StringVar(&cfg.httpProxy, "http-proxy", os.Getenv("HTTP_PROXY"),
+		"Proxy URL for HTTP requests to the Control Plane (defaults to HTTP_PROXY)")
+	flag.StringVar(&cfg.httpsProxy, "https-proxy", os.Getenv("HTTPS_PROXY"),
+		"Proxy URL for HTTPS requests to the Control Plane and gRPC connections to Pub/Sub (defaults to HTTPS_PROXY)")
+	flag.StringVar(&cfg.noProxy, "no-proxy", os.Getenv("NO_PROXY"),
+		"Comma-separated hosts, domains or CIDRs that bypass --http-proxy and --https-proxy (defaults to NO_PROXY)")
+	flag.BoolVar(&cfg.controlPlaneCloudEvents, "controlplane-cloudevents", false,
+		"Wrap workload events sent to the Control Plane in a CloudEvents 1.0 envelope")
+	flag.BoolVar(&cfg.controlPlaneCompress, "controlplane-compress", false,
+		"Gzip-compress all request bodies sent to the Control Plane")
+	flag.IntVar(&cfg.controlPlaneCompressLevel, "controlplane-compress-level", gzip.DefaultCompression,
+		"Gzip compression level used with --controlplane-compress (-2 Huffman only, -1 default, 0 none, 1 fastest to 9 best)")
+	flag.DurationVar(&cfg.controlPlaneTimeout, "controlplane-timeout", controlplane.DefaultHTTPTimeout,
+		"Timeout for each request attempt to the Control Plane")
+	flag.DurationVar(&cfg.controlPlaneKeepAlive, "controlplane-keepalive", controlplane.DefaultKeepAlive,
+		"TCP keep-alive period for Control Plane connections")
+	flag.IntVar(&cfg.controlPlaneMaxIdleConns, "controlplane-max-idle-conns", controlplane.DefaultMaxIdleConns,
+		"Maximum idle connections kept open to the Control Plane")
+	flag.DurationVar(&cfg.controlPlaneIdleTimeout, "controlplane-idle-conn-timeout", controlplane.DefaultIdleConnTimeout,
+		"How long an idle Control Plane connection is kept before closing")
+	flag.BoolVar(&cfg.controlPlaneNoKeepAlive, "controlplane-disable-keepalive", false,
+		"Open a new connection for every Control Plane request instead of reusing connections")
+	flag.IntVar(&cfg.controlPlaneMaxRetries, "controlplane-max-retries", controlplane.DefaultMaxRetries,
+		"Retries for a failed Control Plane request (429, 5xx or connection error); negative disables retries")
+	flag.DurationVar(&cfg.controlPlaneRetryWait, "controlplane-retry-wait", controlplane.DefaultRetryWaitTime,
+		"Base wait before retrying a Control Plane request, doubled with jitter on each retry")
+	flag.DurationVar(&cfg.controlPlaneRetryMaxWait, "controlplane-retry-max-wait", controlplane.DefaultRetryMaxWaitTime,
+		"Maximum wait between Control Plane request retries")
 	flag.StringVar(&cfg.clusterID, "cluster-id", os.Getenv("CLUSTER_ID"),
 		"Unique identifier for this cluster (e.g., staging.stg01)")
+	flag.StringVar(&cfg.projectID, "project-id", os.Getenv("PROJECT_ID"),
+		"Cloud project ID reported with events (auto-detected with the cluster ID when unset)")
 	flag.StringVar(&cfg.pubsubTopic, "pubsub-topic", os.Getenv("PUBSUB_TOPIC"),
 		"Google Cloud Pub/Sub topic path (projects/<project>/topics/<topic>)")
+	flag.StringVar(&cfg.pubsubHeartbeatTopic, "pubsub-heartbeat-topic", os.Getenv("PUBSUB_HEARTBEAT_TOPIC"),
+		"Separate Pub/Sub topic path for heartbeats (defaults to --pubsub-topic)")
+	flag.StringVar(&cfg.pubsubDeadLetterTopic, "pubsub-dead-letter-topic", os.Getenv("PUBSUB_DEAD_LETTER_TOPIC"),
+		"Pub/Sub topic path receiving events that failed to publish after retries")
+	flag.StringVar(&cfg.pubsubNodeTopic, "pubsub-node-topic", os.Getenv("PUBSUB_NODE_TOPIC"),
+		"Separate Pub/Sub topic path for node events (defaults to --pubsub-topic)")
+	flag.StringVar(&cfg.pubsubPodTopic, "pubsub-pod-topic", os.Getenv("PUBSUB_POD_TOPIC"),
+		"Separate Pub/Sub topic path for pod events (defaults to --pubsub-topic)")
+	flag.IntVar(&cfg.pubsubMaxOutstandingMsgs, "pubsub-max-outstanding-messages", pubsub.DefaultMaxOutstandingMessages,
+		"Maximum messages buffered by each Pub/Sub publisher before new events are rejected")
+	flag.Int64Var(&cfg.pubsubMaxOutstandingBytes, "pubsub-max-outstanding-bytes", pubsub.DefaultMaxOutstandingBytes,
+		"Maximum bytes buffered by each Pub/Sub publisher before new events are rejected")
+	flag.StringVar(&cfg.pubsubOrderingStrategy, "pubsub-ordering-strategy", string(pubsub.OrderingWorkload),
+		"Pub/Sub ordering key strategy: workload, namespace, cluster or none (disables ordering)")
+	flag.StringVar(&cfg.s3Bucket, "s3-bucket", os.Getenv("S3_BUCKET"),
+		"S3-compatible bucket to archive resource events to as NDJSON")
+	flag.StringVar(&cfg.s3Endpoint, "s3-endpoint", os.Getenv("S3_ENDPOINT"),
+		"Endpoint override for S3-compatible stores such as DigitalOcean Spaces or MinIO")
+	flag.StringVar(&cfg.s3Region, "s3-region", "",
+		"Region of the S3 bucket (defaults to the AWS SDK region chain)")
+	flag.IntVar(&cfg.s3BatchSize, "s3-batch-size", s3.DefaultBatchSize,
+		"Number of buffered resource events that triggers an S3 upload")
+	flag.DurationVar(&cfg.s3FlushInterval, "s3-flush-interval", s3.DefaultFlushInterval,
+		"Maximum time resource events are buffered before uploading to S3")
+	flag.StringVar(&cfg.cloudLoggingProject, "cloud-logging-project", "",
+		"GCP project to write resource events to as Cloud Logging structured entries")
+	flag.StringVar(&cfg.cloudLoggingLogName, "cloud-logging-log-name", cloudlogging.DefaultLogName,
+		"Cloud Logging log name for resource events")
+	flag.StringVar(&cfg.pushgatewayURL, "pushgateway-url", "",
+		"Prometheus Pushgateway URL to push deployment event metrics to")
+	flag.StringVar(&cfg.pushgatewayJobName, "pushgateway-job-name", prometheus.DefaultJobName,
+		"Job name used when pushing metrics to the Pushgateway")
+	flag.IntVar(&cfg.pushgatewayBatchSize, "pushgateway-batch-size", 1,
+		"Number of events buffered before pushing to the Pushgateway")
+
+	flag.BoolVar(&cfg.trackVirtualMachines, "track-virtual-machines", false,
+		"Track KubeVirt VirtualMachines as workloads (requires the kubevirt.io CRDs)")
 
 	// Infrastructure tracking flags
 	flag.BoolVar(&cfg.trackNodes, "track-nodes", false,
 		"Enable tracking of Kubernetes nodes")
+	flag.BoolVar(&cfg.trackNamespaces, "track-namespaces", false,
+		"Enable namespace lifecycle tracking (creation, deletion, label and phase changes)")
+	flag.BoolVar(&cfg.trackQuotas, "track-quotas", false,
+		"Enable ResourceQuota utilization tracking")
+	flag.Float64Var(&cfg.quotaWarningThreshold, "quota-warning-threshold",
+		infrastructure.DefaultQuotaWarningThreshold,
+		"Used/hard fraction of a ResourceQuota above which a quota warning event is emitted")
+	flag.IntVar(&cfg.nodePodCountThreshold, "node-pod-count-change-threshold", infrastructure.DefaultPodCountChangeThreshold,
+		"Pods a node must gain or lose before a node STATUS_CHANGE event is emitted (0 disables)")
+	flag.Float64Var(&cfg.nodePodCountPercent, "node-pod-count-change-percent", 0,
+		"Percentage change in a node's pod count that emits a node STATUS_CHANGE event (0 disables)")
 	flag.BoolVar(&cfg.trackPods, "track-pods", false,
 		"Enable tracking of Kubernetes pods")
+	flag.DurationVar(&cfg.pendingAlertThreshold, "pending-alert-threshold",
+		infrastructure.DefaultPendingAlertThreshold,
+		"How long a pod may stay Pending before a PENDING_ALERT event is emitted (0 disables)")
+	flag.DurationVar(&cfg.pendingCheckInterval, "pending-check-interval",
+		infrastructure.DefaultPendingCheckInterval,
+		"How often Pending pods are re-checked against the pending alert threshold")
+	flag.IntVar(&cfg.restartAlertThreshold, "pod-restart-alert-threshold", infrastructure.DefaultRestartAlertThreshold,
+		"Total pod restart count at which a HIGH_RESTART_COUNT event is emitted (0 disables restart alerts, including escalations)")
+	flag.StringVar(&cfg.restartAlertEscalations, "pod-restart-alert-escalations", "25,50",
+		"Comma-separated higher restart counts at which HIGH_RESTART_COUNT is emitted again (ignored when the threshold is 0)")
 	flag.StringVar(&cfg.watchNamespaces, "watch-namespaces", "",
 		"Comma-separated list of namespace patterns to watch (e.g., 'production-*,staging-*')")
-	flag.StringVar(&cfg.excludeNamespaces, "exclude-namespaces", "kube-system,kube-public,kube-node-lease",
-		"Comma-separated list of namespace patterns to exclude")
+	flag.StringVar(&cfg.excludeNamespaces, "exclude-namespaces", "",
+		"Comma-separated list of namespace patterns to exclude, merged with --exclude-namespaces-preset")
+	flag.StringVar(&cfg.excludeNamespacesPreset, "exclude-namespaces-preset", filter.PresetMinimal,
+		"Namespaces excluded in addition to --exclude-namespaces: minimal (kube-system, kube-public, kube-node-lease), "+
+			"recommended (minimal plus cert-manager, monitoring, istio-system, linkerd) or none")
+	flag.StringVar(&cfg.excludeNamespacesFile, "exclude-namespaces-file", "",
+		"Path to a file with one namespace pattern to exclude per line, e.g. a mounted ConfigMap")
+	flag.BoolVar(&cfg.invertNamespaceFilter, "invert-namespace-filter", false,
+		"Watch only the namespaces that the namespace filter would otherwise exclude")
 	flag.StringVar(&cfg.requireLabels, "require-labels", "",
 		"Comma-separated list of label keys that must be present (e.g., 'app.kubernetes.io/managed-by')")
 	flag.StringVar(&cfg.excludeLabels, "exclude-labels", "",
 		"Comma-separated list of label key=value pairs that cause exclusion (e.g., 'internal.apptrail.sh/ignore=true')")
+	flag.StringVar(&cfg.watchNamespaceLabels, "watch-namespace-labels", "",
+		"Comma-separated list of namespace label key=value pairs to watch (e.g., 'team=platform')")
+	flag.StringVar(&cfg.excludeNamespaceLabels, "exclude-namespace-labels", "",
+		"Comma-separated list of namespace label key=value pairs that cause exclusion (e.g., 'env=sandbox')")
+	flag.BoolVar(&cfg.filterDryRun, "filter-dry-run", false,
+		"Log resources that would be filtered out instead of filtering them")
+	flag.StringVar(&cfg.configFile, "config", "",
+		"YAML file with resource filter settings overriding the filter flags; reloaded on SIGHUP")
+	flag.StringVar(&cfg.agentConfigName, "agent-config", "",
+		"Name of the cluster-scoped AppTrailAgentConfig whose filter settings override the flags and --config file at runtime")
+	flag.StringVar(&cfg.resourceDropPolicy, "resource-drop-policy", string(hooks.DropNewest),
+		"Which resource event to drop when the publisher buffer is full: 'newest' or 'oldest'")
+	flag.IntVar(&cfg.aggregationThreshold, "aggregation-threshold", 0,
+		"Resource events per namespace per window published individually before the rest are replaced by one AGGREGATED event (0 disables)")
+	flag.IntVar(&cfg.eventTimestampJitterMs, "event-timestamp-jitter-ms", 0,
+		"Random offset of up to this many milliseconds added to event timestamps, spreading simultaneous events (0 disables)")
+	flag.IntVar(&cfg.maxEventPayloadSize, "max-event-payload-size-bytes", hooks.DefaultMaxEventPayloadSize,
+		"Largest encoded workload event sent to the webhook, Pub/Sub and control plane publishers; larger events have labels dropped (0 disables)")
+	flag.DurationVar(&cfg.rolloutTimeout, "rollout-timeout", reconciler.DefaultRolloutTimeout,
+		"How long a rollout may run before it is reported as failed (overridable per workload with apptrail.sh/rollout-timeout)")
+	flag.DurationVar(&cfg.rateLimitBaseDelay, "workload-ratelimit-base-delay", reconciler.DefaultRateLimitBaseDelay,
+		"Requeue delay after a workload's first failed reconcile, doubled on every further failure (must be positive)")
+	flag.DurationVar(&cfg.rateLimitMaxDelay, "workload-ratelimit-max-delay", reconciler.DefaultRateLimitMaxDelay,
+		"Maximum requeue delay of workloads whose reconcile keeps failing (must be at least the base delay)")
+	flag.StringVar(&cfg.versionFromImage, "version-from-image", "",
+		"Container name whose image tag is used as the workload version instead of the app.kubernetes.io/version label")
+	flag.StringVar(&cfg.versionLabels, "version-labels", "",
+		"Comma-separated label keys holding the workload version, the first one set wins (default: app.kubernetes.io/version)")
+	flag.StringVar(&cfg.passthroughAnnotations, "passthrough-annotation-prefixes", "",
+		"Comma-separated annotation key prefixes copied from workloads into events (e.g., 'argocd.argoproj.io/,kustomize.toolkit.fluxcd.io/')")
+	flag.DurationVar(&cfg.warmUpTimeout, "warmup-timeout", 30*time.Second,
+		"Timeout for restoring workload state from WorkloadRolloutState CRDs on startup (0 disables warm-up)")
 	flag.BoolVar(&cfg.heartbeatEnabled, "heartbeat-enabled", true,
 		"Enable periodic heartbeat to control plane (default: true when tracking nodes/pods)")
 	flag.DurationVar(&cfg.heartbeatInterval, "heartbeat-interval", 5*time.Minute,
 		"Interval between heartbeats (default: 5m)")
+	flag.DurationVar(&cfg.fullSyncInterval, "full-sync-interval", hooks.DefaultFullSyncInterval,
+		"Interval between full-state syncs of workloads, nodes and pods to resource publishers (0 disables)")
+	flag.BoolVar(&cfg.skipPreflight, "skip-preflight", false,
+		"Skip the startup check that the agent's RBAC permissions cover every enabled reconciler")
 
 	opts := zap.Options{Development: true}
 	opts.BindFlags(flag.CommandLine)
@@ -200,6 +532,20 @@ func setupManager(cfg config) ctrl.Manager {
 		metricsServerOptions.FilterProvider = filters.WithAuthenticationAndAuthorization
 	}
 
+	if cfg.enableLeaderElection {
+		// Kubernetes requires the leader to give up before others may take over the lease
+		if cfg.renewDeadline >= cfg.leaseDuration {
+			setupLog.Error(nil, "leader-election-renew-deadline must be less than leader-election-lease-duration",
+				"renewDeadline", cfg.renewDeadline, "leaseDuration", cfg.leaseDuration)
+			os.Exit(1)
+		}
+		setupLog.Info("Leader election enabled",
+			"leaseDuration", cfg.leaseDuration,
+			"renewDeadline", cfg.renewDeadline,
+			"retryPeriod", cfg.retryPeriod,
+		)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricsServerOptions,
@@ -207,6 +553,9 @@ func setupManager(cfg config) ctrl.Manager {
 		HealthProbeBindAddress: cfg.probeAddr,
 		LeaderElection:         cfg.enableLeaderElection,
 		LeaderElectionID:       "ce02bd06.apptrail.sh",
+		LeaseDuration:          &cfg.leaseDuration,
+		RenewDeadline:          &cfg.renewDeadline,
+		RetryPeriod:            &cfg.retryPeriod,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -220,28 +569,204 @@ func setupPublishers(cfg config, agentVersion string) (
 	[]hooks.EventPublisher,
 	[]hooks.ResourceEventPublisher,
 	[]hooks.HeartbeatPublisher,
+	[]hooks.HealthCheckable,
+	hooks.CompositeCloser,
 ) {
 	var publishers []hooks.EventPublisher
 	var resourcePublishers []hooks.ResourceEventPublisher
 	var heartbeatPublishers []hooks.HeartbeatPublisher
+	var healthChecks []hooks.HealthCheckable
+	var closers hooks.CompositeCloser
+
+	// addPublisher registers an event publisher and tracks its health for the heartbeat. The
+	// returned tracker records the publisher's resource batches and heartbeats under the same name.
+	addPublisher := func(name string, publisher hooks.EventPublisher) *hooks.PublishTracker {
+		tracked := hooks.NewTrackedPublisher(name, publisher)
+		publishers = append(publishers, tracked)
+		healthChecks = append(healthChecks, tracked)
+		return tracked.PublishTracker
+	}
+	// addResourcePublisher registers a resource event publisher whose batches tracker records
+	addResourcePublisher := func(tracker *hooks.PublishTracker, publisher hooks.ResourceEventPublisher) {
+		resourcePublishers = append(resourcePublishers, hooks.NewTrackedResourcePublisher(tracker, publisher))
+	}
+	// newPublishTracker tracks the health of a publisher without workload events
+	newPublishTracker := func(name string) *hooks.PublishTracker {
+		tracker := hooks.NewPublishTracker(name)
+		healthChecks = append(healthChecks, tracker)
+		return tracker
+	}
 
 	if cfg.slackWebhookURL != "" {
-		slackPublisher := slack.NewSlackPublisher(cfg.slackWebhookURL)
-		publishers = append(publishers, slackPublisher)
+		slackPublisher := slack.NewSlackPublisher(cfg.slackWebhookURL, cfg.slackRateLimit)
+		slackPublisher.ProjectID = cfg.projectID
+		slackPublisher.GitOpsLinks = gitOpsLinks(cfg)
+		if cfg.slackOnCallScheduleURL != "" || cfg.slackDefaultOnCall != "" {
+			slackPublisher.OnCall = slack.NewOnCallResolver(cfg.slackOnCallScheduleURL, cfg.slackDefaultOnCall, cfg.onCallCacheTTL)
+		}
+		addPublisher("slack", slackPublisher)
+		closers = append(closers, slackPublisher)
 		setupLog.Info("Slack publisher enabled", "webhook", cfg.slackWebhookURL)
 	}
 
+	if cfg.slackBotToken != "" {
+		if cfg.slackChannel == "" {
+			setupLog.Error(nil, "slack-channel is required when slack-bot-token is set")
+			os.Exit(1)
+		}
+		slackUpdater := slack.NewSlackMessageUpdater(cfg.slackBotToken, cfg.slackChannel,
+			cfg.slackUpdateWindow, cfg.slackRateLimit)
+		slackUpdater.ProjectID = cfg.projectID
+		slackUpdater.GitOpsLinks = gitOpsLinks(cfg)
+		addPublisher("slack-bot", slackUpdater)
+		closers = append(closers, slackUpdater)
+		setupLog.Info("Slack Bot API publisher enabled",
+			"channel", cfg.slackChannel,
+			"updateWindow", cfg.slackUpdateWindow)
+	}
+
+	if cfg.webhookURL != "" {
+		signingSecret, err := loadWebhookSigningSecret(cfg)
+		if err != nil {
+			setupLog.Error(err, "unable to load webhook signing secret")
+			os.Exit(1)
+		}
+		webhookPublisher := apptrailwebhook.NewWebhookPublisher(apptrailwebhook.WebhookConfig{
+			URL:            cfg.webhookURL,
+			SigningSecret:  signingSecret,
+			ClusterID:      cfg.clusterID,
+			ProjectID:      cfg.projectID,
+			AgentVersion:   agentVersion,
+			MaxPayloadSize: cfg.maxEventPayloadSize,
+		})
+		var publisher hooks.EventPublisher = webhookPublisher
+		if cfg.cloudEventsFormat != "" {
+			format, err := cloudevents.ParseFormat(cfg.cloudEventsFormat)
+			if err != nil {
+				setupLog.Error(err, "invalid cloudevents-format")
+				os.Exit(1)
+			}
+			publisher = cloudevents.NewCloudEventsPublisher(webhookPublisher, cloudevents.CloudEventsConfig{
+				Format:         format,
+				ClusterID:      cfg.clusterID,
+				ProjectID:      cfg.projectID,
+				AgentVersion:   agentVersion,
+				MaxPayloadSize: cfg.maxEventPayloadSize,
+			})
+		}
+		addPublisher("webhook", publisher)
+		closers = append(closers, webhookPublisher)
+		setupLog.Info("Webhook publisher enabled",
+			"url", cfg.webhookURL,
+			"cloudEventsFormat", cfg.cloudEventsFormat,
+			"signed", signingSecret != "")
+	}
+
+	if cfg.victorOpsRoutingKey != "" {
+		if cfg.victorOpsIntegrationKey == "" {
+			setupLog.Error(nil, "victorops-integration-key is required when victorops-routing-key is set")
+			os.Exit(1)
+		}
+		victorOpsPublisher := victorops.NewVictorOpsPublisher(victorops.VictorOpsConfig{
+			RoutingKey:     cfg.victorOpsRoutingKey,
+			IntegrationKey: cfg.victorOpsIntegrationKey,
+			ClusterID:      cfg.clusterID,
+		})
+		addPublisher("victorops", victorOpsPublisher)
+		closers = append(closers, victorOpsPublisher)
+		setupLog.Info("VictorOps publisher enabled", "routingKey", cfg.victorOpsRoutingKey)
+	}
+
+	controlPlaneURLs := splitAndTrim(cfg.controlPlaneURLs)
 	if cfg.controlPlaneURL != "" {
+		if len(controlPlaneURLs) > 0 {
+			setupLog.Error(nil, "controlplane-url and controlplane-urls are mutually exclusive")
+			os.Exit(1)
+		}
+		controlPlaneURLs = []string{cfg.controlPlaneURL}
+	}
+	if len(controlPlaneURLs) > 0 {
 		if cfg.clusterID == "" {
 			setupLog.Error(nil, "cluster-id is required when controlplane-url is set")
 			os.Exit(1)
 		}
-		cpPublisher := controlplane.NewHTTPPublisher(cfg.controlPlaneURL, cfg.clusterID, agentVersion, cfg.controlPlaneAPIKey)
-		publishers = append(publishers, cpPublisher)
-		resourcePublishers = append(resourcePublishers, cpPublisher)
-		heartbeatPublishers = append(heartbeatPublishers, cpPublisher)
+		if cfg.controlPlaneCompressLevel < gzip.HuffmanOnly || cfg.controlPlaneCompressLevel > gzip.BestCompression {
+			setupLog.Error(nil, "controlplane-compress-level must be between -2 (Huffman only) and 9 (best compression)",
+				"level", cfg.controlPlaneCompressLevel)
+			os.Exit(1)
+		}
+		cpOptions := controlplane.Options{
+			CloudEventsMode: cfg.controlPlaneCloudEvents,
+			Compress:        cfg.controlPlaneCompress,
+			CompressLevel:   cfg.controlPlaneCompressLevel,
+			Proxy:           proxyFunc(cfg),
+			HTTP: controlplane.HTTPPublisherConfig{
+				Timeout:          cfg.controlPlaneTimeout,
+				KeepAlive:        cfg.controlPlaneKeepAlive,
+				MaxIdleConns:     cfg.controlPlaneMaxIdleConns,
+				IdleConnTimeout:  cfg.controlPlaneIdleTimeout,
+				DisableKeepAlive: cfg.controlPlaneNoKeepAlive,
+			},
+			Retry: controlplane.RetryConfig{
+				MaxRetries:  cfg.controlPlaneMaxRetries,
+				WaitTime:    cfg.controlPlaneRetryWait,
+				MaxWaitTime: cfg.controlPlaneRetryMaxWait,
+			},
+			ProjectID:      cfg.projectID,
+			FailoverURLs:   controlPlaneURLs[1:],
+			MaxPayloadSize: cfg.maxEventPayloadSize,
+		}
+		if cfg.controlPlaneCACert != "" {
+			rootCAs, err := controlplane.LoadCACertPool(cfg.controlPlaneCACert)
+			if err != nil {
+				setupLog.Error(err, "unable to load control plane CA certificate", "path", cfg.controlPlaneCACert)
+				os.Exit(1)
+			}
+			cpOptions.RootCAs = rootCAs
+		}
+		if cfg.controlPlaneInsecure {
+			setupLog.Info("WARNING: Control Plane TLS certificate verification is disabled; do not use in production")
+			cpOptions.InsecureSkipVerify = true
+		}
+		if cfg.controlPlaneTokenFile != "" {
+			tokenProvider, err := controlplane.NewFileTokenProvider(cfg.controlPlaneTokenFile)
+			if err != nil {
+				setupLog.Error(err, "unable to load control plane token", "path", cfg.controlPlaneTokenFile)
+				os.Exit(1)
+			}
+			cpOptions.TokenProvider = tokenProvider
+			closers = append(closers, tokenProvider)
+		}
+		if cfg.controlPlaneOAuth2URL != "" {
+			if cfg.controlPlaneTokenFile != "" {
+				setupLog.Error(nil, "controlplane-token-file and controlplane-oauth2-token-url are mutually exclusive")
+				os.Exit(1)
+			}
+			tokenProvider, err := controlplane.NewOAuth2TokenProvider(controlplane.OAuth2Config{
+				TokenURL:     cfg.controlPlaneOAuth2URL,
+				ClientID:     cfg.controlPlaneOAuth2ID,
+				ClientSecret: cfg.controlPlaneOAuth2Secret,
+				Scopes:       splitAndTrim(cfg.controlPlaneOAuth2Scopes),
+
+				Proxy:              cpOptions.Proxy,
+				RootCAs:            cpOptions.RootCAs,
+				InsecureSkipVerify: cpOptions.InsecureSkipVerify,
+			})
+			if err != nil {
+				setupLog.Error(err, "unable to configure control plane OAuth2", "tokenURL", cfg.controlPlaneOAuth2URL)
+				os.Exit(1)
+			}
+			cpOptions.TokenProvider = tokenProvider
+			setupLog.Info("Control Plane OAuth2 client credentials enabled", "tokenURL", cfg.controlPlaneOAuth2URL)
+		}
+		cpPublisher := controlplane.NewHTTPPublisher(controlPlaneURLs[0], cfg.clusterID, agentVersion, cfg.controlPlaneAPIKey,
+			cpOptions)
+		tracker := addPublisher("controlplane", cpPublisher)
+		addResourcePublisher(tracker, cpPublisher)
+		heartbeatPublishers = append(heartbeatPublishers, hooks.NewTrackedHeartbeatPublisher(tracker, cpPublisher))
+		closers = append(closers, cpPublisher)
 		setupLog.Info("Control Plane publisher enabled",
-			"endpoint", cfg.controlPlaneURL,
+			"endpoints", controlPlaneURLs,
 			"clusterID", cfg.clusterID)
 	}
 
@@ -250,47 +775,260 @@ func setupPublishers(cfg config, agentVersion string) (
 			setupLog.Error(nil, "cluster-id is required when pubsub is enabled")
 			os.Exit(1)
 		}
+		orderingStrategy, err := pubsub.ParseOrderingStrategy(cfg.pubsubOrderingStrategy)
+		if err != nil {
+			setupLog.Error(err, "invalid --pubsub-ordering-strategy")
+			os.Exit(1)
+		}
 		ctx := context.Background()
-		pubsubPublisher, err := pubsub.NewPubSubPublisher(ctx, cfg.pubsubTopic, cfg.clusterID, agentVersion)
+		pubsubPublisher, err := pubsub.NewPubSubPublisher(ctx, pubsub.PubSubConfig{
+			TopicPath:              cfg.pubsubTopic,
+			HeartbeatTopicPath:     cfg.pubsubHeartbeatTopic,
+			DeadLetterTopicPath:    cfg.pubsubDeadLetterTopic,
+			NodeTopicPath:          cfg.pubsubNodeTopic,
+			PodTopicPath:           cfg.pubsubPodTopic,
+			ClusterID:              cfg.clusterID,
+			ProjectID:              cfg.projectID,
+			AgentVersion:           agentVersion,
+			MaxOutstandingMessages: cfg.pubsubMaxOutstandingMsgs,
+			MaxOutstandingBytes:    cfg.pubsubMaxOutstandingBytes,
+			OrderingStrategy:       orderingStrategy,
+			MaxPayloadSize:         cfg.maxEventPayloadSize,
+			Proxy:                  proxyURLFunc(cfg),
+		})
 		if err != nil {
 			setupLog.Error(err, "unable to create Pub/Sub publisher",
 				"hint", "Ensure valid credentials via Workload Identity, GOOGLE_APPLICATION_CREDENTIALS, or gcloud auth")
 			os.Exit(1)
 		}
-		publishers = append(publishers, pubsubPublisher)
-		resourcePublishers = append(resourcePublishers, pubsubPublisher)
-		heartbeatPublishers = append(heartbeatPublishers, pubsubPublisher)
+		tracker := addPublisher("pubsub", pubsubPublisher)
+		addResourcePublisher(tracker, pubsubPublisher)
+		heartbeatPublishers = append(heartbeatPublishers, hooks.NewTrackedHeartbeatPublisher(tracker, pubsubPublisher))
+		closers = append(closers, pubsubPublisher)
 		setupLog.Info("Google Pub/Sub publisher enabled",
 			"topic", cfg.pubsubTopic,
+			"heartbeatTopic", cfg.pubsubHeartbeatTopic,
+			"deadLetterTopic", cfg.pubsubDeadLetterTopic,
+			"nodeTopic", cfg.pubsubNodeTopic,
+			"podTopic", cfg.pubsubPodTopic,
+			"maxOutstandingMessages", cfg.pubsubMaxOutstandingMsgs,
+			"maxOutstandingBytes", cfg.pubsubMaxOutstandingBytes,
+			"orderingStrategy", orderingStrategy,
 			"clusterID", cfg.clusterID)
 	}
 
+	if cfg.s3Bucket != "" {
+		if cfg.clusterID == "" {
+			setupLog.Error(nil, "cluster-id is required when s3-bucket is set")
+			os.Exit(1)
+		}
+		s3Publisher, err := s3.NewS3Publisher(context.Background(), s3.S3Config{
+			Bucket:        cfg.s3Bucket,
+			Endpoint:      cfg.s3Endpoint,
+			Region:        cfg.s3Region,
+			ClusterID:     cfg.clusterID,
+			BatchSize:     cfg.s3BatchSize,
+			FlushInterval: cfg.s3FlushInterval,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create S3 publisher")
+			os.Exit(1)
+		}
+		addResourcePublisher(newPublishTracker("s3"), s3Publisher)
+		closers = append(closers, s3Publisher)
+		setupLog.Info("S3 archive publisher enabled",
+			"bucket", cfg.s3Bucket,
+			"endpoint", cfg.s3Endpoint,
+			"batchSize", cfg.s3BatchSize,
+			"flushInterval", cfg.s3FlushInterval)
+	}
+
+	if cfg.cloudLoggingProject != "" {
+		cloudLoggingPublisher, err := cloudlogging.NewCloudLoggingPublisher(context.Background(), cloudlogging.CloudLoggingConfig{
+			ProjectID: cfg.cloudLoggingProject,
+			LogName:   cfg.cloudLoggingLogName,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create Cloud Logging publisher")
+			os.Exit(1)
+		}
+		addResourcePublisher(newPublishTracker("cloudlogging"), cloudLoggingPublisher)
+		closers = append(closers, cloudLoggingPublisher)
+		setupLog.Info("Cloud Logging publisher enabled",
+			"project", cfg.cloudLoggingProject,
+			"logName", cfg.cloudLoggingLogName)
+	}
+
+	if cfg.pushgatewayURL != "" {
+		promPublisher := prometheus.NewPrometheusPublisher(prometheus.PushConfig{
+			URL:       cfg.pushgatewayURL,
+			JobName:   cfg.pushgatewayJobName,
+			BatchSize: cfg.pushgatewayBatchSize,
+		}, reconciler.AppVersionCollector())
+		addPublisher("pushgateway", promPublisher)
+		closers = append(closers, promPublisher)
+		setupLog.Info("Prometheus Pushgateway publisher enabled",
+			"url", cfg.pushgatewayURL,
+			"job", cfg.pushgatewayJobName)
+	}
+
 	if len(publishers) == 0 {
 		setupLog.Info("No event publishers configured, events will only be exported as metrics")
 	}
 
-	return publishers, resourcePublishers, heartbeatPublishers
+	return publishers, resourcePublishers, heartbeatPublishers, healthChecks, closers
+}
+
+// proxyURLFunc returns the proxy selector built from the proxy flags, for the Pub/Sub dialer
+func proxyURLFunc(cfg config) func(*url.URL) (*url.URL, error) {
+	proxyConfig := &httpproxy.Config{
+		HTTPProxy:  cfg.httpProxy,
+		HTTPSProxy: cfg.httpsProxy,
+		NoProxy:    cfg.noProxy,
+	}
+	return proxyConfig.ProxyFunc()
+}
+
+// proxyFunc returns the proxy selector built from the proxy flags, for HTTP transports
+func proxyFunc(cfg config) func(*http.Request) (*url.URL, error) {
+	selectProxy := proxyURLFunc(cfg)
+	return func(req *http.Request) (*url.URL, error) {
+		return selectProxy(req.URL)
+	}
+}
+
+// loadWebhookSigningSecret returns the webhook signing secret, preferring the secret file when set
+func loadWebhookSigningSecret(cfg config) (string, error) {
+	if cfg.webhookSigningSecretFile == "" {
+		return cfg.webhookSigningSecret, nil
+	}
+	data, err := os.ReadFile(cfg.webhookSigningSecretFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read webhook signing secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// closePublishers releases publisher resources once the manager has stopped
+func closePublishers(closers hooks.CompositeCloser) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := closers.Close(ctx); err != nil {
+		setupLog.Error(err, "failed to close publishers")
+		return
+	}
+	setupLog.Info("publishers closed", "count", len(closers))
+}
+
+// gitOpsLinks returns the GitOps dashboards Slack messages link to
+func gitOpsLinks(cfg config) slack.GitOpsLinks {
+	return slack.GitOpsLinks{
+		ArgoCDServerURL:  cfg.argoCDServerURL,
+		FluxDashboardURL: cfg.fluxDashboardURL,
+	}
+}
+
+// setupPublisherRouter returns the router selecting the publishers of each workload event by its
+// namespace, or nil when --publisher-routing-file is not set
+func setupPublisherRouter(
+	mgr ctrl.Manager,
+	cfg config,
+	publishers []hooks.EventPublisher,
+	namespaceWatcher *infrastructure.NamespaceWatcher,
+) *hooks.PublisherRouter {
+	if cfg.publisherRoutingFile == "" {
+		return nil
+	}
+
+	routingConfig, err := hooks.LoadRoutingConfig(cfg.publisherRoutingFile)
+	if err != nil {
+		setupLog.Error(err, "unable to load --publisher-routing-file")
+		os.Exit(1)
+	}
+	router, err := hooks.NewPublisherRouter(publishers, routingConfig, namespaceWatcher.Get)
+	if err != nil {
+		setupLog.Error(err, "invalid publisher routing", "path", cfg.publisherRoutingFile)
+		os.Exit(1)
+	}
+	startNamespaceWatcher(mgr, namespaceWatcher)
+	setupLog.Info("Publisher routing enabled", "path", cfg.publisherRoutingFile, "routes", len(routingConfig.Routes))
+	return router
+}
+
+// setupNamespaceAggregator starts namespace aggregation of the reconcilers' resource events when a
+// threshold is set, and returns the channel the resource event queue reads from. The aggregator
+// closes first so its pending aggregated events reach the queue.
+func setupNamespaceAggregator(
+	cfg config,
+	resourceEventChan <-chan model.ResourceEventPayload,
+	resourcePublishers []hooks.ResourceEventPublisher,
+	closers hooks.CompositeCloser,
+) (<-chan model.ResourceEventPayload, hooks.CompositeCloser) {
+	if cfg.aggregationThreshold <= 0 || len(resourcePublishers) == 0 || !cfg.trackInfrastructure() {
+		return resourceEventChan, closers
+	}
+
+	aggregatedEventChan := make(chan model.ResourceEventPayload, cap(resourceEventChan))
+	aggregator := hooks.NewNamespaceAggregator(resourceEventChan, aggregatedEventChan, hooks.AggregationConfig{
+		Threshold: cfg.aggregationThreshold,
+	})
+	go aggregator.Loop()
+	setupLog.Info("Namespace event aggregation enabled",
+		"threshold", cfg.aggregationThreshold,
+		"window", hooks.DefaultAggregationWindow)
+	return aggregatedEventChan, append(hooks.CompositeCloser{aggregator}, closers...)
 }
 
 func startPublisherQueues(
 	cfg config,
 	publisherChan chan model.WorkloadUpdate,
-	resourceEventChan chan model.ResourceEventPayload,
+	resourceEventChan <-chan model.ResourceEventPayload,
+	resourceDeletionChan <-chan model.ResourceEventPayload,
 	publishers []hooks.EventPublisher,
+	router *hooks.PublisherRouter,
 	resourcePublishers []hooks.ResourceEventPublisher,
-) {
+	agentVersion string,
+) *hooks.EventReplayBuffer {
 	publisherQueue := hooks.NewEventPublisherQueue(publisherChan, publishers)
+	publisherQueue.Router = router
+	// Recent events are only readable through the token-protected trigger server
+	if cfg.triggerToken != "" && cfg.eventReplayBufferSize > 0 {
+		publisherQueue.ReplayBuffer = hooks.NewEventReplayBuffer(cfg.eventReplayBufferSize, cfg.clusterID, cfg.projectID, agentVersion)
+	}
+	if cfg.filterClusterPrefix != "" || cfg.excludeClusterPrefix != "" {
+		publisherQueue.ClusterFilter = &hooks.ClusterFilter{
+			ClusterID:     cfg.clusterID,
+			IncludePrefix: cfg.filterClusterPrefix,
+			ExcludePrefix: cfg.excludeClusterPrefix,
+		}
+		setupLog.Info("Cluster prefix filter enabled",
+			"clusterID", cfg.clusterID,
+			"publishing", publisherQueue.ClusterFilter.Allowed(),
+		)
+	}
 	go publisherQueue.Loop()
 
-	if len(resourcePublishers) > 0 && (cfg.trackNodes || cfg.trackPods) {
+	if len(resourcePublishers) > 0 && cfg.trackInfrastructure() {
+		dropPolicy, err := hooks.ParseDropPolicy(cfg.resourceDropPolicy)
+		if err != nil {
+			setupLog.Error(err, "invalid resource-drop-policy")
+			os.Exit(1)
+		}
 		batchConfig := hooks.DefaultBatchConfig()
+		batchConfig.DropPolicy = dropPolicy
 		resourcePublisherQueue := hooks.NewResourceEventPublisherQueue(resourceEventChan, resourcePublishers, batchConfig)
+		resourcePublisherQueue.HighPriorityChan = resourceDeletionChan
 		go resourcePublisherQueue.Loop()
 		setupLog.Info("Resource event publisher queue started",
 			"trackNodes", cfg.trackNodes,
 			"trackPods", cfg.trackPods,
+			"trackNamespaces", cfg.trackNamespaces,
+			"trackQuotas", cfg.trackQuotas,
 		)
 	}
+
+	return publisherQueue.ReplayBuffer
 }
 
 func getControllerNamespace() string {
@@ -302,14 +1040,104 @@ func getControllerNamespace() string {
 	return controllerNamespace
 }
 
-func setupWorkloadReconcilers(mgr ctrl.Manager, cfg config, publisherChan chan<- model.WorkloadUpdate, controllerNamespace string) {
+// filterSetter is implemented by reconcilers whose resource filter can be swapped on reload
+type filterSetter interface {
+	SetFilter(*filter.ResourceFilter)
+}
+
+// setupFilterReloader reloads resource filters from --config on SIGHUP and from the --agent-config
+// AppTrailAgentConfig when it changes; nil when neither is set
+func setupFilterReloader(mgr ctrl.Manager, cfg config) *filter.Reloader {
+	if cfg.configFile == "" && cfg.agentConfigName == "" {
+		return nil
+	}
+	reloader := filter.NewReloader(cfg.configFile)
+
+	if cfg.configFile != "" {
+		if err := mgr.Add(reloader); err != nil {
+			setupLog.Error(err, "unable to add filter config reloader")
+			os.Exit(1)
+		}
+		setupLog.Info("Filter config reload on SIGHUP enabled", "path", cfg.configFile)
+	}
+
+	if cfg.agentConfigName != "" {
+		agentConfigReconciler := reconciler.NewAgentConfigReconciler(mgr.GetClient(), cfg.agentConfigName, reloader)
+
+		// The cache is not started yet, so read straight from the API server. The initial
+		// filters are built from the override, so the first reconciles already use it.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := agentConfigReconciler.Preload(ctrl.LoggerInto(ctx, setupLog), mgr.GetAPIReader()); err != nil {
+			setupLog.Error(err, "failed to load agent config, using filter flags until its controller starts")
+		}
+
+		if err := agentConfigReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AppTrailAgentConfig")
+			os.Exit(1)
+		}
+		setupLog.Info("Filter config from AppTrailAgentConfig enabled", "name", cfg.agentConfigName)
+	}
+	return reloader
+}
+
+// newResourceFilter builds a filter from the flag config, applying the --config file when set.
+// Settings from the --agent-config AppTrailAgentConfig are loaded before the reconcilers are built.
+// On reload the rebuilt filter is handed to every reconciler in targets.
+func newResourceFilter(reloader *filter.Reloader, name string, filterConfig filter.ResourceFilterConfig, targets *[]filterSetter) *filter.ResourceFilter {
+	if reloader == nil {
+		return filter.NewResourceFilter(filterConfig)
+	}
+	resourceFilter, err := reloader.NewFilter(name, filterConfig, func(updated *filter.ResourceFilter) {
+		for _, target := range *targets {
+			target.SetFilter(updated)
+		}
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to load filter config")
+		os.Exit(1)
+	}
+	return resourceFilter
+}
+
+func setupWorkloadReconcilers(
+	mgr ctrl.Manager,
+	cfg config,
+	reloader *filter.Reloader,
+	publisherChan chan<- model.WorkloadUpdate,
+	resourceEventChan chan<- model.ResourceEventPayload,
+	controllerNamespace string,
+	agentVersion string,
+) []*reconciler.WorkloadReconciler {
+	// A non-positive delay would requeue failing workloads in a hot loop
+	if cfg.rateLimitBaseDelay <= 0 || cfg.rateLimitMaxDelay < cfg.rateLimitBaseDelay {
+		setupLog.Error(nil, "workload-ratelimit-base-delay must be positive and at most workload-ratelimit-max-delay",
+			"baseDelay", cfg.rateLimitBaseDelay, "maxDelay", cfg.rateLimitMaxDelay)
+		os.Exit(1)
+	}
+
 	// Create a resource filter for workload reconcilers using the same namespace
 	// exclusion config as infrastructure reconcilers, ensuring consistent filtering
 	filterConfig := filter.ResourceFilterConfig{
-		WatchNamespaces:   splitAndTrim(cfg.watchNamespaces),
-		ExcludeNamespaces: splitAndTrim(cfg.excludeNamespaces),
+		WatchNamespaces:       splitAndTrim(cfg.watchNamespaces),
+		ExcludeNamespaces:     cfg.excludedNamespaces,
+		InvertNamespaceFilter: cfg.invertNamespaceFilter,
+		DryRun:                cfg.filterDryRun,
+	}
+	var reloadTargets []filterSetter
+	resourceFilter := newResourceFilter(reloader, "workload", filterConfig, &reloadTargets)
+
+	// Read versions from image tags when configured, otherwise from the version label
+	var versionExtractor reconciler.VersionExtractor
+	if cfg.versionFromImage != "" {
+		versionExtractor = reconciler.ImageTagVersionExtractor(cfg.versionFromImage)
+		setupLog.Info("Reading workload versions from image tags", "container", cfg.versionFromImage)
+	}
+	versionLabels := splitAndTrim(cfg.versionLabels)
+	if versionExtractor == nil && len(versionLabels) > 0 {
+		versionExtractor = reconciler.LabelKeysVersionExtractor(versionLabels)
+		setupLog.Info("Reading workload versions from labels", "labels", versionLabels)
 	}
-	resourceFilter := filter.NewResourceFilter(filterConfig)
 
 	deploymentReconciler := reconciler.NewDeploymentReconciler(
 		mgr.GetClient(),
@@ -318,11 +1146,18 @@ func setupWorkloadReconcilers(mgr ctrl.Manager, cfg config, publisherChan chan<-
 		publisherChan,
 		controllerNamespace,
 		resourceFilter)
+	deploymentReconciler.RolloutTimeout = cfg.rolloutTimeout
+	deploymentReconciler.VersionLabels = versionLabels
+	deploymentReconciler.RateLimitBaseDelay = cfg.rateLimitBaseDelay
+	deploymentReconciler.RateLimitMaxDelay = cfg.rateLimitMaxDelay
+	deploymentReconciler.VersionExtractor = versionExtractor
+	deploymentReconciler.PassthroughAnnotationPrefixes = splitAndTrim(cfg.passthroughAnnotations)
 
 	if err := deploymentReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AppTrailDeployment")
 		os.Exit(1)
 	}
+	reloadTargets = append(reloadTargets, deploymentReconciler)
 
 	statefulSetReconciler := reconciler.NewStatefulSetReconciler(
 		mgr.GetClient(),
@@ -331,11 +1166,24 @@ func setupWorkloadReconcilers(mgr ctrl.Manager, cfg config, publisherChan chan<-
 		publisherChan,
 		controllerNamespace,
 		resourceFilter)
+	statefulSetReconciler.RolloutTimeout = cfg.rolloutTimeout
+	statefulSetReconciler.VersionLabels = versionLabels
+	statefulSetReconciler.RateLimitBaseDelay = cfg.rateLimitBaseDelay
+	statefulSetReconciler.RateLimitMaxDelay = cfg.rateLimitMaxDelay
+	statefulSetReconciler.VersionExtractor = versionExtractor
+	statefulSetReconciler.PassthroughAnnotationPrefixes = splitAndTrim(cfg.passthroughAnnotations)
+	// Resource events are only published while infrastructure tracking runs the resource queue
+	if cfg.trackInfrastructure() {
+		statefulSetReconciler.ResourceEventChan = resourceEventChan
+		statefulSetReconciler.ClusterID = cfg.clusterID
+		statefulSetReconciler.AgentVersion = agentVersion
+	}
 
 	if err := statefulSetReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AppTrailStatefulSet")
 		os.Exit(1)
 	}
+	reloadTargets = append(reloadTargets, statefulSetReconciler)
 
 	daemonSetReconciler := reconciler.NewDaemonSetReconciler(
 		mgr.GetClient(),
@@ -344,34 +1192,156 @@ func setupWorkloadReconcilers(mgr ctrl.Manager, cfg config, publisherChan chan<-
 		publisherChan,
 		controllerNamespace,
 		resourceFilter)
+	daemonSetReconciler.RolloutTimeout = cfg.rolloutTimeout
+	daemonSetReconciler.VersionLabels = versionLabels
+	daemonSetReconciler.RateLimitBaseDelay = cfg.rateLimitBaseDelay
+	daemonSetReconciler.RateLimitMaxDelay = cfg.rateLimitMaxDelay
+	daemonSetReconciler.VersionExtractor = versionExtractor
+	daemonSetReconciler.PassthroughAnnotationPrefixes = splitAndTrim(cfg.passthroughAnnotations)
 
 	if err := daemonSetReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AppTrailDaemonSet")
 		os.Exit(1)
 	}
+	reloadTargets = append(reloadTargets, daemonSetReconciler)
+
+	workloadReconcilers := []*reconciler.WorkloadReconciler{
+		deploymentReconciler.WorkloadReconciler,
+		statefulSetReconciler.WorkloadReconciler,
+		daemonSetReconciler.WorkloadReconciler,
+	}
+
+	if cfg.trackVirtualMachines {
+		virtualMachineReconciler := reconciler.NewVirtualMachineReconciler(
+			mgr.GetClient(),
+			mgr.GetScheme(),
+			mgr.GetEventRecorderFor("apptrail-agent"),
+			publisherChan,
+			controllerNamespace,
+			resourceFilter)
+		virtualMachineReconciler.RolloutTimeout = cfg.rolloutTimeout
+		virtualMachineReconciler.VersionLabels = versionLabels
+		virtualMachineReconciler.RateLimitBaseDelay = cfg.rateLimitBaseDelay
+		virtualMachineReconciler.RateLimitMaxDelay = cfg.rateLimitMaxDelay
+		virtualMachineReconciler.PassthroughAnnotationPrefixes = splitAndTrim(cfg.passthroughAnnotations)
+
+		if err := virtualMachineReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AppTrailVirtualMachine")
+			os.Exit(1)
+		}
+		reloadTargets = append(reloadTargets, virtualMachineReconciler)
+		workloadReconcilers = append(workloadReconcilers, virtualMachineReconciler.WorkloadReconciler)
+		setupLog.Info("VirtualMachine tracking enabled")
+	}
+
+	warmUpWorkloadReconcilers(mgr, cfg, workloadReconcilers...)
+
+	// A newly elected leader re-checks its state against the CRDs before trusting it
+	if cfg.enableLeaderElection {
+		stateValidator := reconciler.NewStateValidator(workloadReconcilers...)
+		if err := mgr.Add(stateValidator); err != nil {
+			setupLog.Error(err, "unable to add workload state validator")
+			os.Exit(1)
+		}
+	}
+
+	return workloadReconcilers
+}
+
+// setupReconcileTrigger serves the endpoint that republishes every workload's state, e.g. after
+// a publisher outage, the recent events endpoint showing what was sent and the endpoint listing
+// workloads backing off after failed reconciles. The probe server of
+// controller-runtime takes no extra handlers, so they get their own address.
+func setupReconcileTrigger(
+	mgr ctrl.Manager,
+	cfg config,
+	workloadReconcilers []*reconciler.WorkloadReconciler,
+	replayBuffer *hooks.EventReplayBuffer,
+) {
+	if cfg.triggerToken == "" {
+		return
+	}
+
+	replayers := make([]trigger.Replayer, 0, len(workloadReconcilers))
+	for _, r := range workloadReconcilers {
+		replayers = append(replayers, r)
+	}
+	server := trigger.NewServer(cfg.triggerAddr, cfg.triggerToken, mgr.Elected(), replayers...)
+	server.Handle(trigger.DebugQueuesPath, reconciler.QueueDebugHandler(workloadReconcilers...))
+	if replayBuffer != nil {
+		server.Handle(trigger.RecentEventsPath, replayBuffer)
+		setupLog.Info("Recent events endpoint enabled", "path", trigger.RecentEventsPath, "size", cfg.eventReplayBufferSize)
+	}
+	if err := mgr.Add(server); err != nil {
+		setupLog.Error(err, "unable to add full reconcile trigger")
+		os.Exit(1)
+	}
+	setupLog.Info("Full reconcile trigger enabled", "addr", cfg.triggerAddr, "path", trigger.ReconcilePath)
+}
+
+// warmUpWorkloadReconcilers restores in-memory workload state from CRDs before the manager starts,
+// so an agent restart does not re-emit events for every workload in the cluster
+func warmUpWorkloadReconcilers(mgr ctrl.Manager, cfg config, reconcilers ...*reconciler.WorkloadReconciler) {
+	if cfg.warmUpTimeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.warmUpTimeout)
+	defer cancel()
+	ctx = ctrl.LoggerInto(ctx, setupLog)
+
+	for _, r := range reconcilers {
+		// The cache is not started yet, so read straight from the API server
+		r.APIReader = mgr.GetAPIReader()
+		if err := r.WarmUp(ctx); err != nil {
+			setupLog.Error(err, "failed to warm up workload state, duplicate events may be sent after restart")
+		}
+	}
+}
+
+// startNamespaceWatcher starts the controller keeping namespace labels in memory. Until it is
+// started, namespace label lookups read the namespace instead.
+func startNamespaceWatcher(mgr ctrl.Manager, namespaceWatcher *infrastructure.NamespaceWatcher) {
+	if err := namespaceWatcher.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NamespaceLabels")
+		os.Exit(1)
+	}
 }
 
 func setupInfrastructureReconcilers(
 	mgr ctrl.Manager,
 	cfg config,
+	reloader *filter.Reloader,
 	resourceEventChan chan<- model.ResourceEventPayload,
+	resourceDeletionChan chan<- model.ResourceEventPayload,
+	namespaceWatcher *infrastructure.NamespaceWatcher,
 	agentVersion string,
-) {
-	if !cfg.trackNodes && !cfg.trackPods {
-		return
+) []hooks.FullSyncSource {
+	if !cfg.trackInfrastructure() {
+		return nil
 	}
 
 	filterConfig := filter.ResourceFilterConfig{
-		TrackNodes:        cfg.trackNodes,
-		TrackPods:         cfg.trackPods,
-		TrackServices:     false,
-		WatchNamespaces:   splitAndTrim(cfg.watchNamespaces),
-		ExcludeNamespaces: splitAndTrim(cfg.excludeNamespaces),
-		RequireLabels:     splitAndTrim(cfg.requireLabels),
-		ExcludeLabels:     splitAndTrim(cfg.excludeLabels),
+		TrackNodes:            cfg.trackNodes,
+		TrackPods:             cfg.trackPods,
+		TrackNamespaces:       cfg.trackNamespaces,
+		TrackServices:         false,
+		WatchNamespaces:       splitAndTrim(cfg.watchNamespaces),
+		ExcludeNamespaces:     cfg.excludedNamespaces,
+		InvertNamespaceFilter: cfg.invertNamespaceFilter,
+		RequireLabels:         splitAndTrim(cfg.requireLabels),
+		ExcludeLabels:         splitAndTrim(cfg.excludeLabels),
+		DryRun:                cfg.filterDryRun,
+
+		WatchNamespaceLabels:   splitAndTrim(cfg.watchNamespaceLabels),
+		ExcludeNamespaceLabels: splitAndTrim(cfg.excludeNamespaceLabels),
 	}
 
-	resourceFilter := filter.NewResourceFilter(filterConfig)
+	var reloadTargets []filterSetter
+	resourceFilter := newResourceFilter(reloader, "infrastructure", filterConfig, &reloadTargets)
+
+	// Nodes and pods are included in full-state syncs
+	var syncSources []hooks.FullSyncSource
 
 	if cfg.trackNodes {
 		nodeReconciler := infrastructure.NewNodeReconciler(
@@ -382,10 +1352,14 @@ func setupInfrastructureReconcilers(
 			cfg.clusterID,
 			agentVersion,
 		)
+		nodeReconciler.PodCountChangeThreshold = cfg.nodePodCountThreshold
+		nodeReconciler.PodCountChangePercent = cfg.nodePodCountPercent
+		nodeReconciler.DeletionChan = resourceDeletionChan
 		if err := nodeReconciler.SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "AppTrailNode")
 			os.Exit(1)
 		}
+		syncSources = append(syncSources, nodeReconciler)
 		setupLog.Info("Node reconciler enabled")
 	}
 
@@ -399,14 +1373,107 @@ func setupInfrastructureReconcilers(
 			agentVersion,
 			resourceFilter,
 		)
+		podReconciler.PendingAlertThreshold = cfg.pendingAlertThreshold
+		podReconciler.PendingCheckInterval = cfg.pendingCheckInterval
+		podReconciler.DeletionChan = resourceDeletionChan
+		podReconciler.NamespaceWatcher = namespaceWatcher
+		if resourceFilter.HasNamespaceLabelFilters() {
+			startNamespaceWatcher(mgr, namespaceWatcher)
+		}
+		restartThresholds, err := infrastructure.ParseRestartAlertThresholds(splitAndTrim(cfg.restartAlertEscalations))
+		if err != nil {
+			setupLog.Error(err, "invalid pod-restart-alert-escalations")
+			os.Exit(1)
+		}
+		// Escalations only follow the first alert, so a disabled threshold turns them off too
+		if cfg.restartAlertThreshold > 0 {
+			restartThresholds = append(restartThresholds, int32(cfg.restartAlertThreshold))
+		} else {
+			restartThresholds = nil
+		}
+		slices.Sort(restartThresholds)
+		podReconciler.RestartAlertThresholds = slices.Compact(restartThresholds)
 		if err := podReconciler.SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "AppTrailPod")
 			os.Exit(1)
 		}
+		reloadTargets = append(reloadTargets, podReconciler)
+		syncSources = append(syncSources, podReconciler)
 		setupLog.Info("Pod reconciler enabled",
 			"excludeNamespaces", filterConfig.ExcludeNamespaces,
 		)
 	}
+
+	if cfg.trackNamespaces {
+		namespaceReconciler := infrastructure.NewNamespaceReconciler(
+			mgr.GetClient(),
+			mgr.GetScheme(),
+			mgr.GetEventRecorderFor("apptrail-agent"),
+			resourceEventChan,
+			cfg.clusterID,
+			agentVersion,
+			resourceFilter,
+		)
+		namespaceReconciler.DeletionChan = resourceDeletionChan
+		if err := namespaceReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AppTrailNamespace")
+			os.Exit(1)
+		}
+		reloadTargets = append(reloadTargets, namespaceReconciler)
+		setupLog.Info("Namespace reconciler enabled",
+			"excludeNamespaces", filterConfig.ExcludeNamespaces,
+		)
+	}
+
+	if cfg.trackQuotas {
+		quotaReconciler := infrastructure.NewResourceQuotaReconciler(
+			mgr.GetClient(),
+			mgr.GetScheme(),
+			mgr.GetEventRecorderFor("apptrail-agent"),
+			resourceEventChan,
+			cfg.clusterID,
+			agentVersion,
+			resourceFilter,
+			cfg.quotaWarningThreshold,
+		)
+		if err := quotaReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AppTrailResourceQuota")
+			os.Exit(1)
+		}
+		reloadTargets = append(reloadTargets, quotaReconciler)
+		setupLog.Info("ResourceQuota reconciler enabled",
+			"warningThreshold", cfg.quotaWarningThreshold,
+			"excludeNamespaces", filterConfig.ExcludeNamespaces,
+		)
+	}
+
+	return syncSources
+}
+
+// runPreflight verifies RBAC permissions before any controller starts. A failed check is a
+// configuration error and exits with code 2.
+func runPreflight(mgr ctrl.Manager, cfg config) {
+	if cfg.skipPreflight {
+		setupLog.Info("Preflight check skipped")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), preflightTimeout)
+	defer cancel()
+
+	// The manager's cache is not started yet, so list through the API reader
+	err := preflight.Check(ctrl.LoggerInto(ctx, setupLog), mgr.GetAPIReader(), preflight.Config{
+		TrackNodes:           cfg.trackNodes,
+		TrackPods:            cfg.trackPods,
+		TrackNamespaces:      cfg.trackNamespaces,
+		TrackQuotas:          cfg.trackQuotas,
+		TrackVirtualMachines: cfg.trackVirtualMachines,
+	})
+	if err != nil {
+		setupLog.Error(err, "preflight check failed",
+			"hint", "grant the missing permissions or pass --skip-preflight to start anyway")
+		os.Exit(2)
+	}
 }
 
 func setupHealthChecks(mgr ctrl.Manager) {
@@ -424,6 +1491,7 @@ func setupHeartbeatSender(
 	mgr ctrl.Manager,
 	cfg config,
 	heartbeatPublishers []hooks.HeartbeatPublisher,
+	healthChecks []hooks.HealthCheckable,
 	agentVersion string,
 ) {
 	// Only enable heartbeat if tracking infrastructure and has publishers
@@ -450,7 +1518,7 @@ func setupHeartbeatSender(
 		TrackPods:    cfg.trackPods,
 	}
 
-	sender := heartbeat.NewSender(heartbeatConfig, mgr.GetClient(), heartbeatPublishers)
+	sender := heartbeat.NewSender(heartbeatConfig, mgr.GetClient(), heartbeatPublishers, healthChecks)
 
 	// Start heartbeat sender in a goroutine
 	go func() {
@@ -467,14 +1535,82 @@ func setupHeartbeatSender(
 	)
 }
 
-// resolveClusterID resolves the cluster ID using the following priority:
+// setupFullStateSync periodically sends the state of every workload, node and pod to the
+// resource publishers, letting the control plane recover from incremental events it missed
+func setupFullStateSync(
+	mgr ctrl.Manager,
+	cfg config,
+	workloadReconcilers []*reconciler.WorkloadReconciler,
+	infrastructureSources []hooks.FullSyncSource,
+	resourcePublishers []hooks.ResourceEventPublisher,
+	agentVersion string,
+) {
+	if cfg.fullSyncInterval <= 0 {
+		setupLog.Info("Full-state sync disabled")
+		return
+	}
+
+	if len(resourcePublishers) == 0 {
+		setupLog.Info("Full-state sync disabled: no resource event publishers configured")
+		return
+	}
+
+	sources := make([]hooks.FullSyncSource, 0, len(workloadReconcilers)+len(infrastructureSources))
+	for _, workloadReconciler := range workloadReconcilers {
+		sources = append(sources, workloadReconciler)
+	}
+	sources = append(sources, infrastructureSources...)
+
+	fullSync := hooks.NewFullStateSyncPublisher(hooks.FullSyncConfig{
+		Interval:     cfg.fullSyncInterval,
+		ClusterID:    cfg.clusterID,
+		AgentVersion: agentVersion,
+	}, sources, resourcePublishers)
+
+	go func() {
+		// Wait for the manager to start and cache to sync
+		<-mgr.Elected()
+		fullSync.Start(context.Background())
+	}()
+
+	setupLog.Info("Full-state sync enabled",
+		"interval", cfg.fullSyncInterval,
+		"sources", len(sources),
+	)
+}
+
+// resolveExcludedNamespaces merges the exclude namespaces preset, file and flag, in that order
+func resolveExcludedNamespaces(cfg config) []string {
+	preset, err := filter.ExcludedNamespacesPreset(cfg.excludeNamespacesPreset)
+	if err != nil {
+		setupLog.Error(err, "invalid --exclude-namespaces-preset")
+		os.Exit(1)
+	}
+
+	var fromFile []string
+	if cfg.excludeNamespacesFile != "" {
+		fromFile, err = filter.LoadNamespacePatterns(cfg.excludeNamespacesFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load --exclude-namespaces-file")
+			os.Exit(1)
+		}
+	}
+
+	excluded := filter.MergeNamespacePatterns(preset, fromFile, splitAndTrim(cfg.excludeNamespaces))
+	setupLog.Info("Excluding namespaces", "preset", cfg.excludeNamespacesPreset, "namespaces", excluded)
+	return excluded
+}
+
+// resolveClusterID resolves the cluster and project ID using the following priority:
 // 1. Explicit flag/env (highest priority)
 // 2. Auto-detection from GCP metadata service
-func resolveClusterID(explicitID string) string {
+// Detection only runs when the cluster ID isn't set, so an explicit cluster ID
+// needs --project-id for the project to be known.
+func resolveClusterID(explicitID, explicitProjectID string) (clusterID, projectID string) {
 	// If explicitly provided, use it
 	if explicitID != "" {
-		setupLog.Info("Using explicit cluster ID", "clusterID", explicitID)
-		return explicitID
+		setupLog.Info("Using explicit cluster ID", "clusterID", explicitID, "projectID", explicitProjectID)
+		return explicitID, explicitProjectID
 	}
 
 	// Attempt auto-detection
@@ -494,7 +1630,11 @@ func resolveClusterID(explicitID string) string {
 			setupLog.Error(err, "Failed to auto-detect cluster ID",
 				"hint", "Use --cluster-id flag or CLUSTER_ID env var to set cluster ID manually")
 		}
-		return ""
+		return "", explicitProjectID
+	}
+
+	if explicitProjectID != "" {
+		info.ProjectID = explicitProjectID
 	}
 
 	setupLog.Info("Auto-detected cluster ID",
@@ -502,9 +1642,10 @@ func resolveClusterID(explicitID string) string {
 		"provider", info.Provider,
 		"region", info.Region,
 		"clusterName", info.ClusterName,
+		"projectID", info.ProjectID,
 	)
 
-	return info.ClusterID
+	return info.ClusterID, info.ProjectID
 }
 
 // splitAndTrim splits a comma-separated string and trims whitespace from each element
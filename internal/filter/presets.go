@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Namespace exclusion presets selectable with --exclude-namespaces-preset
+const (
+	PresetMinimal     = "minimal"
+	PresetRecommended = "recommended"
+	PresetNone        = "none"
+)
+
+// ExcludedNamespacesPreset returns the namespace patterns excluded by a named preset
+func ExcludedNamespacesPreset(preset string) ([]string, error) {
+	switch preset {
+	case PresetMinimal:
+		return DefaultExcludedNamespaces(), nil
+	case PresetRecommended:
+		return append(DefaultExcludedNamespaces(), "cert-manager", "monitoring", "istio-system", "linkerd"), nil
+	case PresetNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown exclude namespaces preset %q: must be minimal, recommended or none", preset)
+	}
+}
+
+// LoadNamespacePatterns reads namespace patterns from a file with one pattern per line,
+// e.g. a mounted ConfigMap key. Blank lines and lines starting with # are ignored.
+func LoadNamespacePatterns(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespace file %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read namespace file %s: %w", path, err)
+	}
+	return patterns, nil
+}
+
+// MergeNamespacePatterns concatenates pattern lists in order, dropping duplicates
+func MergeNamespacePatterns(lists ...[]string) []string {
+	seen := make(map[string]struct{})
+	var merged []string
+	for _, list := range lists {
+		for _, pattern := range list {
+			if _, ok := seen[pattern]; ok {
+				continue
+			}
+			seen[pattern] = struct{}{}
+			merged = append(merged, pattern)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,60 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExcludedNamespacesPreset(t *testing.T) {
+	tests := []struct {
+		preset    string
+		want      []string
+		expectErr bool
+	}{
+		{preset: PresetMinimal, want: []string{"kube-system", "kube-public", "kube-node-lease"}},
+		{preset: PresetRecommended, want: []string{"kube-system", "kube-public", "kube-node-lease",
+			"cert-manager", "monitoring", "istio-system", "linkerd"}},
+		{preset: PresetNone, want: nil},
+		{preset: "strict", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			got, err := ExcludedNamespacesPreset(tt.preset)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ExcludedNamespacesPreset(%q) error = %v, expectErr %v", tt.preset, err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExcludedNamespacesPreset(%q) = %v, want %v", tt.preset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadNamespacePatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exclude")
+	if err := os.WriteFile(path, []byte("# platform\nvault\n\n  team-*-sandbox  \n"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	got, err := LoadNamespacePatterns(path)
+	if err != nil {
+		t.Fatalf("LoadNamespacePatterns() error = %v", err)
+	}
+	if want := []string{"vault", "team-*-sandbox"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadNamespacePatterns() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadNamespacePatterns(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestMergeNamespacePatterns(t *testing.T) {
+	got := MergeNamespacePatterns([]string{"kube-system", "monitoring"}, nil, []string{"monitoring", "vault"})
+	if want := []string{"kube-system", "monitoring", "vault"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeNamespacePatterns() = %v, want %v", got, want)
+	}
+}
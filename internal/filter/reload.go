@@ -0,0 +1,208 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// FileConfig holds the filter settings that can be set in the --config file and reloaded on SIGHUP.
+// Unset fields keep the value from the command-line flags. Resource type toggles are not
+// reloadable because controllers cannot be added or removed after the manager starts.
+type FileConfig struct {
+	WatchNamespaces        []string `json:"watchNamespaces,omitempty"`
+	ExcludeNamespaces      []string `json:"excludeNamespaces,omitempty"`
+	InvertNamespaceFilter  *bool    `json:"invertNamespaceFilter,omitempty"`
+	WatchNamespaceLabels   []string `json:"watchNamespaceLabels,omitempty"`
+	ExcludeNamespaceLabels []string `json:"excludeNamespaceLabels,omitempty"`
+	RequireLabels          []string `json:"requireLabels,omitempty"`
+	ExcludeLabels          []string `json:"excludeLabels,omitempty"`
+	DryRun                 *bool    `json:"dryRun,omitempty"`
+}
+
+// LoadFileConfig reads a YAML filter config file. Unknown keys are rejected so typos don't
+// silently leave a filter unchanged.
+func LoadFileConfig(path string) (FileConfig, error) {
+	var fileConfig FileConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileConfig, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := yaml.UnmarshalStrict(data, &fileConfig); err != nil {
+		return fileConfig, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return fileConfig, nil
+}
+
+// Apply returns base with the settings present in the file config overriding it
+func (c FileConfig) Apply(base ResourceFilterConfig) ResourceFilterConfig {
+	if c.WatchNamespaces != nil {
+		base.WatchNamespaces = c.WatchNamespaces
+	}
+	if c.ExcludeNamespaces != nil {
+		base.ExcludeNamespaces = c.ExcludeNamespaces
+	}
+	if c.InvertNamespaceFilter != nil {
+		base.InvertNamespaceFilter = *c.InvertNamespaceFilter
+	}
+	if c.WatchNamespaceLabels != nil {
+		base.WatchNamespaceLabels = c.WatchNamespaceLabels
+	}
+	if c.ExcludeNamespaceLabels != nil {
+		base.ExcludeNamespaceLabels = c.ExcludeNamespaceLabels
+	}
+	if c.RequireLabels != nil {
+		base.RequireLabels = c.RequireLabels
+	}
+	if c.ExcludeLabels != nil {
+		base.ExcludeLabels = c.ExcludeLabels
+	}
+	if c.DryRun != nil {
+		base.DryRun = *c.DryRun
+	}
+	return base
+}
+
+// Config returns the configuration the filter was built from
+func (f *ResourceFilter) Config() ResourceFilterConfig {
+	return f.config
+}
+
+// configChanges describes the settings that differ between two filter configs, e.g. "ExcludeNamespaces: [a] -> [a b]"
+func configChanges(old, updated ResourceFilterConfig) []string {
+	var changes []string
+	oldValue, updatedValue := reflect.ValueOf(old), reflect.ValueOf(updated)
+	for i := 0; i < oldValue.NumField(); i++ {
+		before, after := fmt.Sprint(oldValue.Field(i)), fmt.Sprint(updatedValue.Field(i))
+		if before != after {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", oldValue.Type().Field(i).Name, before, after))
+		}
+	}
+	return changes
+}
+
+// reloadTarget is a set of reconcilers sharing one filter built from the same flag config
+type reloadTarget struct {
+	name    string
+	base    ResourceFilterConfig
+	current *ResourceFilter
+	swap    func(*ResourceFilter)
+}
+
+// Reloader re-reads the filter config file on SIGHUP and swaps the rebuilt filters into
+// the registered reconcilers. Settings from an AppTrailAgentConfig are layered on top of
+// the file with SetOverride. It runs on every replica so standbys are current when elected.
+type Reloader struct {
+	path string
+
+	mu         sync.Mutex
+	fileConfig FileConfig
+	override   FileConfig
+	targets    []*reloadTarget
+}
+
+// NewReloader creates a reloader for the given config file; an empty path means no file
+func NewReloader(path string) *Reloader {
+	return &Reloader{path: path}
+}
+
+// loadFile reads the config file, returning an empty config when no file is set
+func (r *Reloader) loadFile() (FileConfig, error) {
+	if r.path == "" {
+		return FileConfig{}, nil
+	}
+	return LoadFileConfig(r.path)
+}
+
+// NewFilter builds the initial filter from base with the config file and override applied
+// and registers swap to receive a rebuilt filter on every reload
+func (r *Reloader) NewFilter(name string, base ResourceFilterConfig, swap func(*ResourceFilter)) (*ResourceFilter, error) {
+	fileConfig, err := r.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fileConfig = fileConfig
+	resourceFilter := NewResourceFilter(r.override.Apply(fileConfig.Apply(base)))
+	r.targets = append(r.targets, &reloadTarget{name: name, base: base, current: resourceFilter, swap: swap})
+	return resourceFilter, nil
+}
+
+// Reload re-reads the config file and swaps in filters whose settings changed.
+// On a read or parse error the current filters stay in place.
+func (r *Reloader) Reload(ctx context.Context) error {
+	fileConfig, err := r.loadFile()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fileConfig = fileConfig
+	r.apply(ctx)
+	return nil
+}
+
+// SetOverride replaces the settings layered on top of the config file and swaps in
+// filters whose settings changed. An empty override reverts to the file and flags.
+func (r *Reloader) SetOverride(ctx context.Context, override FileConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.override = override
+	r.apply(ctx)
+}
+
+// apply rebuilds every target's filter from the current layers. Callers must hold r.mu.
+func (r *Reloader) apply(ctx context.Context) {
+	logger := ctrl.LoggerFrom(ctx)
+
+	for _, target := range r.targets {
+		updated := r.override.Apply(r.fileConfig.Apply(target.base))
+		changes := configChanges(target.current.Config(), updated)
+		if len(changes) == 0 {
+			logger.Info("Filter config unchanged", "filter", target.name)
+			continue
+		}
+
+		target.current = NewResourceFilter(updated)
+		target.swap(target.current)
+		logger.Info("Filter config reloaded", "filter", target.name, "changes", changes)
+	}
+}
+
+// Start reloads the filters on every SIGHUP until ctx is cancelled.
+// Implements manager.Runnable.
+func (r *Reloader) Start(ctx context.Context) error {
+	logger := ctrl.LoggerFrom(ctx).WithName("filter-reloader")
+	ctx = ctrl.LoggerInto(ctx, logger)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-signals:
+			logger.Info("Received SIGHUP, reloading filter config", "path", r.path)
+			if err := r.Reload(ctx); err != nil {
+				logger.Error(err, "Failed to reload filter config, keeping current filters")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection makes the manager run the reloader on every replica
+func (r *Reloader) NeedLeaderElection() bool {
+	return false
+}
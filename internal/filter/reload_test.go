@@ -0,0 +1,130 @@
+package filter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileConfig_Apply(t *testing.T) {
+	base := ResourceFilterConfig{
+		ExcludeNamespaces: DefaultExcludedNamespaces(),
+		RequireLabels:     []string{"team"},
+		TrackPods:         true,
+	}
+	invert := true
+
+	got := FileConfig{
+		WatchNamespaces:       []string{"production-*"},
+		ExcludeLabels:         []string{},
+		InvertNamespaceFilter: &invert,
+	}.Apply(base)
+
+	want := ResourceFilterConfig{
+		WatchNamespaces:       []string{"production-*"},
+		ExcludeNamespaces:     DefaultExcludedNamespaces(),
+		InvertNamespaceFilter: true,
+		RequireLabels:         []string{"team"},
+		ExcludeLabels:         []string{},
+		TrackPods:             true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadFileConfig_RejectsUnknownKeys(t *testing.T) {
+	path := writeConfigFile(t, "excludeNamespace:\n  - kube-system\n")
+	if _, err := LoadFileConfig(path); err == nil {
+		t.Error("Expected error for unknown key")
+	}
+}
+
+func TestReloader_Reload(t *testing.T) {
+	path := writeConfigFile(t, "excludeNamespaces:\n  - kube-*\n")
+	reloader := NewReloader(path)
+
+	var swapped []*ResourceFilter
+	base := ResourceFilterConfig{ExcludeNamespaces: DefaultExcludedNamespaces(), TrackPods: true}
+	resourceFilter, err := reloader.NewFilter("test", base, func(f *ResourceFilter) {
+		swapped = append(swapped, f)
+	})
+	if err != nil {
+		t.Fatalf("NewFilter() error = %v", err)
+	}
+	if resourceFilter.ShouldWatchNamespace("kube-flannel") {
+		t.Error("Expected config file exclusion to apply to the initial filter")
+	}
+
+	// Unchanged file keeps the current filter
+	if err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(swapped) != 0 {
+		t.Fatalf("Expected no swap for unchanged config, got %d", len(swapped))
+	}
+
+	writeFile(t, path, "excludeNamespaces:\n  - staging\nrequireLabels:\n  - team\n")
+	if err := reloader.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(swapped) != 1 {
+		t.Fatalf("Expected one swap, got %d", len(swapped))
+	}
+	reloaded := swapped[0]
+	if !reloaded.ShouldWatchNamespace("kube-flannel") {
+		t.Error("Expected kube-flannel to be watched after reload")
+	}
+	if reloaded.ShouldWatchNamespace("staging") {
+		t.Error("Expected staging to be excluded after reload")
+	}
+	if reloaded.ShouldWatchResource(map[string]string{"app": "web"}) {
+		t.Error("Expected reloaded required label to apply")
+	}
+	if !reloaded.ShouldTrackPods() {
+		t.Error("Expected resource type toggles to keep the flag values")
+	}
+
+	// A broken file keeps the current filter
+	writeFile(t, path, "excludeNamespaces: [")
+	if err := reloader.Reload(context.Background()); err == nil {
+		t.Error("Expected error for invalid config file")
+	}
+	if len(swapped) != 1 {
+		t.Errorf("Expected no swap after failed reload, got %d swaps", len(swapped))
+	}
+}
+
+func TestConfigChanges(t *testing.T) {
+	changes := configChanges(
+		ResourceFilterConfig{ExcludeNamespaces: []string{"kube-system"}},
+		ResourceFilterConfig{ExcludeNamespaces: []string{"kube-system", "staging"}, DryRun: true},
+	)
+	want := []string{
+		"ExcludeNamespaces: [kube-system] -> [kube-system staging]",
+		"DryRun: false -> true",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("configChanges() = %v, want %v", changes, want)
+	}
+
+	if changes := configChanges(ResourceFilterConfig{}, ResourceFilterConfig{WatchNamespaces: []string{}}); len(changes) != 0 {
+		t.Errorf("Expected nil and empty lists to be equal, got %v", changes)
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, content)
+	return path
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+}
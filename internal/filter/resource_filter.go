@@ -1,8 +1,33 @@
 package filter
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Reasons a resource would be excluded, used as the dry-run metric label
+const (
+	reasonExcludedNamespace         = "excluded_namespace"
+	reasonNamespaceNotWatched       = "namespace_not_watched"
+	reasonMissingRequiredLabel      = "missing_required_label"
+	reasonExcludedLabel             = "excluded_label"
+	reasonExcludedNamespaceLabel    = "excluded_namespace_label"
+	reasonNamespaceLabelsNotWatched = "namespace_labels_not_watched"
+	reasonInvertedNamespace         = "inverted_namespace"
+)
+
+var (
+	dryRunWouldExcludeCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_filter_dry_run_would_exclude_total",
+		Help: "Number of resources that would have been excluded by the filter in dry-run mode",
+	}, []string{"reason"})
+
+	metricsRegistered = false
 )
 
 // ResourceFilterConfig holds the configuration for resource filtering
@@ -11,14 +36,25 @@ type ResourceFilterConfig struct {
 	WatchNamespaces   []string // Glob patterns for namespaces to watch (e.g., "production-*")
 	ExcludeNamespaces []string // Glob patterns for namespaces to exclude (e.g., "kube-system")
 
+	// InvertNamespaceFilter watches exactly the namespaces the namespace filter would otherwise drop
+	InvertNamespaceFilter bool
+
+	// Namespace label filtering, same key=value format as ExcludeLabels
+	WatchNamespaceLabels   []string // Namespace labels to watch (e.g., "team=platform")
+	ExcludeNamespaceLabels []string // Namespace labels that cause exclusion (e.g., "env=sandbox")
+
 	// Label filtering
 	RequireLabels []string // Label keys that must be present (e.g., "app.kubernetes.io/managed-by")
 	ExcludeLabels []string // Label key=value pairs that cause exclusion (e.g., "internal.apptrail.sh/ignore=true")
 
 	// Resource type toggles
-	TrackNodes    bool
-	TrackPods     bool
-	TrackServices bool
+	TrackNodes      bool
+	TrackPods       bool
+	TrackServices   bool
+	TrackNamespaces bool
+
+	// DryRun logs what would be filtered instead of filtering it
+	DryRun bool
 }
 
 // ResourceFilter implements namespace and label-based resource filtering
@@ -28,52 +64,120 @@ type ResourceFilter struct {
 
 // NewResourceFilter creates a new resource filter
 func NewResourceFilter(config ResourceFilterConfig) *ResourceFilter {
+	if config.DryRun && !metricsRegistered {
+		metrics.Registry.MustRegister(dryRunWouldExcludeCounter)
+		metricsRegistered = true
+	}
 	return &ResourceFilter{config: config}
 }
 
 // ShouldWatchNamespace returns true if the namespace should be watched
 func (f *ResourceFilter) ShouldWatchNamespace(namespace string) bool {
+	reason, detail := f.namespaceExclusion(namespace)
+	if f.config.InvertNamespaceFilter {
+		if reason != "" {
+			return true
+		}
+		reason, detail = reasonInvertedNamespace, fmt.Sprintf("namespace %s is watched by the non-inverted filter", namespace)
+	}
+	if reason == "" {
+		return true
+	}
+	return f.dryRunAllow(reason, detail)
+}
+
+func (f *ResourceFilter) namespaceExclusion(namespace string) (reason, detail string) {
 	// Check exclusions first
 	for _, pattern := range f.config.ExcludeNamespaces {
 		if matchGlob(pattern, namespace) {
-			return false
+			return reasonExcludedNamespace, fmt.Sprintf("namespace %s matches pattern %s", namespace, pattern)
 		}
 	}
 
 	// If no watch patterns specified, watch all (that aren't excluded)
 	if len(f.config.WatchNamespaces) == 0 {
-		return true
+		return "", ""
 	}
 
 	// Check if namespace matches any watch pattern
 	for _, pattern := range f.config.WatchNamespaces {
 		if matchGlob(pattern, namespace) {
-			return true
+			return "", ""
 		}
 	}
 
-	return false
+	return reasonNamespaceNotWatched, fmt.Sprintf("namespace %s matches no watch pattern", namespace)
+}
+
+// HasNamespaceLabelFilters returns true if filtering depends on namespace labels
+func (f *ResourceFilter) HasNamespaceLabelFilters() bool {
+	return len(f.config.WatchNamespaceLabels) > 0 || len(f.config.ExcludeNamespaceLabels) > 0
+}
+
+// ShouldWatchNamespaceByLabels returns true if a namespace with the given labels should be watched
+func (f *ResourceFilter) ShouldWatchNamespaceByLabels(nsLabels map[string]string) bool {
+	reason, detail := f.namespaceLabelExclusion(nsLabels)
+	if reason == "" {
+		return true
+	}
+	return f.dryRunAllow(reason, detail)
+}
+
+func (f *ResourceFilter) namespaceLabelExclusion(nsLabels map[string]string) (reason, detail string) {
+	for _, exclusion := range f.config.ExcludeNamespaceLabels {
+		if matchLabel(exclusion, nsLabels) {
+			return reasonExcludedNamespaceLabel, fmt.Sprintf("namespace labels match exclusion %s", exclusion)
+		}
+	}
+
+	// If no watch labels specified, watch all (that aren't excluded)
+	if len(f.config.WatchNamespaceLabels) == 0 {
+		return "", ""
+	}
+
+	for _, selector := range f.config.WatchNamespaceLabels {
+		if matchLabel(selector, nsLabels) {
+			return "", ""
+		}
+	}
+
+	return reasonNamespaceLabelsNotWatched, "namespace labels match no watch selector"
 }
 
 // ShouldWatchResource returns true if the resource should be watched based on labels
 func (f *ResourceFilter) ShouldWatchResource(labels map[string]string) bool {
+	reason, detail := f.labelExclusion(labels)
+	if reason == "" {
+		return true
+	}
+	return f.dryRunAllow(reason, detail)
+}
+
+func (f *ResourceFilter) labelExclusion(labels map[string]string) (reason, detail string) {
 	// Check required labels
 	for _, requiredKey := range f.config.RequireLabels {
 		if _, exists := labels[requiredKey]; !exists {
-			return false
+			return reasonMissingRequiredLabel, fmt.Sprintf("required label %s is missing", requiredKey)
 		}
 	}
 
 	// Check exclusion labels
 	for _, exclusion := range f.config.ExcludeLabels {
-		key, value := parseKeyValue(exclusion)
-		if labelValue, exists := labels[key]; exists {
-			if value == "" || labelValue == value {
-				return false
-			}
+		if matchLabel(exclusion, labels) {
+			return reasonExcludedLabel, fmt.Sprintf("labels match exclusion %s", exclusion)
 		}
 	}
 
+	return "", ""
+}
+
+// dryRunAllow records an exclusion in dry-run mode and reports whether the resource should still be watched
+func (f *ResourceFilter) dryRunAllow(reason, detail string) bool {
+	if !f.config.DryRun {
+		return false
+	}
+	ctrl.Log.WithName("resource-filter").V(1).Info("would exclude: "+detail, "reason", reason)
+	dryRunWouldExcludeCounter.WithLabelValues(reason).Inc()
 	return true
 }
 
@@ -87,6 +191,11 @@ func (f *ResourceFilter) ShouldTrackPods() bool {
 	return f.config.TrackPods
 }
 
+// ShouldTrackNamespaces returns true if namespace tracking is enabled
+func (f *ResourceFilter) ShouldTrackNamespaces() bool {
+	return f.config.TrackNamespaces
+}
+
 // ShouldTrackServices returns true if service tracking is enabled
 func (f *ResourceFilter) ShouldTrackServices() bool {
 	return f.config.TrackServices
@@ -102,6 +211,13 @@ func matchGlob(pattern, s string) bool {
 	return matched
 }
 
+// matchLabel returns true if labels contain the "key=value" (or bare "key") selector
+func matchLabel(selector string, labels map[string]string) bool {
+	key, value := parseKeyValue(selector)
+	labelValue, exists := labels[key]
+	return exists && (value == "" || labelValue == value)
+}
+
 // parseKeyValue parses a "key=value" or "key" string
 func parseKeyValue(s string) (key, value string) {
 	parts := strings.SplitN(s, "=", 2)
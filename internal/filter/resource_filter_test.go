@@ -0,0 +1,199 @@
+package filter
+
+import "testing"
+
+func TestResourceFilter_ShouldWatchNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    ResourceFilterConfig
+		namespace string
+		expected  bool
+	}{
+		{
+			name:      "no filters watches everything",
+			config:    ResourceFilterConfig{},
+			namespace: "default",
+			expected:  true,
+		},
+		{
+			name:      "excluded namespace",
+			config:    ResourceFilterConfig{ExcludeNamespaces: []string{"kube-*"}},
+			namespace: "kube-system",
+			expected:  false,
+		},
+		{
+			name:      "namespace not in watch list",
+			config:    ResourceFilterConfig{WatchNamespaces: []string{"production-*"}},
+			namespace: "staging-api",
+			expected:  false,
+		},
+		{
+			name: "exclusion wins over watch",
+			config: ResourceFilterConfig{
+				WatchNamespaces:   []string{"production-*"},
+				ExcludeNamespaces: []string{"production-sandbox"},
+			},
+			namespace: "production-sandbox",
+			expected:  false,
+		},
+		{
+			name:      "dry run never excludes",
+			config:    ResourceFilterConfig{ExcludeNamespaces: []string{"kube-*"}, DryRun: true},
+			namespace: "kube-system",
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewResourceFilter(tt.config)
+			if got := f.ShouldWatchNamespace(tt.namespace); got != tt.expected {
+				t.Errorf("ShouldWatchNamespace(%q) = %v, want %v", tt.namespace, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResourceFilter_ShouldWatchNamespace_Inverted(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    ResourceFilterConfig
+		namespace string
+		expected  bool
+	}{
+		{
+			name:      "no filters watches nothing",
+			config:    ResourceFilterConfig{},
+			namespace: "default",
+			expected:  false,
+		},
+		{
+			name:      "excluded namespace is watched",
+			config:    ResourceFilterConfig{ExcludeNamespaces: DefaultExcludedNamespaces()},
+			namespace: "kube-system",
+			expected:  true,
+		},
+		{
+			name:      "non-excluded namespace is not watched",
+			config:    ResourceFilterConfig{ExcludeNamespaces: DefaultExcludedNamespaces()},
+			namespace: "default",
+			expected:  false,
+		},
+		{
+			name: "namespace matching watch pattern is not watched",
+			config: ResourceFilterConfig{
+				WatchNamespaces:   []string{"production-*"},
+				ExcludeNamespaces: []string{"production-sandbox"},
+			},
+			namespace: "production-api",
+			expected:  false,
+		},
+		{
+			name: "namespace excluded despite matching watch pattern is watched",
+			config: ResourceFilterConfig{
+				WatchNamespaces:   []string{"production-*"},
+				ExcludeNamespaces: []string{"production-sandbox"},
+			},
+			namespace: "production-sandbox",
+			expected:  true,
+		},
+		{
+			name: "namespace outside watch patterns is watched",
+			config: ResourceFilterConfig{
+				WatchNamespaces:   []string{"production-*"},
+				ExcludeNamespaces: []string{"production-sandbox"},
+			},
+			namespace: "staging-api",
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.InvertNamespaceFilter = true
+			f := NewResourceFilter(tt.config)
+			if got := f.ShouldWatchNamespace(tt.namespace); got != tt.expected {
+				t.Errorf("ShouldWatchNamespace(%q) = %v, want %v", tt.namespace, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResourceFilter_ShouldWatchNamespaceByLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   ResourceFilterConfig
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name:     "no label filters watches everything",
+			config:   ResourceFilterConfig{},
+			labels:   nil,
+			expected: true,
+		},
+		{
+			name:     "matching watch label",
+			config:   ResourceFilterConfig{WatchNamespaceLabels: []string{"team=platform"}},
+			labels:   map[string]string{"team": "platform"},
+			expected: true,
+		},
+		{
+			name:     "different watch label value",
+			config:   ResourceFilterConfig{WatchNamespaceLabels: []string{"team=platform"}},
+			labels:   map[string]string{"team": "payments"},
+			expected: false,
+		},
+		{
+			name:     "watch label key only",
+			config:   ResourceFilterConfig{WatchNamespaceLabels: []string{"team"}},
+			labels:   map[string]string{"team": "payments"},
+			expected: true,
+		},
+		{
+			name:     "unlabeled namespace with watch labels",
+			config:   ResourceFilterConfig{WatchNamespaceLabels: []string{"team=platform"}},
+			labels:   nil,
+			expected: false,
+		},
+		{
+			name:     "any watch label matches",
+			config:   ResourceFilterConfig{WatchNamespaceLabels: []string{"team=platform", "env=production"}},
+			labels:   map[string]string{"env": "production"},
+			expected: true,
+		},
+		{
+			name:     "excluded label",
+			config:   ResourceFilterConfig{ExcludeNamespaceLabels: []string{"env=sandbox"}},
+			labels:   map[string]string{"env": "sandbox"},
+			expected: false,
+		},
+		{
+			name: "exclusion wins over watch",
+			config: ResourceFilterConfig{
+				WatchNamespaceLabels:   []string{"team=platform"},
+				ExcludeNamespaceLabels: []string{"env=sandbox"},
+			},
+			labels:   map[string]string{"team": "platform", "env": "sandbox"},
+			expected: false,
+		},
+		{
+			name:     "dry run never excludes",
+			config:   ResourceFilterConfig{ExcludeNamespaceLabels: []string{"env=sandbox"}, DryRun: true},
+			labels:   map[string]string{"env": "sandbox"},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewResourceFilter(tt.config)
+			if got := f.ShouldWatchNamespaceByLabels(tt.labels); got != tt.expected {
+				t.Errorf("ShouldWatchNamespaceByLabels(%v) = %v, want %v", tt.labels, got, tt.expected)
+			}
+			if got := f.HasNamespaceLabelFilters(); got != (len(tt.config.WatchNamespaceLabels)+len(tt.config.ExcludeNamespaceLabels) > 0) {
+				t.Errorf("HasNamespaceLabelFilters() = %v", got)
+			}
+		})
+	}
+}
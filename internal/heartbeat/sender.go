@@ -6,9 +6,33 @@ import (
 
 	"github.com/apptrail-sh/agent/internal/hooks"
 	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// initialRetryDelay is the first retry delay after a failed heartbeat; it doubles up to the interval
+const initialRetryDelay = 30 * time.Second
+
+var (
+	publishFailuresCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_heartbeat_publish_failures_total",
+		Help: "Number of heartbeats that failed to publish",
+	})
+
+	publishSuccessCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_heartbeat_publish_success_total",
+		Help: "Number of heartbeats published successfully",
+	})
+
+	lastSuccessGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "apptrail_heartbeat_last_success_timestamp_seconds",
+		Help: "Unix timestamp of the last successfully published heartbeat",
+	})
+
+	metricsRegistered = false
 )
 
 // Config holds configuration for the heartbeat sender
@@ -31,10 +55,14 @@ func DefaultConfig() Config {
 
 // Sender periodically sends heartbeats to the control plane
 type Sender struct {
-	config     Config
-	client     client.Client
-	publishers []hooks.HeartbeatPublisher
-	stopCh     chan struct{}
+	config       Config
+	client       client.Client
+	publishers   []hooks.HeartbeatPublisher
+	healthChecks []hooks.HealthCheckable
+	stopCh       chan struct{}
+
+	// nextRetryDelay is the backoff after a failed heartbeat; zero means the normal interval
+	nextRetryDelay time.Duration
 }
 
 // NewSender creates a new heartbeat sender
@@ -42,12 +70,20 @@ func NewSender(
 	config Config,
 	k8sClient client.Client,
 	publishers []hooks.HeartbeatPublisher,
+	healthChecks []hooks.HealthCheckable,
 ) *Sender {
+	// Register metrics only once
+	if !metricsRegistered {
+		metrics.Registry.MustRegister(publishFailuresCounter, publishSuccessCounter, lastSuccessGauge)
+		metricsRegistered = true
+	}
+
 	return &Sender{
-		config:     config,
-		client:     k8sClient,
-		publishers: publishers,
-		stopCh:     make(chan struct{}),
+		config:       config,
+		client:       k8sClient,
+		publishers:   publishers,
+		healthChecks: healthChecks,
+		stopCh:       make(chan struct{}),
 	}
 }
 
@@ -64,15 +100,19 @@ func (s *Sender) Start(ctx context.Context) {
 	)
 
 	// Send initial heartbeat immediately
-	s.sendHeartbeat(ctx)
+	delay := s.nextDelay(s.sendHeartbeat(ctx))
 
-	ticker := time.NewTicker(s.config.Interval)
-	defer ticker.Stop()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			s.sendHeartbeat(ctx)
+		case <-timer.C:
+			delay = s.nextDelay(s.sendHeartbeat(ctx))
+			if s.nextRetryDelay > 0 {
+				logger.Info("Heartbeat failed, retrying with backoff", "retryIn", delay)
+			}
+			timer.Reset(delay)
 		case <-s.stopCh:
 			logger.Info("Heartbeat sender stopped")
 			return
@@ -88,7 +128,27 @@ func (s *Sender) Stop() {
 	close(s.stopCh)
 }
 
-func (s *Sender) sendHeartbeat(ctx context.Context) {
+// nextDelay returns the wait before the next heartbeat, backing off exponentially after failures
+// and returning to the normal interval on the first success
+func (s *Sender) nextDelay(success bool) time.Duration {
+	if success {
+		s.nextRetryDelay = 0
+		return s.config.Interval
+	}
+
+	if s.nextRetryDelay == 0 {
+		s.nextRetryDelay = initialRetryDelay
+	} else {
+		s.nextRetryDelay *= 2
+	}
+	if s.nextRetryDelay > s.config.Interval {
+		s.nextRetryDelay = s.config.Interval
+	}
+	return s.nextRetryDelay
+}
+
+// sendHeartbeat publishes a heartbeat and reports whether every publisher accepted it
+func (s *Sender) sendHeartbeat(ctx context.Context) bool {
 	logger := log.FromContext(ctx).WithName("heartbeat-sender")
 
 	// Collect node UIDs if tracking nodes
@@ -111,11 +171,18 @@ func (s *Sender) sendHeartbeat(ctx context.Context) {
 		}
 	}
 
+	// Report event publisher health alongside the inventory
+	publisherHealth := make([]model.PublisherHealthStatus, 0, len(s.healthChecks))
+	for _, check := range s.healthChecks {
+		publisherHealth = append(publisherHealth, check.Check(ctx))
+	}
+
 	payload := model.NewClusterHeartbeatPayload(
 		s.config.ClusterID,
 		s.config.AgentVersion,
 		nodeUIDs,
 		podUIDs,
+		publisherHealth,
 	)
 
 	logger.Info("Sending heartbeat",
@@ -125,11 +192,21 @@ func (s *Sender) sendHeartbeat(ctx context.Context) {
 	)
 
 	// Publish to all registered publishers
+	success := true
 	for _, publisher := range s.publishers {
 		if err := publisher.PublishHeartbeat(ctx, payload); err != nil {
 			logger.Error(err, "Failed to publish heartbeat")
+			success = false
 		}
 	}
+
+	if !success {
+		publishFailuresCounter.Inc()
+		return false
+	}
+	publishSuccessCounter.Inc()
+	lastSuccessGauge.SetToCurrentTime()
+	return true
 }
 
 func (s *Sender) collectNodeUIDs(ctx context.Context) ([]string, error) {
@@ -0,0 +1,68 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/hooks"
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+// flakyPublisher fails the first failures heartbeats, then succeeds
+type flakyPublisher struct {
+	failures int
+	calls    int
+}
+
+func (p *flakyPublisher) PublishHeartbeat(_ context.Context, _ model.ClusterHeartbeatPayload) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("control plane unavailable")
+	}
+	return nil
+}
+
+func TestSender_BackoffSequence(t *testing.T) {
+	publisher := &flakyPublisher{failures: 7}
+	sender := NewSender(Config{Interval: 5 * time.Minute}, nil, []hooks.HeartbeatPublisher{publisher}, nil)
+
+	expected := []time.Duration{
+		30 * time.Second,
+		1 * time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		5 * time.Minute, // 8m capped at the interval
+		5 * time.Minute,
+		5 * time.Minute,
+		5 * time.Minute, // success: back to the normal interval
+		5 * time.Minute,
+	}
+
+	for i, want := range expected {
+		got := sender.nextDelay(sender.sendHeartbeat(context.Background()))
+		if got != want {
+			t.Errorf("Attempt %d: expected delay %v, got %v", i+1, want, got)
+		}
+	}
+
+	if sender.nextRetryDelay != 0 {
+		t.Errorf("Expected backoff reset after success, got %v", sender.nextRetryDelay)
+	}
+}
+
+func TestSender_BackoffResetsOnSuccess(t *testing.T) {
+	publisher := &flakyPublisher{failures: 2}
+	sender := NewSender(Config{Interval: 10 * time.Minute}, nil, []hooks.HeartbeatPublisher{publisher}, nil)
+
+	for range 3 {
+		sender.nextDelay(sender.sendHeartbeat(context.Background()))
+	}
+
+	// A later failure starts again from the initial retry delay
+	publisher.failures = publisher.calls + 1
+	if got := sender.nextDelay(sender.sendHeartbeat(context.Background())); got != initialRetryDelay {
+		t.Errorf("Expected backoff to restart at %v, got %v", initialRetryDelay, got)
+	}
+}
@@ -0,0 +1,194 @@
+package hooks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// DefaultAggregationWindow is how long events are counted per namespace before counts reset
+	DefaultAggregationWindow = 30 * time.Second
+
+	// EventCountsMetadataKey holds the per-kind counts of an aggregated event
+	EventCountsMetadataKey = "eventCounts"
+)
+
+var (
+	aggregatedEventsCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_namespace_aggregated_events_total",
+		Help: "Number of resource events replaced by a namespace AGGREGATED event",
+	})
+
+	aggregatorMetricsOnce sync.Once
+)
+
+// AggregationConfig holds configuration for namespace event aggregation
+type AggregationConfig struct {
+	Threshold int           // Events per namespace per window published individually
+	Window    time.Duration // Defaults to DefaultAggregationWindow
+}
+
+// namespaceAggregate collects the events of a namespace held back in the current window
+type namespaceAggregate struct {
+	source model.SourceMetadata
+	counts map[model.ResourceEventKind]int
+}
+
+// NamespaceAggregator limits the events each namespace sends to the resource event queue. It sits
+// between the reconcilers and the ResourceEventPublisherQueue: once a namespace exceeds the
+// threshold within a window, its further events are replaced by a single AGGREGATED event with
+// per-kind counts, sent to the queue when the window ends. Lifecycle events (CREATED, DELETED)
+// and cluster-scoped resources always pass so the control plane inventory stays accurate.
+type NamespaceAggregator struct {
+	in     <-chan model.ResourceEventPayload
+	out    chan<- model.ResourceEventPayload
+	config AggregationConfig
+
+	// Only used by the Loop goroutine
+	windowStart time.Time
+	counts      map[string]int
+	aggregates  map[string]*namespaceAggregate
+
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+	now       func() time.Time
+}
+
+// NewNamespaceAggregator creates an aggregator forwarding the events read from in to out
+func NewNamespaceAggregator(in <-chan model.ResourceEventPayload, out chan<- model.ResourceEventPayload,
+	config AggregationConfig) *NamespaceAggregator {
+	if config.Window <= 0 {
+		config.Window = DefaultAggregationWindow
+	}
+
+	aggregatorMetricsOnce.Do(func() {
+		metrics.Registry.MustRegister(aggregatedEventsCounter)
+	})
+
+	return &NamespaceAggregator{
+		in:          in,
+		out:         out,
+		config:      config,
+		windowStart: time.Now(),
+		counts:      make(map[string]int),
+		aggregates:  make(map[string]*namespaceAggregate),
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+		now:         time.Now,
+	}
+}
+
+// Loop forwards events below the namespace threshold and sends the aggregated events at the end of
+// every window, until in is closed or Close is called
+func (a *NamespaceAggregator) Loop() {
+	defer close(a.doneCh)
+
+	ticker := time.NewTicker(a.config.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-a.in:
+			if !ok {
+				a.flush(context.Background())
+				return
+			}
+			if a.pass(event) {
+				a.out <- event
+			}
+		case <-ticker.C:
+			a.flush(context.Background())
+		case <-a.stopCh:
+			a.flush(context.Background())
+			return
+		}
+	}
+}
+
+// pass counts the event against its namespace and reports whether to forward it individually
+func (a *NamespaceAggregator) pass(event model.ResourceEventPayload) bool {
+	namespace := event.Resource.Namespace
+	if namespace == "" || !aggregatable(event.EventKind) {
+		return true
+	}
+
+	a.counts[namespace]++
+	if a.counts[namespace] <= a.config.Threshold {
+		return true
+	}
+
+	aggregate, ok := a.aggregates[namespace]
+	if !ok {
+		aggregate = &namespaceAggregate{source: event.Source, counts: make(map[model.ResourceEventKind]int)}
+		a.aggregates[namespace] = aggregate
+	}
+	aggregate.counts[event.EventKind]++
+	aggregatedEventsCounter.Inc()
+	return false
+}
+
+// aggregatable reports whether events of this kind may be replaced by an aggregated event
+func aggregatable(kind model.ResourceEventKind) bool {
+	return kind != model.ResourceEventKindCreated && kind != model.ResourceEventKindDeleted
+}
+
+// flush ends the current window, sending one AGGREGATED event per namespace that exceeded the threshold
+func (a *NamespaceAggregator) flush(ctx context.Context) {
+	windowStart, windowEnd := a.windowStart, a.now()
+	aggregates := a.aggregates
+	a.windowStart = windowEnd
+	a.counts = make(map[string]int)
+	a.aggregates = make(map[string]*namespaceAggregate)
+
+	namespaces := make([]string, 0, len(aggregates))
+	for namespace := range aggregates {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		aggregate := aggregates[namespace]
+		event := model.NewResourceEventPayload(
+			model.ResourceTypeNamespace,
+			model.ResourceRef{Kind: "Namespace", Name: namespace},
+			nil,
+			model.ResourceEventKindAggregated,
+			nil,
+			map[string]any{
+				EventCountsMetadataKey: aggregate.counts,
+				"windowStart":          windowStart,
+				"windowEnd":            windowEnd,
+			},
+			aggregate.source.ClusterID,
+			aggregate.source.AgentVersion,
+		)
+		event.Source = aggregate.source
+
+		log.FromContext(ctx).Info("Aggregated namespace resource events",
+			"namespace", namespace,
+			"eventCounts", aggregate.counts,
+		)
+		a.out <- event
+	}
+}
+
+// Close stops the loop once it has sent the pending aggregated events to the queue
+func (a *NamespaceAggregator) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() {
+		close(a.stopCh)
+	})
+	select {
+	case <-a.doneCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func namespacedEvents(namespace string, kind model.ResourceEventKind, n int) []model.ResourceEventPayload {
+	events := make([]model.ResourceEventPayload, 0, n)
+	for range n {
+		events = append(events, model.NewResourceEventPayload(model.ResourceTypePod,
+			model.ResourceRef{Kind: "Pod", Name: "api", Namespace: namespace}, nil, kind, nil, nil, "cluster", "v1"))
+	}
+	return events
+}
+
+// drain returns the events waiting on ch
+func drain(ch <-chan model.ResourceEventPayload) []model.ResourceEventPayload {
+	var events []model.ResourceEventPayload
+	for len(ch) > 0 {
+		events = append(events, <-ch)
+	}
+	return events
+}
+
+func TestNamespaceAggregator(t *testing.T) {
+	in := make(chan model.ResourceEventPayload, 20)
+	out := make(chan model.ResourceEventPayload, 20)
+	aggregator := NewNamespaceAggregator(in, out, AggregationConfig{Threshold: 3, Window: time.Hour})
+
+	var events []model.ResourceEventPayload
+	events = append(events, namespacedEvents("shop", model.ResourceEventKindStatusChange, 5)...)
+	events = append(events, namespacedEvents("shop", model.ResourceEventKindUpdated, 2)...)
+	events = append(events, namespacedEvents("shop", model.ResourceEventKindDeleted, 2)...)
+	events = append(events, namespacedEvents("billing", model.ResourceEventKindStatusChange, 2)...)
+	events = append(events, namespacedEvents("", model.ResourceEventKindStatusChange, 5)...)
+	for _, event := range events {
+		in <- event
+	}
+	close(in)
+
+	// Closing the input ends the window, so the aggregated event follows the passed ones
+	aggregator.Loop()
+	forwarded := drain(out)
+
+	// 3 shop status changes, 2 shop deletions, 2 billing and 5 cluster-scoped events pass
+	if got := len(forwarded); got != 13 {
+		t.Fatalf("Expected 12 events forwarded individually and 1 aggregated, got %d", got)
+	}
+	for _, event := range forwarded[:12] {
+		if event.EventKind == model.ResourceEventKindAggregated {
+			t.Fatalf("Expected the aggregated event last, got %+v", forwarded)
+		}
+	}
+
+	aggregated := forwarded[12]
+	if aggregated.EventKind != model.ResourceEventKindAggregated || aggregated.Resource.Name != "shop" {
+		t.Fatalf("Expected AGGREGATED event for shop, got %+v", aggregated)
+	}
+	counts := aggregated.Metadata[EventCountsMetadataKey].(map[model.ResourceEventKind]int)
+	if counts[model.ResourceEventKindStatusChange] != 2 || counts[model.ResourceEventKindUpdated] != 2 || len(counts) != 2 {
+		t.Errorf("Expected 2 status changes and 2 updates aggregated, got %v", counts)
+	}
+	if aggregated.Source.ClusterID != "cluster" {
+		t.Errorf("Expected source of the aggregated events, got %+v", aggregated.Source)
+	}
+}
+
+func TestNamespaceAggregator_WindowResetsCounts(t *testing.T) {
+	out := make(chan model.ResourceEventPayload, 10)
+	aggregator := NewNamespaceAggregator(nil, out, AggregationConfig{Threshold: 2, Window: time.Hour})
+
+	events := namespacedEvents("shop", model.ResourceEventKindStatusChange, 2)
+	for _, event := range events {
+		if !aggregator.pass(event) {
+			t.Fatal("Expected events below the threshold to pass")
+		}
+	}
+	aggregator.flush(context.Background())
+	for _, event := range events {
+		if !aggregator.pass(event) {
+			t.Fatal("Expected the counts to reset with the window")
+		}
+	}
+	if got := len(out); got != 0 {
+		t.Errorf("Expected no aggregated event below the threshold, got %d", got)
+	}
+}
+
+func TestNamespaceAggregator_Close(t *testing.T) {
+	in := make(chan model.ResourceEventPayload)
+	out := make(chan model.ResourceEventPayload, 10)
+	aggregator := NewNamespaceAggregator(in, out, AggregationConfig{Threshold: 1, Window: time.Hour})
+	go aggregator.Loop()
+
+	for _, event := range namespacedEvents("shop", model.ResourceEventKindStatusChange, 3) {
+		in <- event
+	}
+	if err := aggregator.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	forwarded := drain(out)
+	if len(forwarded) != 2 || forwarded[1].EventKind != model.ResourceEventKindAggregated {
+		t.Fatalf("Expected 1 forwarded and 1 aggregated event on close, got %+v", forwarded)
+	}
+	if err := aggregator.Close(context.Background()); err != nil {
+		t.Errorf("Expected a second Close to succeed, got %v", err)
+	}
+}
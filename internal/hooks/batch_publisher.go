@@ -2,17 +2,46 @@ package hooks
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/google/uuid"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// deletionBufferFactor sizes the DELETED event buffer as a multiple of BufferSize. Deletions
+// get more room than other events, but are still bounded while publishers are unavailable.
+const deletionBufferFactor = 4
+
+// DropPolicy decides which event is discarded when the queue buffer is full
+type DropPolicy string
+
+const (
+	// DropNewest discards incoming events while the buffer is full
+	DropNewest DropPolicy = "newest"
+	// DropOldest discards the oldest buffered event to make room for the incoming one
+	DropOldest DropPolicy = "oldest"
+)
+
+// ParseDropPolicy parses a drop policy name
+func ParseDropPolicy(s string) (DropPolicy, error) {
+	switch DropPolicy(s) {
+	case DropNewest, DropOldest:
+		return DropPolicy(s), nil
+	default:
+		return "", fmt.Errorf("invalid drop policy %q: expected %q or %q", s, DropNewest, DropOldest)
+	}
+}
+
 // BatchConfig holds configuration for event batching
 type BatchConfig struct {
 	FlushWindow  time.Duration // Time window for batching events
 	MaxBatchSize int           // Maximum events per batch
+	BufferSize   int           // Maximum events buffered while publishing is in progress
+	DropPolicy   DropPolicy    // Which event to discard when the buffer is full
 }
 
 // DefaultBatchConfig returns the default batching configuration
@@ -20,12 +49,15 @@ func DefaultBatchConfig() BatchConfig {
 	return BatchConfig{
 		FlushWindow:  2 * time.Second,
 		MaxBatchSize: 100,
+		BufferSize:   1000,
+		DropPolicy:   DropNewest,
 	}
 }
 
 // ResourceEventPublisher is the interface for publishing resource events (batched)
 type ResourceEventPublisher interface {
-	PublishBatch(ctx context.Context, events []model.ResourceEventPayload) error
+	PublishBatch(ctx context.Context, events []model.ResourceEventPayload, meta model.BatchMetadata) error
+	Close(ctx context.Context) error
 }
 
 // ResourceEventPublisherQueue handles batching and publishing of resource events
@@ -34,11 +66,21 @@ type ResourceEventPublisherQueue struct {
 	publishers []ResourceEventPublisher
 	config     BatchConfig
 
-	mu      sync.Mutex
-	buffer  []model.ResourceEventPayload
-	timer   *time.Timer
-	stopCh  chan struct{}
-	stopped bool
+	// HighPriorityChan, when set, carries DELETED events. It is read before eventChan, so a
+	// deletion is neither blocked by a full eventChan nor queued behind other resources' events.
+	HighPriorityChan <-chan model.ResourceEventPayload
+
+	mu         sync.Mutex
+	highBuffer []model.ResourceEventPayload // DELETED events, bounded by deletionBufferFactor*BufferSize
+	buffer     *eventRing
+	dropped    model.BatchMetadata // Drops since the last flush, reported with the next batch
+	timer      *time.Timer
+	flushCh    chan struct{}
+	stopCh     chan struct{}
+	stopped    bool
+
+	// Serializes publishing so batches reach publishers in order
+	publishMu sync.Mutex
 }
 
 // NewResourceEventPublisherQueue creates a new batching resource event publisher queue
@@ -47,11 +89,20 @@ func NewResourceEventPublisherQueue(
 	publishers []ResourceEventPublisher,
 	config BatchConfig,
 ) *ResourceEventPublisherQueue {
+	if config.BufferSize < config.MaxBatchSize {
+		config.BufferSize = config.MaxBatchSize
+	}
+	if config.DropPolicy == "" {
+		config.DropPolicy = DropNewest
+	}
+
 	return &ResourceEventPublisherQueue{
 		eventChan:  eventChan,
 		publishers: publishers,
 		config:     config,
-		buffer:     make([]model.ResourceEventPayload, 0, config.MaxBatchSize),
+		highBuffer: make([]model.ResourceEventPayload, 0, config.MaxBatchSize),
+		buffer:     newEventRing(config.BufferSize),
+		flushCh:    make(chan struct{}, 1),
 		stopCh:     make(chan struct{}),
 	}
 }
@@ -65,17 +116,55 @@ func (q *ResourceEventPublisherQueue) Loop() {
 		"publishers", len(q.publishers),
 		"flushWindow", q.config.FlushWindow,
 		"maxBatchSize", q.config.MaxBatchSize,
+		"bufferSize", q.config.BufferSize,
+		"dropPolicy", q.config.DropPolicy,
 	)
 
+	// Publish from a separate goroutine so the channel keeps draining while publishers are slow
+	flusherDone := make(chan struct{})
+	defer func() { <-flusherDone }()
+	go func() {
+		defer close(flusherDone)
+		for {
+			select {
+			case <-q.flushCh:
+				q.flush(ctx)
+			case <-q.stopCh:
+				return
+			}
+		}
+	}()
+
+	highChan := q.HighPriorityChan
 	for {
+		// Prefer deletions over events already waiting on eventChan
+		select {
+		case event, ok := <-highChan:
+			if !ok {
+				highChan = nil
+			} else {
+				q.addEvent(event)
+			}
+			continue
+		default:
+		}
+
 		select {
+		case event, ok := <-highChan:
+			if !ok {
+				highChan = nil
+				continue
+			}
+			q.addEvent(event)
+
 		case event, ok := <-q.eventChan:
 			if !ok {
 				// Channel closed, flush remaining events
+				q.Stop()
 				q.flush(ctx)
 				return
 			}
-			q.addEvent(ctx, event)
+			q.addEvent(event)
 
 		case <-q.stopCh:
 			q.flush(ctx)
@@ -94,60 +183,238 @@ func (q *ResourceEventPublisherQueue) Stop() {
 	q.mu.Unlock()
 }
 
-func (q *ResourceEventPublisherQueue) addEvent(ctx context.Context, event model.ResourceEventPayload) {
+func (q *ResourceEventPublisherQueue) addEvent(event model.ResourceEventPayload) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	q.buffer = append(q.buffer, event)
+	// Deletions have their own larger buffer so a burst of status changes cannot drop them
+	if event.EventKind == model.ResourceEventKindDeleted {
+		q.addDeletionLocked(event)
+	} else if q.buffer.Full() {
+		if q.config.DropPolicy == DropOldest {
+			q.recordDropLocked(q.buffer.Pop())
+			q.buffer.Push(event)
+		} else {
+			q.recordDropLocked(event)
+		}
+	} else {
+		q.buffer.Push(event)
+	}
+
+	pending := q.pendingLocked()
 
 	// Start timer on first event
-	if len(q.buffer) == 1 {
-		q.timer = time.AfterFunc(q.config.FlushWindow, func() {
-			q.flush(ctx)
-		})
+	if pending == 1 {
+		q.timer = time.AfterFunc(q.config.FlushWindow, q.requestFlush)
 	}
 
 	// Flush immediately if batch is full
-	if len(q.buffer) >= q.config.MaxBatchSize {
-		q.flushLocked(ctx)
+	if pending >= q.config.MaxBatchSize {
+		q.requestFlush()
+	}
+}
+
+// addDeletionLocked buffers a DELETED event, applying the drop policy once the deletion buffer is full
+func (q *ResourceEventPublisherQueue) addDeletionLocked(event model.ResourceEventPayload) {
+	if len(q.highBuffer) < deletionBufferFactor*q.config.BufferSize {
+		q.highBuffer = append(q.highBuffer, event)
+		return
+	}
+	if q.config.DropPolicy == DropOldest {
+		q.recordDropLocked(q.highBuffer[0])
+		q.highBuffer = append(q.highBuffer[1:], event)
+		return
+	}
+	q.recordDropLocked(event)
+}
+
+func (q *ResourceEventPublisherQueue) recordDropLocked(event model.ResourceEventPayload) {
+	q.dropped.DroppedCount++
+	if q.dropped.OldestDroppedAt.IsZero() || event.OccurredAt.Before(q.dropped.OldestDroppedAt) {
+		q.dropped.OldestDroppedAt = event.OccurredAt
+	}
+}
+
+func (q *ResourceEventPublisherQueue) pendingLocked() int {
+	return len(q.highBuffer) + q.buffer.Len()
+}
+
+// drainHighPriority buffers the deletions waiting on HighPriorityChan so a flush includes them
+func (q *ResourceEventPublisherQueue) drainHighPriority() {
+	for {
+		select {
+		case event, ok := <-q.HighPriorityChan:
+			if !ok {
+				return
+			}
+			q.addEvent(event)
+		default:
+			return
+		}
+	}
+}
+
+// requestFlush asks the flusher goroutine to publish buffered events
+func (q *ResourceEventPublisherQueue) requestFlush() {
+	select {
+	case q.flushCh <- struct{}{}:
+	default:
+		// A flush is already pending
 	}
 }
 
 func (q *ResourceEventPublisherQueue) flush(ctx context.Context) {
+	q.publishMu.Lock()
+	defer q.publishMu.Unlock()
+
+	q.drainHighPriority()
+
 	q.mu.Lock()
-	defer q.mu.Unlock()
-	q.flushLocked(ctx)
-}
+	batches, meta := q.takeLocked()
+	q.mu.Unlock()
 
-func (q *ResourceEventPublisherQueue) flushLocked(ctx context.Context) {
-	if len(q.buffer) == 0 {
+	if len(batches) == 0 {
 		return
 	}
 
+	logger := log.FromContext(ctx)
+
+	for i, events := range batches {
+		// Drop statistics ride along with the first batch only
+		batchMeta := model.BatchMetadata{}
+		if i == 0 {
+			batchMeta = meta
+		}
+
+		logger.Info("Flushing resource event batch",
+			"batchID", events[0].BatchID,
+			"eventCount", len(events),
+			"droppedCount", batchMeta.DroppedCount,
+			"publishers", len(q.publishers),
+		)
+
+		// Publish to all registered publishers
+		for _, publisher := range q.publishers {
+			if err := publisher.PublishBatch(ctx, events, batchMeta); err != nil {
+				logger.Error(err, "Failed to publish resource event batch")
+			}
+		}
+	}
+}
+
+// takeLocked removes all buffered events, high priority first, split into batches of at most MaxBatchSize
+func (q *ResourceEventPublisherQueue) takeLocked() ([][]model.ResourceEventPayload, model.BatchMetadata) {
 	// Stop timer if running
 	if q.timer != nil {
 		q.timer.Stop()
 		q.timer = nil
 	}
 
-	logger := log.FromContext(ctx)
+	meta := q.dropped
+	q.dropped = model.BatchMetadata{}
 
-	// Copy buffer for publishing
-	events := make([]model.ResourceEventPayload, len(q.buffer))
-	copy(events, q.buffer)
+	pending := q.pendingLocked()
+	if pending == 0 {
+		return nil, meta
+	}
 
-	// Clear buffer
-	q.buffer = q.buffer[:0]
+	normal := make([]model.ResourceEventPayload, 0, q.buffer.Len())
+	for q.buffer.Len() > 0 {
+		normal = append(normal, q.buffer.Pop())
+	}
+	events := orderTiers(q.highBuffer, normal)
 
-	// Publish to all registered publishers
-	logger.Info("Flushing resource event batch",
-		"eventCount", len(events),
-		"publishers", len(q.publishers),
-	)
+	// Clear buffers
+	q.highBuffer = q.highBuffer[:0]
 
-	for _, publisher := range q.publishers {
-		if err := publisher.PublishBatch(ctx, events); err != nil {
-			logger.Error(err, "Failed to publish resource event batch")
+	// Every event taken by this flush shares a batch ID, even when split across publishes
+	batchID := uuid.NewString()
+	for i := range events {
+		events[i].BatchID = batchID
+		events[i].BatchSize = len(events)
+		events[i].BatchIndex = i
+	}
+
+	var batches [][]model.ResourceEventPayload
+	for len(events) > 0 {
+		size := min(len(events), q.config.MaxBatchSize)
+		batches = append(batches, events[:size])
+		events = events[size:]
+	}
+	return batches, meta
+}
+
+// eventRing is a fixed-capacity FIFO circular buffer of resource events
+type eventRing struct {
+	items []model.ResourceEventPayload
+	head  int
+	size  int
+}
+
+// orderTiers returns the deletions in high ahead of the events in normal, each tier sorted by
+// resource sequence. A deletion is only held back behind the earlier events of its own
+// resource, which move ahead with it; sequence numbers are not comparable across resources.
+func orderTiers(high, normal []model.ResourceEventPayload) []model.ResourceEventPayload {
+	events := make([]model.ResourceEventPayload, 0, len(high)+len(normal))
+	if len(high) == 0 {
+		sortByResourceSequence(normal)
+		return append(events, normal...)
+	}
+
+	deletedAt := make(map[string]uint64, len(high))
+	for _, event := range high {
+		deletedAt[event.Resource.UID] = max(deletedAt[event.Resource.UID], event.SequenceNum)
+	}
+	var held, rest []model.ResourceEventPayload
+	for _, event := range normal {
+		if seq, ok := deletedAt[event.Resource.UID]; ok && event.SequenceNum < seq {
+			held = append(held, event)
+		} else {
+			rest = append(rest, event)
 		}
 	}
+
+	// The held events sort ahead of the deletion that follows them within each resource
+	events = append(append(events, held...), high...)
+	sortByResourceSequence(events)
+	sortByResourceSequence(rest)
+	return append(events, rest...)
+}
+
+// sortByResourceSequence orders events by (resource UID, sequence number) so events for the
+// same resource are delivered in the order they were emitted
+func sortByResourceSequence(events []model.ResourceEventPayload) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Resource.UID != events[j].Resource.UID {
+			return events[i].Resource.UID < events[j].Resource.UID
+		}
+		return events[i].SequenceNum < events[j].SequenceNum
+	})
+}
+
+func newEventRing(capacity int) *eventRing {
+	return &eventRing{items: make([]model.ResourceEventPayload, capacity)}
+}
+
+func (r *eventRing) Len() int {
+	return r.size
+}
+
+func (r *eventRing) Full() bool {
+	return r.size == len(r.items)
+}
+
+// Push appends an event; the caller must ensure the ring is not full
+func (r *eventRing) Push(event model.ResourceEventPayload) {
+	r.items[(r.head+r.size)%len(r.items)] = event
+	r.size++
+}
+
+// Pop removes and returns the oldest event; the caller must ensure the ring is not empty
+func (r *eventRing) Pop() model.ResourceEventPayload {
+	event := r.items[r.head]
+	r.items[r.head] = model.ResourceEventPayload{}
+	r.head = (r.head + 1) % len(r.items)
+	r.size--
+	return event
 }
@@ -0,0 +1,388 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+type recordingPublisher struct {
+	mu      sync.Mutex
+	batches [][]model.ResourceEventPayload
+	metas   []model.BatchMetadata
+}
+
+func (p *recordingPublisher) PublishBatch(_ context.Context, events []model.ResourceEventPayload, meta model.BatchMetadata) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	batch := make([]model.ResourceEventPayload, len(events))
+	copy(batch, events)
+	p.batches = append(p.batches, batch)
+	p.metas = append(p.metas, meta)
+	return nil
+}
+
+func (p *recordingPublisher) Close(_ context.Context) error {
+	return nil
+}
+
+func (p *recordingPublisher) Batches() [][]model.ResourceEventPayload {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.batches
+}
+
+func TestResourceEventPublisherQueue_DeletionsFlushedFirst(t *testing.T) {
+	eventChan := make(chan model.ResourceEventPayload, 10)
+	publisher := &recordingPublisher{}
+	queue := NewResourceEventPublisherQueue(eventChan, []ResourceEventPublisher{publisher}, BatchConfig{
+		FlushWindow:  time.Hour,
+		MaxBatchSize: 100,
+	})
+
+	// The deleted resource's UID sorts after those of the queued status changes
+	for i, uid := range []string{"a", "b", "c"} {
+		eventChan <- model.ResourceEventPayload{
+			EventID:     uid + "-status",
+			EventKind:   model.ResourceEventKindStatusChange,
+			Resource:    model.ResourceRef{UID: uid},
+			SequenceNum: uint64(i + 1),
+		}
+	}
+	eventChan <- model.ResourceEventPayload{
+		EventID:     "z-deleted",
+		EventKind:   model.ResourceEventKindDeleted,
+		Resource:    model.ResourceRef{UID: "z"},
+		SequenceNum: 4,
+	}
+	close(eventChan)
+
+	// Loop returns once the channel is closed and remaining events are flushed
+	queue.Loop()
+
+	var ids []string
+	for _, batch := range publisher.Batches() {
+		for _, event := range batch {
+			ids = append(ids, event.EventID)
+		}
+	}
+	expected := []string{"z-deleted", "a-status", "b-status", "c-status"}
+	if !slices.Equal(ids, expected) {
+		t.Fatalf("Expected events %v, got %v", expected, ids)
+	}
+}
+
+func TestResourceEventPublisherQueue_DeletionBufferBounded(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    DropPolicy
+		wantFirst string
+		wantLast  string
+	}{
+		{name: "drop newest keeps buffered deletions", policy: DropNewest, wantFirst: "d0", wantLast: "d7"},
+		{name: "drop oldest keeps latest deletions", policy: DropOldest, wantFirst: "d2", wantLast: "d9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			queue := NewResourceEventPublisherQueue(nil, []ResourceEventPublisher{publisher}, BatchConfig{
+				FlushWindow:  time.Hour,
+				MaxBatchSize: 2,
+				BufferSize:   2,
+				DropPolicy:   tt.policy,
+			})
+
+			// The deletion buffer holds deletionBufferFactor times BufferSize events
+			for i := range 10 {
+				queue.addEvent(model.ResourceEventPayload{
+					EventID:     fmt.Sprintf("d%d", i),
+					EventKind:   model.ResourceEventKindDeleted,
+					Resource:    model.ResourceRef{UID: fmt.Sprintf("pod-%d", i)},
+					SequenceNum: uint64(i + 1),
+				})
+			}
+			queue.flush(context.Background())
+
+			var ids []string
+			for _, batch := range publisher.Batches() {
+				for _, event := range batch {
+					ids = append(ids, event.EventID)
+				}
+			}
+			if len(ids) != 8 || ids[0] != tt.wantFirst || ids[7] != tt.wantLast {
+				t.Fatalf("Expected deletions %s to %s, got %v", tt.wantFirst, tt.wantLast, ids)
+			}
+			if got := publisher.metas[0].DroppedCount; got != 2 {
+				t.Errorf("Expected 2 dropped events, got %d", got)
+			}
+		})
+	}
+}
+
+func TestResourceEventPublisherQueue_DeletionsKeepResourceOrder(t *testing.T) {
+	publisher := &recordingPublisher{}
+	queue := NewResourceEventPublisherQueue(nil, []ResourceEventPublisher{publisher}, BatchConfig{
+		FlushWindow:  time.Hour,
+		MaxBatchSize: 2,
+		BufferSize:   2,
+	})
+
+	for i, id := range []string{"a-created", "a-status", "b-status"} {
+		queue.addEvent(model.ResourceEventPayload{
+			EventID:     id,
+			EventKind:   model.ResourceEventKindStatusChange,
+			Resource:    model.ResourceRef{UID: id[:1]},
+			SequenceNum: uint64(i + 1),
+		})
+	}
+	// The buffer is full, but deletions are never dropped
+	queue.addEvent(model.ResourceEventPayload{
+		EventID:     "a-deleted",
+		EventKind:   model.ResourceEventKindDeleted,
+		Resource:    model.ResourceRef{UID: "a"},
+		SequenceNum: 4,
+	})
+	queue.flush(context.Background())
+
+	var ids []string
+	for _, batch := range publisher.Batches() {
+		for _, event := range batch {
+			ids = append(ids, event.EventID)
+		}
+	}
+	expected := []string{"a-created", "a-status", "a-deleted"}
+	if len(ids) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, ids)
+	}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Fatalf("Expected events %v, got %v", expected, ids)
+		}
+	}
+}
+
+func TestResourceEventPublisherQueue_DeletionsAheadOfOtherResources(t *testing.T) {
+	publisher := &recordingPublisher{}
+	queue := NewResourceEventPublisherQueue(nil, []ResourceEventPublisher{publisher}, BatchConfig{
+		FlushWindow:  time.Hour,
+		MaxBatchSize: 10,
+		BufferSize:   10,
+	})
+
+	// Pod and node reconcilers number their events independently, so sequence numbers of
+	// different resources say nothing about their order
+	events := []model.ResourceEventPayload{
+		{EventID: "a-status", EventKind: model.ResourceEventKindStatusChange, Resource: model.ResourceRef{UID: "a"}, SequenceNum: 1},
+		{EventID: "m-status", EventKind: model.ResourceEventKindStatusChange, Resource: model.ResourceRef{UID: "m"}, SequenceNum: 7},
+		{EventID: "z-deleted", EventKind: model.ResourceEventKindDeleted, Resource: model.ResourceRef{UID: "z"}, SequenceNum: 2},
+		{EventID: "m-deleted", EventKind: model.ResourceEventKindDeleted, Resource: model.ResourceRef{UID: "m"}, SequenceNum: 9},
+	}
+	for _, event := range events {
+		queue.addEvent(event)
+	}
+	queue.flush(context.Background())
+
+	var ids []string
+	for _, batch := range publisher.Batches() {
+		for _, event := range batch {
+			ids = append(ids, event.EventID)
+		}
+	}
+	// Only m's deletion waits, and only for m's earlier status change
+	expected := []string{"m-status", "m-deleted", "z-deleted", "a-status"}
+	if !slices.Equal(ids, expected) {
+		t.Fatalf("Expected events %v, got %v", expected, ids)
+	}
+}
+
+func TestResourceEventPublisherQueue_HighPriorityChan(t *testing.T) {
+	// The event channel is full, yet the deletion waiting on the high priority channel is published
+	eventChan := make(chan model.ResourceEventPayload, 2)
+	highChan := make(chan model.ResourceEventPayload, 1)
+	for _, id := range []string{"a-status", "b-status"} {
+		eventChan <- model.ResourceEventPayload{EventID: id, EventKind: model.ResourceEventKindStatusChange, Resource: model.ResourceRef{UID: id[:1]}}
+	}
+	highChan <- model.ResourceEventPayload{EventID: "c-deleted", EventKind: model.ResourceEventKindDeleted, Resource: model.ResourceRef{UID: "c"}}
+	close(eventChan)
+
+	publisher := &recordingPublisher{}
+	queue := NewResourceEventPublisherQueue(eventChan, []ResourceEventPublisher{publisher}, BatchConfig{
+		FlushWindow:  time.Hour,
+		MaxBatchSize: 10,
+		BufferSize:   10,
+	})
+	queue.HighPriorityChan = highChan
+	queue.Loop()
+
+	var ids []string
+	for _, batch := range publisher.Batches() {
+		for _, event := range batch {
+			ids = append(ids, event.EventID)
+		}
+	}
+	expected := []string{"c-deleted", "a-status", "b-status"}
+	if len(ids) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, ids)
+	}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Fatalf("Expected events %v, got %v", expected, ids)
+		}
+	}
+}
+
+func TestResourceEventPublisherQueue_DropPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      DropPolicy
+		expectedIDs []string
+	}{
+		{
+			name:        "drop newest keeps buffered events",
+			policy:      DropNewest,
+			expectedIDs: []string{"e1", "e2"},
+		},
+		{
+			name:        "drop oldest keeps latest events",
+			policy:      DropOldest,
+			expectedIDs: []string{"e3", "e4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			queue := NewResourceEventPublisherQueue(nil, []ResourceEventPublisher{publisher}, BatchConfig{
+				FlushWindow:  time.Hour,
+				MaxBatchSize: 2,
+				BufferSize:   2,
+				DropPolicy:   tt.policy,
+			})
+
+			base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i, id := range []string{"e1", "e2", "e3", "e4"} {
+				queue.addEvent(model.ResourceEventPayload{
+					EventID:    id,
+					EventKind:  model.ResourceEventKindStatusChange,
+					OccurredAt: base.Add(time.Duration(i) * time.Second),
+				})
+			}
+			queue.flush(context.Background())
+
+			batches := publisher.Batches()
+			if len(batches) != 1 {
+				t.Fatalf("Expected 1 batch, got %d", len(batches))
+			}
+			var ids []string
+			for _, event := range batches[0] {
+				ids = append(ids, event.EventID)
+			}
+			if len(ids) != len(tt.expectedIDs) {
+				t.Fatalf("Expected events %v, got %v", tt.expectedIDs, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.expectedIDs[i] {
+					t.Errorf("Expected events %v, got %v", tt.expectedIDs, ids)
+					break
+				}
+			}
+
+			meta := publisher.metas[0]
+			if meta.DroppedCount != 2 {
+				t.Errorf("Expected 2 dropped events, got %d", meta.DroppedCount)
+			}
+			expectedOldest := base
+			if tt.policy == DropNewest {
+				expectedOldest = base.Add(2 * time.Second)
+			}
+			if !meta.OldestDroppedAt.Equal(expectedOldest) {
+				t.Errorf("Expected oldest dropped at %v, got %v", expectedOldest, meta.OldestDroppedAt)
+			}
+		})
+	}
+}
+
+func TestResourceEventPublisherQueue_OrderedByResourceSequence(t *testing.T) {
+	eventChan := make(chan model.ResourceEventPayload, 10)
+	publisher := &recordingPublisher{}
+	queue := NewResourceEventPublisherQueue(eventChan, []ResourceEventPublisher{publisher}, BatchConfig{
+		FlushWindow:  time.Hour,
+		MaxBatchSize: 100,
+	})
+
+	eventChan <- model.ResourceEventPayload{EventID: "b-status", Resource: model.ResourceRef{UID: "b"}, SequenceNum: 4}
+	eventChan <- model.ResourceEventPayload{EventID: "a-status", Resource: model.ResourceRef{UID: "a"}, SequenceNum: 3}
+	eventChan <- model.ResourceEventPayload{EventID: "a-created", Resource: model.ResourceRef{UID: "a"}, SequenceNum: 1}
+	eventChan <- model.ResourceEventPayload{EventID: "b-created", Resource: model.ResourceRef{UID: "b"}, SequenceNum: 2}
+	close(eventChan)
+
+	queue.Loop()
+
+	batches := publisher.Batches()
+	if len(batches) != 1 {
+		t.Fatalf("Expected 1 batch, got %d", len(batches))
+	}
+
+	expected := []string{"a-created", "a-status", "b-created", "b-status"}
+	for i, id := range expected {
+		if batches[0][i].EventID != id {
+			t.Errorf("Event %d: expected %s, got %s", i, id, batches[0][i].EventID)
+		}
+	}
+}
+
+func TestResourceEventPublisherQueue_BatchID(t *testing.T) {
+	queue := NewResourceEventPublisherQueue(nil, nil, BatchConfig{
+		FlushWindow:  time.Hour,
+		MaxBatchSize: 2,
+		BufferSize:   10,
+	})
+
+	for i := 0; i < 3; i++ {
+		queue.addEvent(model.ResourceEventPayload{EventID: "status", EventKind: model.ResourceEventKindStatusChange})
+	}
+
+	queue.mu.Lock()
+	batches, _ := queue.takeLocked()
+	queue.mu.Unlock()
+
+	if len(batches) != 2 {
+		t.Fatalf("Expected 2 batches, got %d", len(batches))
+	}
+
+	batchID := batches[0][0].BatchID
+	if batchID == "" {
+		t.Fatal("Expected BatchID to be set")
+	}
+	index := 0
+	for _, batch := range batches {
+		for _, event := range batch {
+			if event.BatchID != batchID {
+				t.Errorf("Event %d: expected BatchID %s, got %s", index, batchID, event.BatchID)
+			}
+			if event.BatchSize != 3 {
+				t.Errorf("Event %d: expected BatchSize 3, got %d", index, event.BatchSize)
+			}
+			if event.BatchIndex != index {
+				t.Errorf("Event %d: expected BatchIndex %d, got %d", index, index, event.BatchIndex)
+			}
+			index++
+		}
+	}
+	// The first event's index survives encoding
+	data, err := json.Marshal(batches[0][0])
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	if !strings.Contains(string(data), `"batchIndex":0`) {
+		t.Errorf("Expected batchIndex 0 in payload, got %s", data)
+	}
+}
@@ -0,0 +1,130 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/hooks"
+	"github.com/apptrail-sh/agent/internal/model"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// EventTypeDeployment is the CloudEvents type of workload deployment events
+const EventTypeDeployment = "sh.apptrail.deployment.v1"
+
+// NewDeploymentEvent builds the CloudEvent for a workload event, carrying data, the event's
+// JSON encoding, as its payload
+func NewDeploymentEvent(event model.AgentEventPayload, data []byte) (cloudevents.Event, error) {
+	envelope := cloudevents.NewEvent(cloudevents.VersionV1)
+	envelope.SetID(event.EventID)
+	envelope.SetType(EventTypeDeployment)
+	envelope.SetSource("//apptrail/" + event.Source.ClusterID)
+	envelope.SetTime(event.OccurredAt)
+	// Raw bytes would be carried as data_base64; a JSON value keeps the event readable under data
+	if err := envelope.SetData(cloudevents.ApplicationJSON, json.RawMessage(data)); err != nil {
+		return envelope, fmt.Errorf("failed to build cloudevent: %w", err)
+	}
+	if err := envelope.Validate(); err != nil {
+		return envelope, fmt.Errorf("invalid cloudevent: %w", err)
+	}
+	return envelope, nil
+}
+
+// Format selects how the CloudEvent is carried by the wrapped publisher
+type Format string
+
+const (
+	// FormatStructured sends the whole event, attributes and data, as one JSON envelope
+	FormatStructured Format = "structured"
+	// FormatBinary sends the attributes as ce-* headers and the data as the body
+	FormatBinary Format = "binary"
+)
+
+// ParseFormat parses a CloudEvents format name
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatStructured, FormatBinary:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("invalid CloudEvents format %q: expected %q or %q", s, FormatStructured, FormatBinary)
+	}
+}
+
+// CloudEventsConfig holds configuration for the CloudEvents publisher
+type CloudEventsConfig struct {
+	Format         Format
+	ClusterID      string
+	ProjectID      string
+	AgentVersion   string
+	MaxPayloadSize int // Largest encoded event data in bytes; labels are dropped to fit (0 disables)
+}
+
+// CloudEventsPublisher wraps workload events in a CloudEvents 1.0 envelope before handing them to
+// the wrapped publisher, which sends update.Envelope with update.Labels as headers
+type CloudEventsPublisher struct {
+	config CloudEventsConfig
+	next   hooks.EventPublisher
+}
+
+// NewCloudEventsPublisher creates a CloudEvents publisher wrapping next
+func NewCloudEventsPublisher(next hooks.EventPublisher, config CloudEventsConfig) *CloudEventsPublisher {
+	if config.Format == "" {
+		config.Format = FormatStructured
+	}
+	return &CloudEventsPublisher{config: config, next: next}
+}
+
+// Publish wraps the update in a CloudEvent and publishes it with the wrapped publisher
+func (p *CloudEventsPublisher) Publish(ctx context.Context, update model.WorkloadUpdate) error {
+	enveloped, err := p.envelope(ctx, update)
+	if err != nil {
+		return err
+	}
+	return p.next.Publish(ctx, enveloped)
+}
+
+// envelope returns a copy of update carrying the encoded CloudEvent and its headers
+func (p *CloudEventsPublisher) envelope(ctx context.Context, update model.WorkloadUpdate) (model.WorkloadUpdate, error) {
+	payload := model.NewAgentEventPayload(update, p.config.ClusterID, p.config.ProjectID, p.config.AgentVersion)
+	data, err := hooks.MarshalLimitedEvent(ctx, "cloudevents", &payload, p.config.MaxPayloadSize)
+	if err != nil {
+		return update, fmt.Errorf("failed to marshal cloudevent data: %w", err)
+	}
+
+	event, err := NewDeploymentEvent(payload, data)
+	if err != nil {
+		return update, err
+	}
+	update.EventID = payload.EventID
+	update.OccurredAt = payload.OccurredAt
+
+	if p.config.Format == FormatBinary {
+		update.Envelope = event.Data()
+		update.Labels = map[string]string{
+			"Content-Type":   event.DataContentType(),
+			"ce-specversion": event.SpecVersion(),
+			"ce-type":        event.Type(),
+			"ce-source":      event.Source(),
+			"ce-id":          event.ID(),
+			"ce-time":        event.Time().UTC().Format(time.RFC3339Nano),
+		}
+		return update, nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return update, fmt.Errorf("failed to marshal cloudevent: %w", err)
+	}
+	update.Envelope = body
+	update.Labels = map[string]string{
+		"Content-Type": cloudevents.ApplicationCloudEventsJSON,
+	}
+	return update, nil
+}
+
+// Close closes the wrapped publisher
+func (p *CloudEventsPublisher) Close(ctx context.Context) error {
+	return p.next.Close(ctx)
+}
@@ -0,0 +1,155 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/hooks"
+	"github.com/apptrail-sh/agent/internal/hooks/webhook"
+	"github.com/apptrail-sh/agent/internal/model"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// recordingPublisher records the last published update
+type recordingPublisher struct {
+	update model.WorkloadUpdate
+	closed bool
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, update model.WorkloadUpdate) error {
+	p.update = update
+	return nil
+}
+
+func (p *recordingPublisher) Close(context.Context) error {
+	p.closed = true
+	return nil
+}
+
+var testUpdate = model.WorkloadUpdate{
+	Name:            "api",
+	Namespace:       "shop",
+	Kind:            "Deployment",
+	CurrentVersion:  "v2",
+	DeploymentPhase: "success",
+	Labels:          map[string]string{"app": "api"},
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"structured", "binary"} {
+		if got, err := ParseFormat(s); err != nil || string(got) != s {
+			t.Errorf("ParseFormat(%q) = %q, %v", s, got, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestCloudEventsPublisher_Structured(t *testing.T) {
+	next := &recordingPublisher{}
+	p := NewCloudEventsPublisher(next, CloudEventsConfig{Format: FormatStructured, ClusterID: "staging.stg01"})
+
+	if err := p.Publish(context.Background(), testUpdate); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if got := next.update.Labels["Content-Type"]; got != cloudevents.ApplicationCloudEventsJSON {
+		t.Errorf("Content-Type = %q, want %q", got, cloudevents.ApplicationCloudEventsJSON)
+	}
+
+	event := cloudevents.NewEvent()
+	if err := json.Unmarshal(next.update.Envelope, &event); err != nil {
+		t.Fatalf("Failed to decode envelope: %v", err)
+	}
+	if event.SpecVersion() != "1.0" || event.Type() != EventTypeDeployment || event.Source() != "//apptrail/staging.stg01" {
+		t.Errorf("Unexpected attributes %s", event.Context)
+	}
+	if event.DataContentType() != cloudevents.ApplicationJSON {
+		t.Errorf("datacontenttype = %q, want %q", event.DataContentType(), cloudevents.ApplicationJSON)
+	}
+
+	var payload model.AgentEventPayload
+	if err := event.DataAs(&payload); err != nil {
+		t.Fatalf("Failed to decode data: %v", err)
+	}
+	if payload.EventID != event.ID() || payload.Workload.Name != "api" || payload.Revision.Current != "v2" {
+		t.Errorf("Unexpected data %+v for event %s", payload, event.ID())
+	}
+
+	if err := p.Close(context.Background()); err != nil || !next.closed {
+		t.Errorf("Expected Close to close the wrapped publisher, got %v", err)
+	}
+}
+
+func TestCloudEventsPublisher_BinaryOverWebhook(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	next := webhook.NewWebhookPublisher(webhook.WebhookConfig{URL: server.URL, ClusterID: "staging.stg01"})
+	p := NewCloudEventsPublisher(next, CloudEventsConfig{Format: FormatBinary, ClusterID: "staging.stg01"})
+
+	if err := p.Publish(context.Background(), testUpdate); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"Content-Type":   "application/json",
+		"Ce-Specversion": "1.0",
+		"Ce-Type":        EventTypeDeployment,
+		"Ce-Source":      "//apptrail/staging.stg01",
+	}
+	for name, value := range expected {
+		if got := headers.Get(name); got != value {
+			t.Errorf("Header %s = %q, want %q", name, got, value)
+		}
+	}
+
+	var payload model.AgentEventPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if payload.EventID != headers.Get("Ce-Id") || headers.Get("Ce-Time") == "" {
+		t.Errorf("Expected ce-id %q to match event %q and ce-time to be set", headers.Get("Ce-Id"), payload.EventID)
+	}
+}
+
+func TestCloudEventsPublisher_MaxPayloadSize(t *testing.T) {
+	next := &recordingPublisher{}
+	p := NewCloudEventsPublisher(next, CloudEventsConfig{ClusterID: "staging.stg01", MaxPayloadSize: 1024})
+
+	update := testUpdate
+	update.Labels = map[string]string{"app": "api", "notes": strings.Repeat("x", 2048)}
+	if err := p.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	event := cloudevents.NewEvent()
+	if err := json.Unmarshal(next.update.Envelope, &event); err != nil {
+		t.Fatalf("Failed to decode envelope: %v", err)
+	}
+	if size := len(event.Data()); size > 1024 {
+		t.Errorf("Expected data within 1024 bytes, got %d", size)
+	}
+	var payload model.AgentEventPayload
+	if err := event.DataAs(&payload); err != nil {
+		t.Fatalf("Failed to decode data: %v", err)
+	}
+	if _, ok := payload.Labels["notes"]; ok || payload.Metadata[hooks.TruncatedMetadataKey] != true {
+		t.Errorf("Expected the large label dropped and the event marked truncated, got %+v", payload)
+	}
+	if next.update.EventID != event.ID() {
+		t.Errorf("Expected the update to carry event ID %q, got %q", event.ID(), next.update.EventID)
+	}
+}
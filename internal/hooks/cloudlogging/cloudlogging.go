@@ -0,0 +1,237 @@
+package cloudlogging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	logging "google.golang.org/api/logging/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DefaultLogName is the log resource events are written to
+	DefaultLogName = "apptrail.resource-events"
+
+	// delayThreshold writes buffered entries at least this often
+	delayThreshold = time.Second
+	// entryCountThreshold is the number of buffered entries that triggers a write
+	entryCountThreshold = 100
+	// Failed writes are retried with the next flush; beyond this many entries the oldest are dropped
+	maxBufferedEntries = entryCountThreshold * 10
+	// writeTimeout bounds each periodic write so a stalled API call can't block the flush loop
+	writeTimeout = 10 * time.Second
+)
+
+// CloudLoggingConfig holds configuration for the Cloud Logging publisher
+type CloudLoggingConfig struct {
+	ProjectID string
+	// LogName defaults to DefaultLogName
+	LogName string
+}
+
+// entryWriter is the subset of the Cloud Logging API used by the publisher
+type entryWriter interface {
+	WriteEntries(ctx context.Context, req *logging.WriteLogEntriesRequest) error
+}
+
+// serviceWriter writes entries through the Cloud Logging REST API
+type serviceWriter struct {
+	service *logging.Service
+}
+
+func (w serviceWriter) WriteEntries(ctx context.Context, req *logging.WriteLogEntriesRequest) error {
+	_, err := w.service.Entries.Write(req).Context(ctx).Do()
+	return err
+}
+
+// CloudLoggingPublisher writes resource events to GCP Cloud Logging as structured entries,
+// so they can be queried in Logs Explorer or routed with log sinks.
+// It batches on the REST API rather than using cloud.google.com/go/logging: that client's
+// bundler discards entries whose write failed and its Flush and Close take no context, while
+// failed writes here are retried and Close is bounded by the shutdown context.
+type CloudLoggingPublisher struct {
+	config  CloudLoggingConfig
+	logName string
+	writer  entryWriter
+
+	mu     sync.Mutex
+	buffer []*logging.LogEntry
+
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCloudLoggingPublisher creates a Cloud Logging publisher and starts its periodic flush.
+// Credentials come from Application Default Credentials, e.g. GKE Workload Identity.
+func NewCloudLoggingPublisher(ctx context.Context, config CloudLoggingConfig) (*CloudLoggingPublisher, error) {
+	service, err := logging.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Logging client: %w", err)
+	}
+	return newCloudLoggingPublisher(config, serviceWriter{service: service}), nil
+}
+
+// newCloudLoggingPublisher applies defaults and starts the flush loop for the given writer
+func newCloudLoggingPublisher(config CloudLoggingConfig, writer entryWriter) *CloudLoggingPublisher {
+	if config.LogName == "" {
+		config.LogName = DefaultLogName
+	}
+
+	p := &CloudLoggingPublisher{
+		config:  config,
+		logName: fmt.Sprintf("projects/%s/logs/%s", config.ProjectID, url.PathEscape(config.LogName)),
+		writer:  writer,
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	go p.flushLoop()
+	return p
+}
+
+// PublishBatch buffers events as log entries and writes them once entryCountThreshold is reached
+// Implements hooks.ResourceEventPublisher interface
+func (p *CloudLoggingPublisher) PublishBatch(ctx context.Context, events []model.ResourceEventPayload, _ model.BatchMetadata) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	entries := make([]*logging.LogEntry, 0, len(events))
+	for _, event := range events {
+		entry, err := logEntry(event)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	p.mu.Lock()
+	p.buffer = append(p.buffer, entries...)
+	full := len(p.buffer) >= entryCountThreshold
+	p.mu.Unlock()
+
+	if !full {
+		return nil
+	}
+	return p.flush(ctx)
+}
+
+// logEntry converts a resource event to a structured log entry
+func logEntry(event model.ResourceEventPayload) (*logging.LogEntry, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event %s: %w", event.EventID, err)
+	}
+
+	labels := map[string]string{
+		"cluster_id":    event.Source.ClusterID,
+		"resource_type": string(event.ResourceType),
+		"event_kind":    string(event.EventKind),
+		"kind":          event.Resource.Kind,
+		"name":          event.Resource.Name,
+	}
+	if event.Resource.Namespace != "" {
+		labels["namespace"] = event.Resource.Namespace
+	}
+
+	return &logging.LogEntry{
+		InsertId:    event.EventID,
+		Timestamp:   event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		Severity:    severity(event.EventKind),
+		Labels:      labels,
+		JsonPayload: payload,
+	}, nil
+}
+
+// severity maps an event kind to a Cloud Logging severity
+func severity(kind model.ResourceEventKind) string {
+	switch kind {
+	case model.ResourceEventKindDeleted:
+		return "WARNING"
+	case model.ResourceEventKindCreated:
+		return "NOTICE"
+	default:
+		return "INFO"
+	}
+}
+
+// flushLoop writes buffered entries every delayThreshold until Close is called
+func (p *CloudLoggingPublisher) flushLoop() {
+	defer close(p.doneCh)
+
+	ticker := time.NewTicker(delayThreshold)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+			if err := p.flush(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "Failed to write resource events to Cloud Logging", "logName", p.logName)
+			}
+			cancel()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// flush writes all buffered entries in one request. On failure the entries are
+// put back so the next flush retries them.
+func (p *CloudLoggingPublisher) flush(ctx context.Context) error {
+	p.mu.Lock()
+	entries := p.buffer
+	p.buffer = nil
+	p.mu.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err := p.writer.WriteEntries(ctx, &logging.WriteLogEntriesRequest{
+		LogName:  p.logName,
+		Resource: &logging.MonitoredResource{Type: "global"},
+		Entries:  entries,
+	})
+	if err != nil {
+		p.requeue(ctx, entries)
+		return fmt.Errorf("failed to write %d entries to %s: %w", len(entries), p.logName, err)
+	}
+	return nil
+}
+
+// requeue puts entries from a failed write ahead of newer ones, dropping the oldest
+// when the buffer would exceed maxBufferedEntries
+func (p *CloudLoggingPublisher) requeue(ctx context.Context, entries []*logging.LogEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buffer = append(entries, p.buffer...)
+
+	if dropped := len(p.buffer) - maxBufferedEntries; dropped > 0 {
+		p.buffer = p.buffer[dropped:]
+		log.FromContext(ctx).Info("Cloud Logging buffer full, dropping oldest events",
+			"dropped", dropped,
+			"logName", p.logName,
+		)
+	}
+}
+
+// Close stops the periodic flush and writes any buffered entries. It gives up once ctx is done,
+// so a stalled write can't hang shutdown; calling it again is safe.
+func (p *CloudLoggingPublisher) Close(ctx context.Context) error {
+	p.closeOnce.Do(func() {
+		close(p.stopCh)
+	})
+	select {
+	case <-p.doneCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.flush(ctx)
+}
@@ -0,0 +1,171 @@
+package cloudlogging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	logging "google.golang.org/api/logging/v2"
+)
+
+// fakeWriter records written requests and fails while err is set
+type fakeWriter struct {
+	mu       sync.Mutex
+	err      error
+	requests []*logging.WriteLogEntriesRequest
+}
+
+func (f *fakeWriter) WriteEntries(_ context.Context, req *logging.WriteLogEntriesRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.requests = append(f.requests, req)
+	return nil
+}
+
+func (f *fakeWriter) entryCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, req := range f.requests {
+		count += len(req.Entries)
+	}
+	return count
+}
+
+func events(n int) []model.ResourceEventPayload {
+	var out []model.ResourceEventPayload
+	for i := range n {
+		out = append(out, model.ResourceEventPayload{
+			EventID:      fmt.Sprintf("event-%d", i),
+			ResourceType: model.ResourceTypePod,
+			Resource:     model.ResourceRef{Kind: "Pod", Name: "api-0", Namespace: "shop"},
+			EventKind:    model.ResourceEventKindUpdated,
+		})
+	}
+	return out
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		kind     model.ResourceEventKind
+		expected string
+	}{
+		{model.ResourceEventKindDeleted, "WARNING"},
+		{model.ResourceEventKindCreated, "NOTICE"},
+		{model.ResourceEventKindUpdated, "INFO"},
+		{model.ResourceEventKindStatusChange, "INFO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			if got := severity(tt.kind); got != tt.expected {
+				t.Errorf("severity(%s) = %q, want %q", tt.kind, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLogEntry(t *testing.T) {
+	event := events(1)[0]
+	event.Source.ClusterID = "staging.stg01"
+
+	entry, err := logEntry(event)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry.InsertId != "event-0" || entry.Severity != "INFO" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entry.Labels["cluster_id"] != "staging.stg01" || entry.Labels["namespace"] != "shop" || entry.Labels["event_kind"] != "UPDATED" {
+		t.Errorf("unexpected labels %v", entry.Labels)
+	}
+
+	var payload model.ResourceEventPayload
+	if err := json.Unmarshal(entry.JsonPayload, &payload); err != nil || payload.EventID != "event-0" {
+		t.Errorf("expected JSON payload of the event, got %s (%v)", entry.JsonPayload, err)
+	}
+}
+
+func TestCloudLoggingPublisher_WritesWhenThresholdReached(t *testing.T) {
+	writer := &fakeWriter{}
+	p := newCloudLoggingPublisher(CloudLoggingConfig{ProjectID: "proj"}, writer)
+	defer func() { _ = p.Close(context.Background()) }()
+
+	if err := p.PublishBatch(context.Background(), events(entryCountThreshold-1), model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := writer.entryCount(); got != 0 {
+		t.Fatalf("expected no write below the threshold, got %d entries", got)
+	}
+
+	if err := p.PublishBatch(context.Background(), events(1), model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := writer.entryCount(); got != entryCountThreshold {
+		t.Fatalf("expected %d entries written, got %d", entryCountThreshold, got)
+	}
+	if logName := writer.requests[0].LogName; logName != "projects/proj/logs/apptrail.resource-events" {
+		t.Errorf("unexpected log name %q", logName)
+	}
+}
+
+func TestCloudLoggingPublisher_CloseFlushesAndRetries(t *testing.T) {
+	writer := &fakeWriter{err: errors.New("unavailable")}
+	p := newCloudLoggingPublisher(CloudLoggingConfig{ProjectID: "proj", LogName: "custom/log"}, writer)
+
+	if err := p.PublishBatch(context.Background(), events(2), model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.flush(context.Background()); err == nil {
+		t.Fatal("expected write error")
+	}
+
+	writer.mu.Lock()
+	writer.err = nil
+	writer.mu.Unlock()
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := writer.entryCount(); got != 2 {
+		t.Fatalf("expected failed entries to be written on close, got %d", got)
+	}
+	if logName := writer.requests[0].LogName; logName != "projects/proj/logs/custom%2Flog" {
+		t.Errorf("expected escaped log name, got %q", logName)
+	}
+}
+
+// blockingWriter never completes a write before its context is done
+type blockingWriter struct{}
+
+func (blockingWriter) WriteEntries(ctx context.Context, _ *logging.WriteLogEntriesRequest) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCloudLoggingPublisher_CloseGivesUpWithContext(t *testing.T) {
+	p := newCloudLoggingPublisher(CloudLoggingConfig{ProjectID: "proj"}, blockingWriter{})
+
+	if err := p.PublishBatch(context.Background(), events(1), model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the stalled write to be abandoned, got %v", err)
+	}
+
+	// A second Close must not panic on the already closed stop channel
+	expired, cancelExpired := context.WithCancel(context.Background())
+	cancelExpired()
+	_ = p.Close(expired)
+}
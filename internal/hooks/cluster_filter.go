@@ -0,0 +1,44 @@
+package hooks
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var clusterFilterCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "apptrail_cluster_filter_total",
+	Help: "Number of workload events allowed or excluded by the cluster ID prefix filter",
+}, []string{"action"})
+
+// ClusterFilter publishes events only for clusters whose ID matches the configured prefixes, so
+// one agent configuration can be deployed to every cluster and stay quiet where it is not wanted
+type ClusterFilter struct {
+	ClusterID     string
+	IncludePrefix string // When set, the cluster ID must start with it
+	ExcludePrefix string // When set, the cluster ID must not start with it
+}
+
+// Allowed reports whether events of this cluster should be published
+func (f ClusterFilter) Allowed() bool {
+	if f.IncludePrefix != "" && !strings.HasPrefix(f.ClusterID, f.IncludePrefix) {
+		return false
+	}
+	if f.ExcludePrefix != "" && strings.HasPrefix(f.ClusterID, f.ExcludePrefix) {
+		return false
+	}
+	return true
+}
+
+// recordClusterFilter counts a filter decision and returns it
+func recordClusterFilter(filter *ClusterFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if !filter.Allowed() {
+		clusterFilterCounter.WithLabelValues("excluded").Inc()
+		return false
+	}
+	clusterFilterCounter.WithLabelValues("allowed").Inc()
+	return true
+}
@@ -0,0 +1,45 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestClusterFilter_Allowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  ClusterFilter
+		allowed bool
+	}{
+		{"no prefixes", ClusterFilter{ClusterID: "prod-eu"}, true},
+		{"include matches", ClusterFilter{ClusterID: "prod-eu", IncludePrefix: "prod-"}, true},
+		{"include does not match", ClusterFilter{ClusterID: "dev-eu", IncludePrefix: "prod-"}, false},
+		{"exclude matches", ClusterFilter{ClusterID: "dev-eu", ExcludePrefix: "dev-"}, false},
+		{"exclude does not match", ClusterFilter{ClusterID: "prod-eu", ExcludePrefix: "dev-"}, true},
+		{"excluded within included", ClusterFilter{ClusterID: "prod-canary", IncludePrefix: "prod-", ExcludePrefix: "prod-canary"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Allowed(); got != tt.allowed {
+				t.Errorf("Allowed() = %v, want %v", got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestEventPublisherQueue_ClusterFilter(t *testing.T) {
+	publisher := &channelPublisher{published: make(chan model.WorkloadUpdate, 10)}
+	updates := make(chan model.WorkloadUpdate, 1)
+	queue := NewEventPublisherQueue(updates, []EventPublisher{publisher})
+	queue.ClusterFilter = &ClusterFilter{ClusterID: "dev-eu", IncludePrefix: "prod-"}
+
+	updates <- model.WorkloadUpdate{Name: "api", Namespace: "default"}
+	close(updates)
+	queue.Loop()
+
+	if len(publisher.published) != 0 {
+		t.Errorf("Expected updates of an excluded cluster not to be published, got %d", len(publisher.published))
+	}
+}
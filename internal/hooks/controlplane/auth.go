@@ -0,0 +1,126 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// DefaultTokenTTL is how long a token read from file is cached, 5 minutes short of the
+	// typical 1h projected ServiceAccount token lifetime
+	DefaultTokenTTL = 55 * time.Minute
+
+	// tokenRefreshLead is how long before expiry the background refresh re-reads the token
+	tokenRefreshLead = 5 * time.Minute
+)
+
+// TokenProvider supplies the bearer token sent with each control plane request
+type TokenProvider interface {
+	// GetToken returns the current token; an error fails the request instead of sending it
+	// without a valid token
+	GetToken() (string, error)
+}
+
+// FileTokenProvider reads a bearer token from a file, such as a projected ServiceAccount
+// token, and reloads it before it expires so rotated tokens are picked up without a restart
+type FileTokenProvider struct {
+	path string
+	ttl  time.Duration
+
+	mu       sync.RWMutex
+	token    string
+	loadedAt time.Time
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFileTokenProvider reads the token at path and starts refreshing it in the background
+func NewFileTokenProvider(path string) (*FileTokenProvider, error) {
+	p := &FileTokenProvider{
+		path:   path,
+		ttl:    DefaultTokenTTL,
+		stopCh: make(chan struct{}),
+	}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	go p.refreshLoop()
+	return p, nil
+}
+
+// GetToken returns the cached token, re-reading the file once the TTL has passed.
+// If the file can't be read the last known token is kept.
+func (p *FileTokenProvider) GetToken() (string, error) {
+	p.mu.RLock()
+	token, expired := p.token, time.Since(p.loadedAt) >= p.ttl
+	p.mu.RUnlock()
+
+	if !expired {
+		return token, nil
+	}
+	if err := p.reload(); err != nil {
+		ctrl.Log.WithName("controlplane").Error(err, "Failed to reload control plane token, using cached token",
+			"path", p.path)
+		return token, nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.token, nil
+}
+
+// reload reads the token file and resets the cache TTL
+func (p *FileTokenProvider) reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read control plane token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return fmt.Errorf("control plane token file %s is empty", p.path)
+	}
+
+	p.mu.Lock()
+	p.token = token
+	p.loadedAt = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// refreshLoop pre-fetches the token shortly before it expires so requests don't pay for the read
+func (p *FileTokenProvider) refreshLoop() {
+	for {
+		p.mu.RLock()
+		wait := time.Until(p.loadedAt.Add(p.ttl - tokenRefreshLead))
+		p.mu.RUnlock()
+		if wait <= 0 {
+			// TTL shorter than the lead, or the previous refresh failed; retry later instead of spinning
+			wait = tokenRefreshLead
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+			if err := p.reload(); err != nil {
+				ctrl.Log.WithName("controlplane").Error(err, "Failed to refresh control plane token",
+					"path", p.path)
+			}
+		case <-p.stopCh:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Close stops the background refresh
+func (p *FileTokenProvider) Close(_ context.Context) error {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+	return nil
+}
@@ -0,0 +1,98 @@
+package controlplane
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestFileTokenProvider_ReloadsRotatedToken(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("token-1\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+
+	provider, err := NewFileTokenProvider(tokenPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer provider.Close(context.Background())
+
+	var mu sync.Mutex
+	var authHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{TokenProvider: provider})
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+
+	if err := publisher.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Rotate the token; the cached one is used until the TTL expires
+	if err := os.WriteFile(tokenPath, []byte("token-2\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+	if err := publisher.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	provider.mu.Lock()
+	provider.ttl = 0
+	provider.mu.Unlock()
+	if err := publisher.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(authHeaders) != len(expected) {
+		t.Fatalf("Expected %d requests, got %d", len(expected), len(authHeaders))
+	}
+	for i, want := range expected {
+		if authHeaders[i] != want {
+			t.Errorf("Request %d: expected Authorization %q, got %q", i, want, authHeaders[i])
+		}
+	}
+}
+
+func TestFileTokenProvider_KeepsTokenWhenFileUnreadable(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("token-1"), 0o600); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+
+	provider, err := NewFileTokenProvider(tokenPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	defer provider.Close(context.Background())
+
+	if err := os.Remove(tokenPath); err != nil {
+		t.Fatalf("Failed to remove token: %v", err)
+	}
+	provider.mu.Lock()
+	provider.ttl = 0
+	provider.mu.Unlock()
+
+	if got, err := provider.GetToken(); err != nil || got != "token-1" {
+		t.Errorf("Expected cached token-1, got %q, %v", got, err)
+	}
+
+	if _, err := NewFileTokenProvider(tokenPath); err == nil {
+		t.Error("Expected error for missing token file")
+	}
+}
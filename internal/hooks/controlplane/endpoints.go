@@ -0,0 +1,108 @@
+package controlplane
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// endpointFailureThreshold is the number of consecutive failures after which an endpoint is skipped
+	endpointFailureThreshold = 3
+
+	// endpointRetestInterval is how long a skipped endpoint waits before it is tried again
+	endpointRetestInterval = 5 * time.Minute
+)
+
+// endpointState tracks the health of one control plane base URL
+type endpointState struct {
+	baseURL             string
+	consecutiveFailures int
+	lastFailure         time.Time
+}
+
+// endpointPool spreads control plane requests over one or more base URLs. Requests go to the
+// active endpoint; on failure the next endpoint in round-robin order becomes active. Endpoints
+// with endpointFailureThreshold consecutive failures are skipped until endpointRetestInterval
+// has passed since their last failure.
+type endpointPool struct {
+	mu        sync.Mutex
+	endpoints []*endpointState
+	active    int
+	now       func() time.Time
+}
+
+// newEndpointPool creates a pool for the given base URLs, the first one starting active
+func newEndpointPool(baseURLs []string) *endpointPool {
+	pool := &endpointPool{now: time.Now}
+	for _, baseURL := range baseURLs {
+		pool.endpoints = append(pool.endpoints, &endpointState{baseURL: strings.TrimSuffix(baseURL, "/")})
+	}
+	pool.setActive(0)
+	return pool
+}
+
+// candidates returns the base URLs to try for a request, starting with the active endpoint.
+// Skipped endpoints are left out unless every endpoint is skipped, in which case all are tried.
+func (p *endpointPool) candidates() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var available, all []string
+	for i := range p.endpoints {
+		endpoint := p.endpoints[(p.active+i)%len(p.endpoints)]
+		all = append(all, endpoint.baseURL)
+		if endpoint.consecutiveFailures < endpointFailureThreshold || now.Sub(endpoint.lastFailure) >= endpointRetestInterval {
+			available = append(available, endpoint.baseURL)
+		}
+	}
+	if len(available) == 0 {
+		return all
+	}
+	return available
+}
+
+// recordSuccess resets the failure count of baseURL and makes it the active endpoint
+func (p *endpointPool) recordSuccess(baseURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, endpoint := range p.endpoints {
+		if endpoint.baseURL == baseURL {
+			endpoint.consecutiveFailures = 0
+			p.setActive(i)
+			return
+		}
+	}
+}
+
+// recordFailure counts a failure for baseURL and, if it is active, moves on to the next endpoint
+func (p *endpointPool) recordFailure(baseURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	endpointFailuresCounter.WithLabelValues(baseURL).Inc()
+	for i, endpoint := range p.endpoints {
+		if endpoint.baseURL == baseURL {
+			endpoint.consecutiveFailures++
+			endpoint.lastFailure = p.now()
+			if i == p.active {
+				p.setActive((i + 1) % len(p.endpoints))
+			}
+			return
+		}
+	}
+}
+
+// setActive marks the endpoint at index as active. Callers must hold p.mu or own the pool.
+func (p *endpointPool) setActive(index int) {
+	p.active = index
+	for i, endpoint := range p.endpoints {
+		value := 0.0
+		if i == index {
+			value = 1
+		}
+		activeEndpointGauge.WithLabelValues(endpoint.baseURL).Set(value)
+	}
+}
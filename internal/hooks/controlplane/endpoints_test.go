@@ -0,0 +1,56 @@
+package controlplane
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEndpointPool_CircuitBreaksFailingEndpoint(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	pool := newEndpointPool([]string{"http://cp-a/", "http://cp-b", "http://cp-c"})
+	pool.now = func() time.Time { return now }
+
+	if got, want := pool.candidates(), []string{"http://cp-a", "http://cp-b", "http://cp-c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidates() = %v, want %v", got, want)
+	}
+
+	// A failure moves on to the next endpoint in round-robin order
+	pool.recordFailure("http://cp-a")
+	if got, want := pool.candidates(), []string{"http://cp-b", "http://cp-c", "http://cp-a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidates() after failure = %v, want %v", got, want)
+	}
+
+	// Three consecutive failures skip the endpoint
+	pool.recordFailure("http://cp-a")
+	pool.recordFailure("http://cp-a")
+	pool.recordSuccess("http://cp-b")
+	if got, want := pool.candidates(), []string{"http://cp-b", "http://cp-c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidates() with circuit-broken endpoint = %v, want %v", got, want)
+	}
+
+	// The skipped endpoint is tried again after the retest interval
+	now = now.Add(endpointRetestInterval)
+	if got, want := pool.candidates(), []string{"http://cp-b", "http://cp-c", "http://cp-a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates() after retest interval = %v, want %v", got, want)
+	}
+
+	// A success closes the circuit again
+	pool.recordSuccess("http://cp-a")
+	now = now.Add(time.Second)
+	if got, want := pool.candidates(), []string{"http://cp-a", "http://cp-b", "http://cp-c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates() after recovery = %v, want %v", got, want)
+	}
+}
+
+func TestEndpointPool_AllEndpointsSkipped(t *testing.T) {
+	pool := newEndpointPool([]string{"http://cp"})
+	for range endpointFailureThreshold {
+		pool.recordFailure("http://cp")
+	}
+
+	// With nothing healthy left every endpoint is still tried rather than dropping the event
+	if got, want := pool.candidates(), []string{"http://cp"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates() = %v, want %v", got, want)
+	}
+}
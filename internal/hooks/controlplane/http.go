@@ -4,12 +4,21 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
 	"time"
 
+	"github.com/apptrail-sh/agent/internal/hooks"
+	apptrailcloudevents "github.com/apptrail-sh/agent/internal/hooks/cloudevents"
 	"github.com/apptrail-sh/agent/internal/model"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"resty.dev/v3"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -17,43 +26,266 @@ import (
 const (
 	// Compress batches larger than 10KB
 	compressionThreshold = 10 * 1024
+
+	// Headers reporting resource events dropped before a batch
+	droppedCountHeader    = "X-AppTrail-Dropped-Count"
+	oldestDroppedAtHeader = "X-AppTrail-Oldest-Dropped-At"
+
+	// Control plane ingest paths, relative to the base URL
+	eventsPath    = "/ingest/v1/agent/events"
+	batchPath     = "/ingest/v1/agent/events/batch"
+	heartbeatPath = "/ingest/v1/agent/heartbeat"
 )
 
+// Defaults for the control plane HTTP client
+const (
+	DefaultHTTPTimeout     = 10 * time.Second
+	DefaultKeepAlive       = 30 * time.Second
+	DefaultMaxIdleConns    = 100
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// HTTPPublisherConfig tunes the HTTP client and connection pool used to reach the control plane.
+// Zero values fall back to the defaults above.
+type HTTPPublisherConfig struct {
+	// Timeout bounds each request attempt, including reading the response
+	Timeout time.Duration
+	// KeepAlive is the TCP keep-alive period for control plane connections
+	KeepAlive time.Duration
+	// MaxIdleConns caps the idle connections kept for reuse
+	MaxIdleConns int
+	// IdleConnTimeout closes idle connections after this long
+	IdleConnTimeout time.Duration
+	// DisableKeepAlive opens a new connection for every request
+	DisableKeepAlive bool
+}
+
+// withDefaults fills unset fields with their defaults
+func (c HTTPPublisherConfig) withDefaults() HTTPPublisherConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultHTTPTimeout
+	}
+	if c.KeepAlive <= 0 {
+		c.KeepAlive = DefaultKeepAlive
+	}
+	if c.MaxIdleConns <= 0 {
+		c.MaxIdleConns = DefaultMaxIdleConns
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	return c
+}
+
+// Defaults for retrying failed control plane requests
+const (
+	DefaultMaxRetries       = 3
+	DefaultRetryWaitTime    = 1 * time.Second
+	DefaultRetryMaxWaitTime = 5 * time.Second
+)
+
+// RetryConfig controls how failed control plane requests are retried. Waits grow exponentially
+// with jitter from WaitTime up to MaxWaitTime; a Retry-After header on 429 and 503 responses
+// takes precedence. Zero values fall back to the defaults above.
+type RetryConfig struct {
+	// MaxRetries is the number of retries after the first attempt; negative disables retries
+	MaxRetries int
+	// WaitTime is the base wait before the first retry
+	WaitTime time.Duration
+	// MaxWaitTime caps the wait between retries
+	MaxWaitTime time.Duration
+	// RetryOnHTTPStatus lists the status codes that are retried. Empty retries 429 and 5xx
+	// except 501. Connection errors are always retried.
+	RetryOnHTTPStatus []int
+}
+
+// withDefaults fills unset fields with their defaults
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.MaxRetries == 0 {
+		c.MaxRetries = DefaultMaxRetries
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	}
+	if c.WaitTime <= 0 {
+		c.WaitTime = DefaultRetryWaitTime
+	}
+	if c.MaxWaitTime <= 0 {
+		c.MaxWaitTime = DefaultRetryMaxWaitTime
+	}
+	if c.MaxWaitTime < c.WaitTime {
+		c.MaxWaitTime = c.WaitTime
+	}
+	return c
+}
+
+// Options holds optional HTTP publisher behaviour
+type Options struct {
+	// CloudEventsMode wraps workload events in a CloudEvents 1.0 envelope
+	CloudEventsMode bool
+	// Compress gzips every request body, not just large batches
+	Compress bool
+	// CompressLevel is the gzip level used when Compress is set
+	CompressLevel int
+	// TokenProvider supplies a bearer token fetched before each request, if set
+	TokenProvider TokenProvider
+	// Proxy selects the proxy for each request; nil keeps the HTTP_PROXY/HTTPS_PROXY environment default
+	Proxy func(*http.Request) (*url.URL, error)
+	// RootCAs verifies the control plane certificate instead of the system roots, if set
+	RootCAs *x509.CertPool
+	// InsecureSkipVerify disables certificate verification; for development only
+	InsecureSkipVerify bool
+	// HTTP tunes timeouts and connection reuse
+	HTTP HTTPPublisherConfig
+	// Retry controls retries of failed requests
+	Retry RetryConfig
+	// ProjectID is the cloud project the cluster runs in, reported in workload event sources
+	ProjectID string
+	// FailoverURLs are further control plane base URLs tried in round-robin order when the
+	// active one fails
+	FailoverURLs []string
+	// MaxPayloadSize is the largest encoded workload event in bytes; labels are dropped to fit
+	// (0 disables)
+	MaxPayloadSize int
+}
+
+// LoadCACertPool reads a PEM bundle of CA certificates for verifying the control plane
+func LoadCACertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// newTLSConfig returns the TLS settings for control plane connections, nil to keep the system defaults
+func newTLSConfig(rootCAs *x509.CertPool, insecureSkipVerify bool) *tls.Config {
+	if rootCAs == nil && !insecureSkipVerify {
+		return nil
+	}
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            rootCAs,
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // opt-in for development environments
+	}
+}
+
 // HTTPPublisher sends workload updates to the AppTrail Control Plane via HTTP
 type HTTPPublisher struct {
-	client            *resty.Client
-	endpoint          string
-	batchEndpoint     string
-	heartbeatEndpoint string
-	clusterID         string
-	agentVersion      string
+	client       *resty.Client
+	endpoints    *endpointPool
+	clusterID    string
+	agentVersion string
+	options      Options
 }
 
 // NewHTTPPublisher creates a new HTTP publisher for the control plane
-func NewHTTPPublisher(baseURL, clusterID, agentVersion, apiKey string) *HTTPPublisher {
-	client := resty.New().
-		SetTimeout(10 * time.Second).
-		SetRetryCount(3).
-		SetRetryWaitTime(1 * time.Second).
-		SetRetryMaxWaitTime(5 * time.Second)
+func NewHTTPPublisher(baseURL, clusterID, agentVersion, apiKey string, opts Options) *HTTPPublisher {
+	httpConfig := opts.HTTP.withDefaults()
+
+	// Requests go to one active host at a time, so the per-host idle pool gets the full limit
+	client := resty.NewWithTransportSettings(&resty.TransportSettings{
+		DialerKeepAlive:     httpConfig.KeepAlive,
+		MaxIdleConns:        httpConfig.MaxIdleConns,
+		MaxIdleConnsPerHost: httpConfig.MaxIdleConns,
+		IdleConnTimeout:     httpConfig.IdleConnTimeout,
+		DisableKeepAlives:   httpConfig.DisableKeepAlive,
+	}).
+		SetTimeout(httpConfig.Timeout)
+	configureRetries(client, opts.Retry.withDefaults())
 
 	if apiKey != "" {
 		client.SetHeader("X-API-Key", apiKey)
 	}
 
-	// Construct all endpoints from base URL
-	baseURL = strings.TrimSuffix(baseURL, "/")
-	endpoint := baseURL + "/ingest/v1/agent/events"
-	batchEndpoint := baseURL + "/ingest/v1/agent/events/batch"
-	heartbeatEndpoint := baseURL + "/ingest/v1/agent/heartbeat"
+	if opts.Proxy != nil {
+		if transport, err := client.HTTPTransport(); err == nil {
+			transport.Proxy = opts.Proxy
+		}
+	}
+
+	if tlsConfig := newTLSConfig(opts.RootCAs, opts.InsecureSkipVerify); tlsConfig != nil {
+		client.SetTLSClientConfig(tlsConfig)
+	}
+
+	registerMetrics()
 
 	return &HTTPPublisher{
-		client:            client,
-		endpoint:          endpoint,
-		batchEndpoint:     batchEndpoint,
-		heartbeatEndpoint: heartbeatEndpoint,
-		clusterID:         clusterID,
-		agentVersion:      agentVersion,
+		client:       client,
+		endpoints:    newEndpointPool(append([]string{baseURL}, opts.FailoverURLs...)),
+		clusterID:    clusterID,
+		agentVersion: agentVersion,
+		options:      opts,
+	}
+}
+
+// post sends the request built by newRequest to path on each candidate endpoint until one
+// answers without a server error, and returns the response with the URL it was sent to.
+// Client errors such as 400 are returned as is since another endpoint would reject them too.
+func (p *HTTPPublisher) post(ctx context.Context, path string, newRequest func() (*resty.Request, error)) (*resty.Response, string, error) {
+	var resp *resty.Response
+	var target string
+	var err error
+	for _, baseURL := range p.endpoints.candidates() {
+		req, buildErr := newRequest()
+		if buildErr != nil {
+			return nil, "", buildErr
+		}
+		target = baseURL + path
+		start := time.Now()
+		resp, err = req.Post(target)
+		observeRequest(req, resp, err, time.Since(start))
+		if ctx.Err() != nil {
+			return resp, target, err
+		}
+		if err == nil && !isServerError(resp) {
+			p.endpoints.recordSuccess(baseURL)
+			return resp, target, nil
+		}
+
+		p.endpoints.recordFailure(baseURL)
+		log.FromContext(ctx).V(1).Info("Control plane endpoint failed, trying next endpoint", "url", target)
+	}
+	return resp, target, err
+}
+
+// isServerError reports whether the response means the endpoint itself is unhealthy
+func isServerError(resp *resty.Response) bool {
+	return resp.StatusCode() >= http.StatusInternalServerError || resp.StatusCode() == http.StatusTooManyRequests
+}
+
+// configureRetries applies the retry policy. Resty's default strategy is capped exponential
+// backoff with jitter that honours Retry-After.
+func configureRetries(client *resty.Client, retry RetryConfig) {
+	client.
+		SetRetryCount(retry.MaxRetries).
+		SetRetryWaitTime(retry.WaitTime).
+		SetRetryMaxWaitTime(retry.MaxWaitTime).
+		// Events carry an ID the control plane deduplicates on, so POSTs are safe to retry
+		SetAllowNonIdempotentRetry(true).
+		AddRetryHooks(func(res *resty.Response, err error) {
+			if res == nil {
+				return
+			}
+			logger := log.FromContext(res.Request.Context())
+			if err != nil {
+				logger.V(1).Info("Retrying control plane request", "url", res.Request.URL, "error", err.Error())
+				return
+			}
+			logger.V(1).Info("Retrying control plane request", "url", res.Request.URL, "status", res.StatusCode())
+		})
+
+	if len(retry.RetryOnHTTPStatus) > 0 {
+		statuses := slices.Clone(retry.RetryOnHTTPStatus)
+		client.
+			SetRetryDefaultConditions(false).
+			AddRetryConditions(func(res *resty.Response, err error) bool {
+				return err != nil || res == nil || slices.Contains(statuses, res.StatusCode())
+			})
 	}
 }
 
@@ -61,10 +293,13 @@ func NewHTTPPublisher(baseURL, clusterID, agentVersion, apiKey string) *HTTPPubl
 func (p *HTTPPublisher) Publish(ctx context.Context, update model.WorkloadUpdate) error {
 	logger := log.FromContext(ctx)
 
-	event := model.NewAgentEventPayload(update, p.clusterID, p.agentVersion)
+	event := model.NewAgentEventPayload(update, p.clusterID, p.options.ProjectID, p.agentVersion)
+	data, err := hooks.MarshalLimitedEvent(ctx, publisherLabel, &event, p.options.MaxPayloadSize)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
 
 	logger.Info("Publishing event to control plane",
-		"endpoint", p.endpoint,
 		"eventID", event.EventID,
 		"namespace", event.Workload.Namespace,
 		"name", event.Workload.Name,
@@ -72,18 +307,33 @@ func (p *HTTPPublisher) Publish(ctx context.Context, update model.WorkloadUpdate
 		"previousVersion", event.Revision.Previous,
 	)
 
+	// The encoded event is sent as is, so it is not marshaled again for the request
+	body := data
+	contentType := "application/json"
+	if p.options.CloudEventsMode {
+		envelope, err := apptrailcloudevents.NewDeploymentEvent(event, data)
+		if err != nil {
+			return err
+		}
+		if body, err = json.Marshal(envelope); err != nil {
+			return fmt.Errorf("failed to marshal cloudevent: %w", err)
+		}
+		contentType = cloudevents.ApplicationCloudEventsJSON
+	}
+
 	// Send request with Resty
 	var errorResponse map[string]interface{}
-	resp, err := p.client.R().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetBody(event).
-		SetError(&errorResponse).
-		Post(p.endpoint)
+	resp, endpoint, err := p.post(ctx, eventsPath, func() (*resty.Request, error) {
+		req, err := p.newRequest(ctx, contentType, body)
+		if err != nil {
+			return nil, err
+		}
+		return req.SetError(&errorResponse), nil
+	})
 
 	if err != nil {
 		logger.Error(err, "Failed to send event to control plane",
-			"endpoint", p.endpoint,
+			"endpoint", endpoint,
 			"eventID", event.EventID,
 		)
 		return fmt.Errorf("failed to send event to control plane: %w", err)
@@ -96,14 +346,14 @@ func (p *HTTPPublisher) Publish(ctx context.Context, update model.WorkloadUpdate
 			"status", resp.Status(),
 			"error", errorResponse,
 			"body", resp.String(),
-			"endpoint", p.endpoint,
+			"endpoint", endpoint,
 			"eventID", event.EventID,
 		)
 		return fmt.Errorf("control plane returned error status %d: %s", resp.StatusCode(), resp.String())
 	}
 
 	logger.Info("Event successfully published to control plane",
-		"endpoint", p.endpoint,
+		"endpoint", endpoint,
 		"eventID", event.EventID,
 		"statusCode", resp.StatusCode(),
 		"namespace", event.Workload.Namespace,
@@ -113,9 +363,80 @@ func (p *HTTPPublisher) Publish(ctx context.Context, update model.WorkloadUpdate
 	return nil
 }
 
+// newRequest builds a request for body, gzip-compressing it when compression is enabled. A []byte
+// body is sent as already encoded JSON.
+func (p *HTTPPublisher) newRequest(ctx context.Context, contentType string, body any) (*resty.Request, error) {
+	req := p.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", contentType)
+	if err := p.setAuthorization(req); err != nil {
+		return nil, err
+	}
+
+	if !p.options.Compress {
+		return req.SetBody(body), nil
+	}
+
+	jsonData, ok := body.([]byte)
+	if !ok {
+		var err error
+		if jsonData, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+	compressed, err := gzipBody(jsonData, p.compressLevel())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+
+	return req.
+		SetHeader("Content-Encoding", "gzip").
+		SetBody(compressed), nil
+}
+
+// setAuthorization adds the current bearer token, read per request so rotated tokens are used
+func (p *HTTPPublisher) setAuthorization(req *resty.Request) error {
+	if p.options.TokenProvider == nil {
+		return nil
+	}
+	token, err := p.options.TokenProvider.GetToken()
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.SetAuthToken(token)
+	}
+	return nil
+}
+
+// compressLevel returns the configured gzip level, or the default when compression is only threshold-based
+func (p *HTTPPublisher) compressLevel() int {
+	if p.options.Compress {
+		return p.options.CompressLevel
+	}
+	return gzip.DefaultCompression
+}
+
+// gzipBody compresses data with the given gzip level
+func gzipBody(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	gzWriter, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := gzWriter.Write(data); err != nil {
+		_ = gzWriter.Close()
+		return nil, err
+	}
+	if err := gzWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // PublishBatch sends a batch of resource events to the control plane
 // Implements hooks.ResourceEventPublisher interface
-func (p *HTTPPublisher) PublishBatch(ctx context.Context, events []model.ResourceEventPayload) error {
+func (p *HTTPPublisher) PublishBatch(ctx context.Context, events []model.ResourceEventPayload, meta model.BatchMetadata) error {
 	if len(events) == 0 {
 		return nil
 	}
@@ -123,7 +444,6 @@ func (p *HTTPPublisher) PublishBatch(ctx context.Context, events []model.Resourc
 	logger := log.FromContext(ctx)
 
 	logger.Info("Publishing resource event batch to control plane",
-		"endpoint", p.batchEndpoint,
 		"eventCount", len(events),
 	)
 
@@ -133,20 +453,14 @@ func (p *HTTPPublisher) PublishBatch(ctx context.Context, events []model.Resourc
 		return fmt.Errorf("failed to marshal events: %w", err)
 	}
 
-	// Compress if above threshold
+	// Compress if above threshold (or always, when compression is enabled)
 	var body []byte
 	var contentEncoding string
-	if len(jsonData) > compressionThreshold {
-		var buf bytes.Buffer
-		gzWriter := gzip.NewWriter(&buf)
-		if _, err := gzWriter.Write(jsonData); err != nil {
-			_ = gzWriter.Close()
+	if p.options.Compress || len(jsonData) > compressionThreshold {
+		body, err = gzipBody(jsonData, p.compressLevel())
+		if err != nil {
 			return fmt.Errorf("failed to compress events: %w", err)
 		}
-		if err := gzWriter.Close(); err != nil {
-			return fmt.Errorf("failed to close gzip writer: %w", err)
-		}
-		body = buf.Bytes()
 		contentEncoding = "gzip"
 		logger.V(1).Info("Compressed batch",
 			"originalSize", len(jsonData),
@@ -156,23 +470,31 @@ func (p *HTTPPublisher) PublishBatch(ctx context.Context, events []model.Resourc
 		body = jsonData
 	}
 
-	// Build request
-	req := p.client.R().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetBody(body)
-
-	if contentEncoding != "" {
-		req.SetHeader("Content-Encoding", contentEncoding)
-	}
-
 	var errorResponse map[string]interface{}
-	req.SetError(&errorResponse)
+	resp, endpoint, err := p.post(ctx, batchPath, func() (*resty.Request, error) {
+		req := p.client.R().
+			SetContext(ctx).
+			SetHeader("Content-Type", "application/json").
+			SetBody(body).
+			SetError(&errorResponse)
+		if err := p.setAuthorization(req); err != nil {
+			return nil, err
+		}
+
+		if contentEncoding != "" {
+			req.SetHeader("Content-Encoding", contentEncoding)
+		}
 
-	resp, err := req.Post(p.batchEndpoint)
+		// Report events dropped before this batch so the control plane can detect gaps
+		if meta.DroppedCount > 0 {
+			req.SetHeader(droppedCountHeader, strconv.Itoa(meta.DroppedCount))
+			req.SetHeader(oldestDroppedAtHeader, meta.OldestDroppedAt.UTC().Format(time.RFC3339Nano))
+		}
+		return req, nil
+	})
 	if err != nil {
 		logger.Error(err, "Failed to send batch to control plane",
-			"endpoint", p.batchEndpoint,
+			"endpoint", endpoint,
 			"eventCount", len(events),
 		)
 		return fmt.Errorf("failed to send batch to control plane: %w", err)
@@ -184,13 +506,13 @@ func (p *HTTPPublisher) PublishBatch(ctx context.Context, events []model.Resourc
 			"status", resp.Status(),
 			"error", errorResponse,
 			"body", resp.String(),
-			"endpoint", p.batchEndpoint,
+			"endpoint", endpoint,
 		)
 		return fmt.Errorf("control plane returned error status %d: %s", resp.StatusCode(), resp.String())
 	}
 
 	logger.Info("Batch successfully published to control plane",
-		"endpoint", p.batchEndpoint,
+		"endpoint", endpoint,
 		"eventCount", len(events),
 		"statusCode", resp.StatusCode(),
 	)
@@ -204,23 +526,23 @@ func (p *HTTPPublisher) PublishHeartbeat(ctx context.Context, payload model.Clus
 	logger := log.FromContext(ctx)
 
 	logger.Info("Publishing heartbeat to control plane",
-		"endpoint", p.heartbeatEndpoint,
 		"eventID", payload.EventID,
 		"nodeCount", len(payload.Inventory.NodeUIDs),
 		"podCount", len(payload.Inventory.PodUIDs),
 	)
 
 	var errorResponse map[string]interface{}
-	resp, err := p.client.R().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetBody(payload).
-		SetError(&errorResponse).
-		Post(p.heartbeatEndpoint)
+	resp, endpoint, err := p.post(ctx, heartbeatPath, func() (*resty.Request, error) {
+		req, err := p.newRequest(ctx, "application/json", payload)
+		if err != nil {
+			return nil, err
+		}
+		return req.SetError(&errorResponse), nil
+	})
 
 	if err != nil {
 		logger.Error(err, "Failed to send heartbeat to control plane",
-			"endpoint", p.heartbeatEndpoint,
+			"endpoint", endpoint,
 			"eventID", payload.EventID,
 		)
 		return fmt.Errorf("failed to send heartbeat to control plane: %w", err)
@@ -232,16 +554,22 @@ func (p *HTTPPublisher) PublishHeartbeat(ctx context.Context, payload model.Clus
 			"status", resp.Status(),
 			"error", errorResponse,
 			"body", resp.String(),
-			"endpoint", p.heartbeatEndpoint,
+			"endpoint", endpoint,
 		)
 		return fmt.Errorf("control plane returned error status %d: %s", resp.StatusCode(), resp.String())
 	}
 
 	logger.Info("Heartbeat successfully published to control plane",
-		"endpoint", p.heartbeatEndpoint,
+		"endpoint", endpoint,
 		"eventID", payload.EventID,
 		"statusCode", resp.StatusCode(),
 	)
 
 	return nil
 }
+
+// Close releases idle connections held by the underlying HTTP client
+func (p *HTTPPublisher) Close(_ context.Context) error {
+	p.client.Client().CloseIdleConnections()
+	return nil
+}
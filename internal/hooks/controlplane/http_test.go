@@ -0,0 +1,504 @@
+package controlplane
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// decompressBody reads a gzip-encoded request body and fails the test if it isn't compressed
+func decompressBody(t *testing.T, r *http.Request) []byte {
+	t.Helper()
+	if r.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected Content-Encoding: gzip, got %q", r.Header.Get("Content-Encoding"))
+		return nil
+	}
+	gzReader, err := gzip.NewReader(r.Body)
+	if err != nil {
+		t.Errorf("Failed to create gzip reader: %v", err)
+		return nil
+	}
+	defer gzReader.Close()
+	body, err := io.ReadAll(gzReader)
+	if err != nil {
+		t.Errorf("Failed to decompress body: %v", err)
+		return nil
+	}
+	return body
+}
+
+func TestHTTPPublisher_Publish_Compressed(t *testing.T) {
+	var received model.AgentEventPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.Unmarshal(decompressBody(t, r), &received); err != nil {
+			t.Errorf("Failed to unmarshal event: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{
+		Compress:      true,
+		CompressLevel: gzip.BestCompression,
+	})
+
+	err := publisher.Publish(context.Background(), model.WorkloadUpdate{
+		Name:            "api",
+		Namespace:       "production",
+		Kind:            "Deployment",
+		PreviousVersion: "1.0.0",
+		CurrentVersion:  "1.1.0",
+		DeploymentPhase: "success",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if received.Workload.Name != "api" {
+		t.Errorf("Expected workload name %q, got %q", "api", received.Workload.Name)
+	}
+	if received.Revision == nil || received.Revision.Current != "1.1.0" {
+		t.Errorf("Expected current revision %q, got %+v", "1.1.0", received.Revision)
+	}
+	if received.Source.ClusterID != "test-cluster" {
+		t.Errorf("Expected cluster ID %q, got %q", "test-cluster", received.Source.ClusterID)
+	}
+}
+
+func TestHTTPPublisher_PublishBatch_Compressed(t *testing.T) {
+	var received []model.ResourceEventPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.Unmarshal(decompressBody(t, r), &received); err != nil {
+			t.Errorf("Failed to unmarshal events: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{
+		Compress:      true,
+		CompressLevel: gzip.DefaultCompression,
+	})
+
+	// A single small event stays below the threshold, so only the option triggers compression
+	events := []model.ResourceEventPayload{
+		model.NewPodEvent("default", "api-0", "uid-1", nil, model.ResourceEventKindCreated, nil, nil, "test-cluster", "v1.0.0"),
+	}
+	if err := publisher.PublishBatch(context.Background(), events, model.BatchMetadata{}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(received))
+	}
+	if received[0].Resource.Name != "api-0" {
+		t.Errorf("Expected resource name %q, got %q", "api-0", received[0].Resource.Name)
+	}
+}
+
+func TestHTTPPublisher_Publish_Uncompressed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "" {
+			t.Errorf("Expected no Content-Encoding, got %q", r.Header.Get("Content-Encoding"))
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{})
+
+	err := publisher.Publish(context.Background(), model.WorkloadUpdate{
+		Name:           "api",
+		Namespace:      "production",
+		Kind:           "Deployment",
+		CurrentVersion: "1.1.0",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+}
+
+func TestHTTPPublisher_Publish_CloudEventsMode(t *testing.T) {
+	tests := []struct {
+		name            string
+		cloudEventsMode bool
+		wantContentType string
+	}{
+		{name: "plain event", cloudEventsMode: false, wantContentType: "application/json"},
+		{name: "cloudevents envelope", cloudEventsMode: true, wantContentType: "application/cloudevents+json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contentType string
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType = r.Header.Get("Content-Type")
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{CloudEventsMode: tt.cloudEventsMode})
+			update := model.WorkloadUpdate{Name: "api", Namespace: "production", Kind: "Deployment", CurrentVersion: "1.1.0"}
+			if err := publisher.Publish(context.Background(), update); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if !strings.HasPrefix(contentType, tt.wantContentType) {
+				t.Errorf("Expected Content-Type %q, got %q", tt.wantContentType, contentType)
+			}
+
+			eventJSON := body
+			if tt.cloudEventsMode {
+				var envelope struct {
+					SpecVersion string          `json:"specversion"`
+					ID          string          `json:"id"`
+					Type        string          `json:"type"`
+					Source      string          `json:"source"`
+					Data        json.RawMessage `json:"data"`
+				}
+				if err := json.Unmarshal(body, &envelope); err != nil {
+					t.Fatalf("Failed to decode envelope: %v", err)
+				}
+				if envelope.SpecVersion != "1.0" || envelope.Type != "sh.apptrail.deployment.v1" ||
+					envelope.Source != "//apptrail/test-cluster" || envelope.ID == "" {
+					t.Errorf("Unexpected envelope attributes: %+v", envelope)
+				}
+				eventJSON = envelope.Data
+			}
+
+			var event model.AgentEventPayload
+			if err := json.Unmarshal(eventJSON, &event); err != nil {
+				t.Fatalf("Failed to decode event: %v", err)
+			}
+			if event.Workload.Name != "api" || event.Revision.Current != "1.1.0" {
+				t.Errorf("Expected the api 1.1.0 event, got %s %s", event.Workload.Name, event.Revision.Current)
+			}
+		})
+	}
+}
+
+func TestHTTPPublisher_Publish_ThroughProxy(t *testing.T) {
+	var proxiedURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute target URL
+		proxiedURL = r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse proxy URL: %v", err)
+	}
+
+	publisher := NewHTTPPublisher("http://controlplane.internal:3000", "test-cluster", "v1.0.0", "",
+		Options{Proxy: http.ProxyURL(proxyURL)})
+
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+	if err := publisher.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if proxiedURL != "http://controlplane.internal:3000/ingest/v1/agent/events" {
+		t.Errorf("Expected request to be routed through the proxy, got %q", proxiedURL)
+	}
+}
+
+func TestHTTPPublisher_Publish_CustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Write the server's self-signed certificate as a PEM CA bundle
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write CA bundle: %v", err)
+	}
+	rootCAs, err := LoadCACertPool(caPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "system roots reject self-signed certificate", wantErr: true},
+		{name: "custom CA bundle", opts: Options{RootCAs: rootCAs}},
+		{name: "insecure skip verify", opts: Options{InsecureSkipVerify: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", tt.opts)
+			publisher.client.SetRetryCount(0)
+
+			err := publisher.Publish(context.Background(), update)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadCACertPool(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("Expected error for missing CA bundle")
+	}
+}
+
+func TestNewHTTPPublisher_HTTPConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   HTTPPublisherConfig
+		expected HTTPPublisherConfig
+	}{
+		{
+			name:   "defaults",
+			config: HTTPPublisherConfig{},
+			expected: HTTPPublisherConfig{
+				Timeout:         DefaultHTTPTimeout,
+				MaxIdleConns:    DefaultMaxIdleConns,
+				IdleConnTimeout: DefaultIdleConnTimeout,
+			},
+		},
+		{
+			name: "custom",
+			config: HTTPPublisherConfig{
+				Timeout:          30 * time.Second,
+				KeepAlive:        time.Minute,
+				MaxIdleConns:     10,
+				IdleConnTimeout:  5 * time.Minute,
+				DisableKeepAlive: true,
+			},
+			expected: HTTPPublisherConfig{
+				Timeout:          30 * time.Second,
+				MaxIdleConns:     10,
+				IdleConnTimeout:  5 * time.Minute,
+				DisableKeepAlive: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := NewHTTPPublisher("http://controlplane", "cluster", "v1", "", Options{HTTP: tt.config})
+
+			if got := publisher.client.Timeout(); got != tt.expected.Timeout {
+				t.Errorf("Timeout = %v, want %v", got, tt.expected.Timeout)
+			}
+
+			transport, err := publisher.client.HTTPTransport()
+			if err != nil {
+				t.Fatalf("Expected an *http.Transport, got error: %v", err)
+			}
+			if transport.MaxIdleConns != tt.expected.MaxIdleConns {
+				t.Errorf("MaxIdleConns = %d, want %d", transport.MaxIdleConns, tt.expected.MaxIdleConns)
+			}
+			if transport.MaxIdleConnsPerHost != tt.expected.MaxIdleConns {
+				t.Errorf("MaxIdleConnsPerHost = %d, want %d", transport.MaxIdleConnsPerHost, tt.expected.MaxIdleConns)
+			}
+			if transport.IdleConnTimeout != tt.expected.IdleConnTimeout {
+				t.Errorf("IdleConnTimeout = %v, want %v", transport.IdleConnTimeout, tt.expected.IdleConnTimeout)
+			}
+			if transport.DisableKeepAlives != tt.expected.DisableKeepAlive {
+				t.Errorf("DisableKeepAlives = %v, want %v", transport.DisableKeepAlives, tt.expected.DisableKeepAlive)
+			}
+		})
+	}
+}
+
+func TestHTTPPublisher_Publish_RetriesUnavailable(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{
+		Retry: RetryConfig{MaxRetries: 3, WaitTime: time.Millisecond, MaxWaitTime: 5 * time.Millisecond},
+	})
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+
+	if err := publisher.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+}
+
+func TestHTTPPublisher_Publish_RetryOnHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    RetryConfig
+		status   int
+		expected int32
+	}{
+		{
+			name:     "listed status is retried",
+			retry:    RetryConfig{MaxRetries: 2, RetryOnHTTPStatus: []int{http.StatusConflict}},
+			status:   http.StatusConflict,
+			expected: 3,
+		},
+		{
+			name:     "unlisted status fails fast",
+			retry:    RetryConfig{MaxRetries: 2, RetryOnHTTPStatus: []int{http.StatusConflict}},
+			status:   http.StatusBadGateway,
+			expected: 1,
+		},
+		{
+			name:     "negative max retries disables retries",
+			retry:    RetryConfig{MaxRetries: -1},
+			status:   http.StatusServiceUnavailable,
+			expected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			tt.retry.WaitTime = time.Millisecond
+			tt.retry.MaxWaitTime = time.Millisecond
+			publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{Retry: tt.retry})
+			update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+
+			if err := publisher.Publish(context.Background(), update); err == nil {
+				t.Error("Expected error for failing status")
+			}
+			if got := attempts.Load(); got != tt.expected {
+				t.Errorf("Expected %d attempts, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHTTPPublisher_Publish_FailsOverToNextEndpoint(t *testing.T) {
+	var downAttempts, upAttempts atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downAttempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upAttempts.Add(1)
+		if r.URL.Path != eventsPath {
+			t.Errorf("Expected path %s, got %s", eventsPath, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer up.Close()
+
+	publisher := NewHTTPPublisher(down.URL, "test-cluster", "v1.0.0", "", Options{
+		FailoverURLs: []string{up.URL},
+		Retry:        RetryConfig{MaxRetries: -1},
+	})
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+
+	for range 2 {
+		if err := publisher.Publish(context.Background(), update); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	// The healthy endpoint stays active, so the failed one is only tried once
+	if got := downAttempts.Load(); got != 1 {
+		t.Errorf("Expected 1 attempt on the failed endpoint, got %d", got)
+	}
+	if got := upAttempts.Load(); got != 2 {
+		t.Errorf("Expected 2 attempts on the healthy endpoint, got %d", got)
+	}
+}
+
+func TestHTTPPublisher_Publish_ClientErrorDoesNotFailOver(t *testing.T) {
+	var fallbackAttempts atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackAttempts.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fallback.Close()
+
+	publisher := NewHTTPPublisher(primary.URL, "test-cluster", "v1.0.0", "", Options{FailoverURLs: []string{fallback.URL}})
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+
+	if err := publisher.Publish(context.Background(), update); err == nil {
+		t.Error("Expected error for rejected event")
+	}
+	if got := fallbackAttempts.Load(); got != 0 {
+		t.Errorf("Expected no failover for a client error, got %d attempts", got)
+	}
+}
+
+// histogramCount returns the number of observations of a histogram series
+func histogramCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+	metric := &dto.Metric{}
+	if err := observer.(prometheus.Histogram).Write(metric); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestHTTPPublisher_Publish_ObservesRequestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{})
+
+	duration := requestDurationHistogram.WithLabelValues(publisherLabel, http.MethodPost, "202")
+	bodySize := requestBodySizeHistogram.WithLabelValues(publisherLabel, http.MethodPost)
+	durationBefore, bodySizeBefore := histogramCount(t, duration), histogramCount(t, bodySize)
+
+	err := publisher.Publish(context.Background(), model.WorkloadUpdate{
+		Name:           "api",
+		Namespace:      "production",
+		Kind:           "Deployment",
+		CurrentVersion: "1.1.0",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if got := histogramCount(t, duration) - durationBefore; got != 1 {
+		t.Errorf("Expected 1 duration observation, got %d", got)
+	}
+	if got := histogramCount(t, bodySize) - bodySizeBefore; got != 1 {
+		t.Errorf("Expected 1 body size observation, got %d", got)
+	}
+}
@@ -0,0 +1,62 @@
+package controlplane
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"resty.dev/v3"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// publisherLabel identifies control plane requests in the shared HTTP metrics
+const publisherLabel = "controlplane"
+
+var (
+	endpointFailuresCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_publisher_endpoint_failures_total",
+		Help: "Failed control plane requests per endpoint",
+	}, []string{"endpoint"})
+
+	activeEndpointGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "apptrail_publisher_active_endpoint",
+		Help: "1 for the control plane endpoint requests are currently sent to, 0 otherwise",
+	}, []string{"endpoint"})
+
+	requestDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "apptrail_http_request_duration_seconds",
+		Help:    "Duration of publisher HTTP requests, including retries",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10},
+	}, []string{"publisher", "method", "status_code"})
+
+	requestBodySizeHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "apptrail_http_request_body_size_bytes",
+		Help:    "Size of publisher HTTP request bodies as sent, after compression",
+		Buckets: prometheus.ExponentialBuckets(256, 4, 8), // 256B to 4MB
+	}, []string{"publisher", "method"})
+
+	metricsRegistered = false
+)
+
+// registerMetrics registers the control plane publisher metrics once
+func registerMetrics() {
+	if !metricsRegistered {
+		metrics.Registry.MustRegister(endpointFailuresCounter, activeEndpointGauge,
+			requestDurationHistogram, requestBodySizeHistogram)
+		metricsRegistered = true
+	}
+}
+
+// observeRequest records the duration and body size of a sent request. Requests that got no
+// response are labelled with status code "error".
+func observeRequest(req *resty.Request, resp *resty.Response, err error, duration time.Duration) {
+	statusCode := "error"
+	if err == nil && resp != nil {
+		statusCode = strconv.Itoa(resp.StatusCode())
+	}
+	requestDurationHistogram.WithLabelValues(publisherLabel, req.Method, statusCode).Observe(duration.Seconds())
+
+	if req.RawRequest != nil && req.RawRequest.ContentLength >= 0 {
+		requestBodySizeHistogram.WithLabelValues(publisherLabel, req.Method).Observe(float64(req.RawRequest.ContentLength))
+	}
+}
@@ -0,0 +1,103 @@
+package controlplane
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// oauth2RefreshLead is how long before expiry a cached access token is replaced
+	oauth2RefreshLead = 60 * time.Second
+
+	// oauth2RequestTimeout bounds each token endpoint request
+	oauth2RequestTimeout = 10 * time.Second
+)
+
+// OAuth2Config holds the client credentials used to obtain control plane access tokens
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// Proxy, RootCAs and InsecureSkipVerify configure the token endpoint connection the same
+	// way as the control plane's, see Options
+	Proxy              func(*http.Request) (*url.URL, error)
+	RootCAs            *x509.CertPool
+	InsecureSkipVerify bool
+}
+
+// OAuth2TokenProvider obtains access tokens with the OAuth2 client credentials flow.
+// Tokens are cached and refreshed 60 seconds before they expire.
+type OAuth2TokenProvider struct {
+	tokenURL    string
+	credentials clientcredentials.Config
+	client      *http.Client
+
+	// Cached token, replaced once it is within oauth2RefreshLead of expiry
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// NewOAuth2TokenProvider creates a token provider for the given client credentials.
+// No token is requested until the first control plane request.
+func NewOAuth2TokenProvider(config OAuth2Config) (*OAuth2TokenProvider, error) {
+	if config.TokenURL == "" || config.ClientID == "" || config.ClientSecret == "" {
+		return nil, errors.New("oauth2 token URL, client ID and client secret are required")
+	}
+
+	credentials := clientcredentials.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		TokenURL:     config.TokenURL,
+		Scopes:       config.Scopes,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.Proxy != nil {
+		transport.Proxy = config.Proxy
+	}
+	if tlsConfig := newTLSConfig(config.RootCAs, config.InsecureSkipVerify); tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &OAuth2TokenProvider{
+		tokenURL:    config.TokenURL,
+		credentials: credentials,
+		client:      &http.Client{Timeout: oauth2RequestTimeout, Transport: transport},
+	}, nil
+}
+
+// GetToken returns a valid access token, fetching a new one when the cached token is
+// within 60 seconds of expiry. The fetch runs without holding the cache lock, so concurrent
+// callers may each fetch a token once it has expired.
+func (p *OAuth2TokenProvider) GetToken() (string, error) {
+	p.mu.Lock()
+	cached := p.token
+	p.mu.Unlock()
+	if cached != nil && (cached.Expiry.IsZero() || time.Until(cached.Expiry) > oauth2RefreshLead) {
+		return cached.AccessToken, nil
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, p.client)
+	token, err := p.credentials.Token(ctx)
+	if err != nil {
+		ctrl.Log.WithName("controlplane").Error(err, "Failed to obtain control plane OAuth2 token",
+			"tokenURL", p.tokenURL)
+		return "", fmt.Errorf("failed to obtain oauth2 token: %w", err)
+	}
+
+	p.mu.Lock()
+	p.token = token
+	p.mu.Unlock()
+	return token.AccessToken, nil
+}
@@ -0,0 +1,209 @@
+package controlplane
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+// newTokenServer returns a client credentials token endpoint issuing token-1, token-2, ...
+// valid for expiresIn seconds, and a func reporting how many tokens it issued
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	issued := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "client_credentials" {
+			http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+			return
+		}
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.Form.Get("client_id"), r.Form.Get("client_secret")
+		}
+		if clientID != "agent" || clientSecret != "s3cret" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		if r.Form.Get("scope") != "events:write" {
+			http.Error(w, `{"error":"invalid_scope"}`, http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		issued++
+		token := fmt.Sprintf("token-%d", issued)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": token,
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return issued
+	}
+}
+
+func TestOAuth2TokenProvider_CachesToken(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, 3600)
+
+	provider, err := NewOAuth2TokenProvider(OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "agent",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"events:write"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var mu sync.Mutex
+	var authHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{TokenProvider: provider})
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+
+	for range 2 {
+		if err := publisher.Publish(context.Background(), update); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, got := range authHeaders {
+		if got != "Bearer token-1" {
+			t.Errorf("Request %d: expected Authorization %q, got %q", i, "Bearer token-1", got)
+		}
+	}
+	if got := issued(); got != 1 {
+		t.Errorf("Expected 1 token request, got %d", got)
+	}
+}
+
+func TestOAuth2TokenProvider_RefreshesBeforeExpiry(t *testing.T) {
+	// Tokens expiring within the 60s refresh lead are replaced on every use
+	tokenServer, issued := newTokenServer(t, 30)
+
+	provider, err := NewOAuth2TokenProvider(OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "agent",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"events:write"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if got, err := provider.GetToken(); err != nil || got != "token-1" {
+		t.Errorf("Expected token-1, got %q, %v", got, err)
+	}
+	if got, err := provider.GetToken(); err != nil || got != "token-2" {
+		t.Errorf("Expected refreshed token-2, got %q, %v", got, err)
+	}
+	if got := issued(); got != 2 {
+		t.Errorf("Expected 2 token requests, got %d", got)
+	}
+}
+
+func TestOAuth2TokenProvider_InvalidCredentials(t *testing.T) {
+	tokenServer, issued := newTokenServer(t, 3600)
+
+	provider, err := NewOAuth2TokenProvider(OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "agent",
+		ClientSecret: "wrong",
+		Scopes:       []string{"events:write"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if got, err := provider.GetToken(); err == nil || got != "" {
+		t.Errorf("Expected an error and no token for rejected credentials, got %q, %v", got, err)
+	}
+	if got := issued(); got != 0 {
+		t.Errorf("Expected no tokens issued, got %d", got)
+	}
+
+	if _, err := NewOAuth2TokenProvider(OAuth2Config{TokenURL: tokenServer.URL, ClientID: "agent"}); err == nil {
+		t.Error("Expected error for missing client secret")
+	}
+}
+
+func TestOAuth2TokenProvider_FailedFetchFailsRequest(t *testing.T) {
+	tokenServer, _ := newTokenServer(t, 3600)
+	provider, err := NewOAuth2TokenProvider(OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "agent",
+		ClientSecret: "wrong",
+		Scopes:       []string{"events:write"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, "test-cluster", "v1.0.0", "", Options{TokenProvider: provider})
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+	if err := publisher.Publish(context.Background(), update); err == nil {
+		t.Error("Expected publish to fail without a token")
+	}
+	if requests != 0 {
+		t.Errorf("Expected no request without a token, got %d", requests)
+	}
+}
+
+func TestOAuth2TokenProvider_UsesRootCAs(t *testing.T) {
+	tokenServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"tls-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(tokenServer.Certificate())
+	provider, err := NewOAuth2TokenProvider(OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "agent",
+		ClientSecret: "s3cret",
+		RootCAs:      rootCAs,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if got, err := provider.GetToken(); err != nil || got != "tls-token" {
+		t.Errorf("Expected tls-token from the endpoint signed by the configured CA, got %q, %v", got, err)
+	}
+}
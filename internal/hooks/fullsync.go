@@ -0,0 +1,157 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// SyncTypeMetadataKey marks resource events in their metadata with how they were produced
+	SyncTypeMetadataKey = "syncType"
+	// SyncTypeFull marks events sent by a full-state sync rather than by a resource change
+	SyncTypeFull = "full"
+
+	// DefaultFullSyncInterval is how often the full cluster state is sent
+	DefaultFullSyncInterval = time.Hour
+)
+
+var (
+	fullSyncFailuresCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_full_sync_failures_total",
+		Help: "Number of full-state syncs that failed to collect or publish",
+	})
+
+	fullSyncLastSuccessGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "apptrail_full_sync_last_success_timestamp_seconds",
+		Help: "Unix timestamp of the last successful full-state sync",
+	})
+
+	fullSyncMetricsRegistered = false
+)
+
+// FullSyncSource lists the current state of the resources it tracks as resource events
+type FullSyncSource interface {
+	Snapshot(ctx context.Context) ([]model.ResourceEventPayload, error)
+}
+
+// FullSyncConfig holds configuration for the full-state sync
+type FullSyncConfig struct {
+	Interval     time.Duration
+	MaxBatchSize int // Maximum events per published batch
+	ClusterID    string
+	AgentVersion string
+}
+
+// FullStateSyncPublisher periodically sends the state of every tracked resource, so the
+// control plane can reconcile incremental events it missed during agent or publisher outages
+type FullStateSyncPublisher struct {
+	config     FullSyncConfig
+	sources    []FullSyncSource
+	publishers []ResourceEventPublisher
+}
+
+// NewFullStateSyncPublisher creates a full-state sync publisher
+func NewFullStateSyncPublisher(config FullSyncConfig, sources []FullSyncSource, publishers []ResourceEventPublisher) *FullStateSyncPublisher {
+	// Register metrics only once
+	if !fullSyncMetricsRegistered {
+		metrics.Registry.MustRegister(fullSyncFailuresCounter, fullSyncLastSuccessGauge)
+		fullSyncMetricsRegistered = true
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = DefaultFullSyncInterval
+	}
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = DefaultBatchConfig().MaxBatchSize
+	}
+
+	return &FullStateSyncPublisher{
+		config:     config,
+		sources:    sources,
+		publishers: publishers,
+	}
+}
+
+// Start runs a sync immediately and then every interval until the context is cancelled
+func (f *FullStateSyncPublisher) Start(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName("full-sync")
+
+	logger.Info("Starting full-state sync",
+		"interval", f.config.Interval,
+		"sources", len(f.sources),
+		"publishers", len(f.publishers),
+	)
+
+	ticker := time.NewTicker(f.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.Sync(ctx); err != nil {
+			logger.Error(err, "Full-state sync failed")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			logger.Info("Full-state sync stopped")
+			return
+		}
+	}
+}
+
+// Sync collects the state of every source and publishes it as one logical batch. Sources that
+// fail are skipped so the rest of the cluster is still synced; the error reports them.
+func (f *FullStateSyncPublisher) Sync(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("full-sync")
+
+	var errs []error
+	var events []model.ResourceEventPayload
+	for _, source := range f.sources {
+		snapshot, err := source.Snapshot(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("snapshot %T: %w", source, err))
+			continue
+		}
+		events = append(events, snapshot...)
+	}
+
+	// All events of a sync share a batch ID so the control plane can ingest it as a whole
+	batchID := uuid.NewString()
+	for i := range events {
+		events[i].Source.ClusterID = f.config.ClusterID
+		events[i].Source.AgentVersion = f.config.AgentVersion
+		if events[i].Metadata == nil {
+			events[i].Metadata = make(map[string]any)
+		}
+		events[i].Metadata[SyncTypeMetadataKey] = SyncTypeFull
+		events[i].BatchID = batchID
+		events[i].BatchSize = len(events)
+		events[i].BatchIndex = i
+	}
+
+	logger.Info("Sending full-state sync", "batchID", batchID, "eventCount", len(events))
+
+	for start := 0; start < len(events); start += f.config.MaxBatchSize {
+		batch := events[start:min(start+f.config.MaxBatchSize, len(events))]
+		for _, publisher := range f.publishers {
+			if err := publisher.PublishBatch(ctx, batch, model.BatchMetadata{}); err != nil {
+				errs = append(errs, fmt.Errorf("publish batch at %d: %w", start, err))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		fullSyncFailuresCounter.Inc()
+		return err
+	}
+	fullSyncLastSuccessGauge.SetToCurrentTime()
+	return nil
+}
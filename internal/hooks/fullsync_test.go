@@ -0,0 +1,61 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+type staticSource struct {
+	events []model.ResourceEventPayload
+	err    error
+}
+
+func (s staticSource) Snapshot(_ context.Context) ([]model.ResourceEventPayload, error) {
+	return s.events, s.err
+}
+
+func TestFullStateSyncPublisher_Sync(t *testing.T) {
+	publisher := &recordingPublisher{}
+	sync := NewFullStateSyncPublisher(FullSyncConfig{
+		MaxBatchSize: 2,
+		ClusterID:    "prod",
+		AgentVersion: "1.0.0",
+	}, []FullSyncSource{
+		staticSource{events: []model.ResourceEventPayload{{EventID: "deployment"}}},
+		staticSource{err: errors.New("forbidden")},
+		staticSource{events: []model.ResourceEventPayload{{EventID: "node"}, {EventID: "pod", Metadata: map[string]any{"pod": "meta"}}}},
+	}, []ResourceEventPublisher{publisher})
+
+	err := sync.Sync(context.Background())
+	if err == nil {
+		t.Fatal("Expected the failing source to be reported")
+	}
+
+	batches := publisher.Batches()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("Expected batches of 2 and 1 events, got %v", batches)
+	}
+
+	batchID := batches[0][0].BatchID
+	index := 0
+	for _, batch := range batches {
+		for _, event := range batch {
+			if event.Metadata[SyncTypeMetadataKey] != SyncTypeFull {
+				t.Errorf("Event %s: expected syncType %q, got %v", event.EventID, SyncTypeFull, event.Metadata[SyncTypeMetadataKey])
+			}
+			if event.Source.ClusterID != "prod" || event.Source.AgentVersion != "1.0.0" {
+				t.Errorf("Event %s: unexpected source %+v", event.EventID, event.Source)
+			}
+			if batchID == "" || event.BatchID != batchID || event.BatchSize != 3 || event.BatchIndex != index {
+				t.Errorf("Event %s: unexpected batch %s %d/%d", event.EventID, event.BatchID, event.BatchIndex, event.BatchSize)
+			}
+			index++
+		}
+	}
+	if batches[1][0].Metadata["pod"] != "meta" {
+		t.Error("Expected existing metadata to be kept")
+	}
+}
@@ -0,0 +1,117 @@
+package hooks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+// HealthCheckable is implemented by publishers that can report their own health
+type HealthCheckable interface {
+	Check(ctx context.Context) model.PublisherHealthStatus
+}
+
+// PublishTracker records the outcome of a publisher's most recent publish, whether it carried a
+// workload event, a resource event batch or a heartbeat, and reports it as the publisher's health
+type PublishTracker struct {
+	name string
+
+	mu            sync.Mutex
+	lastErr       error
+	lastSuccessAt time.Time
+}
+
+// NewPublishTracker creates a tracker reporting health under name
+func NewPublishTracker(name string) *PublishTracker {
+	return &PublishTracker{name: name}
+}
+
+// Name returns the name the publisher's health is reported under
+func (t *PublishTracker) Name() string {
+	return t.name
+}
+
+// Record stores the result of a publish
+func (t *PublishTracker) Record(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastErr = err
+	if err == nil {
+		t.lastSuccessAt = time.Now().UTC()
+	}
+}
+
+// Check reports the publisher as healthy unless its most recent publish failed
+func (t *PublishTracker) Check(_ context.Context) model.PublisherHealthStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := model.PublisherHealthStatus{
+		Name:          t.name,
+		Healthy:       t.lastErr == nil,
+		LastSuccessAt: t.lastSuccessAt,
+	}
+	if t.lastErr != nil {
+		status.LastError = t.lastErr.Error()
+	}
+	return status
+}
+
+// TrackedPublisher wraps an EventPublisher and records the outcome of each publish
+type TrackedPublisher struct {
+	EventPublisher
+	*PublishTracker
+}
+
+// NewTrackedPublisher wraps publisher so its health can be reported under name
+func NewTrackedPublisher(name string, publisher EventPublisher) *TrackedPublisher {
+	return &TrackedPublisher{
+		EventPublisher: publisher,
+		PublishTracker: NewPublishTracker(name),
+	}
+}
+
+// Publish forwards to the wrapped publisher and records the result
+func (t *TrackedPublisher) Publish(ctx context.Context, update model.WorkloadUpdate) error {
+	err := t.EventPublisher.Publish(ctx, update)
+	t.Record(err)
+	return err
+}
+
+// TrackedResourcePublisher wraps a ResourceEventPublisher and records the outcome of each batch
+type TrackedResourcePublisher struct {
+	ResourceEventPublisher
+	tracker *PublishTracker
+}
+
+// NewTrackedResourcePublisher wraps publisher so its batches are recorded by tracker
+func NewTrackedResourcePublisher(tracker *PublishTracker, publisher ResourceEventPublisher) *TrackedResourcePublisher {
+	return &TrackedResourcePublisher{ResourceEventPublisher: publisher, tracker: tracker}
+}
+
+// PublishBatch forwards to the wrapped publisher and records the result
+func (t *TrackedResourcePublisher) PublishBatch(ctx context.Context, events []model.ResourceEventPayload, meta model.BatchMetadata) error {
+	err := t.ResourceEventPublisher.PublishBatch(ctx, events, meta)
+	t.tracker.Record(err)
+	return err
+}
+
+// TrackedHeartbeatPublisher wraps a HeartbeatPublisher and records the outcome of each heartbeat
+type TrackedHeartbeatPublisher struct {
+	HeartbeatPublisher
+	tracker *PublishTracker
+}
+
+// NewTrackedHeartbeatPublisher wraps publisher so its heartbeats are recorded by tracker
+func NewTrackedHeartbeatPublisher(tracker *PublishTracker, publisher HeartbeatPublisher) *TrackedHeartbeatPublisher {
+	return &TrackedHeartbeatPublisher{HeartbeatPublisher: publisher, tracker: tracker}
+}
+
+// PublishHeartbeat forwards to the wrapped publisher and records the result
+func (t *TrackedHeartbeatPublisher) PublishHeartbeat(ctx context.Context, payload model.ClusterHeartbeatPayload) error {
+	err := t.HeartbeatPublisher.PublishHeartbeat(ctx, payload)
+	t.tracker.Record(err)
+	return err
+}
@@ -0,0 +1,91 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+// stubPublisher returns err from every Publish call
+type stubPublisher struct {
+	err error
+}
+
+func (p *stubPublisher) Publish(_ context.Context, _ model.WorkloadUpdate) error { return p.err }
+func (p *stubPublisher) Close(_ context.Context) error                           { return nil }
+
+func TestTrackedPublisher_Check(t *testing.T) {
+	stub := &stubPublisher{}
+	tracked := NewTrackedPublisher("controlplane", stub)
+	ctx := context.Background()
+
+	if status := tracked.Check(ctx); !status.Healthy || !status.LastSuccessAt.IsZero() {
+		t.Errorf("Expected healthy status with no success before any publish, got %+v", status)
+	}
+
+	_ = tracked.Publish(ctx, model.WorkloadUpdate{})
+	succeeded := tracked.Check(ctx)
+	if !succeeded.Healthy || succeeded.LastSuccessAt.IsZero() {
+		t.Errorf("Expected healthy status with last success set, got %+v", succeeded)
+	}
+
+	stub.err = errors.New("connection refused")
+	if err := tracked.Publish(ctx, model.WorkloadUpdate{}); err == nil {
+		t.Fatal("Expected publish error to be returned")
+	}
+	failed := tracked.Check(ctx)
+	if failed.Healthy {
+		t.Error("Expected unhealthy status after a failed publish")
+	}
+	if failed.Name != "controlplane" || failed.LastError != "connection refused" {
+		t.Errorf("Unexpected status: %+v", failed)
+	}
+	if !failed.LastSuccessAt.Equal(succeeded.LastSuccessAt) {
+		t.Errorf("Expected last success to be kept after failure, got %v", failed.LastSuccessAt)
+	}
+}
+
+// stubResourcePublisher returns err from every PublishBatch call
+type stubResourcePublisher struct {
+	err error
+}
+
+func (p *stubResourcePublisher) PublishBatch(context.Context, []model.ResourceEventPayload, model.BatchMetadata) error {
+	return p.err
+}
+func (p *stubResourcePublisher) Close(context.Context) error { return nil }
+
+// stubHeartbeatPublisher returns err from every PublishHeartbeat call
+type stubHeartbeatPublisher struct {
+	err error
+}
+
+func (p *stubHeartbeatPublisher) PublishHeartbeat(context.Context, model.ClusterHeartbeatPayload) error {
+	return p.err
+}
+
+func TestPublishTracker_SharedAcrossPublishKinds(t *testing.T) {
+	ctx := context.Background()
+	events := NewTrackedPublisher("pubsub", &stubPublisher{})
+	resources := &stubResourcePublisher{}
+	heartbeats := &stubHeartbeatPublisher{}
+	trackedResources := NewTrackedResourcePublisher(events.PublishTracker, resources)
+	trackedHeartbeats := NewTrackedHeartbeatPublisher(events.PublishTracker, heartbeats)
+
+	resources.err = errors.New("topic not found")
+	if err := trackedResources.PublishBatch(ctx, nil, model.BatchMetadata{}); err == nil {
+		t.Fatal("Expected batch error to be returned")
+	}
+	if status := events.Check(ctx); status.Healthy || status.LastError != "topic not found" {
+		t.Errorf("Expected a failed batch to mark the publisher unhealthy, got %+v", status)
+	}
+
+	if err := trackedHeartbeats.PublishHeartbeat(ctx, model.ClusterHeartbeatPayload{}); err != nil {
+		t.Fatalf("Unexpected heartbeat error: %v", err)
+	}
+	if status := events.Check(ctx); !status.Healthy || status.LastSuccessAt.IsZero() {
+		t.Errorf("Expected a successful heartbeat to mark the publisher healthy, got %+v", status)
+	}
+}
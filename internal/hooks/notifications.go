@@ -2,17 +2,61 @@ package hooks
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sync"
 
 	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
+// publisherQueueSize is the per-publisher buffer; a slow publisher drops events once it is full
+const publisherQueueSize = 100
+
+var (
+	publisherQueueDepthGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "apptrail_publisher_queue_depth",
+		Help: "Number of workload events waiting in each publisher's queue",
+	}, []string{"publisher"})
+
+	metricsRegistered = false
+)
+
+// Named is implemented by publishers that report a name for metrics and logs
+type Named interface {
+	Name() string
+}
+
 type EventPublisherQueue struct {
 	UpdateChan <-chan model.WorkloadUpdate
 	publishers []EventPublisher
+
+	// ReplayBuffer, when set, records every update so recent events can be inspected
+	ReplayBuffer *EventReplayBuffer
+
+	// ClusterFilter, when set, drops every update unless this cluster's ID matches its prefixes
+	ClusterFilter *ClusterFilter
+
+	// Router, when set, limits each update to the workers of the publishers routed for its namespace
+	Router *PublisherRouter
+}
+
+// publisherWorker feeds a single publisher from its own queue so a slow publisher cannot stall the others
+type publisherWorker struct {
+	name      string
+	publisher EventPublisher
+	queue     chan model.WorkloadUpdate
 }
 
 func NewEventPublisherQueue(updateChan <-chan model.WorkloadUpdate, publishers []EventPublisher) *EventPublisherQueue {
+	// Register metrics only once
+	if !metricsRegistered {
+		metrics.Registry.MustRegister(publisherQueueDepthGauge, clusterFilterCounter)
+		metricsRegistered = true
+	}
+
 	return &EventPublisherQueue{
 		UpdateChan: updateChan,
 		publishers: publishers,
@@ -25,6 +69,23 @@ func (eq *EventPublisherQueue) Loop() {
 
 	logger.Info("Event publisher queue started", "publishers", len(eq.publishers))
 
+	// Start one goroutine per publisher
+	var wg sync.WaitGroup
+	workers := make([]*publisherWorker, 0, len(eq.publishers))
+	for i, publisher := range eq.publishers {
+		worker := &publisherWorker{
+			name:      publisherName(i, publisher),
+			publisher: publisher,
+			queue:     make(chan model.WorkloadUpdate, publisherQueueSize),
+		}
+		workers = append(workers, worker)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker.run(ctx)
+		}()
+	}
+
 	for update := range eq.UpdateChan {
 		logger.Info("Received workload update",
 			"namespace", update.Namespace,
@@ -34,16 +95,72 @@ func (eq *EventPublisherQueue) Loop() {
 			"currentVersion", update.CurrentVersion,
 		)
 
-		// Publish to all registered publishers
-		for _, publisher := range eq.publishers {
-			// Publish all version updates, including initial deployments (where PreviousVersion is empty)
-			err := publisher.Publish(ctx, update)
-			if err != nil {
-				logger.Error(err, "failed to publish event",
+		if !recordClusterFilter(eq.ClusterFilter) {
+			logger.V(1).Info("Cluster excluded by cluster prefix filter, not publishing update",
+				"clusterID", eq.ClusterFilter.ClusterID,
+			)
+			continue
+		}
+
+		// Every publisher and the replay buffer report the event under the same ID and time
+		update = model.StampWorkloadUpdate(update)
+
+		if eq.ReplayBuffer != nil {
+			eq.ReplayBuffer.Record(update)
+		}
+
+		var routed []string
+		if eq.Router != nil {
+			routed = eq.Router.Route(ctx, update.Namespace)
+		}
+
+		// Broadcast to all publishers, dropping the event only for publishers that are backed up
+		for _, worker := range workers {
+			if eq.Router != nil && !slices.Contains(routed, worker.name) {
+				continue
+			}
+			select {
+			case worker.queue <- update:
+				publisherQueueDepthGauge.WithLabelValues(worker.name).Set(float64(len(worker.queue)))
+			default:
+				logger.Info("Publisher queue full, dropping event for publisher",
+					"publisher", worker.name,
 					"namespace", update.Namespace,
 					"name", update.Name,
 				)
 			}
 		}
 	}
+
+	for _, worker := range workers {
+		close(worker.queue)
+	}
+	wg.Wait()
+}
+
+// run publishes queued updates until the queue is closed
+func (w *publisherWorker) run(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	for update := range w.queue {
+		publisherQueueDepthGauge.WithLabelValues(w.name).Set(float64(len(w.queue)))
+
+		// Publish all version updates, including initial deployments (where PreviousVersion is empty)
+		err := w.publisher.Publish(ctx, update)
+		if err != nil {
+			logger.Error(err, "failed to publish event",
+				"publisher", w.name,
+				"namespace", update.Namespace,
+				"name", update.Name,
+			)
+		}
+	}
+}
+
+// publisherName returns the publisher's own name, or its position when it has none
+func publisherName(index int, publisher EventPublisher) string {
+	if named, ok := publisher.(Named); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("publisher-%d", index)
 }
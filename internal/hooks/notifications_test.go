@@ -0,0 +1,126 @@
+package hooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+// blockingPublisher blocks every Publish until release is closed
+type blockingPublisher struct {
+	release chan struct{}
+}
+
+func (p *blockingPublisher) Publish(_ context.Context, _ model.WorkloadUpdate) error {
+	<-p.release
+	return nil
+}
+
+func (p *blockingPublisher) Close(_ context.Context) error { return nil }
+
+// channelPublisher forwards every published update to a channel
+type channelPublisher struct {
+	published chan model.WorkloadUpdate
+}
+
+func (p *channelPublisher) Publish(_ context.Context, update model.WorkloadUpdate) error {
+	p.published <- update
+	return nil
+}
+
+func (p *channelPublisher) Close(_ context.Context) error { return nil }
+
+func TestEventPublisherQueue_BlockedPublisherDoesNotDelayOthers(t *testing.T) {
+	slow := &blockingPublisher{release: make(chan struct{})}
+	defer close(slow.release)
+	fast := &channelPublisher{published: make(chan model.WorkloadUpdate, 10)}
+
+	updates := make(chan model.WorkloadUpdate)
+	queue := NewEventPublisherQueue(updates, []EventPublisher{
+		NewTrackedPublisher("slow", slow),
+		NewTrackedPublisher("fast", fast),
+	})
+	go queue.Loop()
+	defer close(updates)
+
+	for i, name := range []string{"api", "web", "worker"} {
+		updates <- model.WorkloadUpdate{Name: name, Namespace: "default"}
+
+		select {
+		case got := <-fast.published:
+			if got.Name != name {
+				t.Errorf("Update %d: expected %s, got %s", i, name, got.Name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Update %d: fast publisher was delayed by the blocked publisher", i)
+		}
+	}
+}
+
+func TestEventPublisherQueue_Router(t *testing.T) {
+	// A blocked publisher outside the route does not delay the routed one
+	slow := &blockingPublisher{release: make(chan struct{})}
+	defer close(slow.release)
+	fast := &channelPublisher{published: make(chan model.WorkloadUpdate, 10)}
+	publishers := []EventPublisher{
+		NewTrackedPublisher("slow", slow),
+		NewTrackedPublisher("fast", fast),
+	}
+	router, err := NewPublisherRouter(publishers, RoutingConfig{
+		Routes: []PublisherRoute{{Namespaces: []string{"sandbox"}, Publishers: []string{}}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	updates := make(chan model.WorkloadUpdate)
+	queue := NewEventPublisherQueue(updates, publishers)
+	queue.Router = router
+	go queue.Loop()
+	defer close(updates)
+
+	updates <- model.WorkloadUpdate{Name: "api", Namespace: "sandbox"}
+	updates <- model.WorkloadUpdate{Name: "api", Namespace: "shop"}
+	select {
+	case got := <-fast.published:
+		if got.Namespace != "shop" {
+			t.Errorf("Expected only the unrouted sandbox update to be skipped, got %s", got.Namespace)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the shop update to be published")
+	}
+}
+
+func TestEventPublisherQueue_ReplayBufferSharesEventID(t *testing.T) {
+	published := &channelPublisher{published: make(chan model.WorkloadUpdate, 1)}
+	updates := make(chan model.WorkloadUpdate)
+	queue := NewEventPublisherQueue(updates, []EventPublisher{published})
+	queue.ReplayBuffer = NewEventReplayBuffer(10, "cluster", "", "v1")
+	go queue.Loop()
+	defer close(updates)
+
+	updates <- model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment"}
+	var update model.WorkloadUpdate
+	select {
+	case update = <-published.published:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the update to be published")
+	}
+
+	sent := model.NewAgentEventPayload(update, "cluster", "", "v1")
+	recorded := queue.ReplayBuffer.Recent(RecentEventsQuery{})
+	if len(recorded) != 1 || recorded[0].EventID != sent.EventID || !recorded[0].OccurredAt.Equal(sent.OccurredAt) {
+		t.Errorf("Expected the replay buffer to hold the published event %s, got %+v", sent.EventID, recorded)
+	}
+}
+
+func TestPublisherName(t *testing.T) {
+	if got := publisherName(0, NewTrackedPublisher("slack", &channelPublisher{})); got != "slack" {
+		t.Errorf("Expected named publisher to use its name, got %q", got)
+	}
+	if got := publisherName(2, &channelPublisher{}); got != "publisher-2" {
+		t.Errorf("Expected unnamed publisher to use its index, got %q", got)
+	}
+}
@@ -2,15 +2,36 @@ package hooks
 
 import (
 	"context"
+	"errors"
 
 	"github.com/apptrail-sh/agent/internal/model"
 )
 
 type EventPublisher interface {
 	Publish(ctx context.Context, update model.WorkloadUpdate) error
+	Close(ctx context.Context) error
 }
 
 // HeartbeatPublisher is the interface for publishing heartbeat events
 type HeartbeatPublisher interface {
 	PublishHeartbeat(ctx context.Context, payload model.ClusterHeartbeatPayload) error
 }
+
+// Closer is implemented by publishers that hold resources which must be released on shutdown
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// CompositeCloser closes a set of closers, aggregating any errors
+type CompositeCloser []Closer
+
+// Close calls Close on every closer, even if earlier ones fail
+func (c CompositeCloser) Close(ctx context.Context) error {
+	var errs []error
+	for _, closer := range c {
+		if err := closer.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// DefaultMaxEventPayloadSize is the largest encoded workload event sent by default (256KB)
+	DefaultMaxEventPayloadSize = 256 * 1024
+
+	// TruncatedMetadataKey marks events in their metadata whose labels were dropped to fit the size limit
+	TruncatedMetadataKey = "truncated"
+)
+
+var (
+	payloadTruncatedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_event_payload_truncated_total",
+		Help: "Number of workload events whose labels were dropped to fit the maximum payload size",
+	}, []string{"publisher"})
+
+	payloadMetricsOnce sync.Once
+)
+
+// MarshalLimitedEvent encodes event as JSON, dropping labels with the largest values first until
+// the encoding is at most maxSize bytes. Truncated events are marked in their metadata. All other
+// fields and the cluster name label are kept, so an event may still exceed the limit once no
+// other labels are left. A maxSize of zero or less disables the limit. The event is updated in
+// place to match the returned encoding.
+func MarshalLimitedEvent(ctx context.Context, publisher string, event *model.AgentEventPayload, maxSize int) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil || maxSize <= 0 || len(data) <= maxSize {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(event.Labels))
+	for key := range event.Labels {
+		if key != model.ClusterNameLabel {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return data, nil
+	}
+	originalSize := len(data)
+
+	// Copy the maps so the caller's update is left untouched
+	labels := maps.Clone(event.Labels)
+	event.Labels = labels
+	event.Metadata = maps.Clone(event.Metadata)
+	if event.Metadata == nil {
+		event.Metadata = make(map[string]any)
+	}
+	event.Metadata[TruncatedMetadataKey] = true
+
+	slices.SortFunc(keys, func(a, b string) int {
+		if diff := len(labels[b]) - len(labels[a]); diff != 0 {
+			return diff
+		}
+		return strings.Compare(a, b)
+	})
+
+	// Re-encode only once the estimated size fits, rather than after every dropped label
+	size := originalSize + len(`,"truncated":true`)
+	dropped := 0
+	for _, key := range keys {
+		size -= len(key) + len(labels[key]) + len(`"":"",`)
+		delete(labels, key)
+		dropped++
+		if size > maxSize && dropped < len(keys) {
+			continue
+		}
+		if data, err = json.Marshal(event); err != nil {
+			return nil, err
+		}
+		if size = len(data); size <= maxSize {
+			break
+		}
+	}
+
+	registerPayloadMetrics()
+	payloadTruncatedCounter.WithLabelValues(publisher).Inc()
+
+	logger := log.FromContext(ctx)
+	logger.Info("Event payload exceeded the maximum size, labels were dropped",
+		"publisher", publisher,
+		"eventID", event.EventID,
+		"namespace", event.Workload.Namespace,
+		"name", event.Workload.Name,
+		"originalSize", originalSize,
+		"truncatedSize", len(data),
+		"maxSize", maxSize,
+		"droppedLabels", dropped,
+	)
+
+	return data, nil
+}
+
+// registerPayloadMetrics registers the payload size metrics once, as several publishers share them
+func registerPayloadMetrics() {
+	payloadMetricsOnce.Do(func() {
+		metrics.Registry.MustRegister(payloadTruncatedCounter)
+	})
+}
@@ -0,0 +1,81 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func newLimitTestEvent(labels map[string]string) model.AgentEventPayload {
+	return model.NewAgentEventPayload(model.WorkloadUpdate{
+		Name:           "api",
+		Namespace:      "prod",
+		Kind:           "Deployment",
+		CurrentVersion: "v2",
+		Labels:         labels,
+	}, "prod-eu", "", "1.0.0")
+}
+
+func TestMarshalLimitedEvent(t *testing.T) {
+	labels := map[string]string{
+		"app":    "api",
+		"large":  strings.Repeat("x", 2000),
+		"medium": strings.Repeat("y", 500),
+	}
+
+	tests := []struct {
+		name          string
+		maxSize       int
+		truncated     bool
+		droppedLabels []string
+	}{
+		{"disabled", 0, false, nil},
+		{"within limit", 64 * 1024, false, nil},
+		{"drops largest label", 1500, true, []string{"large"}},
+		{"drops labels until it fits", 600, true, []string{"large", "medium"}},
+		{"keeps core fields and the cluster name when nothing fits", 10, true, []string{"large", "medium", "app"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := newLimitTestEvent(labels)
+
+			data, err := MarshalLimitedEvent(context.Background(), "test", &event, tt.maxSize)
+			if err != nil {
+				t.Fatalf("MarshalLimitedEvent() error = %v", err)
+			}
+
+			var decoded model.AgentEventPayload
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("failed to decode payload: %v", err)
+			}
+			if truncated, _ := decoded.Metadata[TruncatedMetadataKey].(bool); truncated != tt.truncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.truncated)
+			}
+			if tt.truncated && tt.maxSize >= 600 && len(data) > tt.maxSize {
+				t.Errorf("payload size = %d, want at most %d", len(data), tt.maxSize)
+			}
+			for _, key := range tt.droppedLabels {
+				if _, ok := decoded.Labels[key]; ok {
+					t.Errorf("label %q was kept, want it dropped", key)
+				}
+			}
+			if len(decoded.Labels) != len(labels)+1-len(tt.droppedLabels) {
+				t.Errorf("kept %d labels, want %d", len(decoded.Labels), len(labels)+1-len(tt.droppedLabels))
+			}
+			if decoded.Labels[model.ClusterNameLabel] != "prod-eu" {
+				t.Errorf("cluster name label = %q, want prod-eu", decoded.Labels[model.ClusterNameLabel])
+			}
+			if decoded.Workload.Name != "api" || decoded.Revision == nil || decoded.Revision.Current != "v2" {
+				t.Errorf("core fields were not preserved: %+v", decoded)
+			}
+		})
+	}
+
+	if len(labels["large"]) != 2000 {
+		t.Error("the caller's labels were modified")
+	}
+}
@@ -0,0 +1,137 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// DefaultJobName is the Pushgateway job used when none is configured
+const DefaultJobName = "apptrail-agent"
+
+// Deployment event outcomes, derived from the workload phase
+const (
+	outcomeSuccess    = "success"
+	outcomeFailure    = "failure"
+	outcomeInProgress = "in_progress"
+	outcomeTerminated = "terminated"
+)
+
+// PushConfig holds configuration for the Pushgateway publisher
+type PushConfig struct {
+	URL     string
+	JobName string
+	// BatchSize is the number of events buffered before pushing; 1 pushes after every event
+	BatchSize int
+}
+
+// PrometheusPublisher records workload events as metric increments and pushes them to a Pushgateway
+type PrometheusPublisher struct {
+	config       PushConfig
+	pusher       *push.Pusher
+	eventCounter *prometheus.CounterVec
+
+	mu      sync.Mutex
+	pending int
+}
+
+// NewPrometheusPublisher creates a Pushgateway publisher. Additional collectors (such as the
+// app version gauge) are pushed alongside the deployment event counter.
+func NewPrometheusPublisher(config PushConfig, collectors ...prometheus.Collector) *PrometheusPublisher {
+	if config.JobName == "" {
+		config.JobName = DefaultJobName
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1
+	}
+
+	eventCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_deployment_events_total",
+		Help: "Number of workload deployment events observed by the agent",
+	}, []string{
+		"namespace",
+		"workload",
+		"kind",
+		"phase",
+		"outcome",
+	})
+
+	pusher := push.New(config.URL, config.JobName).Collector(eventCounter)
+	for _, collector := range collectors {
+		pusher = pusher.Collector(collector)
+	}
+
+	return &PrometheusPublisher{
+		config:       config,
+		pusher:       pusher,
+		eventCounter: eventCounter,
+	}
+}
+
+// Publish increments the deployment event counter and pushes once the batch is full
+func (p *PrometheusPublisher) Publish(ctx context.Context, update model.WorkloadUpdate) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	p.eventCounter.WithLabelValues(
+		update.Namespace,
+		update.Name,
+		update.Kind,
+		update.DeploymentPhase,
+		outcome(update.DeploymentPhase),
+	).Inc()
+
+	p.mu.Lock()
+	p.pending++
+	if p.pending < p.config.BatchSize {
+		p.mu.Unlock()
+		return nil
+	}
+	p.pending = 0
+	p.mu.Unlock()
+
+	if err := p.push(ctx); err != nil {
+		log.Error(err, "failed to push metrics to pushgateway", "url", p.config.URL)
+		return err
+	}
+	return nil
+}
+
+// push sends the current metric values to the Pushgateway, replacing the job's previous group
+func (p *PrometheusPublisher) push(ctx context.Context) error {
+	if err := p.pusher.PushContext(ctx); err != nil {
+		return fmt.Errorf("failed to push to pushgateway: %w", err)
+	}
+	return nil
+}
+
+// Close pushes any events still buffered in the current batch
+func (p *PrometheusPublisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = 0
+	p.mu.Unlock()
+
+	if pending == 0 {
+		return nil
+	}
+	return p.push(ctx)
+}
+
+// outcome maps a workload phase to the outcome label
+func outcome(phase string) string {
+	switch phase {
+	case "success":
+		return outcomeSuccess
+	case "failed":
+		return outcomeFailure
+	case "deleted":
+		return outcomeTerminated
+	default:
+		return outcomeInProgress
+	}
+}
@@ -0,0 +1,82 @@
+package prometheus
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestPrometheusPublisher_Publish(t *testing.T) {
+	var mu sync.Mutex
+	var pushes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pushes = append(pushes, r.URL.Path+"\n"+string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	publisher := NewPrometheusPublisher(PushConfig{URL: server.URL, JobName: "test-job", BatchSize: 2})
+	update := model.WorkloadUpdate{
+		Name:            "api",
+		Namespace:       "default",
+		Kind:            "Deployment",
+		DeploymentPhase: "success",
+	}
+
+	if err := publisher.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(pushes) != 0 {
+		t.Fatalf("Expected no push before the batch is full, got %d", len(pushes))
+	}
+
+	if err := publisher.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(pushes) != 1 {
+		t.Fatalf("Expected 1 push, got %d", len(pushes))
+	}
+	if !strings.Contains(pushes[0], "/metrics/job/test-job") {
+		t.Errorf("Expected push to job test-job, got %q", pushes[0])
+	}
+	if !strings.Contains(pushes[0], "apptrail_deployment_events_total") {
+		t.Errorf("Expected pushed body to contain the event counter")
+	}
+
+	if err := publisher.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error on close, got: %v", err)
+	}
+	if len(pushes) != 1 {
+		t.Errorf("Expected no push on close without pending events, got %d", len(pushes))
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		phase    string
+		expected string
+	}{
+		{"success", outcomeSuccess},
+		{"failed", outcomeFailure},
+		{"deleted", outcomeTerminated},
+		{"rolling_out", outcomeInProgress},
+		{"", outcomeInProgress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phase, func(t *testing.T) {
+			if got := outcome(tt.phase); got != tt.expected {
+				t.Errorf("outcome(%q) = %q, want %q", tt.phase, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package pubsub
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// startConnectProxy serves CONNECT requests with status, then echoes the tunnelled bytes.
+// Each request it receives is sent on the returned channel.
+func startConnectProxy(t *testing.T, status int) (string, <-chan *http.Request) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	requests := make(chan *http.Request, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		reader := bufio.NewReader(conn)
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		requests <- req
+		resp := &http.Response{StatusCode: status, ProtoMajor: 1, ProtoMinor: 1}
+		if err := resp.Write(conn); err != nil || status != http.StatusOK {
+			return
+		}
+		_, _ = io.Copy(conn, reader)
+	}()
+	return listener.Addr().String(), requests
+}
+
+func TestProxyDialer(t *testing.T) {
+	proxyAddr, requests := startConnectProxy(t, http.StatusOK)
+	proxyURL := &url.URL{Scheme: "http", Host: proxyAddr, User: url.UserPassword("agent", "secret")}
+	dial := proxyDialer(func(*url.URL) (*url.URL, error) { return proxyURL, nil })
+
+	conn, err := dial(context.Background(), "pubsub.googleapis.com:443")
+	if err != nil {
+		t.Fatalf("dial() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	req := <-requests
+	if req.Method != http.MethodConnect || req.Host != "pubsub.googleapis.com:443" {
+		t.Errorf("Expected CONNECT pubsub.googleapis.com:443, got %s %s", req.Method, req.Host)
+	}
+	if got := req.Header.Get("Proxy-Authorization"); got != "Basic YWdlbnQ6c2VjcmV0" {
+		t.Errorf("Proxy-Authorization = %q", got)
+	}
+
+	// Bytes written to the connection travel through the tunnel
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil || string(reply) != "ping" {
+		t.Errorf("Expected echoed ping, got %q, %v", reply, err)
+	}
+}
+
+func TestProxyDialer_Refused(t *testing.T) {
+	proxyAddr, _ := startConnectProxy(t, http.StatusProxyAuthRequired)
+	dial := proxyDialer(func(*url.URL) (*url.URL, error) { return &url.URL{Scheme: "http", Host: proxyAddr}, nil })
+
+	if _, err := dial(context.Background(), "pubsub.googleapis.com:443"); err == nil {
+		t.Fatal("Expected error when the proxy refuses the tunnel")
+	}
+}
+
+func TestProxyDialer_Direct(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	// No proxy selected, e.g. the host matches --no-proxy
+	dial := proxyDialer(func(*url.URL) (*url.URL, error) { return nil, nil })
+	conn, err := dial(context.Background(), listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial() error = %v", err)
+	}
+	_ = conn.Close()
+}
@@ -3,21 +3,151 @@ package pubsub
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"cloud.google.com/go/pubsub/v2"
+	"github.com/apptrail-sh/agent/internal/hooks"
 	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
+var (
+	batchPublishSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "apptrail_pubsub_batch_publish_size",
+		Help:    "Number of resource events in each batch published to Pub/Sub",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+	})
+
+	deadLetterEligible = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_pubsub_dead_letter_eligible_total",
+		Help: "Number of events that failed to publish to Pub/Sub after the client exhausted its retries",
+	})
+
+	flowControlled = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_pubsub_flow_controlled_total",
+		Help: "Number of events rejected by the Pub/Sub publisher flow control limits",
+	})
+
+	metricsRegistered = false
+)
+
+const (
+	// DefaultMaxOutstandingMessages bounds the messages buffered by each Pub/Sub publisher
+	DefaultMaxOutstandingMessages = 1000
+	// DefaultMaxOutstandingBytes bounds the bytes buffered by each Pub/Sub publisher
+	DefaultMaxOutstandingBytes int64 = 10 * 1024 * 1024
+
+	// maxAnnotationAttributes caps the passthrough annotations copied into message attributes;
+	// the full set is always in the message body
+	maxAnnotationAttributes = 10
+	// annotationAttributePrefix namespaces annotation attributes apart from the fixed ones
+	annotationAttributePrefix = "annotation_"
+	// deadLetterReasonFlowControlled is the dead_letter_reason of events rejected by flow control
+	deadLetterReasonFlowControlled = "flow_controlled"
+
+	// Pub/Sub rejects messages with attribute keys or values larger than these, in bytes
+	maxAttributeKeySize   = 256
+	maxAttributeValueSize = 1024
+)
+
+// OrderingStrategy selects which events share a Pub/Sub ordering key. Events with the same key
+// are delivered in publish order; events with different keys may be reordered but publish in parallel.
+type OrderingStrategy string
+
+const (
+	// OrderingWorkload orders events per workload or resource
+	OrderingWorkload OrderingStrategy = "workload"
+	// OrderingNamespace orders events per namespace; cluster-scoped resources share the cluster key
+	OrderingNamespace OrderingStrategy = "namespace"
+	// OrderingCluster orders all events of the cluster, with the lowest throughput
+	OrderingCluster OrderingStrategy = "cluster"
+	// OrderingNone disables message ordering for maximum throughput
+	OrderingNone OrderingStrategy = "none"
+)
+
+// ParseOrderingStrategy validates an ordering strategy name
+func ParseOrderingStrategy(value string) (OrderingStrategy, error) {
+	switch strategy := OrderingStrategy(value); strategy {
+	case OrderingWorkload, OrderingNamespace, OrderingCluster, OrderingNone:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("invalid ordering strategy %q: must be workload, namespace, cluster or none", value)
+	}
+}
+
+// PubSubConfig holds the configuration for the Pub/Sub publisher
+type PubSubConfig struct {
+	// TopicPath is the full Pub/Sub topic path (projects/<project>/topics/<topic>)
+	TopicPath string
+	// HeartbeatTopicPath is an optional topic path for heartbeats; empty uses TopicPath
+	HeartbeatTopicPath string
+	// DeadLetterTopicPath is an optional topic path receiving events that failed to publish
+	DeadLetterTopicPath string
+	// NodeTopicPath optionally routes node resource events to their own topic
+	NodeTopicPath string
+	// PodTopicPath optionally routes pod resource events to their own topic
+	PodTopicPath string
+
+	// ClusterID uniquely identifies this cluster
+	ClusterID string
+	// ProjectID is the cloud project the cluster runs in, added to workload events for routing
+	ProjectID string
+	// AgentVersion is the version of the agent
+	AgentVersion string
+
+	// MaxOutstandingMessages limits buffered messages per publisher (default: 1000)
+	MaxOutstandingMessages int
+	// MaxOutstandingBytes limits buffered bytes per publisher (default: 10MB)
+	MaxOutstandingBytes int64
+
+	// OrderingStrategy selects the ordering key of events (default: workload)
+	OrderingStrategy OrderingStrategy
+
+	// MaxPayloadSize is the largest encoded workload event in bytes; labels are dropped to fit
+	// (0 disables)
+	MaxPayloadSize int
+
+	// Proxy selects the HTTP proxy the gRPC connection is tunnelled through; nil connects directly
+	Proxy func(*url.URL) (*url.URL, error)
+}
+
 // PubSubPublisher sends workload updates to Google Cloud Pub/Sub
 type PubSubPublisher struct {
-	client       *pubsub.Client
-	publisher    *pubsub.Publisher
-	topicPath    string
-	clusterID    string
-	agentVersion string
+	client    *pubsub.Client
+	publisher *pubsub.Publisher
+	topicPath string
+
+	// Heartbeats go to a separate topic when configured, otherwise to the main topic
+	heartbeatPublisher *pubsub.Publisher
+	heartbeatTopicPath string
+
+	// Node and pod resource events go to dedicated topics when configured
+	nodePublisher *pubsub.Publisher
+	podPublisher  *pubsub.Publisher
+
+	// Events that fail to publish are forwarded here when configured
+	deadLetterPublisher *pubsub.Publisher
+	deadLetterTopicPath string
+
+	clusterID        string
+	projectID        string
+	agentVersion     string
+	orderingStrategy OrderingStrategy
+	maxPayloadSize   int
 }
 
 // ParseTopicPath parses a full Pub/Sub topic path and returns projectID and topicID.
@@ -37,43 +167,136 @@ func ParseTopicPath(topicPath string) (projectID, topicID string, err error) {
 //   - Service Account JSON key: Set GOOGLE_APPLICATION_CREDENTIALS env var
 //   - Default credentials: gcloud auth application-default login
 //
-// Parameters:
-//   - topicPath: Full Pub/Sub topic path (projects/<project>/topics/<topic>)
-//   - clusterID: Unique identifier for this cluster
-//   - agentVersion: Version of the agent
-func NewPubSubPublisher(ctx context.Context, topicPath, clusterID, agentVersion string) (*PubSubPublisher, error) {
-	projectID, topicID, err := ParseTopicPath(topicPath)
+// Publishers reject messages beyond the flow control limits instead of buffering
+// them without bound during bursts.
+func NewPubSubPublisher(ctx context.Context, config PubSubConfig) (*PubSubPublisher, error) {
+	projectID, _, err := ParseTopicPath(config.TopicPath)
 	if err != nil {
 		return nil, err
 	}
+	if config.HeartbeatTopicPath != "" {
+		if _, _, err := ParseTopicPath(config.HeartbeatTopicPath); err != nil {
+			return nil, fmt.Errorf("invalid heartbeat topic: %w", err)
+		}
+	}
+	if config.DeadLetterTopicPath != "" {
+		if _, _, err := ParseTopicPath(config.DeadLetterTopicPath); err != nil {
+			return nil, fmt.Errorf("invalid dead letter topic: %w", err)
+		}
+	}
+	if config.NodeTopicPath != "" {
+		if _, _, err := ParseTopicPath(config.NodeTopicPath); err != nil {
+			return nil, fmt.Errorf("invalid node topic: %w", err)
+		}
+	}
+	if config.PodTopicPath != "" {
+		if _, _, err := ParseTopicPath(config.PodTopicPath); err != nil {
+			return nil, fmt.Errorf("invalid pod topic: %w", err)
+		}
+	}
+
+	// Register metrics only once
+	if !metricsRegistered {
+		metrics.Registry.MustRegister(batchPublishSize, deadLetterEligible, flowControlled, publishRetries)
+		metricsRegistered = true
+	}
 
-	client, err := pubsub.NewClient(ctx, projectID)
+	var opts []option.ClientOption
+	if config.Proxy != nil {
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithContextDialer(proxyDialer(config.Proxy))))
+	}
+	client, err := pubsub.NewClient(ctx, projectID, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pubsub client: %w", err)
 	}
 
-	// Enable message ordering to guarantee events for the same workload
+	return newPubSubPublisher(client, config), nil
+}
+
+// newPubSubPublisher wires the topic publishers on an existing client
+func newPubSubPublisher(client *pubsub.Client, config PubSubConfig) *PubSubPublisher {
+	if config.MaxOutstandingMessages <= 0 {
+		config.MaxOutstandingMessages = DefaultMaxOutstandingMessages
+	}
+	if config.MaxOutstandingBytes <= 0 {
+		config.MaxOutstandingBytes = DefaultMaxOutstandingBytes
+	}
+	if config.OrderingStrategy == "" {
+		config.OrderingStrategy = OrderingWorkload
+	}
+	ordered := config.OrderingStrategy != OrderingNone
+
+	newPublisher := func(topicPath string) *pubsub.Publisher {
+		publisher := client.Publisher(topicPath)
+		publisher.PublishSettings.FlowControlSettings = pubsub.FlowControlSettings{
+			MaxOutstandingMessages: config.MaxOutstandingMessages,
+			MaxOutstandingBytes:    int(config.MaxOutstandingBytes),
+			LimitExceededBehavior:  pubsub.FlowControlSignalError,
+		}
+		return publisher
+	}
+
+	topicPath := config.TopicPath
+
+	// Enable message ordering to guarantee events sharing an ordering key
 	// are delivered in the order they were published.
 	// The subscription must also have message ordering enabled.
-	publisher := client.Publisher(topicID)
-	publisher.EnableMessageOrdering = true
+	publisher := newPublisher(topicPath)
+	publisher.EnableMessageOrdering = ordered
+
+	heartbeatTopicPath := config.HeartbeatTopicPath
+	heartbeatPublisher := publisher
+	if heartbeatTopicPath != "" && heartbeatTopicPath != topicPath {
+		heartbeatPublisher = newPublisher(heartbeatTopicPath)
+		heartbeatPublisher.EnableMessageOrdering = ordered
+	} else {
+		heartbeatTopicPath = topicPath
+	}
+
+	// Per resource type topics keep the same ordering guarantees as the main topic
+	var nodePublisher, podPublisher *pubsub.Publisher
+	if config.NodeTopicPath != "" && config.NodeTopicPath != topicPath {
+		nodePublisher = newPublisher(config.NodeTopicPath)
+		nodePublisher.EnableMessageOrdering = ordered
+	}
+	if config.PodTopicPath != "" && config.PodTopicPath != topicPath {
+		podPublisher = newPublisher(config.PodTopicPath)
+		podPublisher.EnableMessageOrdering = ordered
+	}
+
+	// Dead-lettered events are published without an ordering key, so a failure
+	// there never pauses publishing to the main topic
+	deadLetterTopicPath := config.DeadLetterTopicPath
+	var deadLetterPublisher *pubsub.Publisher
+	if deadLetterTopicPath != "" {
+		deadLetterPublisher = newPublisher(deadLetterTopicPath)
+	}
 
 	return &PubSubPublisher{
-		client:       client,
-		publisher:    publisher,
-		topicPath:    topicPath,
-		clusterID:    clusterID,
-		agentVersion: agentVersion,
-	}, nil
+		client:              client,
+		publisher:           publisher,
+		topicPath:           topicPath,
+		heartbeatPublisher:  heartbeatPublisher,
+		heartbeatTopicPath:  heartbeatTopicPath,
+		nodePublisher:       nodePublisher,
+		podPublisher:        podPublisher,
+		deadLetterPublisher: deadLetterPublisher,
+		deadLetterTopicPath: deadLetterTopicPath,
+		clusterID:           config.ClusterID,
+		projectID:           config.ProjectID,
+		agentVersion:        config.AgentVersion,
+		orderingStrategy:    config.OrderingStrategy,
+		maxPayloadSize:      config.MaxPayloadSize,
+	}
 }
 
 // Publish sends a workload update to Google Cloud Pub/Sub
 func (p *PubSubPublisher) Publish(ctx context.Context, update model.WorkloadUpdate) error {
 	logger := log.FromContext(ctx)
 
-	event := model.NewAgentEventPayload(update, p.clusterID, p.agentVersion)
+	event := model.NewAgentEventPayload(update, p.clusterID, p.projectID, p.agentVersion)
 
-	data, err := json.Marshal(event)
+	data, err := hooks.MarshalLimitedEvent(ctx, "pubsub", &event, p.maxPayloadSize)
 	if err != nil {
 		logger.Error(err, "Failed to marshal event",
 			"eventID", event.EventID,
@@ -83,9 +306,7 @@ func (p *PubSubPublisher) Publish(ctx context.Context, update model.WorkloadUpda
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	// Ordering key ensures events for the same cluster are delivered in order.
-	// Using cluster ID for all events ensures consistent ordering across all event types.
-	orderingKey := p.clusterID
+	orderingKey := p.orderingKey(update)
 
 	logger.Info("Publishing event to Google Pub/Sub",
 		"topic", p.topicPath,
@@ -108,19 +329,24 @@ func (p *PubSubPublisher) Publish(ctx context.Context, update model.WorkloadUpda
 	if event.Phase != nil {
 		attributes["deployment_phase"] = string(*event.Phase)
 	}
+	if p.projectID != "" {
+		attributes["project_id"] = p.projectID
+	}
+	addAnnotationAttributes(ctx, attributes, event.Annotations)
 
-	result := p.publisher.Publish(ctx, &pubsub.Message{
+	msg := &pubsub.Message{
 		Data:        data,
 		Attributes:  attributes,
 		OrderingKey: orderingKey,
-	})
-
-	msgID, err := result.Get(ctx)
+	}
+	msgID, err := publishWithRetry(ctx, p.publisher, msg)
 	if err != nil {
 		logger.Error(err, "Failed to publish event to Pub/Sub",
 			"topic", p.topicPath,
 			"eventID", event.EventID,
 		)
+		p.handlePublishFailure(ctx, p.publisher, msg, err)
+		p.deadLetter(ctx, p.topicPath, msg, err)
 		return fmt.Errorf("failed to publish event to pubsub: %w", err)
 	}
 
@@ -135,9 +361,46 @@ func (p *PubSubPublisher) Publish(ctx context.Context, update model.WorkloadUpda
 	return nil
 }
 
+// addAnnotationAttributes copies up to maxAnnotationAttributes annotations into attributes,
+// taking keys in sorted order so the selection is stable between events. Annotations whose
+// attribute key is too long for Pub/Sub are skipped and values too long are truncated, as
+// either would fail the publish; the message body keeps them whole.
+func addAnnotationAttributes(ctx context.Context, attributes, annotations map[string]string) {
+	logger := log.FromContext(ctx)
+
+	added := 0
+	for _, key := range slices.Sorted(maps.Keys(annotations)) {
+		if added == maxAnnotationAttributes {
+			return
+		}
+		attributeKey := annotationAttributePrefix + key
+		if len(attributeKey) > maxAttributeKeySize {
+			logger.Info("Skipping annotation attribute, key exceeds the Pub/Sub limit",
+				"annotation", key, "maxKeySize", maxAttributeKeySize)
+			continue
+		}
+		value := annotations[key]
+		if len(value) > maxAttributeValueSize {
+			logger.Info("Truncating annotation attribute, value exceeds the Pub/Sub limit",
+				"annotation", key, "size", len(value), "maxValueSize", maxAttributeValueSize)
+			value = truncateUTF8(value, maxAttributeValueSize)
+		}
+		attributes[attributeKey] = value
+		added++
+	}
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a multi-byte character
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 // PublishBatch sends a batch of resource events to Google Cloud Pub/Sub
 // Implements hooks.ResourceEventPublisher interface
-func (p *PubSubPublisher) PublishBatch(ctx context.Context, events []model.ResourceEventPayload) error {
+func (p *PubSubPublisher) PublishBatch(ctx context.Context, events []model.ResourceEventPayload, meta model.BatchMetadata) error {
 	if len(events) == 0 {
 		return nil
 	}
@@ -149,60 +412,82 @@ func (p *PubSubPublisher) PublishBatch(ctx context.Context, events []model.Resou
 		"eventCount", len(events),
 	)
 
-	var publishResults []*pubsub.PublishResult
-	for _, event := range events {
-		data, err := json.Marshal(event)
-		if err != nil {
-			logger.Error(err, "Failed to marshal resource event",
-				"eventID", event.EventID,
-				"resourceType", event.ResourceType,
-				"name", event.Resource.Name,
-			)
-			continue
-		}
-
-		// Ordering key ensures events for the same cluster are delivered in order.
-		// Using cluster ID for all events ensures consistent ordering across all event types.
-		orderingKey := p.clusterID
+	batchPublishSize.Observe(float64(len(events)))
 
-		attributes := map[string]string{
-			"cluster_id":    p.clusterID,
-			"resource_type": string(event.ResourceType),
-			"event_kind":    string(event.EventKind),
-			"resource_name": event.Resource.Name,
-			"message_type":  "resource_event", // Distinguish from workload events
-		}
-		if event.Resource.Namespace != "" {
-			attributes["namespace"] = event.Resource.Namespace
+	// Publish every event first, then wait for the acks together
+	type pendingResult struct {
+		event     model.ResourceEventPayload
+		msg       *pubsub.Message
+		publisher *pubsub.Publisher
+		result    *pubsub.PublishResult
+	}
+	var pending []pendingResult
+	var errs []error
+
+	for _, group := range groupByOrderingKey(events, p.resourceOrderingKey) {
+		for _, event := range group.events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				logger.Error(err, "Failed to marshal resource event",
+					"eventID", event.EventID,
+					"resourceType", event.ResourceType,
+					"name", event.Resource.Name,
+				)
+				errs = append(errs, fmt.Errorf("event %s: failed to marshal: %w", event.EventID, err))
+				continue
+			}
+
+			attributes := map[string]string{
+				"cluster_id":    p.clusterID,
+				"resource_type": string(event.ResourceType),
+				"event_kind":    string(event.EventKind),
+				"resource_name": event.Resource.Name,
+				"message_type":  "resource_event", // Distinguish from workload events
+			}
+			if event.Resource.Namespace != "" {
+				attributes["namespace"] = event.Resource.Namespace
+			}
+			if event.BatchID != "" {
+				attributes["batch_id"] = event.BatchID
+			}
+			// Report events dropped before this batch so consumers can detect gaps
+			if meta.DroppedCount > 0 {
+				attributes["dropped_count"] = strconv.Itoa(meta.DroppedCount)
+				attributes["oldest_dropped_at"] = meta.OldestDroppedAt.UTC().Format(time.RFC3339Nano)
+			}
+
+			msg := &pubsub.Message{
+				Data:        data,
+				Attributes:  attributes,
+				OrderingKey: group.key,
+			}
+			publisher := p.topicPublisher(event.ResourceType)
+			result := publisher.Publish(ctx, msg)
+			pending = append(pending, pendingResult{event: event, msg: msg, publisher: publisher, result: result})
 		}
-
-		result := p.publisher.Publish(ctx, &pubsub.Message{
-			Data:        data,
-			Attributes:  attributes,
-			OrderingKey: orderingKey,
-		})
-		publishResults = append(publishResults, result)
 	}
 
 	// Wait for all publishes to complete
-	var errors []error
-	for i, result := range publishResults {
-		msgID, err := result.Get(ctx)
+	for _, pr := range pending {
+		msgID, err := pr.result.Get(ctx)
 		if err != nil {
 			logger.Error(err, "Failed to publish resource event to Pub/Sub",
-				"eventID", events[i].EventID,
+				"topic", pr.publisher.String(),
+				"eventID", pr.event.EventID,
 			)
-			errors = append(errors, err)
+			p.handlePublishFailure(ctx, pr.publisher, pr.msg, err)
+			p.deadLetter(ctx, pr.publisher.String(), pr.msg, err)
+			errs = append(errs, fmt.Errorf("event %s: %w", pr.event.EventID, err))
 		} else {
 			logger.V(1).Info("Resource event published",
 				"messageID", msgID,
-				"eventID", events[i].EventID,
+				"eventID", pr.event.EventID,
 			)
 		}
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("failed to publish %d/%d events", len(errors), len(events))
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to publish %d/%d events: %w", len(errs), len(events), errors.Join(errs...))
 	}
 
 	logger.Info("Resource event batch successfully published to Google Pub/Sub",
@@ -213,13 +498,80 @@ func (p *PubSubPublisher) PublishBatch(ctx context.Context, events []model.Resou
 	return nil
 }
 
+// topicPublisher returns the publisher for a resource type, falling back to the main topic
+// when no dedicated topic is configured
+func (p *PubSubPublisher) topicPublisher(resourceType model.ResourceType) *pubsub.Publisher {
+	switch {
+	case resourceType == model.ResourceTypeNode && p.nodePublisher != nil:
+		return p.nodePublisher
+	case resourceType == model.ResourceTypePod && p.podPublisher != nil:
+		return p.podPublisher
+	default:
+		return p.publisher
+	}
+}
+
+// orderingKey returns the ordering key for a workload update under the configured strategy
+func (p *PubSubPublisher) orderingKey(update model.WorkloadUpdate) string {
+	return p.keyFor(update.Namespace, update.Name)
+}
+
+// resourceOrderingKey returns the ordering key for a resource event under the configured strategy
+func (p *PubSubPublisher) resourceOrderingKey(event model.ResourceEventPayload) string {
+	return p.keyFor(event.Resource.Namespace, event.Resource.Name)
+}
+
+// keyFor builds the ordering key of an object; an empty key publishes without ordering
+func (p *PubSubPublisher) keyFor(namespace, name string) string {
+	switch p.orderingStrategy {
+	case OrderingNone:
+		return ""
+	case OrderingCluster:
+		return p.clusterID
+	case OrderingNamespace:
+		if namespace == "" {
+			return p.clusterID
+		}
+		return p.clusterID + "/" + namespace
+	default:
+		if namespace == "" {
+			return p.clusterID + "/" + name
+		}
+		return p.clusterID + "/" + namespace + "/" + name
+	}
+}
+
+// orderingGroup is a run of events sharing an ordering key, in publish order
+type orderingGroup struct {
+	key    string
+	events []model.ResourceEventPayload
+}
+
+// groupByOrderingKey groups events by ordering key, keeping the original order within each group
+// and ordering groups by first appearance
+func groupByOrderingKey(events []model.ResourceEventPayload, keyFn func(model.ResourceEventPayload) string) []orderingGroup {
+	var groups []orderingGroup
+	index := make(map[string]int)
+	for _, event := range events {
+		key := keyFn(event)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, orderingGroup{key: key})
+		}
+		groups[i].events = append(groups[i].events, event)
+	}
+	return groups
+}
+
 // PublishHeartbeat sends a heartbeat to Google Cloud Pub/Sub
 // Implements hooks.HeartbeatPublisher interface
 func (p *PubSubPublisher) PublishHeartbeat(ctx context.Context, payload model.ClusterHeartbeatPayload) error {
 	logger := log.FromContext(ctx)
 
 	logger.Info("Publishing heartbeat to Google Pub/Sub",
-		"topic", p.topicPath,
+		"topic", p.heartbeatTopicPath,
 		"eventID", payload.EventID,
 		"nodeCount", len(payload.Inventory.NodeUIDs),
 		"podCount", len(payload.Inventory.PodUIDs),
@@ -233,32 +585,37 @@ func (p *PubSubPublisher) PublishHeartbeat(ctx context.Context, payload model.Cl
 		return fmt.Errorf("failed to marshal heartbeat: %w", err)
 	}
 
-	// Ordering key ensures events for the same cluster are delivered in order.
-	// Using cluster ID for all events ensures consistent ordering across all event types.
+	// Heartbeats are ordered per cluster unless ordering is disabled
 	orderingKey := p.clusterID
+	if p.orderingStrategy == OrderingNone {
+		orderingKey = ""
+	}
 
 	attributes := map[string]string{
 		"cluster_id":   p.clusterID,
 		"message_type": "heartbeat",
 	}
 
-	result := p.publisher.Publish(ctx, &pubsub.Message{
+	msg := &pubsub.Message{
 		Data:        data,
 		Attributes:  attributes,
 		OrderingKey: orderingKey,
-	})
+	}
+	result := p.heartbeatPublisher.Publish(ctx, msg)
 
 	msgID, err := result.Get(ctx)
 	if err != nil {
 		logger.Error(err, "Failed to publish heartbeat to Pub/Sub",
-			"topic", p.topicPath,
+			"topic", p.heartbeatTopicPath,
 			"eventID", payload.EventID,
 		)
+		// Heartbeats are not dead-lettered, the next one supersedes them
+		p.handlePublishFailure(ctx, p.heartbeatPublisher, msg, err)
 		return fmt.Errorf("failed to publish heartbeat to pubsub: %w", err)
 	}
 
 	logger.Info("Heartbeat successfully published to Google Pub/Sub",
-		"topic", p.topicPath,
+		"topic", p.heartbeatTopicPath,
 		"eventID", payload.EventID,
 		"messageID", msgID,
 	)
@@ -266,12 +623,94 @@ func (p *PubSubPublisher) PublishHeartbeat(ctx context.Context, payload model.Cl
 	return nil
 }
 
-// Stop stops the publisher and closes the client
+// handlePublishFailure resumes the failed ordering key so later messages are accepted again,
+// records flow control rejections and reports a missing topic with instructions,
+// since the agent does not create topics itself
+func (p *PubSubPublisher) handlePublishFailure(ctx context.Context, publisher *pubsub.Publisher, msg *pubsub.Message, err error) {
+	topicPath := publisher.String()
+	if msg.OrderingKey != "" {
+		publisher.ResumePublish(msg.OrderingKey)
+	}
+
+	if isFlowControlError(err) {
+		flowControlled.Inc()
+		log.FromContext(ctx).Info("Pub/Sub publisher flow control limit reached, event rejected",
+			"topic", topicPath,
+			"reason", err.Error(),
+		)
+		return
+	}
+
+	if status.Code(err) == codes.NotFound {
+		projectID, topicID, _ := ParseTopicPath(topicPath)
+		log.FromContext(ctx).Error(err, "Pub/Sub topic does not exist; create it with "+
+			"`gcloud pubsub topics create "+topicID+" --project "+projectID+"` "+
+			"and grant the agent's service account roles/pubsub.publisher on it",
+			"topic", topicPath,
+		)
+	}
+}
+
+// deadLetter forwards a message that exhausted its publish retries to the dead letter topic.
+// The failure reason and original topic are added as attributes; flow control rejections get
+// the distinct reason deadLetterReasonFlowControlled, as the event itself was never sent.
+func (p *PubSubPublisher) deadLetter(ctx context.Context, topicPath string, msg *pubsub.Message, publishErr error) {
+	deadLetterEligible.Inc()
+
+	if p.deadLetterPublisher == nil {
+		return
+	}
+
+	logger := log.FromContext(ctx)
+
+	attributes := make(map[string]string, len(msg.Attributes)+2)
+	for k, v := range msg.Attributes {
+		attributes[k] = v
+	}
+	attributes["original_topic"] = topicPath
+	attributes["dead_letter_reason"] = publishErr.Error()
+	if isFlowControlError(publishErr) {
+		attributes["dead_letter_reason"] = deadLetterReasonFlowControlled
+	}
+
+	result := p.deadLetterPublisher.Publish(ctx, &pubsub.Message{
+		Data:       msg.Data,
+		Attributes: attributes,
+	})
+	if _, err := result.Get(ctx); err != nil {
+		logger.Error(err, "Failed to publish event to Pub/Sub dead letter topic",
+			"topic", p.deadLetterTopicPath,
+		)
+		return
+	}
+
+	logger.Info("Event forwarded to Pub/Sub dead letter topic",
+		"topic", p.deadLetterTopicPath,
+	)
+}
+
+// Stop flushes pending messages and stops the publisher
 func (p *PubSubPublisher) Stop() {
 	if p.publisher != nil {
 		p.publisher.Stop()
 	}
+	if p.heartbeatPublisher != nil && p.heartbeatPublisher != p.publisher {
+		p.heartbeatPublisher.Stop()
+	}
+	for _, publisher := range []*pubsub.Publisher{p.nodePublisher, p.podPublisher, p.deadLetterPublisher} {
+		if publisher != nil {
+			publisher.Stop()
+		}
+	}
+}
+
+// Close stops the publisher and closes the client
+func (p *PubSubPublisher) Close(_ context.Context) error {
+	p.Stop()
 	if p.client != nil {
-		_ = p.client.Close()
+		if err := p.client.Close(); err != nil {
+			return fmt.Errorf("failed to close pubsub client: %w", err)
+		}
 	}
+	return nil
 }
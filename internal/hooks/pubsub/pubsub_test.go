@@ -0,0 +1,576 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"cloud.google.com/go/pubsub/v2"
+	pubsubpb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/v2/pstest"
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestGroupByOrderingKey(t *testing.T) {
+	events := []model.ResourceEventPayload{
+		{EventID: "1", Resource: model.ResourceRef{Name: "a"}},
+		{EventID: "2", Resource: model.ResourceRef{Name: "b"}},
+		{EventID: "3", Resource: model.ResourceRef{Name: "a"}},
+		{EventID: "4", Resource: model.ResourceRef{Name: "c"}},
+		{EventID: "5", Resource: model.ResourceRef{Name: "b"}},
+	}
+
+	groups := groupByOrderingKey(events, func(e model.ResourceEventPayload) string {
+		return e.Resource.Name
+	})
+
+	expected := []struct {
+		key string
+		ids []string
+	}{
+		{"a", []string{"1", "3"}},
+		{"b", []string{"2", "5"}},
+		{"c", []string{"4"}},
+	}
+
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, want := range expected {
+		if groups[i].key != want.key {
+			t.Errorf("Group %d: expected key %q, got %q", i, want.key, groups[i].key)
+		}
+		if len(groups[i].events) != len(want.ids) {
+			t.Fatalf("Group %q: expected %d events, got %d", want.key, len(want.ids), len(groups[i].events))
+		}
+		for j, id := range want.ids {
+			if groups[i].events[j].EventID != id {
+				t.Errorf("Group %q event %d: expected %s, got %s", want.key, j, id, groups[i].events[j].EventID)
+			}
+		}
+	}
+}
+
+func TestPublishBatch_DeadLettersMissingTopic(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t)
+
+	deadLetterTopic := "projects/proj/topics/dead-letter"
+	if _, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: deadLetterTopic}); err != nil {
+		t.Fatalf("failed to create dead letter topic: %v", err)
+	}
+
+	// The main topic is never created, so every publish fails with NotFound
+	p := newPubSubPublisher(client, PubSubConfig{
+		TopicPath:           "projects/proj/topics/missing",
+		DeadLetterTopicPath: deadLetterTopic,
+		ClusterID:           "test-cluster",
+		AgentVersion:        "v1",
+	})
+	defer p.Stop()
+
+	before := testutil.ToFloat64(deadLetterEligible)
+
+	events := []model.ResourceEventPayload{
+		{EventID: "1", ResourceType: model.ResourceTypeNode, Resource: model.ResourceRef{Name: "node-a"}},
+		{EventID: "2", ResourceType: model.ResourceTypeNode, Resource: model.ResourceRef{Name: "node-b"}},
+	}
+	if err := p.PublishBatch(ctx, events, model.BatchMetadata{}); err == nil {
+		t.Fatal("expected publish to a missing topic to fail")
+	}
+
+	if got := testutil.ToFloat64(deadLetterEligible) - before; got != 2 {
+		t.Errorf("expected 2 dead letter eligible events, got %v", got)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 dead-lettered messages, got %d", len(messages))
+	}
+	for _, m := range messages {
+		if m.Topic != deadLetterTopic {
+			t.Errorf("expected message on %s, got %s", deadLetterTopic, m.Topic)
+		}
+		if m.Attributes["original_topic"] != "projects/proj/topics/missing" {
+			t.Errorf("unexpected original_topic attribute %q", m.Attributes["original_topic"])
+		}
+		if m.Attributes["dead_letter_reason"] == "" {
+			t.Error("expected dead_letter_reason attribute")
+		}
+	}
+
+	// The ordering key is resumed, so a later publish is attempted rather than rejected as paused
+	err := p.PublishBatch(ctx, events[:1], model.BatchMetadata{})
+	if err == nil || strings.Contains(err.Error(), "paused") {
+		t.Errorf("expected a fresh publish failure after resume, got %v", err)
+	}
+}
+
+func TestNewPubSubPublisher_FlowControlSettings(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        PubSubConfig
+		expectedMsgs  int
+		expectedBytes int
+	}{
+		{
+			name:          "defaults",
+			config:        PubSubConfig{TopicPath: "projects/proj/topics/events"},
+			expectedMsgs:  DefaultMaxOutstandingMessages,
+			expectedBytes: int(DefaultMaxOutstandingBytes),
+		},
+		{
+			name: "custom limits apply to every publisher",
+			config: PubSubConfig{
+				TopicPath:              "projects/proj/topics/events",
+				HeartbeatTopicPath:     "projects/proj/topics/heartbeats",
+				DeadLetterTopicPath:    "projects/proj/topics/dead-letter",
+				MaxOutstandingMessages: 50,
+				MaxOutstandingBytes:    1024,
+			},
+			expectedMsgs:  50,
+			expectedBytes: 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t)
+
+			p := newPubSubPublisher(client, tt.config)
+			defer p.Stop()
+
+			for _, publisher := range []*pubsub.Publisher{p.publisher, p.heartbeatPublisher, p.deadLetterPublisher} {
+				if publisher == nil {
+					continue
+				}
+				fc := publisher.PublishSettings.FlowControlSettings
+				if fc.MaxOutstandingMessages != tt.expectedMsgs {
+					t.Errorf("expected MaxOutstandingMessages %d, got %d", tt.expectedMsgs, fc.MaxOutstandingMessages)
+				}
+				if fc.MaxOutstandingBytes != tt.expectedBytes {
+					t.Errorf("expected MaxOutstandingBytes %d, got %d", tt.expectedBytes, fc.MaxOutstandingBytes)
+				}
+				if fc.LimitExceededBehavior != pubsub.FlowControlSignalError {
+					t.Errorf("expected FlowControlSignalError, got %v", fc.LimitExceededBehavior)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishBatch_FlowControlled(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t)
+
+	topic := "projects/proj/topics/events"
+	deadLetterTopic := "projects/proj/topics/dead-letter"
+	for _, name := range []string{topic, deadLetterTopic} {
+		if _, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: name}); err != nil {
+			t.Fatalf("failed to create topic %s: %v", name, err)
+		}
+	}
+
+	p := newPubSubPublisher(client, PubSubConfig{
+		TopicPath:              topic,
+		DeadLetterTopicPath:    deadLetterTopic,
+		ClusterID:              "test-cluster",
+		MaxOutstandingMessages: 1,
+		OrderingStrategy:       OrderingCluster,
+	})
+	defer p.Stop()
+
+	before := testutil.ToFloat64(flowControlled)
+
+	// Only one message may be outstanding, so the rest of the batch is rejected and its shared
+	// ordering key paused
+	events := []model.ResourceEventPayload{
+		{EventID: "1", ResourceType: model.ResourceTypePod, Resource: model.ResourceRef{Name: "pod-a"}},
+		{EventID: "2", ResourceType: model.ResourceTypePod, Resource: model.ResourceRef{Name: "pod-b"}},
+		{EventID: "3", ResourceType: model.ResourceTypePod, Resource: model.ResourceRef{Name: "pod-c"}},
+	}
+	if err := p.PublishBatch(ctx, events, model.BatchMetadata{}); err == nil {
+		t.Fatal("expected flow control to reject part of the batch")
+	}
+
+	if got := testutil.ToFloat64(flowControlled) - before; got != 2 {
+		t.Errorf("expected 2 flow controlled events, got %v", got)
+	}
+
+	// The ordering key is resumed, so publishing recovers once the burst is over
+	if err := p.PublishBatch(ctx, events[:1], model.BatchMetadata{}); err != nil {
+		t.Fatalf("expected publish to recover after flow control, got %v", err)
+	}
+	published, deadLettered := 0, 0
+	for _, m := range srv.Messages() {
+		switch m.Topic {
+		case topic:
+			published++
+		case deadLetterTopic:
+			if m.Attributes["dead_letter_reason"] == deadLetterReasonFlowControlled {
+				deadLettered++
+			}
+		}
+	}
+	if published != 1 {
+		t.Errorf("expected 1 published message, got %d", published)
+	}
+	if deadLettered != 2 {
+		t.Errorf("expected 2 dead letters with reason %q, got %d", deadLetterReasonFlowControlled, deadLettered)
+	}
+}
+
+// newTestClient returns a Pub/Sub client backed by an in-memory fake server
+func newTestClient(t *testing.T) (*pubsub.Client, *pstest.Server) {
+	t.Helper()
+
+	srv := pstest.NewServer()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	conn, err := grpc.NewClient(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial fake server: %v", err)
+	}
+	client, err := pubsub.NewClient(context.Background(), "proj", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, srv
+}
+
+func TestPublishBatch_RoutesByResourceType(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t)
+
+	const (
+		defaultTopic = "projects/proj/topics/events"
+		nodeTopic    = "projects/proj/topics/nodes"
+		podTopic     = "projects/proj/topics/pods"
+	)
+	for _, topic := range []string{defaultTopic, nodeTopic, podTopic} {
+		if _, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: topic}); err != nil {
+			t.Fatalf("failed to create topic %s: %v", topic, err)
+		}
+	}
+
+	p := newPubSubPublisher(client, PubSubConfig{
+		TopicPath:     defaultTopic,
+		NodeTopicPath: nodeTopic,
+		PodTopicPath:  podTopic,
+		ClusterID:     "test-cluster",
+	})
+	defer p.Stop()
+
+	events := []model.ResourceEventPayload{
+		{EventID: "node", ResourceType: model.ResourceTypeNode, Resource: model.ResourceRef{Name: "node-a"}},
+		{EventID: "pod", ResourceType: model.ResourceTypePod, Resource: model.ResourceRef{Name: "pod-a"}},
+		{EventID: "namespace", ResourceType: model.ResourceTypeNamespace, Resource: model.ResourceRef{Name: "default"}},
+	}
+	if err := p.PublishBatch(ctx, events, model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected publish error: %v", err)
+	}
+
+	expected := map[string]string{
+		"node-a":  nodeTopic,
+		"pod-a":   podTopic,
+		"default": defaultTopic,
+	}
+	messages := srv.Messages()
+	if len(messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %d", len(expected), len(messages))
+	}
+	for _, m := range messages {
+		name := m.Attributes["resource_name"]
+		if m.Topic != expected[name] {
+			t.Errorf("expected %s on %s, got %s", name, expected[name], m.Topic)
+		}
+		if m.OrderingKey != "test-cluster/"+name {
+			t.Errorf("expected ordering key test-cluster/%s, got %q", name, m.OrderingKey)
+		}
+	}
+}
+
+func TestTopicPublisher_FallsBackToDefault(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	p := newPubSubPublisher(client, PubSubConfig{TopicPath: "projects/proj/topics/events"})
+	defer p.Stop()
+
+	for _, resourceType := range []model.ResourceType{model.ResourceTypeNode, model.ResourceTypePod, model.ResourceTypeNamespace} {
+		if got := p.topicPublisher(resourceType); got != p.publisher {
+			t.Errorf("expected %s events on the default publisher, got %s", resourceType, got)
+		}
+	}
+}
+
+func TestPublish_ProjectID(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t)
+
+	const topic = "projects/proj/topics/events"
+	if _, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: topic}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	p := newPubSubPublisher(client, PubSubConfig{
+		TopicPath: topic,
+		ClusterID: "test-cluster",
+		ProjectID: "my-project",
+	})
+	defer p.Stop()
+
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+	if err := p.Publish(ctx, update); err != nil {
+		t.Fatalf("unexpected publish error: %v", err)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if got := messages[0].Attributes["project_id"]; got != "my-project" {
+		t.Errorf("expected project_id attribute my-project, got %q", got)
+	}
+	if !strings.Contains(string(messages[0].Data), `"projectId":"my-project"`) {
+		t.Errorf("expected projectId in event source, got %s", messages[0].Data)
+	}
+}
+
+func TestPublish_SourceLinks(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t)
+
+	const topic = "projects/proj/topics/events"
+	if _, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: topic}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	p := newPubSubPublisher(client, PubSubConfig{TopicPath: topic, ClusterID: "test-cluster"})
+	defer p.Stop()
+
+	updates := []model.WorkloadUpdate{
+		{
+			Name:           "api",
+			Namespace:      "default",
+			Kind:           "Deployment",
+			CurrentVersion: "1.0.0",
+			SourceURL:      "https://github.com/acme/api/commit/4f2a9c1",
+			CIRunURL:       "https://github.com/acme/api/actions/runs/812",
+		},
+		{Name: "web", Namespace: "default", Kind: "Deployment", CurrentVersion: "2.0.0"},
+	}
+	for _, update := range updates {
+		if err := p.Publish(ctx, update); err != nil {
+			t.Fatalf("unexpected publish error: %v", err)
+		}
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	for _, want := range []string{
+		`"sourceUrl":"https://github.com/acme/api/commit/4f2a9c1"`,
+		`"ciRunUrl":"https://github.com/acme/api/actions/runs/812"`,
+	} {
+		if !strings.Contains(string(messages[0].Data), want) {
+			t.Errorf("expected %s in message body, got %s", want, messages[0].Data)
+		}
+	}
+	if body := string(messages[1].Data); strings.Contains(body, "sourceUrl") || strings.Contains(body, "ciRunUrl") {
+		t.Errorf("expected no source links without annotations, got %s", body)
+	}
+}
+
+func TestPublish_AnnotationAttributes(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t)
+
+	const topic = "projects/proj/topics/events"
+	if _, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: topic}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	p := newPubSubPublisher(client, PubSubConfig{TopicPath: topic, ClusterID: "test-cluster"})
+	defer p.Stop()
+
+	annotations := map[string]string{"argocd.argoproj.io/app-name": "api"}
+	for i := range 12 {
+		annotations[fmt.Sprintf("kustomize.toolkit.fluxcd.io/key-%02d", i)] = "value"
+	}
+	update := model.WorkloadUpdate{
+		Name:           "api",
+		Namespace:      "default",
+		Kind:           "Deployment",
+		CurrentVersion: "1.0.0",
+		Annotations:    annotations,
+	}
+	if err := p.Publish(ctx, update); err != nil {
+		t.Fatalf("unexpected publish error: %v", err)
+	}
+
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	attributes := messages[0].Attributes
+	if got := attributes["annotation_argocd.argoproj.io/app-name"]; got != "api" {
+		t.Errorf("expected ArgoCD app name attribute, got %q", got)
+	}
+	count := 0
+	for key := range attributes {
+		if strings.HasPrefix(key, annotationAttributePrefix) {
+			count++
+		}
+	}
+	if count != maxAnnotationAttributes {
+		t.Errorf("expected %d annotation attributes, got %d", maxAnnotationAttributes, count)
+	}
+	if !strings.Contains(string(messages[0].Data), `"kustomize.toolkit.fluxcd.io/key-11":"value"`) {
+		t.Errorf("expected all annotations in the message body, got %s", messages[0].Data)
+	}
+}
+
+func TestAddAnnotationAttributes_Limits(t *testing.T) {
+	longKey := strings.Repeat("k", maxAttributeKeySize)
+	annotations := map[string]string{
+		"a/long-value": strings.Repeat("x", maxAttributeValueSize-1) + "é",
+		"b/short":      "value",
+		longKey:        "value",
+	}
+	attributes := map[string]string{}
+	addAnnotationAttributes(context.Background(), attributes, annotations)
+
+	if _, ok := attributes[annotationAttributePrefix+longKey]; ok {
+		t.Error("expected the annotation with an oversized key to be skipped")
+	}
+	if got := attributes["annotation_b/short"]; got != "value" {
+		t.Errorf("expected short annotation to be copied, got %q", got)
+	}
+	// The two-byte character would cross the limit, so it is dropped whole
+	if got := attributes["annotation_a/long-value"]; len(got) != maxAttributeValueSize-1 || !utf8.ValidString(got) {
+		t.Errorf("expected value truncated to %d bytes of valid UTF-8, got %d bytes", maxAttributeValueSize-1, len(got))
+	}
+	if len(attributes) != 2 {
+		t.Errorf("expected 2 attributes, got %v", attributes)
+	}
+}
+
+func TestOrderingKey(t *testing.T) {
+	update := model.WorkloadUpdate{Name: "api", Namespace: "shop"}
+	nodeEvent := model.ResourceEventPayload{Resource: model.ResourceRef{Name: "node-1"}}
+
+	tests := []struct {
+		strategy         OrderingStrategy
+		expectedWorkload string
+		expectedNode     string
+	}{
+		{strategy: OrderingWorkload, expectedWorkload: "cluster-1/shop/api", expectedNode: "cluster-1/node-1"},
+		{strategy: OrderingNamespace, expectedWorkload: "cluster-1/shop", expectedNode: "cluster-1"},
+		{strategy: OrderingCluster, expectedWorkload: "cluster-1", expectedNode: "cluster-1"},
+		{strategy: OrderingNone, expectedWorkload: "", expectedNode: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			p := &PubSubPublisher{clusterID: "cluster-1", orderingStrategy: tt.strategy}
+			if got := p.orderingKey(update); got != tt.expectedWorkload {
+				t.Errorf("expected workload key %q, got %q", tt.expectedWorkload, got)
+			}
+			if got := p.resourceOrderingKey(nodeEvent); got != tt.expectedNode {
+				t.Errorf("expected node key %q, got %q", tt.expectedNode, got)
+			}
+		})
+	}
+}
+
+func TestPublishBatch_OrdersPerWorkloadByDefault(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t)
+
+	const topic = "projects/proj/topics/events"
+	if _, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: topic}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	p := newPubSubPublisher(client, PubSubConfig{TopicPath: topic, ClusterID: "test-cluster"})
+	defer p.Stop()
+
+	events := []model.ResourceEventPayload{
+		{EventID: "1", ResourceType: model.ResourceTypePod, Resource: model.ResourceRef{Name: "api-0", Namespace: "shop"}},
+		{EventID: "2", ResourceType: model.ResourceTypePod, Resource: model.ResourceRef{Name: "web-0", Namespace: "shop"}},
+		{EventID: "3", ResourceType: model.ResourceTypePod, Resource: model.ResourceRef{Name: "api-0", Namespace: "shop"}},
+	}
+	if err := p.PublishBatch(ctx, events, model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected publish error: %v", err)
+	}
+
+	keys := make(map[string][]string)
+	for _, m := range srv.Messages() {
+		var event model.ResourceEventPayload
+		if err := json.Unmarshal(m.Data, &event); err != nil {
+			t.Fatalf("failed to decode message: %v", err)
+		}
+		keys[m.OrderingKey] = append(keys[m.OrderingKey], event.EventID)
+	}
+	expected := map[string][]string{
+		"test-cluster/shop/api-0": {"1", "3"},
+		"test-cluster/shop/web-0": {"2"},
+	}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d ordering keys, got %v", len(expected), keys)
+	}
+	for key, ids := range expected {
+		if strings.Join(keys[key], ",") != strings.Join(ids, ",") {
+			t.Errorf("expected events %v under %s, got %v", ids, key, keys[key])
+		}
+	}
+}
+
+func TestNewPubSubPublisher_OrderingDisabled(t *testing.T) {
+	tests := []struct {
+		strategy        OrderingStrategy
+		expectedOrdered bool
+	}{
+		{strategy: "", expectedOrdered: true},
+		{strategy: OrderingWorkload, expectedOrdered: true},
+		{strategy: OrderingNone, expectedOrdered: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			client, _ := newTestClient(t)
+
+			p := newPubSubPublisher(client, PubSubConfig{
+				TopicPath:          "projects/proj/topics/events",
+				HeartbeatTopicPath: "projects/proj/topics/heartbeats",
+				OrderingStrategy:   tt.strategy,
+			})
+			defer p.Stop()
+
+			for _, publisher := range []*pubsub.Publisher{p.publisher, p.heartbeatPublisher} {
+				if publisher.EnableMessageOrdering != tt.expectedOrdered {
+					t.Errorf("expected EnableMessageOrdering %v, got %v", tt.expectedOrdered, publisher.EnableMessageOrdering)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOrderingStrategy(t *testing.T) {
+	if _, err := ParseOrderingStrategy("namespace"); err != nil {
+		t.Errorf("expected namespace to be valid, got %v", err)
+	}
+	if _, err := ParseOrderingStrategy("pod"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
@@ -0,0 +1,107 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// maxPublishRetries is how often a workload event is republished after a transient failure
+	maxPublishRetries = 5
+	// publishRetryInitialBackoff is the delay before the first retry, doubled on every further one
+	publishRetryInitialBackoff = 100 * time.Millisecond
+	// publishRetryMaxBackoff caps the delay between two retries
+	publishRetryMaxBackoff = 30 * time.Second
+	// publishRetryJitter is the random fraction added to or removed from each delay
+	publishRetryJitter = 0.25
+)
+
+var publishRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "apptrail_pubsub_retries_total",
+	Help: "Number of workload event publishes to Pub/Sub retried after a transient failure, by retry attempt",
+}, []string{"attempt"})
+
+// isRetryablePublishError reports whether a failed publish may succeed when repeated: flow
+// control rejections, network timeouts and the gRPC codes of a temporarily unreachable service
+func isRetryablePublishError(err error) bool {
+	if isFlowControlError(err) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// isFlowControlError reports whether a publish was rejected by the publisher flow control
+// limits, which clear once the buffered messages are sent
+func isFlowControlError(err error) bool {
+	return errors.Is(err, pubsub.ErrFlowControllerMaxOutstandingMessages) ||
+		errors.Is(err, pubsub.ErrFlowControllerMaxOutstandingBytes)
+}
+
+// publishRetryDelay returns the delay before the given retry attempt, starting at 1
+func publishRetryDelay(attempt int) time.Duration {
+	delay := publishRetryMaxBackoff
+	if shift := attempt - 1; shift < 20 {
+		delay = min(publishRetryInitialBackoff<<shift, publishRetryMaxBackoff)
+	}
+	jitter := 1 + publishRetryJitter*(2*rand.Float64()-1)
+	return time.Duration(float64(delay) * jitter)
+}
+
+// publishWithRetry publishes msg and waits for its acknowledgement, retrying transient failures
+// with exponential backoff. The last error is returned once retries are exhausted.
+func publishWithRetry(ctx context.Context, publisher *pubsub.Publisher, msg *pubsub.Message) (string, error) {
+	for attempt := 1; ; attempt++ {
+		// The client owns a message once published, so every attempt sends a fresh one
+		msgID, err := publisher.Publish(ctx, &pubsub.Message{
+			Data:        msg.Data,
+			Attributes:  msg.Attributes,
+			OrderingKey: msg.OrderingKey,
+		}).Get(ctx)
+		if err == nil || attempt > maxPublishRetries || !isRetryablePublishError(err) {
+			return msgID, err
+		}
+
+		// A failed publish pauses its ordering key until resumed
+		if msg.OrderingKey != "" {
+			publisher.ResumePublish(msg.OrderingKey)
+		}
+		publishRetries.WithLabelValues(strconv.Itoa(attempt)).Inc()
+
+		delay := publishRetryDelay(attempt)
+		log.FromContext(ctx).Info("Retrying Pub/Sub publish after transient failure",
+			"topic", publisher.String(),
+			"attempt", attempt,
+			"delay", delay,
+			"reason", err.Error(),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", err
+		}
+	}
+}
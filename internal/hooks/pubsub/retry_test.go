@@ -0,0 +1,98 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	pubsubpb "cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsRetryablePublishError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"unavailable", status.Error(codes.Unavailable, "connection refused"), true},
+		{"deadline exceeded", status.Error(codes.DeadlineExceeded, "timeout"), true},
+		{"network timeout", fmt.Errorf("publish: %w", os.ErrDeadlineExceeded), true},
+		{"flow controlled messages", pubsub.ErrFlowControllerMaxOutstandingMessages, true},
+		{"flow controlled bytes", fmt.Errorf("publish: %w", pubsub.ErrFlowControllerMaxOutstandingBytes), true},
+		{"not found", status.Error(codes.NotFound, "topic not found"), false},
+		{"permission denied", status.Error(codes.PermissionDenied, "forbidden"), false},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryablePublishError(tt.err); got != tt.retryable {
+				t.Errorf("isRetryablePublishError() = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestPublishRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{5, 1600 * time.Millisecond},
+		{10, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			low := time.Duration(float64(tt.base) * (1 - publishRetryJitter))
+			high := time.Duration(float64(tt.base) * (1 + publishRetryJitter))
+			for range 100 {
+				if got := publishRetryDelay(tt.attempt); got < low || got > high {
+					t.Fatalf("publishRetryDelay(%d) = %v, want within [%v, %v]", tt.attempt, got, low, high)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishWithRetry(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newTestClient(t)
+
+	const topic = "projects/proj/topics/events"
+	if _, err := srv.GServer.CreateTopic(ctx, &pubsubpb.Topic{Name: topic}); err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+
+	p := newPubSubPublisher(client, PubSubConfig{TopicPath: topic})
+	defer p.Stop()
+
+	msg := &pubsub.Message{Data: []byte(`{}`), OrderingKey: "test-cluster"}
+
+	msgID, err := publishWithRetry(ctx, p.publisher, msg)
+	if err != nil || msgID == "" {
+		t.Fatalf("expected publish to succeed, got %q, %v", msgID, err)
+	}
+
+	// A missing topic is not transient, so it fails without retrying
+	missing := newPubSubPublisher(client, PubSubConfig{TopicPath: "projects/proj/topics/missing"})
+	defer missing.Stop()
+
+	before := testutil.CollectAndCount(publishRetries)
+	if _, err := publishWithRetry(ctx, missing.publisher, msg); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if got := testutil.CollectAndCount(publishRetries); got != before {
+		t.Errorf("expected no retries for a non-retryable error, got %d new series", got-before)
+	}
+}
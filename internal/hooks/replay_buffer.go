@@ -0,0 +1,135 @@
+package hooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DefaultEventReplayBufferSize is how many recent workload events are kept for inspection
+const DefaultEventReplayBufferSize = 1000
+
+var (
+	replayBufferSizeGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "apptrail_replay_buffer_size",
+		Help: "Number of recent workload events held in the replay buffer",
+	})
+
+	replayBufferMetricsRegistered = false
+)
+
+// RecentEventsQuery selects events from the replay buffer; zero fields match everything
+type RecentEventsQuery struct {
+	Namespace string
+	Kind      string
+	Since     time.Time
+}
+
+// matches reports whether an event passes the query
+func (q RecentEventsQuery) matches(event model.AgentEventPayload) bool {
+	if q.Namespace != "" && event.Workload.Namespace != q.Namespace {
+		return false
+	}
+	if q.Kind != "" && !strings.EqualFold(string(event.Workload.Kind), q.Kind) {
+		return false
+	}
+	return q.Since.IsZero() || event.OccurredAt.After(q.Since)
+}
+
+// EventReplayBuffer keeps the last workload events seen by the EventPublisherQueue, so operators
+// can find out what a publisher missed during an outage. It is a fixed-size circular slice.
+type EventReplayBuffer struct {
+	clusterID    string
+	projectID    string
+	agentVersion string
+
+	mu     sync.Mutex
+	events []model.AgentEventPayload
+	next   int
+	count  int
+}
+
+// NewEventReplayBuffer creates a buffer holding the last size events
+func NewEventReplayBuffer(size int, clusterID, projectID, agentVersion string) *EventReplayBuffer {
+	if !replayBufferMetricsRegistered {
+		metrics.Registry.MustRegister(replayBufferSizeGauge)
+		replayBufferMetricsRegistered = true
+	}
+
+	return &EventReplayBuffer{
+		clusterID:    clusterID,
+		projectID:    projectID,
+		agentVersion: agentVersion,
+		events:       make([]model.AgentEventPayload, size),
+	}
+}
+
+// Record stores the event for an update, overwriting the oldest one once the buffer is full
+func (b *EventReplayBuffer) Record(update model.WorkloadUpdate) {
+	if len(b.events) == 0 {
+		return
+	}
+	event := model.NewAgentEventPayload(update, b.clusterID, b.projectID, b.agentVersion)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events[b.next] = event
+	b.next = (b.next + 1) % len(b.events)
+	if b.count < len(b.events) {
+		b.count++
+	}
+	replayBufferSizeGauge.Set(float64(b.count))
+}
+
+// Recent returns the buffered events matching query, oldest first
+func (b *EventReplayBuffer) Recent(query RecentEventsQuery) []model.AgentEventPayload {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make([]model.AgentEventPayload, 0, b.count)
+	start := b.next - b.count
+	if start < 0 {
+		start += len(b.events)
+	}
+	for i := range b.count {
+		event := b.events[(start+i)%len(b.events)]
+		if query.matches(event) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// ServeHTTP returns the buffered events as a JSON array, filtered by the namespace, kind and
+// since (RFC 3339) query parameters
+func (b *EventReplayBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	query := RecentEventsQuery{
+		Namespace: params.Get("namespace"),
+		Kind:      params.Get("kind"),
+	}
+	if since := params.Get("since"); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		query.Since = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(b.Recent(query))
+}
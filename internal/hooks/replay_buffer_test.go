@@ -0,0 +1,76 @@
+package hooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestEventReplayBuffer_KeepsLastEvents(t *testing.T) {
+	buffer := NewEventReplayBuffer(3, "cluster-1", "", "v1")
+	for _, name := range []string{"a", "b", "c", "d"} {
+		buffer.Record(model.WorkloadUpdate{Name: name, Namespace: "shop", Kind: "Deployment"})
+	}
+
+	events := buffer.Recent(RecentEventsQuery{})
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for i, want := range []string{"b", "c", "d"} {
+		if events[i].Workload.Name != want {
+			t.Errorf("expected event %d to be %s, got %s", i, want, events[i].Workload.Name)
+		}
+	}
+}
+
+func TestEventReplayBuffer_ServeHTTP(t *testing.T) {
+	buffer := NewEventReplayBuffer(10, "cluster-1", "", "v1")
+	buffer.Record(model.WorkloadUpdate{Name: "api", Namespace: "shop", Kind: "Deployment"})
+	buffer.Record(model.WorkloadUpdate{Name: "db", Namespace: "shop", Kind: "StatefulSet"})
+	buffer.Record(model.WorkloadUpdate{Name: "web", Namespace: "blog", Kind: "Deployment"})
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedNames []string
+		expectedCode  int
+	}{
+		{name: "all", query: "", expectedNames: []string{"api", "db", "web"}, expectedCode: http.StatusOK},
+		{name: "by namespace", query: "?namespace=shop", expectedNames: []string{"api", "db"}, expectedCode: http.StatusOK},
+		{name: "by kind", query: "?kind=deployment", expectedNames: []string{"api", "web"}, expectedCode: http.StatusOK},
+		{name: "since in the future", query: "?since=" + time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			expectedNames: []string{}, expectedCode: http.StatusOK},
+		{name: "invalid since", query: "?since=yesterday", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			buffer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/recent"+tt.query, nil))
+
+			if rec.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedCode, rec.Code, rec.Body)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var events []model.AgentEventPayload
+			if err := json.Unmarshal(rec.Body.Bytes(), &events); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(events) != len(tt.expectedNames) {
+				t.Fatalf("expected %d events, got %d", len(tt.expectedNames), len(events))
+			}
+			for i, name := range tt.expectedNames {
+				if events[i].Workload.Name != name {
+					t.Errorf("expected event %d to be %s, got %s", i, name, events[i].Workload.Name)
+				}
+			}
+		})
+	}
+}
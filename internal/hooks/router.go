@@ -0,0 +1,144 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+// PublisherLabel on a namespace names the publishers its workload events go to, separated by
+// dots since label values cannot contain commas, e.g. "slack.pubsub"
+const PublisherLabel = "apptrail.sh/publisher"
+
+// PublisherRoute sends events of namespaces matching any of the patterns to the named publishers
+type PublisherRoute struct {
+	Namespaces []string `json:"namespaces"`
+	Publishers []string `json:"publishers"`
+}
+
+// RoutingConfig is the --publisher-routing-file format. Routes are matched in order; events of
+// namespaces matching no route go to the Default publishers, or to all publishers if unset.
+type RoutingConfig struct {
+	Routes  []PublisherRoute `json:"routes"`
+	Default []string         `json:"default,omitempty"`
+}
+
+// LoadRoutingConfig reads a YAML publisher routing file
+func LoadRoutingConfig(path string) (RoutingConfig, error) {
+	var config RoutingConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read routing file %s: %w", path, err)
+	}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse routing file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// NamespaceLabelsFunc returns the labels of a namespace
+type NamespaceLabelsFunc func(ctx context.Context, namespace string) (map[string]string, error)
+
+// PublisherRouter selects the publishers each workload event goes to by its namespace: those
+// named by the namespace's apptrail.sh/publisher label, else those of the first matching route.
+// The EventPublisherQueue only hands an event to the workers of the selected publishers.
+type PublisherRouter struct {
+	enabled         map[string]bool
+	order           []string
+	config          RoutingConfig
+	namespaceLabels NamespaceLabelsFunc
+}
+
+// NewPublisherRouter creates a router over named publishers. Routes may only reference
+// publishers that are enabled. namespaceLabels may be nil to route by the config only.
+func NewPublisherRouter(publishers []EventPublisher, config RoutingConfig, namespaceLabels NamespaceLabelsFunc) (*PublisherRouter, error) {
+	r := &PublisherRouter{
+		enabled:         make(map[string]bool, len(publishers)),
+		config:          config,
+		namespaceLabels: namespaceLabels,
+	}
+	for i, publisher := range publishers {
+		name := publisherName(i, publisher)
+		r.enabled[name] = true
+		r.order = append(r.order, name)
+	}
+
+	var errs []error
+	for i, route := range config.Routes {
+		for _, pattern := range route.Namespaces {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("route %d: invalid namespace pattern %q: %w", i, pattern, err))
+			}
+		}
+		errs = append(errs, r.checkNames(fmt.Sprintf("route %d", i), route.Publishers)...)
+	}
+	errs = append(errs, r.checkNames("default", config.Default)...)
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// checkNames returns an error for each name that is not an enabled publisher
+func (r *PublisherRouter) checkNames(where string, names []string) []error {
+	var errs []error
+	for _, name := range names {
+		if !r.enabled[name] {
+			errs = append(errs, fmt.Errorf("%s: unknown publisher %q, enabled publishers are %s",
+				where, name, strings.Join(r.order, ", ")))
+		}
+	}
+	return errs
+}
+
+// Route returns the names of the publishers events of namespace go to
+func (r *PublisherRouter) Route(ctx context.Context, namespace string) []string {
+	if names := r.routeByLabel(ctx, namespace); len(names) > 0 {
+		return names
+	}
+	for _, route := range r.config.Routes {
+		for _, pattern := range route.Namespaces {
+			if matched, _ := filepath.Match(pattern, namespace); matched {
+				return route.Publishers
+			}
+		}
+	}
+	if r.config.Default != nil {
+		return r.config.Default
+	}
+	return r.order
+}
+
+// routeByLabel returns the enabled publishers named by the namespace's publisher label
+func (r *PublisherRouter) routeByLabel(ctx context.Context, namespace string) []string {
+	if r.namespaceLabels == nil {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	labels, err := r.namespaceLabels(ctx, namespace)
+	if err != nil {
+		logger.Error(err, "Failed to get namespace labels for publisher routing", "namespace", namespace)
+		return nil
+	}
+	value, ok := labels[PublisherLabel]
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for name := range strings.SplitSeq(value, ".") {
+		if !r.enabled[name] {
+			logger.Info("Ignoring unknown publisher in namespace label", "namespace", namespace, "publisher", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,99 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPublisherRouter_Route(t *testing.T) {
+	config := RoutingConfig{
+		Routes: []PublisherRoute{
+			{Namespaces: []string{"payments-*"}, Publishers: []string{"pubsub"}},
+			{Namespaces: []string{"sandbox"}, Publishers: []string{}},
+		},
+	}
+	namespaceLabels := func(_ context.Context, namespace string) (map[string]string, error) {
+		switch namespace {
+		case "payments-eu":
+			return map[string]string{PublisherLabel: "slack.unknown"}, nil
+		case "broken":
+			return nil, errors.New("unavailable")
+		}
+		return nil, nil
+	}
+
+	tests := []struct {
+		name      string
+		config    RoutingConfig
+		namespace string
+		want      []string
+	}{
+		{name: "label overrides routes", config: config, namespace: "payments-eu", want: []string{"slack"}},
+		{name: "route by pattern", config: config, namespace: "payments-us", want: []string{"pubsub"}},
+		{name: "empty route drops the event", config: config, namespace: "sandbox", want: nil},
+		{name: "unmatched goes to all", config: config, namespace: "shop", want: []string{"slack", "pubsub"}},
+		{name: "label lookup failure falls back to routes", config: config, namespace: "broken", want: []string{"slack", "pubsub"}},
+		{name: "unmatched goes to default", config: RoutingConfig{Default: []string{"pubsub"}}, namespace: "shop",
+			want: []string{"pubsub"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := NewPublisherRouter([]EventPublisher{
+				NewTrackedPublisher("slack", &channelPublisher{}),
+				NewTrackedPublisher("pubsub", &channelPublisher{}),
+			}, tt.config, namespaceLabels)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := router.Route(context.Background(), tt.namespace)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected event routed to %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewPublisherRouter_UnknownPublisher(t *testing.T) {
+	_, err := NewPublisherRouter([]EventPublisher{NewTrackedPublisher("slack", &channelPublisher{})}, RoutingConfig{
+		Routes: []PublisherRoute{{Namespaces: []string{"shop"}, Publishers: []string{"pubsub"}}},
+	}, nil)
+	if err == nil {
+		t.Fatal("expected an error for a route to a publisher that is not enabled")
+	}
+}
+
+func TestLoadRoutingConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing.yaml")
+	data := "routes:\n  - namespaces: [\"payments-*\"]\n    publishers: [\"pubsub\"]\ndefault: [\"slack\"]\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	got, err := LoadRoutingConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := RoutingConfig{
+		Routes:  []PublisherRoute{{Namespaces: []string{"payments-*"}, Publishers: []string{"pubsub"}}},
+		Default: []string{"slack"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	if err := os.WriteFile(path, []byte("route: []\n"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := LoadRoutingConfig(path); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
@@ -0,0 +1,222 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // S3 Content-MD5 integrity check, not used for security
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DefaultBatchSize is the number of buffered events that triggers an upload
+	DefaultBatchSize = 1000
+	// DefaultFlushInterval uploads buffered events at least this often
+	DefaultFlushInterval = 5 * time.Minute
+
+	// Failed uploads are retried with the next flush; beyond this many batches the oldest events are dropped
+	maxBufferedBatches = 10
+)
+
+// S3Config holds configuration for the S3-compatible archive publisher
+type S3Config struct {
+	Bucket string
+	// Endpoint overrides the AWS endpoint for S3-compatible stores (DigitalOcean Spaces, MinIO)
+	Endpoint string
+	// Region of the bucket; empty uses the AWS SDK default chain (AWS_REGION, shared config)
+	Region    string
+	ClusterID string
+
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// objectPutter is the subset of the S3 client used by the publisher
+type objectPutter interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Publisher archives resource events as newline-delimited JSON objects in an S3-compatible bucket
+type S3Publisher struct {
+	config S3Config
+	client objectPutter
+
+	mu     sync.Mutex
+	buffer []model.ResourceEventPayload
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewS3Publisher creates an S3 archive publisher and starts its periodic flush.
+//
+// Credentials come from the AWS SDK default chain: environment variables, shared config,
+// or IRSA / instance roles. For DigitalOcean Spaces and MinIO set Endpoint along with
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+func NewS3Publisher(ctx context.Context, config S3Config) (*S3Publisher, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if config.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(config.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(config.Endpoint)
+			// S3-compatible stores generally don't support virtual-hosted buckets
+			// or the SDK's default trailing checksums; Content-MD5 covers integrity
+			o.UsePathStyle = true
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		}
+	})
+
+	return newS3Publisher(config, client), nil
+}
+
+// newS3Publisher applies defaults and starts the flush loop for the given client
+func newS3Publisher(config S3Config, client objectPutter) *S3Publisher {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+
+	p := &S3Publisher{
+		config: config,
+		client: client,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	go p.flushLoop()
+	return p
+}
+
+// PublishBatch buffers events and uploads them once the batch size is reached
+// Implements hooks.ResourceEventPublisher interface
+func (p *S3Publisher) PublishBatch(ctx context.Context, events []model.ResourceEventPayload, _ model.BatchMetadata) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.buffer = append(p.buffer, events...)
+	full := len(p.buffer) >= p.config.BatchSize
+	p.mu.Unlock()
+
+	if !full {
+		return nil
+	}
+	return p.flush(ctx)
+}
+
+// flushLoop uploads buffered events every FlushInterval until Close is called
+func (p *S3Publisher) flushLoop() {
+	defer close(p.doneCh)
+
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx := context.Background()
+			if err := p.flush(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "Failed to archive resource events to S3", "bucket", p.config.Bucket)
+			}
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// flush uploads all buffered events as one object. On failure the events are
+// put back so the next flush retries them.
+func (p *S3Publisher) flush(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	p.mu.Lock()
+	events := p.buffer
+	p.buffer = nil
+	p.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("failed to marshal event %s: %w", event.EventID, err)
+		}
+	}
+
+	sum := md5.Sum(body.Bytes()) //nolint:gosec // see import
+	key := objectKey(p.config.ClusterID, time.Now(), uuid.NewString())
+
+	_, err := p.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(p.config.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+		// The store rejects the upload with BadDigest if the body was corrupted in transit
+		ContentMD5: aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+	})
+	if err != nil {
+		p.requeue(ctx, events)
+		return fmt.Errorf("failed to upload %d events to s3://%s/%s: %w", len(events), p.config.Bucket, key, err)
+	}
+
+	logger.Info("Resource events archived to S3",
+		"bucket", p.config.Bucket,
+		"key", key,
+		"eventCount", len(events),
+	)
+	return nil
+}
+
+// requeue puts events from a failed upload ahead of newer ones, dropping the oldest
+// when the buffer would exceed maxBufferedBatches
+func (p *S3Publisher) requeue(ctx context.Context, events []model.ResourceEventPayload) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buffer = append(events, p.buffer...)
+
+	limit := p.config.BatchSize * maxBufferedBatches
+	if dropped := len(p.buffer) - limit; dropped > 0 {
+		p.buffer = p.buffer[dropped:]
+		log.FromContext(ctx).Info("S3 archive buffer full, dropping oldest events",
+			"dropped", dropped,
+			"bucket", p.config.Bucket,
+		)
+	}
+}
+
+// objectKey builds a Hive-style partitioned key so archives can be queried by time
+func objectKey(clusterID string, t time.Time, batchID string) string {
+	t = t.UTC()
+	return fmt.Sprintf("%s/year=%04d/month=%02d/day=%02d/hour=%02d/%s.ndjson",
+		clusterID, t.Year(), t.Month(), t.Day(), t.Hour(), batchID)
+}
+
+// Close stops the periodic flush and uploads any buffered events
+func (p *S3Publisher) Close(ctx context.Context) error {
+	close(p.stopCh)
+	<-p.doneCh
+	return p.flush(ctx)
+}
@@ -0,0 +1,185 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // matches the publisher's Content-MD5
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakePutter records uploaded objects and fails while err is set
+type fakePutter struct {
+	mu      sync.Mutex
+	err     error
+	objects map[string][]byte
+	md5s    map[string]string
+}
+
+func newFakePutter() *fakePutter {
+	return &fakePutter{objects: map[string][]byte{}, md5s: map[string]string{}}
+}
+
+func (f *fakePutter) PutObject(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[*params.Key] = body
+	f.md5s[*params.Key] = *params.ContentMD5
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakePutter) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.objects)
+}
+
+func events(ids ...string) []model.ResourceEventPayload {
+	var out []model.ResourceEventPayload
+	for _, id := range ids {
+		out = append(out, model.ResourceEventPayload{EventID: id, ResourceType: model.ResourceTypePod})
+	}
+	return out
+}
+
+func TestObjectKey(t *testing.T) {
+	at := time.Date(2026, 3, 7, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	got := objectKey("staging.stg01", at, "batch-1")
+	expected := "staging.stg01/year=2026/month=03/day=07/hour=08/batch-1.ndjson"
+	if got != expected {
+		t.Errorf("objectKey() = %q, want %q", got, expected)
+	}
+}
+
+func TestS3Publisher_UploadsWhenBatchIsFull(t *testing.T) {
+	putter := newFakePutter()
+	p := newS3Publisher(S3Config{Bucket: "archive", ClusterID: "c1", BatchSize: 3, FlushInterval: time.Hour}, putter)
+	defer func() { _ = p.Close(context.Background()) }()
+
+	if err := p.PublishBatch(context.Background(), events("1", "2"), model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if putter.count() != 0 {
+		t.Fatal("expected no upload before the batch is full")
+	}
+
+	if err := p.PublishBatch(context.Background(), events("3"), model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if putter.count() != 1 {
+		t.Fatalf("expected 1 upload, got %d", putter.count())
+	}
+
+	keyPattern := regexp.MustCompile(`^c1/year=\d{4}/month=\d{2}/day=\d{2}/hour=\d{2}/[0-9a-f-]{36}\.ndjson$`)
+	for key, body := range putter.objects {
+		if !keyPattern.MatchString(key) {
+			t.Errorf("unexpected object key %q", key)
+		}
+
+		sum := md5.Sum(body) //nolint:gosec // see import
+		if putter.md5s[key] != base64.StdEncoding.EncodeToString(sum[:]) {
+			t.Errorf("Content-MD5 %q does not match body", putter.md5s[key])
+		}
+
+		var ids []string
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			var event model.ResourceEventPayload
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Fatalf("invalid ndjson line %q: %v", scanner.Text(), err)
+			}
+			ids = append(ids, event.EventID)
+		}
+		if len(ids) != 3 || ids[0] != "1" || ids[2] != "3" {
+			t.Errorf("expected events 1-3 in order, got %v", ids)
+		}
+	}
+}
+
+func TestS3Publisher_FlushInterval(t *testing.T) {
+	putter := newFakePutter()
+	p := newS3Publisher(S3Config{Bucket: "archive", ClusterID: "c1", BatchSize: 100, FlushInterval: 10 * time.Millisecond}, putter)
+	defer func() { _ = p.Close(context.Background()) }()
+
+	if err := p.PublishBatch(context.Background(), events("1"), model.BatchMetadata{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for putter.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if putter.count() != 1 {
+		t.Fatalf("expected the flush interval to upload buffered events, got %d uploads", putter.count())
+	}
+}
+
+func TestS3Publisher_RetriesFailedUpload(t *testing.T) {
+	putter := newFakePutter()
+	putter.err = errors.New("service unavailable")
+	p := newS3Publisher(S3Config{Bucket: "archive", ClusterID: "c1", BatchSize: 2, FlushInterval: time.Hour}, putter)
+
+	if err := p.PublishBatch(context.Background(), events("1", "2"), model.BatchMetadata{}); err == nil {
+		t.Fatal("expected upload error")
+	}
+
+	// Close flushes the requeued events once the store recovers
+	putter.mu.Lock()
+	putter.err = nil
+	putter.mu.Unlock()
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error on close: %v", err)
+	}
+
+	if putter.count() != 1 {
+		t.Fatalf("expected 1 upload after recovery, got %d", putter.count())
+	}
+	for _, body := range putter.objects {
+		if lines := bytes.Count(body, []byte("\n")); lines != 2 {
+			t.Errorf("expected 2 archived events, got %d", lines)
+		}
+	}
+}
+
+func TestS3Publisher_RequeueDropsOldest(t *testing.T) {
+	putter := newFakePutter()
+	putter.err = errors.New("service unavailable")
+	p := newS3Publisher(S3Config{Bucket: "archive", BatchSize: 1, FlushInterval: time.Hour}, putter)
+	defer func() {
+		putter.mu.Lock()
+		putter.err = nil
+		putter.mu.Unlock()
+		_ = p.Close(context.Background())
+	}()
+
+	for i := 0; i < maxBufferedBatches+5; i++ {
+		_ = p.PublishBatch(context.Background(), events(string(rune('a'+i))), model.BatchMetadata{})
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.buffer) != maxBufferedBatches {
+		t.Fatalf("expected buffer capped at %d events, got %d", maxBufferedBatches, len(p.buffer))
+	}
+	if p.buffer[0].EventID != "f" {
+		t.Errorf("expected oldest events dropped, buffer starts with %q", p.buffer[0].EventID)
+	}
+}
@@ -0,0 +1,115 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// DefaultOnCallCacheTTL is how long the current on-call user is cached
+const DefaultOnCallCacheTTL = 15 * time.Minute
+
+var onCallLookupFailuresCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "apptrail_oncall_lookup_failures_total",
+	Help: "Number of failed lookups of the on-call user mentioned in failed rollout messages",
+})
+
+// slackUserIDPattern matches Slack user IDs such as U0123ABCD
+var slackUserIDPattern = regexp.MustCompile(`^[UW][A-Z0-9]+$`)
+
+// OnCallResolver finds who to @mention in failed rollout messages. The schedule URL, usually a
+// small service in front of PagerDuty or OpsGenie, must answer GET requests with the Slack user
+// ID of the current on-call engineer as {"slackUserId": "U0123ABCD"}.
+type OnCallResolver struct {
+	scheduleURL    string
+	defaultMention string
+	ttl            time.Duration
+	client         *http.Client
+
+	mu      sync.Mutex
+	userID  string
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewOnCallResolver creates a resolver looking up the on-call user at scheduleURL, caching it
+// for ttl. defaultMention, e.g. "<!subteam^S0123ABCD>", is used when the lookup fails or no
+// schedule URL is set. A ttl of 0 or less uses DefaultOnCallCacheTTL.
+func NewOnCallResolver(scheduleURL, defaultMention string, ttl time.Duration) *OnCallResolver {
+	if ttl <= 0 {
+		ttl = DefaultOnCallCacheTTL
+	}
+	return &OnCallResolver{
+		scheduleURL:    scheduleURL,
+		defaultMention: defaultMention,
+		ttl:            ttl,
+		client:         &http.Client{Timeout: 5 * time.Second},
+		now:            time.Now,
+	}
+}
+
+// Mention returns the Slack mention of the current on-call user, the default mention when
+// they cannot be looked up, or "" when neither is available
+func (r *OnCallResolver) Mention(ctx context.Context) string {
+	if r.scheduleURL == "" {
+		return r.defaultMention
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.userID != "" && r.now().Before(r.expires) {
+		return "<@" + r.userID + ">"
+	}
+
+	userID, err := r.lookup(ctx)
+	if err != nil {
+		onCallLookupFailuresCounter.Inc()
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to look up on-call user, using the default mention",
+			"scheduleURL", r.scheduleURL)
+		return r.defaultMention
+	}
+
+	r.userID = userID
+	r.expires = r.now().Add(r.ttl)
+	return "<@" + userID + ">"
+}
+
+// lookup fetches the Slack user ID of the current on-call user from the schedule URL
+func (r *OnCallResolver) lookup(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.scheduleURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create on-call request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch on-call user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("on-call schedule returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var schedule struct {
+		SlackUserID string `json:"slackUserId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&schedule); err != nil {
+		return "", fmt.Errorf("failed to decode on-call user: %w", err)
+	}
+	if !slackUserIDPattern.MatchString(schedule.SlackUserID) {
+		return "", fmt.Errorf("invalid Slack user ID %q", schedule.SlackUserID)
+	}
+	return schedule.SlackUserID, nil
+}
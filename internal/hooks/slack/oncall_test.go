@@ -0,0 +1,92 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestOnCallResolver_Mention(t *testing.T) {
+	var lookups atomic.Int32
+	var userID atomic.Value
+	userID.Store("U0123ABCD")
+	schedule := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		id := userID.Load().(string)
+		if id == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"slackUserId": id})
+	}))
+	defer schedule.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	resolver := NewOnCallResolver(schedule.URL, "<!subteam^S1>", 15*time.Minute)
+	resolver.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if got := resolver.Mention(ctx); got != "<@U0123ABCD>" || lookups.Load() != 1 {
+		t.Fatalf("Expected on-call mention after one lookup, got %q after %d", got, lookups.Load())
+	}
+
+	userID.Store("U0456EFGH")
+	if got := resolver.Mention(ctx); got != "<@U0123ABCD>" || lookups.Load() != 1 {
+		t.Errorf("Expected cached mention, got %q after %d lookups", got, lookups.Load())
+	}
+
+	now = now.Add(15 * time.Minute)
+	if got := resolver.Mention(ctx); got != "<@U0456EFGH>" || lookups.Load() != 2 {
+		t.Errorf("Expected refreshed mention after the TTL, got %q after %d lookups", got, lookups.Load())
+	}
+
+	now = now.Add(15 * time.Minute)
+	userID.Store("")
+	if got := resolver.Mention(ctx); got != "<!subteam^S1>" {
+		t.Errorf("Expected default mention when the schedule is unavailable, got %q", got)
+	}
+
+	if got := NewOnCallResolver("", "<!here>", 0).Mention(ctx); got != "<!here>" {
+		t.Errorf("Expected default mention without a schedule URL, got %q", got)
+	}
+}
+
+func TestSlackPublisher_OnCallMention(t *testing.T) {
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message struct {
+			Text string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		texts = append(texts, message.Text)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	publisher := NewSlackPublisher(server.URL, 1000)
+	publisher.OnCall = NewOnCallResolver("", "<@U0123ABCD>", 0)
+
+	for _, phase := range []string{"rolling_out", "failed"} {
+		update := model.WorkloadUpdate{Kind: "Deployment", Name: "api", Namespace: "shop", DeploymentPhase: phase}
+		if err := publisher.Publish(context.Background(), update); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(texts))
+	}
+	if strings.Contains(texts[0], "<@U0123ABCD>") {
+		t.Errorf("Expected no mention for a rollout in progress, got %q", texts[0])
+	}
+	if !strings.HasPrefix(texts[1], "<@U0123ABCD> ") {
+		t.Errorf("Expected failed rollout message to start with the on-call mention, got %q", texts[1])
+	}
+}
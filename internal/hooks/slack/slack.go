@@ -8,33 +8,116 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DefaultRateLimit matches Slack's limit of one message per second per incoming webhook
+const DefaultRateLimit = 1.0
+
+// phaseFailed is the deployment phase of failed and timed out rollouts
+const phaseFailed = "failed"
+
+var (
+	rateLimitWaitsCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_slack_rate_limit_waits_total",
+		Help: "Number of Slack messages that had to wait for the rate limiter",
+	})
+
+	rateLimitWaitDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "apptrail_slack_rate_limit_wait_duration_seconds",
+		Help:    "Time spent waiting for the Slack rate limiter",
+		Buckets: []float64{0.01, 0.1, 0.5, 1, 2, 5, 10, 30, 60},
+	})
+
+	metricsRegistered = false
 )
 
 type SlackPublisher struct {
 	WebhookURL string
+	// ProjectID is shown in the message footer when set
+	ProjectID string
+	// GitOpsLinks turns the GitOps application of a workload into a dashboard link
+	GitOpsLinks GitOpsLinks
+	// OnCall, when set, is @mentioned in messages about failed rollouts
+	OnCall  *OnCallResolver
+	limiter *rate.Limiter
+}
+
+// GitOpsLinks holds the dashboard URLs GitOps applications are linked to
+type GitOpsLinks struct {
+	ArgoCDServerURL  string // ArgoCD server, e.g. https://argocd.example.com
+	FluxDashboardURL string // Weave GitOps dashboard, e.g. https://gitops.example.com
 }
 
-func NewSlackPublisher(webhookURL string) *SlackPublisher {
+// URL returns the dashboard page of a GitOps application, or "" when no dashboard is configured
+func (l GitOpsLinks) URL(ref *model.GitOpsRef) string {
+	switch ref.Tool {
+	case model.GitOpsToolArgoCD:
+		if l.ArgoCDServerURL == "" {
+			return ""
+		}
+		path := "/applications/" + url.PathEscape(ref.AppName)
+		if ref.Namespace != "" {
+			path = "/applications/" + url.PathEscape(ref.Namespace) + "/" + url.PathEscape(ref.AppName)
+		}
+		return strings.TrimRight(l.ArgoCDServerURL, "/") + path
+	case model.GitOpsToolFlux:
+		if l.FluxDashboardURL == "" {
+			return ""
+		}
+		page := "/kustomization/details"
+		if ref.Source == "HelmRelease" {
+			page = "/helm_release/details"
+		}
+		query := url.Values{"name": {ref.AppName}, "namespace": {ref.Namespace}}
+		return strings.TrimRight(l.FluxDashboardURL, "/") + page + "?" + query.Encode()
+	default:
+		return ""
+	}
+}
+
+// NewSlackPublisher creates a Slack publisher sending at most rateLimit messages per second
+func NewSlackPublisher(webhookURL string, rateLimit float64) *SlackPublisher {
 	return &SlackPublisher{
 		WebhookURL: webhookURL,
+		limiter:    newLimiter(rateLimit),
 	}
 }
 
+// newLimiter registers the rate limit metrics and returns a limiter for rateLimit messages per second
+func newLimiter(rateLimit float64) *rate.Limiter {
+	if !metricsRegistered {
+		metrics.Registry.MustRegister(rateLimitWaitsCounter, rateLimitWaitDuration, onCallLookupFailuresCounter)
+		metricsRegistered = true
+	}
+	if rateLimit <= 0 {
+		rateLimit = DefaultRateLimit
+	}
+	return rate.NewLimiter(rate.Limit(rateLimit), 1)
+}
+
 func (slack *SlackPublisher) Publish(ctx context.Context, workload model.WorkloadUpdate) error {
 	log := ctrl.LoggerFrom(ctx)
 	httpClient := &http.Client{}
 
-	message := "Workload version released:\n"
-	message += "```"
-	message += "Kind: " + workload.Kind + "\n"
-	message += "Name: " + workload.Name + "\n"
-	message += "Namespace: " + workload.Namespace + "\n"
-	message += "Previous Version: " + workload.PreviousVersion + "\n"
-	message += "Current Version: " + workload.CurrentVersion + "\n"
-	message += "```"
+	if err := waitForRateLimit(ctx, slack.limiter); err != nil {
+		return err
+	}
+
+	message := formatMessage(workload, slack.ProjectID, slack.GitOpsLinks)
+	if workload.DeploymentPhase == phaseFailed && slack.OnCall != nil {
+		if mention := slack.OnCall.Mention(ctx); mention != "" {
+			message = mention + " " + message
+		}
+	}
 
 	type SlackMessage struct {
 		Text string `json:"text"`
@@ -78,3 +161,68 @@ func (slack *SlackPublisher) Publish(ctx context.Context, workload model.Workloa
 	}
 	return nil
 }
+
+// formatMessage renders the Slack message text for a workload update, followed by links to the
+// source commit, CI run and GitOps application when known and the project in the footer
+func formatMessage(workload model.WorkloadUpdate, projectID string, links GitOpsLinks) string {
+	message := "Workload version released:\n"
+	message += "```"
+	message += "Kind: " + workload.Kind + "\n"
+	message += "Name: " + workload.Name + "\n"
+	if release := workload.Labels[model.HelmReleaseLabel]; release != "" {
+		message += "Helm Release: " + release + "\n"
+	}
+	message += "Namespace: " + workload.Namespace + "\n"
+	message += "Previous Version: " + workload.PreviousVersion + "\n"
+	message += "Current Version: " + workload.CurrentVersion + "\n"
+	if workload.DeploymentPhase != "" {
+		message += "Phase: " + workload.DeploymentPhase + "\n"
+	}
+	message += "```"
+	if workload.SourceURL != "" {
+		message += "\nSource: " + formatLink(workload.SourceURL, "commit")
+	}
+	if workload.CIRunURL != "" {
+		message += "\nBuild: " + formatLink(workload.CIRunURL, "CI Run")
+	}
+	if workload.GitOps != nil {
+		if link := links.URL(workload.GitOps); link != "" {
+			message += "\nGitOps: " + formatLink(link, workload.GitOps.AppName)
+		} else {
+			message += "\nGitOps: " + workload.GitOps.Tool + " " + workload.GitOps.AppName
+		}
+	}
+	if projectID != "" {
+		message += "\nProject: " + projectID
+	}
+	return message
+}
+
+// linkEscaper escapes the characters Slack reserves for its markup, so a URL or label
+// containing them can't break out of the <url|label> link syntax
+var linkEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// formatLink renders a Slack link to rawURL labeled label
+func formatLink(rawURL, label string) string {
+	return "<" + linkEscaper.Replace(rawURL) + "|" + linkEscaper.Replace(label) + ">"
+}
+
+// waitForRateLimit blocks until the limiter allows another message or ctx is done
+func waitForRateLimit(ctx context.Context, limiter *rate.Limiter) error {
+	if limiter.Tokens() < 1 {
+		rateLimitWaitsCounter.Inc()
+	}
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	rateLimitWaitDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return fmt.Errorf("slack rate limit wait aborted: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op; the Slack publisher holds no long-lived resources
+func (slack *SlackPublisher) Close(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,162 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestSlackPublisher_RateLimitContextCancelled(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// One message per minute: the first call consumes the burst, the second must wait
+	publisher := NewSlackPublisher(server.URL, 1.0/60)
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment"}
+
+	if err := publisher.Publish(context.Background(), update); err != nil {
+		t.Fatalf("Expected first publish to succeed, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := publisher.Publish(ctx, update)
+	if err == nil {
+		t.Fatal("Expected error when context is cancelled while rate limited")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected Slack to be called once, got %d", got)
+	}
+}
+
+func TestFormatMessage_ProjectFooter(t *testing.T) {
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", CurrentVersion: "1.0.0"}
+
+	if got := formatMessage(update, "", GitOpsLinks{}); strings.Contains(got, "Project:") {
+		t.Errorf("Expected no project footer without a project ID, got %q", got)
+	}
+	if got := formatMessage(update, "my-project", GitOpsLinks{}); !strings.HasSuffix(got, "```\nProject: my-project") {
+		t.Errorf("Expected project footer after the code block, got %q", got)
+	}
+}
+
+func TestFormatMessage_SourceLinks(t *testing.T) {
+	update := model.WorkloadUpdate{
+		Name:           "api",
+		Namespace:      "default",
+		Kind:           "Deployment",
+		CurrentVersion: "1.0.0",
+		SourceURL:      "https://github.com/acme/api/commit/4f2a9c1",
+		CIRunURL:       "https://github.com/acme/api/actions/runs/812",
+	}
+
+	want := "```\nSource: <https://github.com/acme/api/commit/4f2a9c1|commit>" +
+		"\nBuild: <https://github.com/acme/api/actions/runs/812|CI Run>\nProject: my-project"
+	if got := formatMessage(update, "my-project", GitOpsLinks{}); !strings.HasSuffix(got, want) {
+		t.Errorf("Expected source links before the project footer, got %q", got)
+	}
+
+	update.SourceURL, update.CIRunURL = "", ""
+	if got := formatMessage(update, "", GitOpsLinks{}); strings.Contains(got, "Source:") || strings.Contains(got, "Build:") {
+		t.Errorf("Expected no links without annotations, got %q", got)
+	}
+}
+
+func TestFormatMessage_EscapesLinks(t *testing.T) {
+	update := model.WorkloadUpdate{
+		Name:           "api",
+		Namespace:      "default",
+		Kind:           "Deployment",
+		CurrentVersion: "1.0.0",
+		CIRunURL:       "https://ci.example.com/runs?id=812&attempt=2",
+		SourceURL:      "https://git.example.com/<commit>",
+	}
+
+	got := formatMessage(update, "", GitOpsLinks{})
+	if !strings.Contains(got, "Build: <https://ci.example.com/runs?id=812&amp;attempt=2|CI Run>") {
+		t.Errorf("Expected & to be escaped in the CI run link, got %q", got)
+	}
+	if !strings.Contains(got, "Source: <https://git.example.com/&lt;commit&gt;|commit>") {
+		t.Errorf("Expected < and > to be escaped in the source link, got %q", got)
+	}
+}
+
+func TestFormatMessage_HelmRelease(t *testing.T) {
+	update := model.WorkloadUpdate{
+		Name:           "api",
+		Namespace:      "default",
+		Kind:           "Deployment",
+		CurrentVersion: "1.0.0",
+		Labels:         map[string]string{model.HelmReleaseLabel: "api-prod"},
+	}
+
+	if got := formatMessage(update, "", GitOpsLinks{}); !strings.Contains(got, "Name: api\nHelm Release: api-prod\n") {
+		t.Errorf("Expected Helm release after the workload name, got %q", got)
+	}
+
+	update.Labels = nil
+	if got := formatMessage(update, "", GitOpsLinks{}); strings.Contains(got, "Helm Release:") {
+		t.Errorf("Expected no Helm release for unmanaged workloads, got %q", got)
+	}
+}
+
+func TestFormatMessage_GitOpsLink(t *testing.T) {
+	links := GitOpsLinks{
+		ArgoCDServerURL:  "https://argocd.example.com/",
+		FluxDashboardURL: "https://gitops.example.com",
+	}
+
+	tests := []struct {
+		name   string
+		gitOps *model.GitOpsRef
+		links  GitOpsLinks
+		want   string
+	}{
+		{
+			name:   "argocd application",
+			gitOps: &model.GitOpsRef{Tool: model.GitOpsToolArgoCD, AppName: "api", Namespace: "apps"},
+			links:  links,
+			want:   "\nGitOps: <https://argocd.example.com/applications/apps/api|api>",
+		},
+		{
+			name:   "flux kustomization",
+			gitOps: &model.GitOpsRef{Tool: model.GitOpsToolFlux, AppName: "api", Namespace: "flux-system", Source: "Kustomization"},
+			links:  links,
+			want:   "\nGitOps: <https://gitops.example.com/kustomization/details?name=api&amp;namespace=flux-system|api>",
+		},
+		{
+			name:   "flux helm release",
+			gitOps: &model.GitOpsRef{Tool: model.GitOpsToolFlux, AppName: "api", Namespace: "apps", Source: "HelmRelease"},
+			links:  links,
+			want:   "\nGitOps: <https://gitops.example.com/helm_release/details?name=api&amp;namespace=apps|api>",
+		},
+		{
+			name:   "no dashboard configured",
+			gitOps: &model.GitOpsRef{Tool: model.GitOpsToolArgoCD, AppName: "api"},
+			want:   "\nGitOps: argocd api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", GitOps: tt.gitOps}
+			if got := formatMessage(update, "", tt.links); !strings.HasSuffix(got, tt.want) {
+				t.Errorf("Expected message to end with %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
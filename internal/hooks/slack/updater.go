@@ -0,0 +1,185 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"golang.org/x/time/rate"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// DefaultAPIURL is the base URL of the Slack Web API
+	DefaultAPIURL = "https://slack.com/api"
+
+	// DefaultUpdateWindow is how long a rollout message keeps being edited in place
+	DefaultUpdateWindow = 30 * time.Minute
+)
+
+// SlackMessageUpdater posts rollout messages through the Slack Bot API and edits them
+// in place as the rollout moves through its phases
+type SlackMessageUpdater struct {
+	// ProjectID is shown in the message footer when set
+	ProjectID string
+	// GitOpsLinks turns the GitOps application of a workload into a dashboard link
+	GitOpsLinks GitOpsLinks
+
+	apiURL       string
+	token        string
+	channel      string
+	updateWindow time.Duration
+	client       *http.Client
+	limiter      *rate.Limiter
+
+	mu       sync.Mutex
+	messages map[string]postedMessage
+}
+
+// postedMessage identifies a message previously posted for a workload rollout
+type postedMessage struct {
+	channel  string
+	ts       string
+	version  string
+	postedAt time.Time
+}
+
+// slackAPIResponse is the subset of chat.postMessage / chat.update responses we use
+type slackAPIResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	TS      string `json:"ts,omitempty"`
+}
+
+// NewSlackMessageUpdater creates a Bot API publisher that edits rollout messages within updateWindow
+func NewSlackMessageUpdater(token, channel string, updateWindow time.Duration, rateLimit float64) *SlackMessageUpdater {
+	if updateWindow <= 0 {
+		updateWindow = DefaultUpdateWindow
+	}
+	return &SlackMessageUpdater{
+		apiURL:       DefaultAPIURL,
+		token:        token,
+		channel:      channel,
+		updateWindow: updateWindow,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		limiter:      newLimiter(rateLimit),
+		messages:     make(map[string]postedMessage),
+	}
+}
+
+// Publish posts a new message for a rollout, or edits the existing one while within the update window
+func (u *SlackMessageUpdater) Publish(ctx context.Context, workload model.WorkloadUpdate) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	if err := waitForRateLimit(ctx, u.limiter); err != nil {
+		return err
+	}
+
+	key := workloadKey(workload)
+	text := formatMessage(workload, u.ProjectID, u.GitOpsLinks)
+
+	if previous, ok := u.lookup(key, workload.CurrentVersion); ok {
+		_, err := u.call(ctx, "chat.update", map[string]string{
+			"channel": previous.channel,
+			"ts":      previous.ts,
+			"text":    text,
+		})
+		if err == nil {
+			return nil
+		}
+		log.Error(err, "failed to update slack message, posting a new one",
+			"workload", key, "ts", previous.ts)
+	}
+
+	resp, err := u.call(ctx, "chat.postMessage", map[string]string{
+		"channel": u.channel,
+		"text":    text,
+	})
+	if err != nil {
+		log.Error(err, "failed to post slack message", "workload", key)
+		return err
+	}
+
+	u.mu.Lock()
+	u.messages[key] = postedMessage{
+		channel:  resp.Channel,
+		ts:       resp.TS,
+		version:  workload.CurrentVersion,
+		postedAt: time.Now(),
+	}
+	u.mu.Unlock()
+
+	return nil
+}
+
+// lookup returns the message to edit for a workload version, pruning expired entries
+func (u *SlackMessageUpdater) lookup(key, version string) (postedMessage, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	for k, msg := range u.messages {
+		if now.Sub(msg.postedAt) > u.updateWindow {
+			delete(u.messages, k)
+		}
+	}
+
+	msg, ok := u.messages[key]
+	if !ok || msg.version != version {
+		return postedMessage{}, false
+	}
+	return msg, true
+}
+
+// call invokes a Slack Web API method with a JSON body
+func (u *SlackMessageUpdater) call(ctx context.Context, method string, payload map[string]string) (slackAPIResponse, error) {
+	var result slackAPIResponse
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal slack %s request. %w", method, err)
+	}
+
+	url := strings.TrimSuffix(u.apiURL, "/") + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+u.token)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("failed to call slack %s. %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("slack %s returned status %v", method, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, fmt.Errorf("failed to decode slack %s response. %w", method, err)
+	}
+	if !result.OK {
+		return result, fmt.Errorf("slack %s failed: %s", method, result.Error)
+	}
+	return result, nil
+}
+
+// Close releases idle connections held by the HTTP client
+func (u *SlackMessageUpdater) Close(_ context.Context) error {
+	u.client.CloseIdleConnections()
+	return nil
+}
+
+// workloadKey identifies a workload across rollout phase events
+func workloadKey(workload model.WorkloadUpdate) string {
+	return workload.Namespace + "/" + workload.Kind + "/" + workload.Name
+}
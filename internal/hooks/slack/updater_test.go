@@ -0,0 +1,109 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+// fakeSlackAPI records Web API calls and answers like Slack does
+type fakeSlackAPI struct {
+	mu      sync.Mutex
+	methods []string
+	bodies  []map[string]string
+}
+
+func (f *fakeSlackAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	f.methods = append(f.methods, strings.TrimPrefix(r.URL.Path, "/"))
+	f.bodies = append(f.bodies, body)
+	f.mu.Unlock()
+
+	_ = json.NewEncoder(w).Encode(slackAPIResponse{OK: true, Channel: "C123", TS: "1700000000.000100"})
+}
+
+func TestSlackMessageUpdater_Publish(t *testing.T) {
+	update := model.WorkloadUpdate{
+		Name:            "api",
+		Namespace:       "default",
+		Kind:            "Deployment",
+		CurrentVersion:  "1.1.0",
+		DeploymentPhase: "rolling_out",
+	}
+
+	tests := []struct {
+		name         string
+		updateWindow time.Duration
+		second       func(model.WorkloadUpdate) model.WorkloadUpdate
+		expected     []string
+	}{
+		{
+			name:         "phase change within window edits message",
+			updateWindow: time.Hour,
+			second: func(u model.WorkloadUpdate) model.WorkloadUpdate {
+				u.DeploymentPhase = "success"
+				return u
+			},
+			expected: []string{"chat.postMessage", "chat.update"},
+		},
+		{
+			name:         "expired window posts new message",
+			updateWindow: time.Nanosecond,
+			second: func(u model.WorkloadUpdate) model.WorkloadUpdate {
+				u.DeploymentPhase = "success"
+				return u
+			},
+			expected: []string{"chat.postMessage", "chat.postMessage"},
+		},
+		{
+			name:         "new version posts new message",
+			updateWindow: time.Hour,
+			second: func(u model.WorkloadUpdate) model.WorkloadUpdate {
+				u.CurrentVersion = "1.2.0"
+				return u
+			},
+			expected: []string{"chat.postMessage", "chat.postMessage"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeSlackAPI{}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			updater := NewSlackMessageUpdater("xoxb-test", "#deploys", tt.updateWindow, 1000)
+			updater.apiURL = server.URL
+
+			if err := updater.Publish(context.Background(), update); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			time.Sleep(time.Millisecond)
+			if err := updater.Publish(context.Background(), tt.second(update)); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if len(api.methods) != len(tt.expected) {
+				t.Fatalf("Expected calls %v, got %v", tt.expected, api.methods)
+			}
+			for i, method := range tt.expected {
+				if api.methods[i] != method {
+					t.Errorf("Call %d: expected %s, got %s", i, method, api.methods[i])
+				}
+			}
+			if tt.expected[1] == "chat.update" && api.bodies[1]["ts"] != "1700000000.000100" {
+				t.Errorf("Expected chat.update to target original ts, got %q", api.bodies[1]["ts"])
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package victorops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultAlertURL is the VictorOps (Splunk On-Call) generic REST integration endpoint
+const DefaultAlertURL = "https://alert.victorops.com/integrations/generic/20131114/alert"
+
+// VictorOps message types used by the publisher
+const (
+	messageTypeCritical = "CRITICAL"
+	messageTypeRecovery = "RECOVERY"
+)
+
+// VictorOpsConfig holds configuration for the VictorOps publisher
+type VictorOpsConfig struct {
+	RoutingKey     string
+	IntegrationKey string
+	ClusterID      string
+	AlertURL       string // Optional; defaults to DefaultAlertURL
+}
+
+// alert is the JSON body accepted by the VictorOps REST integration
+type alert struct {
+	MessageType       string `json:"message_type"`
+	EntityID          string `json:"entity_id"`
+	EntityDisplayName string `json:"entity_display_name"`
+	StateMessage      string `json:"state_message"`
+}
+
+// VictorOpsPublisher opens VictorOps incidents for failed rollouts and resolves them
+// once the workload rolls out successfully
+type VictorOpsPublisher struct {
+	config VictorOpsConfig
+	client *http.Client
+
+	// Last message type sent per entity ID, so recoveries are only sent for open incidents
+	mu          sync.Mutex
+	entityState map[string]string
+}
+
+// NewVictorOpsPublisher creates a new VictorOps publisher
+func NewVictorOpsPublisher(config VictorOpsConfig) *VictorOpsPublisher {
+	if config.AlertURL == "" {
+		config.AlertURL = DefaultAlertURL
+	}
+	return &VictorOpsPublisher{
+		config:      config,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		entityState: make(map[string]string),
+	}
+}
+
+// Publish sends a CRITICAL alert for failed rollouts and a RECOVERY once a failed workload
+// succeeds or is deleted. Other phases are ignored.
+func (p *VictorOpsPublisher) Publish(ctx context.Context, update model.WorkloadUpdate) error {
+	logger := log.FromContext(ctx)
+
+	entityID := p.entityID(update)
+
+	p.mu.Lock()
+	previous := p.entityState[entityID]
+	p.mu.Unlock()
+
+	var messageType string
+	switch {
+	case update.DeploymentPhase == "failed":
+		messageType = messageTypeCritical
+	case (update.DeploymentPhase == "success" || update.DeploymentPhase == "deleted") && previous == messageTypeCritical:
+		messageType = messageTypeRecovery
+	default:
+		return nil
+	}
+
+	body, err := json.Marshal(alert{
+		MessageType:       messageType,
+		EntityID:          entityID,
+		EntityDisplayName: fmt.Sprintf("%s %s/%s", update.Kind, update.Namespace, update.Name),
+		StateMessage:      stateMessage(update, p.config.ClusterID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal victorops alert: %w", err)
+	}
+
+	url := strings.TrimSuffix(p.config.AlertURL, "/") + "/" + p.config.RoutingKey + "/" + p.config.IntegrationKey
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create victorops request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		logger.Error(err, "Failed to send VictorOps alert", "entityID", entityID)
+		return fmt.Errorf("failed to send victorops alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("victorops returned error status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	p.mu.Lock()
+	if messageType == messageTypeRecovery {
+		delete(p.entityState, entityID)
+	} else {
+		p.entityState[entityID] = messageType
+	}
+	p.mu.Unlock()
+
+	logger.Info("Alert successfully sent to VictorOps",
+		"messageType", messageType,
+		"entityID", entityID,
+	)
+
+	return nil
+}
+
+// entityID identifies a workload across alerts so VictorOps can correlate them into one incident
+func (p *VictorOpsPublisher) entityID(update model.WorkloadUpdate) string {
+	return "apptrail/" + p.config.ClusterID + "/" + update.Namespace + "/" + update.Name
+}
+
+// stateMessage renders the alert details for a workload update
+func stateMessage(update model.WorkloadUpdate, clusterID string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s/%s rollout %s", update.Kind, update.Namespace, update.Name, update.DeploymentPhase)
+	if clusterID != "" {
+		fmt.Fprintf(&b, " in cluster %s", clusterID)
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "Previous Version: %s\n", update.PreviousVersion)
+	fmt.Fprintf(&b, "Current Version: %s\n", update.CurrentVersion)
+	if update.StatusMessage != "" {
+		fmt.Fprintf(&b, "Status: %s\n", update.StatusMessage)
+	}
+	return b.String()
+}
+
+// Close releases idle connections held by the HTTP client
+func (p *VictorOpsPublisher) Close(_ context.Context) error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,113 @@
+package victorops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestVictorOpsPublisher_Publish(t *testing.T) {
+	var received []alert
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a alert
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			t.Errorf("failed to decode alert: %v", err)
+		}
+		received = append(received, a)
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	p := NewVictorOpsPublisher(VictorOpsConfig{
+		RoutingKey:     "routing",
+		IntegrationKey: "integration",
+		ClusterID:      "staging",
+		AlertURL:       server.URL + "/alert",
+	})
+
+	update := model.WorkloadUpdate{
+		Name:            "api",
+		Namespace:       "default",
+		Kind:            "Deployment",
+		PreviousVersion: "v1",
+		CurrentVersion:  "v2",
+	}
+
+	steps := []struct {
+		phase    string
+		expected string // Empty when no alert should be sent
+	}{
+		{"success", ""}, // No open incident to recover
+		{"rolling_out", ""},
+		{"failed", messageTypeCritical},
+		{"rolling_out", ""},
+		{"success", messageTypeRecovery},
+		{"success", ""}, // Incident already resolved
+		{"failed", messageTypeCritical},
+		{"deleted", messageTypeRecovery},
+	}
+
+	for _, step := range steps {
+		before := len(received)
+		update.DeploymentPhase = step.phase
+		if err := p.Publish(context.Background(), update); err != nil {
+			t.Fatalf("phase %s: unexpected error: %v", step.phase, err)
+		}
+
+		if step.expected == "" {
+			if len(received) != before {
+				t.Errorf("phase %s: expected no alert, got %s", step.phase, received[len(received)-1].MessageType)
+			}
+			continue
+		}
+		if len(received) != before+1 {
+			t.Fatalf("phase %s: expected an alert", step.phase)
+		}
+		got := received[len(received)-1]
+		if got.MessageType != step.expected {
+			t.Errorf("phase %s: expected message type %s, got %s", step.phase, step.expected, got.MessageType)
+		}
+		if got.EntityID != "apptrail/staging/default/api" {
+			t.Errorf("phase %s: unexpected entity ID %q", step.phase, got.EntityID)
+		}
+		if paths[len(paths)-1] != "/alert/routing/integration" {
+			t.Errorf("phase %s: unexpected path %q", step.phase, paths[len(paths)-1])
+		}
+	}
+}
+
+func TestVictorOpsPublisher_PublishErrorKeepsState(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	p := NewVictorOpsPublisher(VictorOpsConfig{
+		RoutingKey:     "routing",
+		IntegrationKey: "integration",
+		AlertURL:       server.URL,
+	})
+
+	update := model.WorkloadUpdate{Name: "api", Namespace: "default", Kind: "Deployment", DeploymentPhase: "failed"}
+	if err := p.Publish(context.Background(), update); err == nil {
+		t.Fatal("expected error for failed request")
+	}
+	if len(p.entityState) != 0 {
+		t.Errorf("expected no entity state after a failed request, got %v", p.entityState)
+	}
+
+	status = http.StatusOK
+	if err := p.Publish(context.Background(), update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := p.entityState[p.entityID(update)]; got != messageTypeCritical {
+		t.Errorf("expected entity state %s, got %q", messageTypeCritical, got)
+	}
+}
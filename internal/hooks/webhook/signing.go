@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of the request body
+	SignatureHeader = "X-AppTrail-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// SignPayload returns the signature of body in GitHub webhook format: sha256=<hex>
+func SignPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is a valid signature of body for secret
+func VerifyWebhookSignature(secret, signature, body []byte) bool {
+	expected := SignPayload(secret, body)
+	return hmac.Equal([]byte(expected), signature)
+}
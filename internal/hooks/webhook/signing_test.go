@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestSignPayload(t *testing.T) {
+	// Reference value computed with: printf 'hello' | openssl dgst -sha256 -hmac 'secret'
+	expected := "sha256=88aab3ede8d3adf94d26ab90d3bafd4a2083070c3bcce9c014ee04a443847c0b"
+
+	if got := SignPayload([]byte("secret"), []byte("hello")); got != expected {
+		t.Errorf("SignPayload() = %q, want %q", got, expected)
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	secret := []byte("secret")
+	body := []byte(`{"eventId":"123"}`)
+	signature := []byte(SignPayload(secret, body))
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		signature []byte
+		body      []byte
+		expected  bool
+	}{
+		{"valid signature", secret, signature, body, true},
+		{"wrong secret", []byte("other"), signature, body, false},
+		{"tampered body", secret, signature, []byte(`{"eventId":"456"}`), false},
+		{"missing prefix", secret, signature[len(signaturePrefix):], body, false},
+		{"empty signature", secret, nil, body, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyWebhookSignature(tt.secret, tt.signature, tt.body); got != tt.expected {
+				t.Errorf("VerifyWebhookSignature() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWebhookPublisher_Publish_Signed(t *testing.T) {
+	secret := "signing-secret"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Failed to read body: %v", err)
+		}
+		if !VerifyWebhookSignature([]byte(secret), []byte(r.Header.Get(SignatureHeader)), body) {
+			t.Errorf("Expected valid signature, got %q", r.Header.Get(SignatureHeader))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	publisher := NewWebhookPublisher(WebhookConfig{
+		URL:           server.URL,
+		SigningSecret: secret,
+		ClusterID:     "test-cluster",
+		AgentVersion:  "v1.0.0",
+	})
+
+	err := publisher.Publish(context.Background(), model.WorkloadUpdate{
+		Name:           "api",
+		Namespace:      "default",
+		Kind:           "Deployment",
+		CurrentVersion: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+}
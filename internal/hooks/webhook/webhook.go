@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/hooks"
+	"github.com/apptrail-sh/agent/internal/model"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// WebhookConfig holds configuration for the generic webhook publisher
+type WebhookConfig struct {
+	URL            string
+	SigningSecret  string // Optional; when set, requests carry an X-AppTrail-Signature header
+	ClusterID      string
+	ProjectID      string
+	AgentVersion   string
+	MaxPayloadSize int // Largest encoded event in bytes; labels are dropped to fit (0 disables)
+}
+
+// WebhookPublisher posts workload events as JSON to an arbitrary HTTP endpoint
+type WebhookPublisher struct {
+	config WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookPublisher creates a new generic webhook publisher
+func NewWebhookPublisher(config WebhookConfig) *WebhookPublisher {
+	return &WebhookPublisher{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish sends a workload update to the webhook endpoint. An update carrying an Envelope,
+// such as a CloudEvent, is sent as is with its Labels as request headers.
+func (p *WebhookPublisher) Publish(ctx context.Context, update model.WorkloadUpdate) error {
+	logger := log.FromContext(ctx)
+
+	body, headers, eventID := update.Envelope, update.Labels, update.EventID
+	if body == nil {
+		event := model.NewAgentEventPayload(update, p.config.ClusterID, p.config.ProjectID, p.config.AgentVersion)
+		var err error
+		body, err = hooks.MarshalLimitedEvent(ctx, "webhook", &event, p.config.MaxPayloadSize)
+		if err != nil {
+			return fmt.Errorf("failed to marshal webhook event: %w", err)
+		}
+		headers = map[string]string{"Content-Type": "application/json"}
+		eventID = event.EventID
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if p.config.SigningSecret != "" {
+		req.Header.Set(SignatureHeader, SignPayload([]byte(p.config.SigningSecret), body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		logger.Error(err, "Failed to send webhook event", "eventID", eventID)
+		return fmt.Errorf("failed to send webhook event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned error status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	logger.Info("Event successfully published to webhook",
+		"eventID", eventID,
+		"statusCode", resp.StatusCode,
+		"namespace", update.Namespace,
+		"name", update.Name,
+	)
+
+	return nil
+}
+
+// Close releases idle connections held by the HTTP client
+func (p *WebhookPublisher) Close(_ context.Context) error {
+	p.client.CloseIdleConnections()
+	return nil
+}
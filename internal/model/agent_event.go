@@ -24,14 +24,18 @@ const (
 	WorkloadKindJob         WorkloadKind = "JOB"
 	WorkloadKindCronJob     WorkloadKind = "CRONJOB"
 
+	WorkloadKindVirtualMachine WorkloadKind = "VIRTUALMACHINE"
+
 	DeploymentPhasePending     DeploymentPhase = "PENDING"
 	DeploymentPhaseProgressing DeploymentPhase = "PROGRESSING"
 	DeploymentPhaseCompleted   DeploymentPhase = "COMPLETED"
 	DeploymentPhaseFailed      DeploymentPhase = "FAILED"
+	DeploymentPhaseTerminated  DeploymentPhase = "TERMINATED"
 )
 
 type SourceMetadata struct {
 	ClusterID    string `json:"clusterId"`
+	ProjectID    string `json:"projectId,omitempty"` // Cloud project the cluster runs in, when auto-detected
 	AgentVersion string `json:"agentVersion"`
 }
 
@@ -46,6 +50,67 @@ type Revision struct {
 	Previous string `json:"previous,omitempty"`
 }
 
+// Labels added to workload updates for resources managed by a Helm release
+const (
+	HelmReleaseLabel          = "apptrail.sh/helm-release"
+	HelmReleaseNamespaceLabel = "apptrail.sh/helm-release-namespace"
+
+	// HelmChartLabel is set by Helm charts to <chart name>-<chart version>
+	HelmChartLabel = "helm.sh/chart"
+)
+
+// ClusterNameLabel is added to every workload event's labels with the cluster ID
+const ClusterNameLabel = "cluster_name"
+
+// HelmReleaseRef identifies the Helm release that manages a workload
+type HelmReleaseRef struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace,omitempty"`
+	Chart        string `json:"chart,omitempty"`
+	ChartVersion string `json:"chartVersion,omitempty"`
+}
+
+// NewHelmReleaseRef builds the Helm release of a workload from its update labels, or returns nil
+// when the workload is not managed by Helm
+func NewHelmReleaseRef(labels map[string]string) *HelmReleaseRef {
+	name := labels[HelmReleaseLabel]
+	if name == "" {
+		return nil
+	}
+	chart, version := splitHelmChart(labels[HelmChartLabel])
+	return &HelmReleaseRef{
+		Name:         name,
+		Namespace:    labels[HelmReleaseNamespaceLabel],
+		Chart:        chart,
+		ChartVersion: version,
+	}
+}
+
+// splitHelmChart splits a helm.sh/chart label such as "redis-17.3.2" into chart name and version.
+// Chart names may contain dashes, so the version starts at the first dash followed by a digit.
+func splitHelmChart(value string) (chart, version string) {
+	for i := 0; i < len(value)-1; i++ {
+		if value[i] == '-' && value[i+1] >= '0' && value[i+1] <= '9' {
+			return value[:i], value[i+1:]
+		}
+	}
+	return value, ""
+}
+
+// GitOps tools recognized in GitOpsRef.Tool
+const (
+	GitOpsToolFlux   = "flux"
+	GitOpsToolArgoCD = "argocd"
+)
+
+// GitOpsRef identifies the GitOps application that deployed a workload
+type GitOpsRef struct {
+	Tool      string `json:"tool"` // flux or argocd
+	AppName   string `json:"appName"`
+	Namespace string `json:"namespace,omitempty"`
+	Source    string `json:"source,omitempty"` // Kind of the GitOps object, e.g. Kustomization, HelmRelease or Application
+}
+
 type ErrorDetail struct {
 	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
@@ -53,19 +118,39 @@ type ErrorDetail struct {
 }
 
 type AgentEventPayload struct {
-	EventID    string             `json:"eventId"`
-	OccurredAt time.Time          `json:"occurredAt"`
-	Source     SourceMetadata     `json:"source"`
-	Workload   WorkloadRef        `json:"workload"`
-	Labels     map[string]string  `json:"labels"`
-	Kind       AgentEventKind     `json:"kind"`
-	Outcome    *AgentEventOutcome `json:"outcome,omitempty"`
-	Revision   *Revision          `json:"revision,omitempty"`
-	Phase      *DeploymentPhase   `json:"phase,omitempty"`
-	Error      *ErrorDetail       `json:"error,omitempty"`
+	EventID     string             `json:"eventId"`
+	OccurredAt  time.Time          `json:"occurredAt"`
+	Source      SourceMetadata     `json:"source"`
+	Workload    WorkloadRef        `json:"workload"`
+	Labels      map[string]string  `json:"labels"`
+	Annotations map[string]string  `json:"annotations,omitempty"`
+	SourceURL   string             `json:"sourceUrl,omitempty"` // Commit the version was built from
+	CIRunURL    string             `json:"ciRunUrl,omitempty"`  // CI pipeline run that built the version
+	Kind        AgentEventKind     `json:"kind"`
+	Outcome     *AgentEventOutcome `json:"outcome,omitempty"`
+	Revision    *Revision          `json:"revision,omitempty"`
+	Phase       *DeploymentPhase   `json:"phase,omitempty"`
+	Error       *ErrorDetail       `json:"error,omitempty"`
+	Metadata    map[string]any     `json:"metadata,omitempty"`
+	HelmRelease *HelmReleaseRef    `json:"helmRelease,omitempty"`
+	GitOps      *GitOpsRef         `json:"gitOps,omitempty"`
+}
+
+// StampWorkloadUpdate returns the update with the EventID and OccurredAt of its event, keeping
+// those already set
+func StampWorkloadUpdate(update WorkloadUpdate) WorkloadUpdate {
+	if update.EventID == "" {
+		update.EventID = uuid.New().String()
+	}
+	if update.OccurredAt.IsZero() {
+		update.OccurredAt = eventTime()
+	}
+	return update
 }
 
-func NewAgentEventPayload(update WorkloadUpdate, clusterID, agentVersion string) AgentEventPayload {
+func NewAgentEventPayload(update WorkloadUpdate, clusterID, projectID, agentVersion string) AgentEventPayload {
+	update = StampWorkloadUpdate(update)
+
 	labels := make(map[string]string)
 	if update.Labels != nil {
 		for key, value := range update.Labels {
@@ -73,7 +158,7 @@ func NewAgentEventPayload(update WorkloadUpdate, clusterID, agentVersion string)
 		}
 	}
 
-	labels["cluster_name"] = clusterID
+	labels[ClusterNameLabel] = clusterID
 
 	phase := mapDeploymentPhase(update.DeploymentPhase)
 	outcome := mapDeploymentOutcome(phase)
@@ -89,16 +174,29 @@ func NewAgentEventPayload(update WorkloadUpdate, clusterID, agentVersion string)
 		}
 	}
 
+	metadata := make(map[string]any)
+	if update.WorkloadAgeSeconds > 0 {
+		metadata["workloadAge"] = update.WorkloadAgeSeconds
+	}
+	if update.IsRollback {
+		metadata["isRollback"] = true
+	}
+	if update.ScaledFrom != update.ScaledTo {
+		metadata["scaledFrom"] = update.ScaledFrom
+		metadata["scaledTo"] = update.ScaledTo
+	}
+
 	revision := &Revision{
 		Current:  update.CurrentVersion,
 		Previous: update.PreviousVersion,
 	}
 
 	return AgentEventPayload{
-		EventID:    uuid.New().String(),
-		OccurredAt: time.Now().UTC(),
+		EventID:    update.EventID,
+		OccurredAt: update.OccurredAt,
 		Source: SourceMetadata{
 			ClusterID:    clusterID,
+			ProjectID:    projectID,
 			AgentVersion: agentVersion,
 		},
 		Workload: WorkloadRef{
@@ -106,12 +204,18 @@ func NewAgentEventPayload(update WorkloadUpdate, clusterID, agentVersion string)
 			Name:      update.Name,
 			Namespace: update.Namespace,
 		},
-		Labels:   labels,
-		Kind:     AgentEventKindDeployment,
-		Outcome:  outcome,
-		Revision: revision,
-		Phase:    phase,
-		Error:    errorDetail,
+		Labels:      labels,
+		Annotations: update.Annotations,
+		SourceURL:   update.SourceURL,
+		CIRunURL:    update.CIRunURL,
+		Kind:        AgentEventKindDeployment,
+		Outcome:     outcome,
+		Revision:    revision,
+		Phase:       phase,
+		Error:       errorDetail,
+		Metadata:    metadata,
+		HelmRelease: NewHelmReleaseRef(update.Labels),
+		GitOps:      update.GitOps,
 	}
 }
 
@@ -127,6 +231,8 @@ func mapWorkloadKind(kind string) WorkloadKind {
 		return WorkloadKindJob
 	case "cronjob":
 		return WorkloadKindCronJob
+	case "virtualmachine":
+		return WorkloadKindVirtualMachine
 	default:
 		return WorkloadKindDeployment
 	}
@@ -134,7 +240,7 @@ func mapWorkloadKind(kind string) WorkloadKind {
 
 func mapDeploymentPhase(phase string) *DeploymentPhase {
 	switch phase {
-	case "rolling_out":
+	case "rolling_out", "scaling":
 		value := DeploymentPhaseProgressing
 		return &value
 	case "success":
@@ -143,6 +249,9 @@ func mapDeploymentPhase(phase string) *DeploymentPhase {
 	case "failed":
 		value := DeploymentPhaseFailed
 		return &value
+	case "deleted":
+		value := DeploymentPhaseTerminated
+		return &value
 	default:
 		return nil
 	}
@@ -159,6 +268,9 @@ func mapDeploymentOutcome(phase *DeploymentPhase) *AgentEventOutcome {
 	case DeploymentPhaseFailed:
 		value := AgentEventOutcomeFailed
 		return &value
+	case DeploymentPhaseTerminated:
+		// Deletion ends the workload without a rollout outcome
+		return nil
 	default:
 		return nil
 	}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestNewHelmReleaseRef(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   *HelmReleaseRef
+	}{
+		{
+			name:   "not managed by helm",
+			labels: map[string]string{HelmChartLabel: "api-1.0.0"},
+			want:   nil,
+		},
+		{
+			name: "release with chart",
+			labels: map[string]string{
+				HelmReleaseLabel:          "api-prod",
+				HelmReleaseNamespaceLabel: "releases",
+				HelmChartLabel:            "api-1.4.0",
+			},
+			want: &HelmReleaseRef{Name: "api-prod", Namespace: "releases", Chart: "api", ChartVersion: "1.4.0"},
+		},
+		{
+			name:   "chart name with dashes and prerelease version",
+			labels: map[string]string{HelmReleaseLabel: "cache", HelmChartLabel: "redis-cluster-9.1.0-rc.1"},
+			want:   &HelmReleaseRef{Name: "cache", Chart: "redis-cluster", ChartVersion: "9.1.0-rc.1"},
+		},
+		{
+			name:   "release without chart label",
+			labels: map[string]string{HelmReleaseLabel: "api-prod"},
+			want:   &HelmReleaseRef{Name: "api-prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewHelmReleaseRef(tt.labels)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("NewHelmReleaseRef() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAgentEventPayload_AnnotationsKeptOutOfMetadata(t *testing.T) {
+	update := WorkloadUpdate{
+		Name:               "api",
+		Namespace:          "default",
+		Kind:               "Deployment",
+		WorkloadAgeSeconds: 120,
+		Annotations: map[string]string{
+			"argocd.argoproj.io/app-name": "api-prod",
+			"workloadAge":                 "0",
+		},
+	}
+
+	payload := NewAgentEventPayload(update, "stg01", "", "v1.0.0")
+
+	if got := payload.Annotations["argocd.argoproj.io/app-name"]; got != "api-prod" {
+		t.Errorf("Expected annotation in Annotations, got %q", got)
+	}
+	if _, ok := payload.Metadata["argocd.argoproj.io/app-name"]; ok {
+		t.Error("Expected annotations to be kept out of Metadata")
+	}
+	// An annotation named like agent metadata must not overwrite it
+	if got := payload.Metadata["workloadAge"]; got != float64(120) {
+		t.Errorf("Expected workloadAge 120 in Metadata, got %v (%T)", got, got)
+	}
+}
@@ -14,6 +14,17 @@ type ClusterHeartbeatPayload struct {
 	Source      SourceMetadata    `json:"source"`
 	MessageType string            `json:"messageType"`
 	Inventory   ResourceInventory `json:"inventory"`
+
+	// PublisherHealth reports which publishers are failing while the agent itself is running
+	PublisherHealth []PublisherHealthStatus `json:"publisherHealth,omitempty"`
+}
+
+// PublisherHealthStatus is the health of a single event publisher
+type PublisherHealthStatus struct {
+	Name          string    `json:"name"`
+	Healthy       bool      `json:"healthy"`
+	LastError     string    `json:"lastError,omitempty"`
+	LastSuccessAt time.Time `json:"lastSuccessAt,omitzero"`
 }
 
 // ResourceInventory contains UIDs of all active nodes and pods in the cluster
@@ -26,6 +37,7 @@ type ResourceInventory struct {
 func NewClusterHeartbeatPayload(
 	clusterID, agentVersion string,
 	nodeUIDs, podUIDs []string,
+	publisherHealth []PublisherHealthStatus,
 ) ClusterHeartbeatPayload {
 	return ClusterHeartbeatPayload{
 		EventID:    uuid.New().String(),
@@ -39,5 +51,6 @@ func NewClusterHeartbeatPayload(
 			NodeUIDs: nodeUIDs,
 			PodUIDs:  podUIDs,
 		},
+		PublisherHealth: publisherHealth,
 	}
 }
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// JitterConfig spreads event timestamps so events created at the same instant, e.g. when many
+// pods start together, rarely share one. The offset is random, so jittered timestamps do not
+// preserve the order events were created in; resource events carry SequenceNum for that
+type JitterConfig struct {
+	Max time.Duration // Upper bound of the random offset added to OccurredAt; zero disables jitter
+}
+
+// timestampJitter holds the JitterConfig.Max used by the event factories, in nanoseconds
+var timestampJitter atomic.Int64
+
+// SetTimestampJitter configures the jitter applied by NewResourceEventPayload and NewAgentEventPayload
+func SetTimestampJitter(config JitterConfig) {
+	timestampJitter.Store(int64(max(config.Max, 0)))
+}
+
+// Apply returns t shifted forward by a random offset below Max
+func (c JitterConfig) Apply(t time.Time) time.Time {
+	if c.Max <= 0 {
+		return t
+	}
+	return t.Add(rand.N(c.Max))
+}
+
+// eventTime returns the OccurredAt of a new event
+func eventTime() time.Time {
+	return JitterConfig{Max: time.Duration(timestampJitter.Load())}.Apply(time.Now().UTC())
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterConfig_Apply(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := (JitterConfig{}).Apply(now); !got.Equal(now) {
+		t.Errorf("Expected no jitter when disabled, got %v", got)
+	}
+
+	config := JitterConfig{Max: 50 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		got := config.Apply(now)
+		if got.Before(now) || !got.Before(now.Add(config.Max)) {
+			t.Fatalf("Expected %v within [%v, %v)", got, now, now.Add(config.Max))
+		}
+	}
+}
+
+func TestSetTimestampJitter(t *testing.T) {
+	SetTimestampJitter(JitterConfig{Max: time.Hour})
+	defer SetTimestampJitter(JitterConfig{})
+
+	before := time.Now().UTC()
+	event := NewResourceEventPayload(ResourceTypePod, ResourceRef{}, nil, ResourceEventKindCreated, nil, nil, "cluster", "v1")
+	if event.OccurredAt.Before(before) || event.OccurredAt.After(before.Add(time.Hour+time.Second)) {
+		t.Errorf("Expected OccurredAt within the jitter window, got %v", event.OccurredAt)
+	}
+}
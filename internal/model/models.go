@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type WorkloadUpdate struct {
 	Name            string
 	Namespace       string
@@ -7,9 +9,36 @@ type WorkloadUpdate struct {
 	PreviousVersion string
 	CurrentVersion  string
 	Labels          map[string]string // Kubernetes labels from the workload
+	Annotations     map[string]string // Workload annotations matching the passthrough prefixes
+
+	// Links from the apptrail.sh/source-url and apptrail.sh/ci-run annotations
+	SourceURL string
+	CIRunURL  string
+
+	// GitOps application that deployed the workload, nil when not managed by Flux or ArgoCD
+	GitOps *GitOpsRef
+
+	// Seconds since the workload was created, used to tell new workloads from stuck ones
+	WorkloadAgeSeconds float64
+
+	// CurrentVersion is a lower semantic version than PreviousVersion
+	IsRollback bool
 
 	// Deployment status
-	DeploymentPhase string // rolling_out, success, failed
+	DeploymentPhase string // rolling_out, success, failed, scaling, deleted, argo-managed
 	StatusMessage   string
 	StatusReason    string
+
+	// Replica counts for scaling events
+	ScaledFrom int32
+	ScaledTo   int32
+
+	// Envelope, set by the CloudEvents publisher, is the encoded body to send in place of the
+	// publisher's own encoding; Labels then hold the headers to send with it
+	Envelope []byte
+
+	// EventID and OccurredAt, set by StampWorkloadUpdate, identify the event built from this
+	// update so every publisher and the replay buffer report the same event
+	EventID    string
+	OccurredAt time.Time
 }
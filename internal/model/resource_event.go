@@ -10,10 +10,12 @@ import (
 type ResourceType string
 
 const (
-	ResourceTypeWorkload ResourceType = "WORKLOAD"
-	ResourceTypeNode     ResourceType = "NODE"
-	ResourceTypePod      ResourceType = "POD"
-	ResourceTypeService  ResourceType = "SERVICE"
+	ResourceTypeWorkload      ResourceType = "WORKLOAD"
+	ResourceTypeNode          ResourceType = "NODE"
+	ResourceTypePod           ResourceType = "POD"
+	ResourceTypeService       ResourceType = "SERVICE"
+	ResourceTypeNamespace     ResourceType = "NAMESPACE"
+	ResourceTypeResourceQuota ResourceType = "RESOURCE_QUOTA"
 )
 
 // ResourceEventKind represents the type of event (lifecycle events)
@@ -24,6 +26,16 @@ const (
 	ResourceEventKindUpdated      ResourceEventKind = "UPDATED"
 	ResourceEventKindDeleted      ResourceEventKind = "DELETED"
 	ResourceEventKindStatusChange ResourceEventKind = "STATUS_CHANGE"
+
+	ResourceEventKindInitContainerFailed ResourceEventKind = "INIT_CONTAINER_FAILED"
+	ResourceEventKindQuotaWarning        ResourceEventKind = "QUOTA_WARNING"
+	ResourceEventKindPendingAlert        ResourceEventKind = "PENDING_ALERT"
+	ResourceEventKindTopologyViolation   ResourceEventKind = "TOPOLOGY_VIOLATION"
+	ResourceEventKindHighRestartCount    ResourceEventKind = "HIGH_RESTART_COUNT"
+	ResourceEventKindDigestChanged       ResourceEventKind = "DIGEST_CHANGED"
+
+	// ResourceEventKindAggregated summarizes events of a namespace that exceeded the aggregation threshold
+	ResourceEventKindAggregated ResourceEventKind = "AGGREGATED"
 )
 
 // ResourceRef identifies a Kubernetes resource
@@ -58,6 +70,14 @@ type NodeMetadata struct {
 	Capacity                map[string]string `json:"capacity,omitempty"`
 	Allocatable             map[string]string `json:"allocatable,omitempty"`
 	Taints                  []NodeTaint       `json:"taints,omitempty"`
+	Addresses               []NodeAddress     `json:"addresses,omitempty"`
+	PodCount                int               `json:"podCount"` // Non-terminated pods scheduled on the node
+}
+
+// NodeAddress represents a reachable address of a node
+type NodeAddress struct {
+	Type    string `json:"type"` // InternalIP, ExternalIP, Hostname
+	Address string `json:"address"`
 }
 
 // NodeTaint represents a taint on a node
@@ -76,6 +96,7 @@ type PodMetadata struct {
 	PodIP          string            `json:"podIP,omitempty"`
 	StartTime      *time.Time        `json:"startTime,omitempty"`
 	RestartCount   int32             `json:"restartCount"`
+	QoSClass       string            `json:"qosClass,omitempty"`
 	Containers     []ContainerStatus `json:"containers,omitempty"`
 	InitContainers []ContainerStatus `json:"initContainers,omitempty"`
 }
@@ -84,11 +105,23 @@ type PodMetadata struct {
 type ContainerStatus struct {
 	Name         string `json:"name"`
 	Image        string `json:"image"`
+	ImageDigest  string `json:"imageDigest,omitempty"` // Digest the running image was pulled by, e.g. sha256:...
 	Ready        bool   `json:"ready"`
 	RestartCount int32  `json:"restartCount"`
 	State        string `json:"state"` // running, waiting, terminated
 	Reason       string `json:"reason,omitempty"`
 	Message      string `json:"message,omitempty"`
+
+	ResourceRequests map[string]string `json:"resourceRequests,omitempty"` // resource name -> quantity
+	ResourceLimits   map[string]string `json:"resourceLimits,omitempty"`   // resource name -> quantity
+}
+
+// WorkloadMetadata contains workload-specific data
+type WorkloadMetadata struct {
+	Version       string `json:"version,omitempty"`
+	Phase         string `json:"phase,omitempty"` // Last rollout phase sent for the workload
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"readyReplicas"`
 }
 
 // ResourceEventPayload is the generic event payload for all resource types
@@ -102,6 +135,23 @@ type ResourceEventPayload struct {
 	EventKind    ResourceEventKind `json:"eventKind"`
 	State        *ResourceState    `json:"state,omitempty"`
 	Metadata     map[string]any    `json:"metadata,omitempty"`
+
+	// SequenceNum increases monotonically per reconciler, ordering events for the same resource
+	SequenceNum uint64 `json:"sequenceNum,omitempty"`
+
+	// BatchID is shared by all events published by the same flush, so consumers can group
+	// them for atomic ingestion. BatchSize and BatchIndex give each event's position in it.
+	// BatchIndex is always encoded since the first event of a batch has index 0.
+	BatchID    string `json:"batchId,omitempty"`
+	BatchSize  int    `json:"batchSize,omitempty"`
+	BatchIndex int    `json:"batchIndex"`
+}
+
+// BatchMetadata describes a published batch of resource events, letting the
+// receiver detect gaps caused by events dropped before publishing
+type BatchMetadata struct {
+	DroppedCount    int       `json:"droppedCount"`
+	OldestDroppedAt time.Time `json:"oldestDroppedAt"`
 }
 
 // NewResourceEventPayload creates a new resource event payload
@@ -116,7 +166,7 @@ func NewResourceEventPayload(
 ) ResourceEventPayload {
 	return ResourceEventPayload{
 		EventID:    uuid.New().String(),
-		OccurredAt: time.Now().UTC(),
+		OccurredAt: eventTime(),
 		Source: SourceMetadata{
 			ClusterID:    clusterID,
 			AgentVersion: agentVersion,
@@ -190,3 +240,33 @@ func NewPodEvent(
 		agentVersion,
 	)
 }
+
+// NewWorkloadEvent is a convenience function for creating workload events
+func NewWorkloadEvent(
+	kind, namespace, name, uid string,
+	labels map[string]string,
+	eventKind ResourceEventKind,
+	workloadMetadata *WorkloadMetadata,
+	clusterID, agentVersion string,
+) ResourceEventPayload {
+	metadata := make(map[string]any)
+	if workloadMetadata != nil {
+		metadata["workload"] = workloadMetadata
+	}
+
+	return NewResourceEventPayload(
+		ResourceTypeWorkload,
+		ResourceRef{
+			Kind:      kind,
+			Name:      name,
+			Namespace: namespace,
+			UID:       uid,
+		},
+		labels,
+		eventKind,
+		nil,
+		metadata,
+		clusterID,
+		agentVersion,
+	)
+}
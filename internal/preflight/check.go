@@ -0,0 +1,112 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/apptrail-sh/agent/internal/reconciler"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// rbacHint tells operators how to grant the permissions the agent needs
+const rbacHint = "apply the RBAC manifests in config/rbac (kubectl apply -k config/rbac)"
+
+// Config selects the resources the configured reconcilers need to read
+type Config struct {
+	TrackNodes           bool
+	TrackPods            bool
+	TrackNamespaces      bool
+	TrackQuotas          bool
+	TrackVirtualMachines bool
+}
+
+// MissingPermission is a resource the agent is not allowed to access
+type MissingPermission struct {
+	Verb     string
+	Resource string
+}
+
+func (m MissingPermission) String() string {
+	return m.Verb + " " + m.Resource
+}
+
+// PermissionError reports every permission the preflight check found missing
+type PermissionError struct {
+	Missing []MissingPermission
+}
+
+func (e *PermissionError) Error() string {
+	missing := make([]string, 0, len(e.Missing))
+	for _, m := range e.Missing {
+		missing = append(missing, m.String())
+	}
+	return "missing RBAC permissions: " + strings.Join(missing, ", ")
+}
+
+// check is a single resource the agent must be able to list
+type check struct {
+	resource string
+	list     client.ObjectList
+}
+
+// Check lists every resource the configured reconcilers watch, so missing RBAC permissions
+// surface at startup instead of silently during the first reconcile. reader must not depend
+// on the manager's cache, which is not started yet; use mgr.GetAPIReader().
+// Forbidden resources are collected into a *PermissionError; other errors are returned as is.
+func Check(ctx context.Context, reader client.Reader, cfg Config) error {
+	logger := ctrl.LoggerFrom(ctx).WithName("preflight")
+
+	checks := []check{
+		{resource: "deployments.apps", list: &appsv1.DeploymentList{}},
+		{resource: "statefulsets.apps", list: &appsv1.StatefulSetList{}},
+		{resource: "daemonsets.apps", list: &appsv1.DaemonSetList{}},
+	}
+	if cfg.TrackVirtualMachines {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(reconciler.VirtualMachineGVK.GroupVersion().WithKind("VirtualMachineList"))
+		checks = append(checks, check{resource: "virtualmachines.kubevirt.io", list: list})
+	}
+	if cfg.TrackNodes {
+		checks = append(checks, check{resource: "nodes", list: &corev1.NodeList{}})
+	}
+	// The node reconciler counts pods per node, so it needs pods as well
+	if cfg.TrackPods || cfg.TrackNodes {
+		checks = append(checks, check{resource: "pods", list: &corev1.PodList{}})
+	}
+	if cfg.TrackNamespaces {
+		checks = append(checks, check{resource: "namespaces", list: &corev1.NamespaceList{}})
+	}
+	if cfg.TrackQuotas {
+		checks = append(checks, check{resource: "resourcequotas", list: &corev1.ResourceQuotaList{}})
+	}
+
+	var missing []MissingPermission
+	var errs []error
+	for _, c := range checks {
+		err := reader.List(ctx, c.list, client.Limit(1))
+		switch {
+		case err == nil:
+		case apierrors.IsForbidden(err):
+			logger.Error(err, "Missing RBAC permission", "verb", "list", "resource", c.resource, "hint", rbacHint)
+			missing = append(missing, MissingPermission{Verb: "list", Resource: c.resource})
+		default:
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", c.resource, err))
+		}
+	}
+
+	if len(missing) > 0 {
+		errs = append(errs, &PermissionError{Missing: missing})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	logger.Info("Preflight check passed", "resources", len(checks))
+	return nil
+}
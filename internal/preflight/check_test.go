@@ -0,0 +1,89 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		cfg             Config
+		forbidden       map[string]bool
+		expectedMissing []string
+	}{
+		{
+			name: "all permissions granted",
+			cfg:  Config{TrackNodes: true, TrackPods: true, TrackNamespaces: true, TrackQuotas: true},
+		},
+		{
+			name:            "missing nodes and deployments",
+			cfg:             Config{TrackNodes: true},
+			forbidden:       map[string]bool{"NodeList": true, "DeploymentList": true},
+			expectedMissing: []string{"list deployments.apps", "list nodes"},
+		},
+		{
+			name:      "disabled reconcilers are not checked",
+			cfg:       Config{},
+			forbidden: map[string]bool{"NodeList": true, "PodList": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+				WithInterceptorFuncs(interceptor.Funcs{
+					List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+						gvks, _, err := scheme.ObjectKinds(list)
+						if err != nil {
+							return err
+						}
+						if tt.forbidden[gvks[0].Kind] {
+							return apierrors.NewForbidden(schema.GroupResource{Resource: gvks[0].Kind}, "", errors.New("denied"))
+						}
+						return c.List(ctx, list, opts...)
+					},
+				}).
+				Build()
+
+			err := Check(context.Background(), k8sClient, tt.cfg)
+			if len(tt.expectedMissing) == 0 {
+				if err != nil {
+					t.Fatalf("Expected preflight to pass, got %v", err)
+				}
+				return
+			}
+
+			var permErr *PermissionError
+			if !errors.As(err, &permErr) {
+				t.Fatalf("Expected a PermissionError, got %v", err)
+			}
+			if len(permErr.Missing) != len(tt.expectedMissing) {
+				t.Fatalf("Expected %d missing permissions, got %v", len(tt.expectedMissing), permErr.Missing)
+			}
+			for i, missing := range permErr.Missing {
+				if missing.String() != tt.expectedMissing[i] {
+					t.Errorf("Expected missing permission %q, got %q", tt.expectedMissing[i], missing)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	apptrailv1alpha1 "github.com/apptrail-sh/agent/api/v1alpha1"
+	"github.com/apptrail-sh/agent/internal/filter"
+)
+
+// AgentConfigReconciler applies the filter settings of an AppTrailAgentConfig to the running
+// reconcilers. In-flight reconciles finish with the filter they loaded; the next ones use the new one.
+type AgentConfigReconciler struct {
+	client.Client
+	name     string
+	reloader *filter.Reloader
+}
+
+// NewAgentConfigReconciler creates a reconciler for the AppTrailAgentConfig with the given name
+func NewAgentConfigReconciler(client client.Client, name string, reloader *filter.Reloader) *AgentConfigReconciler {
+	return &AgentConfigReconciler{
+		Client:   client,
+		name:     name,
+		reloader: reloader,
+	}
+}
+
+// +kubebuilder:rbac:groups=apptrail.apptrail.sh,resources=apptrailagentconfigs,verbs=get;list;watch
+
+func (r *AgentConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	agentConfig := &apptrailv1alpha1.AppTrailAgentConfig{}
+	if err := r.Get(ctx, req.NamespacedName, agentConfig); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("Agent config not found, using filter flags and config file")
+			r.reloader.SetOverride(ctx, filter.FileConfig{})
+			return ctrl.Result{}, nil
+		}
+		return HandleReconcileError(ctx, NewReconcileError("get", req.String(), err))
+	}
+
+	log.Info("Applying agent config", "generation", agentConfig.Generation)
+	r.reloader.SetOverride(ctx, agentFilterConfig(agentConfig.Spec.Filter))
+	return ctrl.Result{}, nil
+}
+
+// Preload applies the AppTrailAgentConfig read from reader before the manager starts, so the
+// filters built for the first reconciles already include its settings. A missing object leaves
+// the flags and config file in effect.
+func (r *AgentConfigReconciler) Preload(ctx context.Context, reader client.Reader) error {
+	agentConfig := &apptrailv1alpha1.AppTrailAgentConfig{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: r.name}, agentConfig); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read agent config %s: %w", r.name, err)
+	}
+	r.reloader.SetOverride(ctx, agentFilterConfig(agentConfig.Spec.Filter))
+	return nil
+}
+
+// agentFilterConfig converts the CRD filter settings to the reloader's config layer
+func agentFilterConfig(spec apptrailv1alpha1.AgentFilterSpec) filter.FileConfig {
+	return filter.FileConfig{
+		WatchNamespaces:        spec.WatchNamespaces,
+		ExcludeNamespaces:      spec.ExcludeNamespaces,
+		InvertNamespaceFilter:  spec.InvertNamespaceFilter,
+		WatchNamespaceLabels:   spec.WatchNamespaceLabels,
+		ExcludeNamespaceLabels: spec.ExcludeNamespaceLabels,
+		RequireLabels:          spec.RequireLabels,
+		ExcludeLabels:          spec.ExcludeLabels,
+		DryRun:                 spec.DryRun,
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager. It runs on every replica so
+// standbys hold the current filters when elected.
+func (r *AgentConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apptrailv1alpha1.AppTrailAgentConfig{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			return object.GetName() == r.name
+		})).
+		WithOptions(controller.Options{
+			NeedLeaderElection: ptr.To(false),
+		}).
+		Complete(r)
+}
@@ -0,0 +1,99 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	apptrailv1alpha1 "github.com/apptrail-sh/agent/api/v1alpha1"
+	"github.com/apptrail-sh/agent/internal/filter"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAgentConfigReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add scheme: %v", err)
+	}
+
+	agentConfig := &apptrailv1alpha1.AppTrailAgentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: apptrailv1alpha1.AppTrailAgentConfigSpec{
+			Filter: apptrailv1alpha1.AgentFilterSpec{
+				ExcludeNamespaces: []string{"staging"},
+				DryRun:            ptr.To(false),
+			},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(agentConfig).Build()
+
+	reloader := filter.NewReloader("")
+	var current *filter.ResourceFilter
+	base := filter.ResourceFilterConfig{ExcludeNamespaces: filter.DefaultExcludedNamespaces()}
+	current, err := reloader.NewFilter("test", base, func(f *filter.ResourceFilter) { current = f })
+	if err != nil {
+		t.Fatalf("NewFilter() error = %v", err)
+	}
+
+	r := NewAgentConfigReconciler(k8sClient, "default", reloader)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "default"}}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if current.ShouldWatchNamespace("staging") {
+		t.Error("Expected staging to be excluded by the agent config")
+	}
+	if !current.ShouldWatchNamespace("kube-system") {
+		t.Error("Expected agent config exclusions to replace the flag exclusions")
+	}
+
+	// Deleting the config reverts to the flag settings
+	if err := k8sClient.Delete(context.Background(), agentConfig); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if !current.ShouldWatchNamespace("staging") {
+		t.Error("Expected staging to be watched after the agent config was deleted")
+	}
+	if current.ShouldWatchNamespace("kube-system") {
+		t.Error("Expected flag exclusions to apply after the agent config was deleted")
+	}
+}
+
+func TestAgentConfigReconciler_Preload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to add scheme: %v", err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&apptrailv1alpha1.AppTrailAgentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: apptrailv1alpha1.AppTrailAgentConfigSpec{
+			Filter: apptrailv1alpha1.AgentFilterSpec{ExcludeNamespaces: []string{"staging"}},
+		},
+	}).Build()
+
+	// Filters built after the preload start with the override
+	reloader := filter.NewReloader("")
+	if err := NewAgentConfigReconciler(k8sClient, "default", reloader).Preload(context.Background(), k8sClient); err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+	resourceFilter, err := reloader.NewFilter("test", filter.ResourceFilterConfig{}, func(*filter.ResourceFilter) {})
+	if err != nil {
+		t.Fatalf("NewFilter() error = %v", err)
+	}
+	if resourceFilter.ShouldWatchNamespace("staging") {
+		t.Error("Expected the initial filter to exclude staging")
+	}
+
+	// A missing object keeps the flags
+	if err := NewAgentConfigReconciler(k8sClient, "missing", filter.NewReloader("")).Preload(context.Background(), k8sClient); err != nil {
+		t.Errorf("Expected no error for a missing agent config, got %v", err)
+	}
+}
@@ -2,17 +2,15 @@ package reconciler
 
 import (
 	"context"
-	"time"
 
 	v1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/apptrail-sh/agent/internal/filter"
 	"github.com/apptrail-sh/agent/internal/model"
@@ -24,8 +22,10 @@ type DaemonSetReconciler struct {
 }
 
 func NewDaemonSetReconciler(client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, publisherChan chan<- model.WorkloadUpdate, controllerNamespace string, resourceFilter *filter.ResourceFilter) *DaemonSetReconciler {
+	wr := NewWorkloadReconciler(client, scheme, recorder, publisherChan, controllerNamespace, resourceFilter)
+	wr.kind = "DaemonSet"
 	return &DaemonSetReconciler{
-		WorkloadReconciler: NewWorkloadReconciler(client, scheme, recorder, publisherChan, controllerNamespace, resourceFilter),
+		WorkloadReconciler: wr,
 	}
 }
 
@@ -34,17 +34,22 @@ func NewDaemonSetReconciler(client client.Client, scheme *runtime.Scheme, record
 // +kubebuilder:rbac:groups=apptrail.apptrail.sh,resources=workloadrolloutstates,verbs=get;list;watch;create;update;patch;delete
 
 func (dsr *DaemonSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return ReconcileMetricsMiddleware(ctx, "DaemonSet", req, dsr.reconcile)
+}
+
+func (dsr *DaemonSetReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	log.Info("Reconciling DaemonSet")
 
 	resource := &v1.DaemonSet{}
 	if err := dsr.Get(ctx, req.NamespacedName, resource); err != nil {
 		if apierrors.IsNotFound(err) {
+			setReconcileOutcome(ctx, ReconcileOutcomeNotFound)
 			// DaemonSet was deleted, clean up state
 			_ = dsr.HandleDeletion(ctx, req.Namespace, req.Name, "DaemonSet")
 			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, err
+		return HandleReconcileError(ctx, NewReconcileError("get", req.String(), err))
 	}
 	log.Info("DaemonSet found", "DaemonSet", resource)
 
@@ -59,13 +64,14 @@ func (dsr *DaemonSetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 func (dsr *DaemonSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1.DaemonSet{}).
-		WithEventFilter(DaemonSetStatusChangedPredicate()).
+		WithEventFilter(predicate.Or(
+			DaemonSetStatusChangedPredicate(),
+			VersionLabelsChangedPredicate(dsr.VersionLabels),
+		)).
+		WatchesRawSource(dsr.ReplaySource()).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 5,
-			RateLimiter: workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](
-				200*time.Millisecond,
-				10*time.Minute,
-			),
+			RateLimiter:             dsr.rateLimiter(),
 		}).
 		Complete(dsr)
 }
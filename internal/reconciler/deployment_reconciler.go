@@ -2,17 +2,16 @@ package reconciler
 
 import (
 	"context"
-	"time"
+	"sync"
 
 	v1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/apptrail-sh/agent/internal/filter"
 	"github.com/apptrail-sh/agent/internal/model"
@@ -21,11 +20,17 @@ import (
 // DeploymentReconciler reconciles Deployment objects
 type DeploymentReconciler struct {
 	*WorkloadReconciler
+
+	replicasMu      sync.Mutex
+	desiredReplicas map[string]int32 // Last seen spec.replicas per deployment
 }
 
 func NewDeploymentReconciler(client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, publisherChan chan<- model.WorkloadUpdate, controllerNamespace string, resourceFilter *filter.ResourceFilter) *DeploymentReconciler {
+	wr := NewWorkloadReconciler(client, scheme, recorder, publisherChan, controllerNamespace, resourceFilter)
+	wr.kind = "Deployment"
 	return &DeploymentReconciler{
-		WorkloadReconciler: NewWorkloadReconciler(client, scheme, recorder, publisherChan, controllerNamespace, resourceFilter),
+		WorkloadReconciler: wr,
+		desiredReplicas:    make(map[string]int32),
 	}
 }
 
@@ -34,38 +39,115 @@ func NewDeploymentReconciler(client client.Client, scheme *runtime.Scheme, recor
 // +kubebuilder:rbac:groups=apptrail.apptrail.sh,resources=workloadrolloutstates,verbs=get;list;watch;create;update;patch;delete
 
 func (dr *DeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return ReconcileMetricsMiddleware(ctx, "Deployment", req, dr.reconcile)
+}
+
+func (dr *DeploymentReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	log.Info("Reconciling Deployment")
 
 	resource := &v1.Deployment{}
 	if err := dr.Get(ctx, req.NamespacedName, resource); err != nil {
 		if apierrors.IsNotFound(err) {
+			setReconcileOutcome(ctx, ReconcileOutcomeNotFound)
 			// Deployment was deleted, clean up state
+			dr.replicasMu.Lock()
+			delete(dr.desiredReplicas, req.Namespace+"/"+req.Name)
+			dr.replicasMu.Unlock()
 			_ = dr.HandleDeletion(ctx, req.Namespace, req.Name, "Deployment")
 			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, err
+		return HandleReconcileError(ctx, NewReconcileError("get", req.String(), err))
 	}
 	log.Info("Deployment found", "Deployment", resource)
 
 	// Wrap the Deployment in an adapter
 	adapter := &DeploymentAdapter{Deployment: resource}
 
+	// Detect scaling before the shared logic records a new version
+	dr.detectScaleEvent(ctx, adapter)
+
 	// Use the shared reconciliation logic
 	return dr.ReconcileWorkload(ctx, req, adapter)
 }
 
+// detectScaleEvent publishes a scaling event when spec.replicas changes without a version change
+func (dr *DeploymentReconciler) detectScaleEvent(ctx context.Context, adapter *DeploymentAdapter) {
+	log := ctrl.LoggerFrom(ctx)
+
+	if adapter.Deployment.Spec.Replicas == nil || dr.workloadVersion(adapter) == "" {
+		return
+	}
+	if resourceFilter := dr.filter.Load(); resourceFilter != nil && !resourceFilter.ShouldWatchNamespace(adapter.GetNamespace()) {
+		return
+	}
+
+	key := adapter.GetNamespace() + "/" + adapter.GetName()
+	desired := *adapter.Deployment.Spec.Replicas
+
+	dr.replicasMu.Lock()
+	previous, seen := dr.desiredReplicas[key]
+	dr.desiredReplicas[key] = desired
+	dr.replicasMu.Unlock()
+
+	if !seen || previous == desired {
+		return
+	}
+
+	appkey := key + "/" + adapter.GetKind()
+	dr.mu.RLock()
+	stored := dr.workloadVersions[appkey]
+	dr.mu.RUnlock()
+	if stored.CurrentVersion != dr.workloadVersion(adapter) {
+		// Version changed too, the rollout event covers it
+		return
+	}
+
+	direction := "up"
+	if desired < previous {
+		direction = "down"
+	}
+	scaleEventsCounter.WithLabelValues(adapter.GetNamespace(), adapter.GetKind(), direction).Inc()
+
+	dr.publisherChan <- model.WorkloadUpdate{
+		Name:            adapter.GetName(),
+		Namespace:       adapter.GetNamespace(),
+		Kind:            adapter.GetKind(),
+		PreviousVersion: stored.PreviousVersion,
+		CurrentVersion:  stored.CurrentVersion,
+		Labels:          adapter.GetLabels(),
+		Annotations:     dr.passthroughAnnotations(adapter),
+		SourceURL:       adapter.GetAnnotations()[sourceURLAnnotation],
+		CIRunURL:        adapter.GetAnnotations()[ciRunURLAnnotation],
+		GitOps:          extractGitOpsRef(gitOpsMarkers(adapter)),
+
+		WorkloadAgeSeconds: workloadAgeSeconds(adapter),
+
+		DeploymentPhase: phaseScaling,
+		ScaledFrom:      previous,
+		ScaledTo:        desired,
+	}
+
+	log.Info("Workload scaled",
+		"kind", adapter.GetKind(),
+		"workload", adapter.GetName(),
+		"from", previous,
+		"to", desired)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (dr *DeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1.Deployment{}).
-		WithEventFilter(DeploymentStatusChangedPredicate()).
+		WithEventFilter(predicate.Or(
+			DeploymentStatusChangedPredicate(),
+			VersionLabelsChangedPredicate(dr.VersionLabels),
+			AnnotationChangedPredicate("apptrail.sh/"),
+		)).
+		WatchesRawSource(dr.ReplaySource()).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 5,
-			RateLimiter: workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](
-				200*time.Millisecond,
-				10*time.Minute,
-			),
+			RateLimiter:             dr.rateLimiter(),
 		}).
 		Complete(dr)
 }
@@ -0,0 +1,80 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentReconciler_DetectScaleEvent(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+
+	tests := []struct {
+		name          string
+		seenReplicas  *int32 // nil when the deployment was never seen
+		replicas      *int32
+		storedVersion string
+		version       string
+		wantDirection string // empty when no scale event is expected
+	}{
+		{name: "scale up", seenReplicas: int32Ptr(2), replicas: int32Ptr(5), storedVersion: "1.0.0", version: "1.0.0", wantDirection: "up"},
+		{name: "scale down", seenReplicas: int32Ptr(5), replicas: int32Ptr(1), storedVersion: "1.0.0", version: "1.0.0", wantDirection: "down"},
+		{name: "scale to zero", seenReplicas: int32Ptr(3), replicas: int32Ptr(0), storedVersion: "1.0.0", version: "1.0.0", wantDirection: "down"},
+		{name: "replicas unchanged", seenReplicas: int32Ptr(3), replicas: int32Ptr(3), storedVersion: "1.0.0", version: "1.0.0"},
+		{name: "first sighting", replicas: int32Ptr(3), storedVersion: "1.0.0", version: "1.0.0"},
+		{name: "version changed too", seenReplicas: int32Ptr(2), replicas: int32Ptr(4), storedVersion: "1.0.0", version: "1.1.0"},
+		{name: "no version", seenReplicas: int32Ptr(2), replicas: int32Ptr(4)},
+		{name: "replicas unset", seenReplicas: int32Ptr(2), storedVersion: "1.0.0", version: "1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates := make(chan model.WorkloadUpdate, 1)
+			dr := NewDeploymentReconciler(nil, nil, nil, updates, "apptrail-system", nil)
+			if tt.seenReplicas != nil {
+				dr.desiredReplicas["default/api"] = *tt.seenReplicas
+			}
+			dr.workloadVersions["default/api/Deployment"] = AppVersion{PreviousVersion: "0.9.0", CurrentVersion: tt.storedVersion}
+
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: tt.replicas},
+			}
+			if tt.version != "" {
+				deployment.Labels = map[string]string{"app.kubernetes.io/version": tt.version}
+			}
+
+			var before float64
+			if tt.wantDirection != "" {
+				before = testutil.ToFloat64(scaleEventsCounter.WithLabelValues("default", "Deployment", tt.wantDirection))
+			}
+			dr.detectScaleEvent(context.Background(), &DeploymentAdapter{Deployment: deployment})
+
+			if tt.wantDirection == "" {
+				if len(updates) != 0 {
+					t.Fatalf("Expected no scale event, got %+v", <-updates)
+				}
+				return
+			}
+			if len(updates) != 1 {
+				t.Fatal("Expected a scale event")
+			}
+			update := <-updates
+			if update.DeploymentPhase != phaseScaling || update.ScaledFrom != *tt.seenReplicas || update.ScaledTo != *tt.replicas {
+				t.Errorf("Expected scaling from %d to %d, got %s from %d to %d",
+					*tt.seenReplicas, *tt.replicas, update.DeploymentPhase, update.ScaledFrom, update.ScaledTo)
+			}
+			if update.CurrentVersion != tt.storedVersion || update.PreviousVersion != "0.9.0" {
+				t.Errorf("Expected the stored versions, got %q -> %q", update.PreviousVersion, update.CurrentVersion)
+			}
+			after := testutil.ToFloat64(scaleEventsCounter.WithLabelValues("default", "Deployment", tt.wantDirection))
+			if after-before != 1 {
+				t.Errorf("Expected the %s counter to increase by 1, got %v", tt.wantDirection, after-before)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var reconcileErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "apptrail_reconcile_errors_total",
+	Help: "Number of failed reconcile operations, by whether they are retried",
+}, []string{
+	"retryable",
+})
+
+// ReconcileError describes a failed reconcile operation and whether retrying it can help
+type ReconcileError struct {
+	Op        string // Operation that failed, e.g. "get"
+	Resource  string // namespace/name of the reconciled resource
+	Err       error
+	Retryable bool
+}
+
+func (e *ReconcileError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Resource, e.Err)
+}
+
+func (e *ReconcileError) Unwrap() error {
+	return e.Err
+}
+
+// NewReconcileError wraps err, classifying Kubernetes API errors that won't change on retry
+// (not found, invalid, forbidden, bad request) as non-retryable
+func NewReconcileError(op, resource string, err error) *ReconcileError {
+	retryable := !(apierrors.IsNotFound(err) ||
+		apierrors.IsInvalid(err) ||
+		apierrors.IsForbidden(err) ||
+		apierrors.IsBadRequest(err) ||
+		apierrors.IsMethodNotSupported(err))
+	return &ReconcileError{Op: op, Resource: resource, Err: err, Retryable: retryable}
+}
+
+// IsRetryable reports whether err should be returned to controller-runtime for backoff.
+// Errors that are not a ReconcileError are treated as retryable.
+func IsRetryable(err error) bool {
+	var reconcileErr *ReconcileError
+	if errors.As(err, &reconcileErr) {
+		return reconcileErr.Retryable
+	}
+	return true
+}
+
+// HandleReconcileError records err and converts it into the reconcile result.
+// Retryable errors are returned so controller-runtime requeues with backoff;
+// non-retryable errors are logged and dropped to stop requeueing.
+func HandleReconcileError(ctx context.Context, err error) (ctrl.Result, error) {
+	retryable := IsRetryable(err)
+	setReconcileOutcome(ctx, ReconcileOutcomeError)
+	reconcileErrorsCounter.WithLabelValues(strconv.FormatBool(retryable)).Inc()
+
+	if retryable {
+		return ctrl.Result{}, err
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Reconcile failed, not retrying", "error", err.Error())
+	return ctrl.Result{}, nil
+}
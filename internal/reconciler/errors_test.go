@@ -0,0 +1,82 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNewReconcileError_Retryable(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"server timeout", apierrors.NewServerTimeout(gr, "get", 1), true},
+		{"too many requests", apierrors.NewTooManyRequests("slow down", 1), true},
+		{"internal error", apierrors.NewInternalError(errors.New("boom")), true},
+		{"unclassified error", errors.New("connection reset"), true},
+		{"not found", apierrors.NewNotFound(gr, "api"), false},
+		{"forbidden", apierrors.NewForbidden(gr, "api", errors.New("rbac")), false},
+		{"bad request", apierrors.NewBadRequest("bad"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewReconcileError("get", "default/api", tt.err)
+			if err.Retryable != tt.retryable {
+				t.Errorf("Retryable = %v, want %v", err.Retryable, tt.retryable)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("Expected ReconcileError to unwrap to the original error")
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	nonRetryable := &ReconcileError{Op: "get", Resource: "default/api", Err: errors.New("forbidden")}
+
+	if IsRetryable(nonRetryable) {
+		t.Error("Expected non-retryable ReconcileError")
+	}
+	if IsRetryable(fmt.Errorf("wrapped: %w", nonRetryable)) {
+		t.Error("Expected wrapped non-retryable ReconcileError to stay non-retryable")
+	}
+	if !IsRetryable(errors.New("plain error")) {
+		t.Error("Expected plain errors to be retryable")
+	}
+}
+
+func TestHandleReconcileError(t *testing.T) {
+	retryable := &ReconcileError{Op: "get", Resource: "default/api", Err: errors.New("timeout"), Retryable: true}
+	nonRetryable := &ReconcileError{Op: "get", Resource: "default/api", Err: errors.New("forbidden")}
+
+	beforeRetryable := testutil.ToFloat64(reconcileErrorsCounter.WithLabelValues("true"))
+	beforeNonRetryable := testutil.ToFloat64(reconcileErrorsCounter.WithLabelValues("false"))
+
+	if _, err := HandleReconcileError(context.Background(), retryable); !errors.Is(err, retryable) {
+		t.Errorf("Expected retryable error to be returned for backoff, got %v", err)
+	}
+	result, err := HandleReconcileError(context.Background(), nonRetryable)
+	if err != nil {
+		t.Errorf("Expected non-retryable error to be dropped, got %v", err)
+	}
+	if result.RequeueAfter != 0 {
+		t.Errorf("Expected no requeue, got %v", result.RequeueAfter)
+	}
+
+	if got := testutil.ToFloat64(reconcileErrorsCounter.WithLabelValues("true")) - beforeRetryable; got != 1 {
+		t.Errorf("Expected 1 retryable error recorded, got %v", got)
+	}
+	if got := testutil.ToFloat64(reconcileErrorsCounter.WithLabelValues("false")) - beforeNonRetryable; got != 1 {
+		t.Errorf("Expected 1 non-retryable error recorded, got %v", got)
+	}
+}
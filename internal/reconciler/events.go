@@ -0,0 +1,62 @@
+package reconciler
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons of the Kubernetes Events recorded on workloads. The AppTrail prefix sets them apart
+// from the built-in controllers' reasons, e.g. for kubectl get events --field-selector reason=...
+const (
+	ReasonRolloutStarted   = "AppTrailRolloutStarted"
+	ReasonRolloutSucceeded = "AppTrailRolloutSucceeded"
+	ReasonRolloutFailed    = "AppTrailRolloutFailed"
+	ReasonRolloutTimedOut  = "AppTrailRolloutTimedOut"
+	ReasonVersionChanged   = "AppTrailVersionChanged"
+)
+
+// MessageTemplates render the Event message of each reason from the workload update it belongs to
+var MessageTemplates = map[string]*template.Template{
+	ReasonRolloutStarted:   newMessageTemplate(ReasonRolloutStarted, "{{.Kind}} rollout started (version {{.CurrentVersion}})"),
+	ReasonRolloutSucceeded: newMessageTemplate(ReasonRolloutSucceeded, "{{.Kind}} rollout succeeded (version {{.CurrentVersion}})"),
+	ReasonRolloutFailed:    newMessageTemplate(ReasonRolloutFailed, "{{.Kind}} rollout failed (version {{.CurrentVersion}})"),
+	ReasonRolloutTimedOut: newMessageTemplate(ReasonRolloutTimedOut,
+		"{{.Kind}} rollout exceeded the rollout timeout (version {{.CurrentVersion}})"),
+	ReasonVersionChanged: newMessageTemplate(ReasonVersionChanged,
+		"{{.Kind}} version changed from {{.PreviousVersion}} to {{.CurrentVersion}}"),
+}
+
+func newMessageTemplate(reason, text string) *template.Template {
+	return template.Must(template.New(reason).Option("missingkey=zero").Parse(text))
+}
+
+// eventTypes maps reasons that report a problem to the Warning event type
+var eventTypes = map[string]string{
+	ReasonRolloutFailed:   corev1.EventTypeWarning,
+	ReasonRolloutTimedOut: corev1.EventTypeWarning,
+}
+
+// recordEvent records a Kubernetes Event with reason on the workload, its message rendered from
+// the reason's template. It is a no-op without a recorder.
+func (wr *WorkloadReconciler) recordEvent(workload WorkloadAdapter, reason string, update model.WorkloadUpdate) {
+	if wr.Recorder == nil {
+		return
+	}
+
+	message := reason
+	if tmpl, ok := MessageTemplates[reason]; ok {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, update); err == nil {
+			message = sb.String()
+		}
+	}
+
+	eventType, ok := eventTypes[reason]
+	if !ok {
+		eventType = corev1.EventTypeNormal
+	}
+	wr.Recorder.Event(workload.GetObject(), eventType, reason, message)
+}
@@ -0,0 +1,66 @@
+package reconciler
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+// Markers GitOps tools leave on the resources they apply. Flux records its owner as labels,
+// ArgoCD as an annotation, so both are read.
+const (
+	fluxKustomizationName      = "kustomize.toolkit.fluxcd.io/name"
+	fluxKustomizationNamespace = "kustomize.toolkit.fluxcd.io/namespace"
+	fluxHelmReleaseName        = "helm.toolkit.fluxcd.io/name"
+	fluxHelmReleaseNamespace   = "helm.toolkit.fluxcd.io/namespace"
+
+	// Value is <app>:<group>/<kind>:<namespace>/<name>, where <app> is <namespace>_<name>
+	// for applications outside the ArgoCD namespace
+	argoCDTrackingID = "argocd.argoproj.io/tracking-id"
+	argoCDInstance   = "argocd.argoproj.io/instance"
+)
+
+// gitOpsMarkers returns the workload's labels overlaid with its annotations
+func gitOpsMarkers(workload WorkloadResourceAdapter) map[string]string {
+	markers := maps.Clone(workload.GetLabels())
+	if markers == nil {
+		markers = make(map[string]string)
+	}
+	maps.Copy(markers, workload.GetAnnotations())
+	return markers
+}
+
+// extractGitOpsRef identifies the Flux or ArgoCD application that applied a workload, or
+// returns nil when it was not deployed by either
+func extractGitOpsRef(annotations map[string]string) *model.GitOpsRef {
+	if name := annotations[fluxHelmReleaseName]; name != "" {
+		return &model.GitOpsRef{
+			Tool:      model.GitOpsToolFlux,
+			AppName:   name,
+			Namespace: annotations[fluxHelmReleaseNamespace],
+			Source:    "HelmRelease",
+		}
+	}
+	if name := annotations[fluxKustomizationName]; name != "" {
+		return &model.GitOpsRef{
+			Tool:      model.GitOpsToolFlux,
+			AppName:   name,
+			Namespace: annotations[fluxKustomizationNamespace],
+			Source:    "Kustomization",
+		}
+	}
+
+	app := annotations[argoCDInstance]
+	if trackingID := annotations[argoCDTrackingID]; trackingID != "" {
+		app, _, _ = strings.Cut(trackingID, ":")
+	}
+	if app == "" {
+		return nil
+	}
+	ref := &model.GitOpsRef{Tool: model.GitOpsToolArgoCD, AppName: app, Source: "Application"}
+	if namespace, name, ok := strings.Cut(app, "_"); ok {
+		ref.Namespace, ref.AppName = namespace, name
+	}
+	return ref
+}
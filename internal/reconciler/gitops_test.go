@@ -0,0 +1,61 @@
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+func TestExtractGitOpsRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		markers map[string]string
+		want    *model.GitOpsRef
+	}{
+		{
+			name:    "not managed by gitops",
+			markers: map[string]string{"app": "api"},
+			want:    nil,
+		},
+		{
+			name: "flux kustomization",
+			markers: map[string]string{
+				"kustomize.toolkit.fluxcd.io/name":      "apps",
+				"kustomize.toolkit.fluxcd.io/namespace": "flux-system",
+			},
+			want: &model.GitOpsRef{Tool: "flux", AppName: "apps", Namespace: "flux-system", Source: "Kustomization"},
+		},
+		{
+			name: "flux helm release",
+			markers: map[string]string{
+				"helm.toolkit.fluxcd.io/name":      "api",
+				"helm.toolkit.fluxcd.io/namespace": "apps",
+			},
+			want: &model.GitOpsRef{Tool: "flux", AppName: "api", Namespace: "apps", Source: "HelmRelease"},
+		},
+		{
+			name:    "argocd tracking id",
+			markers: map[string]string{"argocd.argoproj.io/tracking-id": "api:apps/Deployment:default/api"},
+			want:    &model.GitOpsRef{Tool: "argocd", AppName: "api", Source: "Application"},
+		},
+		{
+			name:    "argocd application outside the argocd namespace",
+			markers: map[string]string{"argocd.argoproj.io/tracking-id": "team-a_api:apps/Deployment:default/api"},
+			want:    &model.GitOpsRef{Tool: "argocd", AppName: "api", Namespace: "team-a", Source: "Application"},
+		},
+		{
+			name:    "argocd instance label",
+			markers: map[string]string{"argocd.argoproj.io/instance": "api"},
+			want:    &model.GitOpsRef{Tool: "argocd", AppName: "api", Source: "Application"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractGitOpsRef(tt.markers)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("extractGitOpsRef() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
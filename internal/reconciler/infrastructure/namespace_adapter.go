@@ -0,0 +1,68 @@
+package infrastructure
+
+import (
+	"github.com/apptrail-sh/agent/internal/model"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NamespaceAdapter wraps a Namespace to implement InfrastructureResourceAdapter
+type NamespaceAdapter struct {
+	Namespace *corev1.Namespace
+}
+
+func NewNamespaceAdapter(namespace *corev1.Namespace) *NamespaceAdapter {
+	return &NamespaceAdapter{Namespace: namespace}
+}
+
+func (n *NamespaceAdapter) GetName() string {
+	return n.Namespace.Name
+}
+
+func (n *NamespaceAdapter) GetNamespace() string {
+	return "" // Namespaces are cluster-scoped
+}
+
+func (n *NamespaceAdapter) GetKind() string {
+	return "Namespace"
+}
+
+func (n *NamespaceAdapter) GetUID() string {
+	return string(n.Namespace.UID)
+}
+
+func (n *NamespaceAdapter) GetLabels() map[string]string {
+	return n.Namespace.Labels
+}
+
+func (n *NamespaceAdapter) GetResourceType() model.ResourceType {
+	return model.ResourceTypeNamespace
+}
+
+func (n *NamespaceAdapter) GetState() *model.ResourceState {
+	conditions := make([]model.Condition, 0, len(n.Namespace.Status.Conditions))
+	for _, c := range n.Namespace.Status.Conditions {
+		conditions = append(conditions, model.Condition{
+			Type:    string(c.Type),
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+		})
+	}
+
+	return &model.ResourceState{
+		Phase:      string(n.GetPhase()),
+		Conditions: conditions,
+	}
+}
+
+func (n *NamespaceAdapter) GetMetadata() map[string]any {
+	return map[string]any{}
+}
+
+// GetPhase returns the namespace phase, defaulting to Active when unset
+func (n *NamespaceAdapter) GetPhase() corev1.NamespacePhase {
+	if n.Namespace.Status.Phase == "" {
+		return corev1.NamespaceActive
+	}
+	return n.Namespace.Status.Phase
+}
@@ -0,0 +1,198 @@
+package infrastructure
+
+import (
+	"context"
+	"maps"
+	"sync/atomic"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/apptrail-sh/agent/internal/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NamespaceReconciler reconciles Namespace objects to track team onboarding and teardown
+type NamespaceReconciler struct {
+	client.Client
+	Scheme       *runtime.Scheme
+	Recorder     record.EventRecorder
+	eventChan    chan<- model.ResourceEventPayload
+	clusterID    string
+	agentVersion string
+	filter       atomic.Pointer[ResourceFilter] // Swapped on config reload
+
+	// Track last known state to detect changes
+	namespaceStates map[string]namespaceState
+
+	// sequence numbers emitted events so same-namespace events keep their order in a batch
+	sequence atomic.Uint64
+
+	// DeletionChan, when set, receives deletion events instead of the event channel
+	DeletionChan chan<- model.ResourceEventPayload
+}
+
+type namespaceState struct {
+	phase  corev1.NamespacePhase
+	labels map[string]string
+	uid    string // Reported with the deletion event, when the namespace can no longer be read
+}
+
+func NewNamespaceReconciler(
+	client client.Client,
+	scheme *runtime.Scheme,
+	recorder record.EventRecorder,
+	eventChan chan<- model.ResourceEventPayload,
+	clusterID, agentVersion string,
+	filter *ResourceFilter,
+) *NamespaceReconciler {
+	r := &NamespaceReconciler{
+		Client:          client,
+		Scheme:          scheme,
+		Recorder:        recorder,
+		eventChan:       eventChan,
+		clusterID:       clusterID,
+		agentVersion:    agentVersion,
+		namespaceStates: make(map[string]namespaceState),
+	}
+	r.filter.Store(filter)
+	return r
+}
+
+// SetFilter replaces the resource filter used by subsequent reconciles
+func (r *NamespaceReconciler) SetFilter(filter *ResourceFilter) {
+	r.filter.Store(filter)
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// Skip excluded namespaces
+	if filter := r.filter.Load(); filter != nil && !filter.ShouldWatchNamespace(req.Name) {
+		return ctrl.Result{}, nil
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, req.NamespacedName, namespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Namespace was deleted
+			r.handleDeletion(ctx, req.Name)
+			return ctrl.Result{}, nil
+		}
+		return reconciler.HandleReconcileError(ctx, reconciler.NewReconcileError("get", req.String(), err))
+	}
+
+	adapter := NewNamespaceAdapter(namespace)
+	log.V(1).Info("Reconciling Namespace", "name", req.Name, "phase", adapter.GetPhase())
+
+	r.reconcileNamespace(ctx, adapter)
+
+	return ctrl.Result{}, nil
+}
+
+func (r *NamespaceReconciler) reconcileNamespace(ctx context.Context, adapter *NamespaceAdapter) {
+	log := ctrl.LoggerFrom(ctx)
+	name := adapter.GetName()
+
+	currentState := namespaceState{
+		phase:  adapter.GetPhase(),
+		labels: maps.Clone(adapter.GetLabels()),
+		uid:    adapter.GetUID(),
+	}
+
+	// Check if this is a new namespace or state changed
+	lastState, exists := r.namespaceStates[name]
+	if !exists {
+		r.publishEvent(adapter, model.ResourceEventKindCreated)
+		r.namespaceStates[name] = currentState
+		log.Info("Namespace created", "namespace", name)
+		return
+	}
+
+	switch {
+	case lastState.phase != currentState.phase:
+		// Phase changes, most importantly Active -> Terminating
+		r.publishEvent(adapter, model.ResourceEventKindStatusChange)
+		log.Info("Namespace phase changed",
+			"namespace", name,
+			"previousPhase", lastState.phase,
+			"phase", currentState.phase,
+		)
+	case !maps.Equal(lastState.labels, currentState.labels):
+		r.publishEvent(adapter, model.ResourceEventKindUpdated)
+		log.Info("Namespace labels changed", "namespace", name)
+	default:
+		return
+	}
+	r.namespaceStates[name] = currentState
+}
+
+func (r *NamespaceReconciler) handleDeletion(ctx context.Context, name string) {
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("Namespace deleted", "namespace", name)
+
+	// Send deletion event
+	event := model.NewResourceEventPayload(
+		model.ResourceTypeNamespace,
+		model.ResourceRef{
+			Kind: "Namespace",
+			Name: name,
+			UID:  r.namespaceStates[name].uid,
+		},
+		nil,
+		model.ResourceEventKindDeleted,
+		nil,
+		nil,
+		r.clusterID,
+		r.agentVersion,
+	)
+	event.SequenceNum = r.sequence.Add(1)
+
+	select {
+	case deletionChannel(r.eventChan, r.DeletionChan) <- event:
+	default:
+		log.Error(nil, "Event channel full, dropping namespace deletion event", "namespace", name)
+	}
+
+	delete(r.namespaceStates, name)
+}
+
+func (r *NamespaceReconciler) publishEvent(adapter *NamespaceAdapter, eventKind model.ResourceEventKind) {
+	event := model.NewResourceEventPayload(
+		model.ResourceTypeNamespace,
+		model.ResourceRef{
+			Kind: adapter.GetKind(),
+			Name: adapter.GetName(),
+			UID:  adapter.GetUID(),
+		},
+		adapter.GetLabels(),
+		eventKind,
+		adapter.GetState(),
+		nil,
+		r.clusterID,
+		r.agentVersion,
+	)
+	event.SequenceNum = r.sequence.Add(1)
+
+	select {
+	case r.eventChan <- event:
+	default:
+		// Log if channel is full but don't block
+		ctrl.Log.Error(nil, "Event channel full, dropping namespace event",
+			"namespace", adapter.GetName(),
+			"eventKind", eventKind,
+		)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		Complete(r)
+}
@@ -0,0 +1,96 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNamespaceReconciler_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", UID: "ns-uid", Labels: map[string]string{"team": "a"}},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(namespace).WithStatusSubresource(namespace).Build()
+
+	events := make(chan model.ResourceEventPayload, 10)
+	resourceFilter := NewResourceFilter(ResourceFilterConfig{
+		TrackNamespaces:   true,
+		ExcludeNamespaces: []string{"kube-*"},
+	})
+	r := NewNamespaceReconciler(k8sClient, nil, nil, events, "test-cluster", "v1.0.0", resourceFilter)
+
+	reconcile := func(name string) {
+		t.Helper()
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name}}
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+	expectEvent := func(kind model.ResourceEventKind) model.ResourceEventPayload {
+		t.Helper()
+		if len(events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(events))
+		}
+		event := <-events
+		if event.EventKind != kind {
+			t.Errorf("Expected %s, got %s", kind, event.EventKind)
+		}
+		if event.ResourceType != model.ResourceTypeNamespace {
+			t.Errorf("Expected NAMESPACE resource type, got %s", event.ResourceType)
+		}
+		return event
+	}
+
+	// New namespace
+	reconcile("team-a")
+	expectEvent(model.ResourceEventKindCreated)
+
+	// Unchanged namespace emits nothing
+	reconcile("team-a")
+	if len(events) != 0 {
+		t.Fatalf("Expected no event for unchanged namespace, got %d", len(events))
+	}
+
+	// Label change
+	namespace.Labels = map[string]string{"team": "b"}
+	if err := k8sClient.Update(ctx, namespace); err != nil {
+		t.Fatalf("Failed to update namespace: %v", err)
+	}
+	reconcile("team-a")
+	expectEvent(model.ResourceEventKindUpdated)
+
+	// Terminating phase
+	namespace.Status.Phase = corev1.NamespaceTerminating
+	if err := k8sClient.Status().Update(ctx, namespace); err != nil {
+		t.Fatalf("Failed to update namespace status: %v", err)
+	}
+	reconcile("team-a")
+	event := expectEvent(model.ResourceEventKindStatusChange)
+	if event.State == nil || event.State.Phase != string(corev1.NamespaceTerminating) {
+		t.Errorf("Expected Terminating phase, got %+v", event.State)
+	}
+
+	// Deletion
+	if err := k8sClient.Delete(ctx, namespace); err != nil {
+		t.Fatalf("Failed to delete namespace: %v", err)
+	}
+	reconcile("team-a")
+	event = expectEvent(model.ResourceEventKindDeleted)
+	if event.Resource.UID != "ns-uid" {
+		t.Errorf("Expected deletion to carry the last known UID, got %q", event.Resource.UID)
+	}
+
+	// Excluded namespaces are ignored
+	reconcile("kube-system")
+	if len(events) != 0 {
+		t.Fatalf("Expected no event for excluded namespace, got %d", len(events))
+	}
+}
@@ -0,0 +1,115 @@
+package infrastructure
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+var (
+	namespaceCacheHitsCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_namespace_cache_hits_total",
+		Help: "Number of namespace label lookups served from the namespace watcher",
+	})
+
+	namespaceCacheMissesCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_namespace_cache_misses_total",
+		Help: "Number of namespace label lookups that read a namespace the watcher has not seen",
+	})
+)
+
+// NamespaceWatcher keeps the labels of every namespace in memory, updated by a dedicated
+// controller watching namespaces, so namespace label filters never fetch a namespace on the
+// pod reconcile path. Until its controller is started, or for a namespace it has not seen
+// yet, lookups fall back to reading the namespace.
+type NamespaceWatcher struct {
+	reader client.Reader
+
+	mu     sync.RWMutex
+	labels map[string]map[string]string // namespace -> labels
+
+	setupOnce sync.Once
+	setupErr  error
+}
+
+// NewNamespaceWatcher creates a watcher reading namespaces through reader, normally the
+// manager's cache-backed client
+func NewNamespaceWatcher(reader client.Reader) *NamespaceWatcher {
+	return &NamespaceWatcher{
+		reader: reader,
+		labels: make(map[string]map[string]string),
+	}
+}
+
+// GetLabels returns the labels of a namespace and whether the namespace has been seen
+func (w *NamespaceWatcher) GetLabels(namespace string) (map[string]string, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	labels, ok := w.labels[namespace]
+	return labels, ok
+}
+
+// Get returns the labels of a namespace, reading it through the reader when the watcher has
+// not seen it. A namespace that no longer exists is treated as unlabeled.
+func (w *NamespaceWatcher) Get(ctx context.Context, namespace string) (map[string]string, error) {
+	if labels, ok := w.GetLabels(namespace); ok {
+		namespaceCacheHitsCounter.Inc()
+		return labels, nil
+	}
+	namespaceCacheMissesCounter.Inc()
+
+	ns := &corev1.Namespace{}
+	if err := w.reader.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return ns.Labels, nil
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// Reconcile records the current labels of a namespace, or forgets a deleted namespace
+func (w *NamespaceWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ns := &corev1.Namespace{}
+	if err := w.reader.Get(ctx, req.NamespacedName, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			w.mu.Lock()
+			delete(w.labels, req.Name)
+			w.mu.Unlock()
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	labels := maps.Clone(ns.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	w.mu.Lock()
+	w.labels[req.Name] = labels
+	w.mu.Unlock()
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager. Only label changes are watched,
+// besides creations and deletions. Calling it again is a no-op, so every component that
+// needs namespace labels can start the watcher.
+func (w *NamespaceWatcher) SetupWithManager(mgr ctrl.Manager) error {
+	w.setupOnce.Do(func() {
+		w.setupErr = ctrl.NewControllerManagedBy(mgr).
+			For(&corev1.Namespace{}, builder.WithPredicates(predicate.LabelChangedPredicate{})).
+			Named("namespacelabels").
+			Complete(w)
+	})
+	return w.setupErr
+}
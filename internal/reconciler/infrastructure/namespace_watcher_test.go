@@ -0,0 +1,101 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestNamespaceWatcher(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"team": "a"}}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ns).Build()
+
+	ctx := context.Background()
+	watcher := NewNamespaceWatcher(k8sClient)
+	reconcile := func() {
+		t.Helper()
+		if _, err := watcher.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop"}}); err != nil {
+			t.Fatalf("Reconcile failed: %v", err)
+		}
+	}
+
+	if _, ok := watcher.GetLabels("shop"); ok {
+		t.Fatal("Expected namespace to be unknown before its first reconcile")
+	}
+
+	reconcile()
+	if labels, ok := watcher.GetLabels("shop"); !ok || labels["team"] != "a" {
+		t.Fatalf("Expected team=a, got %v (known: %v)", labels, ok)
+	}
+
+	ns.Labels["team"] = "b"
+	if err := k8sClient.Update(ctx, ns); err != nil {
+		t.Fatalf("Failed to update namespace: %v", err)
+	}
+	reconcile()
+	if labels, _ := watcher.GetLabels("shop"); labels["team"] != "b" {
+		t.Errorf("Expected label change to be picked up, got %v", labels)
+	}
+
+	if err := k8sClient.Delete(ctx, ns); err != nil {
+		t.Fatalf("Failed to delete namespace: %v", err)
+	}
+	reconcile()
+	if _, ok := watcher.GetLabels("shop"); ok {
+		t.Error("Expected deleted namespace to be forgotten")
+	}
+}
+
+func TestPodReconciler_NamespaceLabelsFromWatcher(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	known := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"team": "a"}}}
+	unknown := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "billing", Labels: map[string]string{"team": "b"}}}
+	fetches := 0
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(known, unknown).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				fetches++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	r := NewPodReconciler(k8sClient, scheme, nil, nil, "cluster", "v1", nil)
+	if _, err := r.NamespaceWatcher.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop"}}); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	fetches = 0
+
+	labels, err := r.namespaceLabels(context.Background(), "shop")
+	if err != nil || labels["team"] != "a" || fetches != 0 {
+		t.Errorf("Expected team=a from the watcher without fetching, got %v, %v after %d fetches", labels, err, fetches)
+	}
+
+	labels, err = r.namespaceLabels(context.Background(), "billing")
+	if err != nil || labels["team"] != "b" || fetches != 1 {
+		t.Errorf("Expected unseen namespace to be read, got %v, %v after %d fetches", labels, err, fetches)
+	}
+
+	labels, err = r.namespaceLabels(context.Background(), "gone")
+	if err != nil || labels != nil {
+		t.Errorf("Expected missing namespace to be unlabeled, got %v, %v", labels, err)
+	}
+}
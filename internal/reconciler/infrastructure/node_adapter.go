@@ -85,6 +85,7 @@ func (n *NodeAdapter) GetMetadata() map[string]any {
 		Capacity:                capacity,
 		Allocatable:             allocatable,
 		Taints:                  taints,
+		Addresses:               n.GetAddresses(),
 	}
 
 	return map[string]any{
@@ -92,6 +93,18 @@ func (n *NodeAdapter) GetMetadata() map[string]any {
 	}
 }
 
+// GetAddresses returns all addresses reported for the node (IPs and hostnames)
+func (n *NodeAdapter) GetAddresses() []model.NodeAddress {
+	addresses := make([]model.NodeAddress, 0, len(n.Node.Status.Addresses))
+	for _, a := range n.Node.Status.Addresses {
+		addresses = append(addresses, model.NodeAddress{
+			Type:    string(a.Type),
+			Address: a.Address,
+		})
+	}
+	return addresses
+}
+
 // IsReady returns true if the node is in Ready condition
 func (n *NodeAdapter) IsReady() bool {
 	for _, c := range n.Node.Status.Conditions {
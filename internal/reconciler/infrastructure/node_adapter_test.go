@@ -0,0 +1,56 @@
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeAdapter_GetAddresses(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.5"},
+				{Type: corev1.NodeExternalIP, Address: "34.120.1.2"},
+				{Type: corev1.NodeHostName, Address: "node-1.internal"},
+			},
+		},
+	}
+
+	adapter := NewNodeAdapter(node)
+	addresses := adapter.GetAddresses()
+
+	expected := []model.NodeAddress{
+		{Type: "InternalIP", Address: "10.0.0.5"},
+		{Type: "ExternalIP", Address: "34.120.1.2"},
+		{Type: "Hostname", Address: "node-1.internal"},
+	}
+
+	if len(addresses) != len(expected) {
+		t.Fatalf("Expected %d addresses, got %d", len(expected), len(addresses))
+	}
+	for i, want := range expected {
+		if addresses[i] != want {
+			t.Errorf("Address %d: expected %+v, got %+v", i, want, addresses[i])
+		}
+	}
+
+	nodeMetadata, ok := adapter.GetMetadata()["node"].(*model.NodeMetadata)
+	if !ok {
+		t.Fatal("Expected node metadata in GetMetadata")
+	}
+	if len(nodeMetadata.Addresses) != len(expected) {
+		t.Errorf("Expected %d addresses in metadata, got %d", len(expected), len(nodeMetadata.Addresses))
+	}
+}
+
+func TestNodeAdapter_GetAddresses_Empty(t *testing.T) {
+	adapter := NewNodeAdapter(&corev1.Node{})
+
+	if addresses := adapter.GetAddresses(); len(addresses) != 0 {
+		t.Errorf("Expected no addresses, got %d", len(addresses))
+	}
+}
@@ -2,14 +2,31 @@ package infrastructure
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 
 	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/apptrail-sh/agent/internal/reconciler"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// podNodeNameField indexes pods by the node they are scheduled on
+	podNodeNameField = "spec.nodeName"
+
+	// DefaultPodCountChangeThreshold is how many pods a node must gain or lose before a status change is emitted
+	DefaultPodCountChangeThreshold = 5
 )
 
 // NodeReconciler reconciles Node objects
@@ -21,8 +38,22 @@ type NodeReconciler struct {
 	clusterID    string
 	agentVersion string
 
+	// PodCountChangeThreshold is how many pods a node must gain or lose since the last
+	// published event before a status change is emitted; 0 disables the absolute check
+	PodCountChangeThreshold int
+
+	// PodCountChangePercent emits a status change when the pod count moves by more than this
+	// percentage of the last published count; 0 disables the relative check
+	PodCountChangePercent float64
+
 	// Track last known state to detect changes
 	nodeStates map[string]nodeState
+
+	// sequence numbers emitted events so same-node events keep their order in a batch
+	sequence atomic.Uint64
+
+	// DeletionChan, when set, receives deletion events instead of the event channel
+	DeletionChan chan<- model.ResourceEventPayload
 }
 
 type nodeState struct {
@@ -30,7 +61,9 @@ type nodeState struct {
 	unschedulable   bool
 	hasPressure     bool
 	kubeletVersion  string
+	podCount        int
 	resourceVersion string
+	uid             string // Reported with the deletion event, when the node can no longer be read
 }
 
 func NewNodeReconciler(
@@ -41,22 +74,24 @@ func NewNodeReconciler(
 	clusterID, agentVersion string,
 ) *NodeReconciler {
 	return &NodeReconciler{
-		Client:       client,
-		Scheme:       scheme,
-		Recorder:     recorder,
-		eventChan:    eventChan,
-		clusterID:    clusterID,
-		agentVersion: agentVersion,
-		nodeStates:   make(map[string]nodeState),
+		Client:                  client,
+		Scheme:                  scheme,
+		Recorder:                recorder,
+		eventChan:               eventChan,
+		clusterID:               clusterID,
+		agentVersion:            agentVersion,
+		PodCountChangeThreshold: DefaultPodCountChangeThreshold,
+		nodeStates:              make(map[string]nodeState),
 	}
 }
 
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=nodes/status,verbs=get
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 
 func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
-	log.Info("Reconciling Node", "name", req.Name)
+	log.Info("Reconciling Node", "kind", "Node")
 
 	node := &corev1.Node{}
 	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
@@ -65,17 +100,38 @@ func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 			r.handleDeletion(ctx, req.Name)
 			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, err
+		return reconciler.HandleReconcileError(ctx, reconciler.NewReconcileError("get", req.String(), err))
+	}
+
+	podCount, err := r.countPods(ctx, node.Name)
+	if err != nil {
+		return reconciler.HandleReconcileError(ctx, reconciler.NewReconcileError("list pods", req.String(), err))
 	}
 
 	adapter := NewNodeAdapter(node)
-	r.reconcileNode(ctx, adapter)
+	r.reconcileNode(ctx, adapter, podCount)
 
 	return ctrl.Result{}, nil
 }
 
-func (r *NodeReconciler) reconcileNode(ctx context.Context, adapter *NodeAdapter) {
-	log := ctrl.LoggerFrom(ctx)
+// countPods returns the number of non-terminated pods scheduled on a node
+func (r *NodeReconciler) countPods(ctx context.Context, nodeName string) (int, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.MatchingFields{podNodeNameField: nodeName}); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range pods.Items {
+		if !isTerminated(&pods.Items[i]) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *NodeReconciler) reconcileNode(ctx context.Context, adapter *NodeAdapter, podCount int) {
+	log := ctrl.LoggerFrom(ctx).WithValues(reconciler.ResourceLogFields(adapter)...)
 	nodeName := adapter.GetName()
 
 	// Get current state
@@ -84,32 +140,55 @@ func (r *NodeReconciler) reconcileNode(ctx context.Context, adapter *NodeAdapter
 		unschedulable:   adapter.IsUnschedulable(),
 		hasPressure:     adapter.HasPressure(),
 		kubeletVersion:  adapter.Node.Status.NodeInfo.KubeletVersion,
+		podCount:        podCount,
 		resourceVersion: adapter.Node.ResourceVersion,
+		uid:             adapter.GetUID(),
 	}
 
 	// Check if this is a new node or state changed
 	lastState, exists := r.nodeStates[nodeName]
 	if !exists {
 		// New node
-		r.publishEvent(adapter, model.ResourceEventKindCreated)
+		r.publishEvent(adapter, model.ResourceEventKindCreated, podCount)
 		r.nodeStates[nodeName] = currentState
-		log.Info("Node created", "node", nodeName)
+		log.Info("Node created")
 		return
 	}
 
 	// Check for meaningful state changes
-	if r.hasStateChanged(lastState, currentState) {
-		r.publishEvent(adapter, model.ResourceEventKindStatusChange)
+	// Small pod count changes accumulate against the last published count until they are significant
+	if r.hasStateChanged(lastState, currentState) || r.hasPodCountChanged(lastState.podCount, podCount) {
+		r.publishEvent(adapter, model.ResourceEventKindStatusChange, podCount)
 		r.nodeStates[nodeName] = currentState
 		log.Info("Node status changed",
-			"node", nodeName,
 			"ready", currentState.ready,
 			"unschedulable", currentState.unschedulable,
 			"hasPressure", currentState.hasPressure,
+			"podCount", podCount,
+			"previousPodCount", lastState.podCount,
 		)
 	}
 }
 
+// hasPodCountChanged reports whether the pod count moved beyond the absolute or percentage threshold
+func (r *NodeReconciler) hasPodCountChanged(last, current int) bool {
+	delta := current - last
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta == 0 {
+		return false
+	}
+	if r.PodCountChangeThreshold > 0 && delta > r.PodCountChangeThreshold {
+		return true
+	}
+	if r.PodCountChangePercent > 0 {
+		// Any pod landing on an empty node is an unbounded relative change
+		return last == 0 || float64(delta)/float64(last)*100 > r.PodCountChangePercent
+	}
+	return false
+}
+
 func (r *NodeReconciler) hasStateChanged(last, current nodeState) bool {
 	return last.ready != current.ready ||
 		last.unschedulable != current.unschedulable ||
@@ -118,8 +197,8 @@ func (r *NodeReconciler) hasStateChanged(last, current nodeState) bool {
 }
 
 func (r *NodeReconciler) handleDeletion(ctx context.Context, nodeName string) {
-	log := ctrl.LoggerFrom(ctx)
-	log.Info("Node deleted", "node", nodeName)
+	log := ctrl.LoggerFrom(ctx).WithValues("kind", "Node")
+	log.Info("Node deleted")
 
 	// Send deletion event
 	event := model.NewResourceEventPayload(
@@ -127,6 +206,7 @@ func (r *NodeReconciler) handleDeletion(ctx context.Context, nodeName string) {
 		model.ResourceRef{
 			Kind: "Node",
 			Name: nodeName,
+			UID:  r.nodeStates[nodeName].uid,
 		},
 		nil,
 		model.ResourceEventKindDeleted,
@@ -136,26 +216,56 @@ func (r *NodeReconciler) handleDeletion(ctx context.Context, nodeName string) {
 		r.agentVersion,
 	)
 
+	event.SequenceNum = r.sequence.Add(1)
+
 	select {
-	case r.eventChan <- event:
+	case deletionChannel(r.eventChan, r.DeletionChan) <- event:
 	default:
-		log.Error(nil, "Event channel full, dropping node deletion event", "node", nodeName)
+		log.Error(nil, "Event channel full, dropping node deletion event")
 	}
 
 	delete(r.nodeStates, nodeName)
 }
 
-func (r *NodeReconciler) publishEvent(adapter *NodeAdapter, eventKind model.ResourceEventKind) {
-	event := model.NewNodeEvent(
+// Snapshot returns the current state of every node, for full-state syncs
+func (r *NodeReconciler) Snapshot(ctx context.Context) ([]model.ResourceEventPayload, error) {
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	events := make([]model.ResourceEventPayload, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		podCount, err := r.countPods(ctx, nodes.Items[i].Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count pods on node %s: %w", nodes.Items[i].Name, err)
+		}
+		events = append(events, r.newEvent(NewNodeAdapter(&nodes.Items[i]), model.ResourceEventKindUpdated, podCount))
+	}
+	return events, nil
+}
+
+func (r *NodeReconciler) newEvent(adapter *NodeAdapter, eventKind model.ResourceEventKind, podCount int) model.ResourceEventPayload {
+	nodeMetadata := r.extractNodeMetadata(adapter)
+	if nodeMetadata != nil {
+		nodeMetadata.PodCount = podCount
+	}
+
+	return model.NewNodeEvent(
 		adapter.GetName(),
 		adapter.GetUID(),
 		adapter.GetLabels(),
 		eventKind,
 		adapter.GetState(),
-		r.extractNodeMetadata(adapter),
+		nodeMetadata,
 		r.clusterID,
 		r.agentVersion,
 	)
+}
+
+func (r *NodeReconciler) publishEvent(adapter *NodeAdapter, eventKind model.ResourceEventKind, podCount int) {
+	event := r.newEvent(adapter, eventKind, podCount)
+	event.SequenceNum = r.sequence.Add(1)
 
 	select {
 	case r.eventChan <- event:
@@ -176,9 +286,57 @@ func (r *NodeReconciler) extractNodeMetadata(adapter *NodeAdapter) *model.NodeMe
 	return nil
 }
 
-// SetupWithManager sets up the controller with the Manager
+// SetupWithManager sets up the controller with the Manager. Pods are indexed by node so pod
+// counts can be listed per node, and pods being bound or removed requeue their node.
 func (r *NodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, podNodeNameField, podNodeName); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Node{}).
+		Watches(&corev1.Pod{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+				nodeName := podNodeName(obj)
+				if len(nodeName) == 0 {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: nodeName[0]}}}
+			}),
+			builder.WithPredicates(podNodeCountPredicate()),
+		).
 		Complete(r)
 }
+
+// podNodeName is the index function for podNodeNameField
+func podNodeName(obj client.Object) []string {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return nil
+	}
+	return []string{pod.Spec.NodeName}
+}
+
+// podNodeCountPredicate passes pod events that can change a node's pod count: scheduling,
+// deletion and pods reaching a terminal phase
+func podNodeCountPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return true },
+		DeleteFunc: func(e event.DeleteEvent) bool { return true },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPod, okOld := e.ObjectOld.(*corev1.Pod)
+			newPod, okNew := e.ObjectNew.(*corev1.Pod)
+			if !okOld || !okNew {
+				return false
+			}
+			return oldPod.Spec.NodeName != newPod.Spec.NodeName ||
+				isTerminated(oldPod) != isTerminated(newPod)
+		},
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+}
+
+// isTerminated reports whether a pod no longer occupies its node
+func isTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNodeReconciler_PodCount(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(node).
+		WithIndex(&corev1.Pod{}, podNodeNameField, podNodeName).
+		Build()
+
+	ctx := context.Background()
+	addPods := func(from, to int, phase corev1.PodPhase) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"},
+				Spec:       corev1.PodSpec{NodeName: "node-1"},
+				Status:     corev1.PodStatus{Phase: phase},
+			}
+			if err := k8sClient.Create(ctx, pod); err != nil {
+				t.Fatalf("Failed to create pod: %v", err)
+			}
+		}
+	}
+
+	events := make(chan model.ResourceEventPayload, 10)
+	r := NewNodeReconciler(k8sClient, scheme, nil, events, "test-cluster", "v1.0.0")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "node-1"}}
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+	podCount := func(event model.ResourceEventPayload) int {
+		t.Helper()
+		nodeMetadata, ok := event.Metadata["node"].(*model.NodeMetadata)
+		if !ok {
+			t.Fatalf("Expected node metadata, got %v", event.Metadata)
+		}
+		return nodeMetadata.PodCount
+	}
+
+	addPods(0, 3, corev1.PodRunning)
+	addPods(3, 5, corev1.PodSucceeded)
+	reconcile()
+	created := <-events
+	if created.EventKind != model.ResourceEventKindCreated || podCount(created) != 3 {
+		t.Fatalf("Expected CREATED with 3 running pods, got %s with %d", created.EventKind, podCount(created))
+	}
+
+	// Five more pods stay within the default threshold
+	addPods(5, 10, corev1.PodRunning)
+	reconcile()
+	if len(events) != 0 {
+		t.Fatalf("Expected no event for a change of 5 pods, got %d", len(events))
+	}
+
+	// The sixth pod since the last event crosses it
+	addPods(10, 11, corev1.PodPending)
+	reconcile()
+	if len(events) != 1 {
+		t.Fatalf("Expected a status change for a change of 6 pods, got %d events", len(events))
+	}
+	if changed := <-events; changed.EventKind != model.ResourceEventKindStatusChange || podCount(changed) != 9 {
+		t.Errorf("Expected STATUS_CHANGE with 9 pods, got %s with %d", changed.EventKind, podCount(changed))
+	}
+}
+
+func TestNodeReconciler_HasPodCountChanged(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		percent   float64
+		last      int
+		current   int
+		want      bool
+	}{
+		{name: "unchanged", threshold: 5, last: 10, current: 10, want: false},
+		{name: "within threshold", threshold: 5, last: 10, current: 15, want: false},
+		{name: "above threshold", threshold: 5, last: 10, current: 4, want: true},
+		{name: "percentage exceeded", percent: 20, last: 10, current: 13, want: true},
+		{name: "percentage not exceeded", percent: 20, last: 10, current: 12, want: false},
+		{name: "percentage from empty node", percent: 20, last: 0, current: 1, want: true},
+		{name: "both disabled", last: 0, current: 100, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &NodeReconciler{PodCountChangeThreshold: tt.threshold, PodCountChangePercent: tt.percent}
+			if got := r.hasPodCountChanged(tt.last, tt.current); got != tt.want {
+				t.Errorf("hasPodCountChanged(%d, %d) = %v, want %v", tt.last, tt.current, got, tt.want)
+			}
+		})
+	}
+}
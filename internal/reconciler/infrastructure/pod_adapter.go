@@ -1,10 +1,20 @@
 package infrastructure
 
 import (
+	"strings"
+
 	"github.com/apptrail-sh/agent/internal/model"
 	corev1 "k8s.io/api/core/v1"
 )
 
+const (
+	// podTemplateHashLabel is the suffix a Deployment appends to its ReplicaSet names
+	podTemplateHashLabel = "pod-template-hash"
+
+	// versionLabel is the label workloads and their pods carry their version in
+	versionLabel = "app.kubernetes.io/version"
+)
+
 // PodAdapter wraps a Pod to implement InfrastructureResourceAdapter
 type PodAdapter struct {
 	Pod *corev1.Pod
@@ -60,8 +70,9 @@ func (p *PodAdapter) GetMetadata() map[string]any {
 		NodeName:       p.Pod.Spec.NodeName,
 		PodIP:          p.Pod.Status.PodIP,
 		RestartCount:   p.getTotalRestartCount(),
-		Containers:     p.getContainerStatuses(p.Pod.Status.ContainerStatuses),
-		InitContainers: p.getContainerStatuses(p.Pod.Status.InitContainerStatuses),
+		QoSClass:       p.GetQoSClass(),
+		Containers:     p.getContainerStatuses(p.Pod.Status.ContainerStatuses, p.Pod.Spec.Containers),
+		InitContainers: p.getContainerStatuses(p.Pod.Status.InitContainerStatuses, p.Pod.Spec.InitContainers),
 	}
 
 	if p.Pod.Status.StartTime != nil {
@@ -82,14 +93,20 @@ func (p *PodAdapter) GetMetadata() map[string]any {
 	}
 }
 
-func (p *PodAdapter) getContainerStatuses(statuses []corev1.ContainerStatus) []model.ContainerStatus {
+func (p *PodAdapter) getContainerStatuses(statuses []corev1.ContainerStatus, containers []corev1.Container) []model.ContainerStatus {
+	requests := containerResources(containers, func(c corev1.Container) corev1.ResourceList { return c.Resources.Requests })
+	limits := containerResources(containers, func(c corev1.Container) corev1.ResourceList { return c.Resources.Limits })
+
 	result := make([]model.ContainerStatus, 0, len(statuses))
 	for _, cs := range statuses {
 		containerStatus := model.ContainerStatus{
-			Name:         cs.Name,
-			Image:        cs.Image,
-			Ready:        cs.Ready,
-			RestartCount: cs.RestartCount,
+			Name:             cs.Name,
+			Image:            cs.Image,
+			ImageDigest:      imageDigest(cs.ImageID),
+			Ready:            cs.Ready,
+			RestartCount:     cs.RestartCount,
+			ResourceRequests: requests[cs.Name],
+			ResourceLimits:   limits[cs.Name],
 		}
 
 		// Determine state and reason
@@ -110,6 +127,33 @@ func (p *PodAdapter) getContainerStatuses(statuses []corev1.ContainerStatus) []m
 	return result
 }
 
+// containerResources maps container name to resource name to quantity string
+func containerResources(containers []corev1.Container, list func(corev1.Container) corev1.ResourceList) map[string]map[string]string {
+	result := make(map[string]map[string]string, len(containers))
+	for _, c := range containers {
+		resources := list(c)
+		if len(resources) == 0 {
+			continue
+		}
+		quantities := make(map[string]string, len(resources))
+		for name, quantity := range resources {
+			quantities[string(name)] = quantity.String()
+		}
+		result[c.Name] = quantities
+	}
+	return result
+}
+
+// GetResourceRequests returns resource requests keyed by container name
+func (p *PodAdapter) GetResourceRequests() map[string]map[string]string {
+	return containerResources(p.Pod.Spec.Containers, func(c corev1.Container) corev1.ResourceList { return c.Resources.Requests })
+}
+
+// GetResourceLimits returns resource limits keyed by container name
+func (p *PodAdapter) GetResourceLimits() map[string]map[string]string {
+	return containerResources(p.Pod.Spec.Containers, func(c corev1.Container) corev1.ResourceList { return c.Resources.Limits })
+}
+
 func (p *PodAdapter) getTotalRestartCount() int32 {
 	var total int32
 	for _, cs := range p.Pod.Status.ContainerStatuses {
@@ -132,6 +176,43 @@ func (p *PodAdapter) GetOwnerReference() (kind, name, uid string) {
 	return "", "", ""
 }
 
+// GetWorkloadName returns the name of the workload managing the pod, resolving ReplicaSets to
+// their Deployment through the pod-template-hash label; empty for pods without an owner
+func (p *PodAdapter) GetWorkloadName() string {
+	kind, name, _ := p.GetOwnerReference()
+	if kind == "ReplicaSet" {
+		if hash := p.Pod.Labels[podTemplateHashLabel]; hash != "" {
+			return strings.TrimSuffix(name, "-"+hash)
+		}
+	}
+	return name
+}
+
+// GetImageDigests returns the image digest of each running container that reports one
+func (p *PodAdapter) GetImageDigests() map[string]string {
+	digests := make(map[string]string, len(p.Pod.Status.ContainerStatuses))
+	for _, cs := range p.Pod.Status.ContainerStatuses {
+		if digest := imageDigest(cs.ImageID); digest != "" {
+			digests[cs.Name] = digest
+		}
+	}
+	return digests
+}
+
+// imageDigest extracts the digest from a container imageID such as
+// docker.io/library/nginx@sha256:abc, returning "" when the runtime reports none
+func imageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	return ""
+}
+
+// GetQoSClass returns the pod QoS class (Guaranteed, Burstable, BestEffort)
+func (p *PodAdapter) GetQoSClass() string {
+	return string(p.Pod.Status.QOSClass)
+}
+
 // IsReady returns true if the pod is in Ready condition
 func (p *PodAdapter) IsReady() bool {
 	for _, c := range p.Pod.Status.Conditions {
@@ -151,3 +232,56 @@ func (p *PodAdapter) IsTerminating() bool {
 func (p *PodAdapter) GetPhase() corev1.PodPhase {
 	return p.Pod.Status.Phase
 }
+
+// initContainerFailure describes an init container run that exited non-zero
+type initContainerFailure struct {
+	containerName string
+	attempt       int32 // Restart count of the failed run, used to report each failure once
+	exitCode      int32
+	reason        string
+	message       string
+}
+
+// GetInitContainerFailures returns init containers whose current or last run exited non-zero
+func (p *PodAdapter) GetInitContainerFailures() []initContainerFailure {
+	var failures []initContainerFailure
+	for _, status := range p.Pod.Status.InitContainerStatuses {
+		// A failed run is in State until the kubelet restarts it, then moves to LastTerminationState
+		terminated := status.State.Terminated
+		attempt := status.RestartCount
+		if terminated == nil {
+			terminated = status.LastTerminationState.Terminated
+			attempt = status.RestartCount - 1
+		}
+		if terminated == nil || terminated.ExitCode == 0 {
+			continue
+		}
+		failures = append(failures, initContainerFailure{
+			containerName: status.Name,
+			attempt:       attempt,
+			exitCode:      terminated.ExitCode,
+			reason:        terminated.Reason,
+			message:       terminated.Message,
+		})
+	}
+	return failures
+}
+
+// topologySpreadMessage is the scheduler's wording when no node satisfies the pod's topology spread constraints
+const topologySpreadMessage = "topology spread constraint"
+
+// GetTopologySpreadViolation returns the scheduler message when the pod is unschedulable
+// because of its topology spread constraints
+func (p *PodAdapter) GetTopologySpreadViolation() (message string, violated bool) {
+	for _, c := range p.Pod.Status.Conditions {
+		if c.Type != corev1.PodScheduled {
+			continue
+		}
+		if c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable &&
+			strings.Contains(c.Message, topologySpreadMessage) {
+			return c.Message, true
+		}
+		return "", false
+	}
+	return "", false
+}
@@ -0,0 +1,119 @@
+package infrastructure
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodAdapter_GetQoSClass(t *testing.T) {
+	tests := []struct {
+		name     string
+		qosClass corev1.PodQOSClass
+		want     string
+	}{
+		{name: "guaranteed", qosClass: corev1.PodQOSGuaranteed, want: "Guaranteed"},
+		{name: "burstable", qosClass: corev1.PodQOSBurstable, want: "Burstable"},
+		{name: "best effort", qosClass: corev1.PodQOSBestEffort, want: "BestEffort"},
+		{name: "not yet assigned", qosClass: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewPodAdapter(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default"},
+				Status:     corev1.PodStatus{QOSClass: tt.qosClass},
+			})
+
+			if got := adapter.GetQoSClass(); got != tt.want {
+				t.Errorf("Expected QoS class %q, got %q", tt.want, got)
+			}
+			podMetadata, ok := adapter.GetMetadata()["pod"].(*model.PodMetadata)
+			if !ok {
+				t.Fatal("Expected pod metadata in GetMetadata")
+			}
+			if podMetadata.QoSClass != tt.want {
+				t.Errorf("Expected QoS class %q in metadata, got %q", tt.want, podMetadata.QoSClass)
+			}
+		})
+	}
+}
+
+func TestPodAdapter_ContainerResources(t *testing.T) {
+	tests := []struct {
+		name         string
+		resources    corev1.ResourceRequirements
+		wantRequests map[string]string
+		wantLimits   map[string]string
+	}{
+		{
+			name: "requests and limits",
+			resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("128Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				},
+			},
+			wantRequests: map[string]string{"cpu": "250m", "memory": "128Mi"},
+			wantLimits:   map[string]string{"memory": "256Mi"},
+		},
+		{
+			name: "requests only",
+			resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+			wantRequests: map[string]string{"cpu": "1"},
+		},
+		{
+			name: "no resources",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewPodAdapter(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default"},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "app", Resources: tt.resources},
+						{Name: "sidecar"},
+					},
+				},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{{Name: "app"}, {Name: "sidecar"}},
+				},
+			})
+
+			if got := adapter.GetResourceRequests()["app"]; !maps.Equal(got, tt.wantRequests) {
+				t.Errorf("Expected requests %v, got %v", tt.wantRequests, got)
+			}
+			if got := adapter.GetResourceLimits()["app"]; !maps.Equal(got, tt.wantLimits) {
+				t.Errorf("Expected limits %v, got %v", tt.wantLimits, got)
+			}
+
+			podMetadata, ok := adapter.GetMetadata()["pod"].(*model.PodMetadata)
+			if !ok {
+				t.Fatal("Expected pod metadata in GetMetadata")
+			}
+			if len(podMetadata.Containers) != 2 {
+				t.Fatalf("Expected 2 containers in metadata, got %d", len(podMetadata.Containers))
+			}
+			app, sidecar := podMetadata.Containers[0], podMetadata.Containers[1]
+			if !maps.Equal(app.ResourceRequests, tt.wantRequests) || !maps.Equal(app.ResourceLimits, tt.wantLimits) {
+				t.Errorf("Expected requests %v and limits %v in metadata, got %v and %v",
+					tt.wantRequests, tt.wantLimits, app.ResourceRequests, app.ResourceLimits)
+			}
+			// A container without resources carries none
+			if sidecar.ResourceRequests != nil || sidecar.ResourceLimits != nil {
+				t.Errorf("Expected no resources for sidecar, got %v and %v", sidecar.ResourceRequests, sidecar.ResourceLimits)
+			}
+		})
+	}
+}
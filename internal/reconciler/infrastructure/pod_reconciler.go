@@ -2,14 +2,59 @@ package infrastructure
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/apptrail-sh/agent/internal/reconciler"
+	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// DefaultPendingAlertThreshold is how long a pod may stay Pending before a pending alert is emitted
+	DefaultPendingAlertThreshold = 10 * time.Minute
+
+	// DefaultPendingCheckInterval is how often Pending pods are re-checked against the threshold
+	DefaultPendingCheckInterval = 2 * time.Minute
+
+	// DefaultRestartAlertThreshold is the total restart count at which a pod is first reported
+	DefaultRestartAlertThreshold = 10
+)
+
+// DefaultRestartAlertEscalations are the further restart counts at which a pod is reported again
+var DefaultRestartAlertEscalations = []int32{25, 50}
+
+var (
+	initContainerFailuresCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_init_container_failures_total",
+		Help: "Number of init container runs that exited with a non-zero code",
+	})
+
+	pendingOverThresholdCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "apptrail_pods_pending_over_threshold_total",
+		Help: "Number of pods that stayed Pending longer than the pending alert threshold",
+	})
+
+	topologyViolationsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_topology_spread_violations_total",
+		Help: "Number of pods found unschedulable because of topology spread constraints",
+	}, []string{"namespace"})
+
+	restartThresholdCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_pod_restart_threshold_exceeded_total",
+		Help: "Number of times a pod's total restart count reached a restart alert threshold",
+	}, []string{"namespace", "threshold"})
+
+	metricsRegistered = false
 )
 
 // PodReconciler reconciles Pod objects
@@ -20,10 +65,45 @@ type PodReconciler struct {
 	eventChan    chan<- model.ResourceEventPayload
 	clusterID    string
 	agentVersion string
-	filter       *ResourceFilter
+	filter       atomic.Pointer[ResourceFilter] // Swapped on config reload
+
+	// PendingAlertThreshold is how long a pod may stay Pending before a pending alert is emitted
+	PendingAlertThreshold time.Duration
+
+	// PendingCheckInterval is how often Pending pods are requeued to check the threshold
+	PendingCheckInterval time.Duration
+
+	// RestartAlertThresholds are the total restart counts at which a HIGH_RESTART_COUNT event
+	// is emitted, catching pods that restart too slowly to enter CrashLoopBackOff
+	RestartAlertThresholds []int32
 
 	// Track last known state to detect changes
 	podStates map[string]podState
+
+	// sequence numbers emitted events so same-pod events keep their order in a batch
+	sequence atomic.Uint64
+
+	// Image digests per workload (namespace/workload-name), from the pods that last started running
+	imageDigests map[string]imageDigestState
+	// Tracked pods per workload key, so a workload's digests are dropped with its last pod
+	workloadPods map[string]int
+
+	// Init container failures already reported, per pod key, keyed by podUID/containerName/restartCount
+	reportedInitFailures map[string]map[string]struct{}
+
+	// NamespaceWatcher serves namespace labels for namespace label filtering. Share the one
+	// started with the manager so lookups are served from memory.
+	NamespaceWatcher *NamespaceWatcher
+
+	// DeletionChan, when set, receives deletion events instead of the event channel so they are
+	// not held up behind queued status changes
+	DeletionChan chan<- model.ResourceEventPayload
+}
+
+// imageDigestState is the version label and per-container image digests a workload last ran with
+type imageDigestState struct {
+	version string
+	digests map[string]string
 }
 
 type podState struct {
@@ -32,6 +112,15 @@ type podState struct {
 	nodeName        string
 	restartCount    int32
 	resourceVersion string
+	uid             string // Reported with the deletion event, when the pod can no longer be read
+	workloadKey     string // Key of the owning workload in imageDigests, empty for unowned pods
+
+	// pendingSince is when the pod entered Pending, nil once it leaves it
+	pendingSince *time.Time
+	// pendingAlerted records that the current Pending stint has already been alerted
+	pendingAlerted bool
+	// topologyViolationReported records that the current unschedulable stint has already been reported
+	topologyViolationReported bool
 }
 
 func NewPodReconciler(
@@ -42,49 +131,98 @@ func NewPodReconciler(
 	clusterID, agentVersion string,
 	filter *ResourceFilter,
 ) *PodReconciler {
-	return &PodReconciler{
-		Client:       client,
-		Scheme:       scheme,
-		Recorder:     recorder,
-		eventChan:    eventChan,
-		clusterID:    clusterID,
-		agentVersion: agentVersion,
-		filter:       filter,
-		podStates:    make(map[string]podState),
+	// Register metrics only once
+	if !metricsRegistered {
+		metrics.Registry.MustRegister(initContainerFailuresCounter, pendingOverThresholdCounter, topologyViolationsCounter,
+			restartThresholdCounter, namespaceCacheHitsCounter, namespaceCacheMissesCounter)
+		metricsRegistered = true
 	}
+
+	r := &PodReconciler{
+		Client:                 client,
+		Scheme:                 scheme,
+		Recorder:               recorder,
+		eventChan:              eventChan,
+		clusterID:              clusterID,
+		agentVersion:           agentVersion,
+		PendingAlertThreshold:  DefaultPendingAlertThreshold,
+		PendingCheckInterval:   DefaultPendingCheckInterval,
+		RestartAlertThresholds: append([]int32{DefaultRestartAlertThreshold}, DefaultRestartAlertEscalations...),
+		podStates:              make(map[string]podState),
+		reportedInitFailures:   make(map[string]map[string]struct{}),
+		imageDigests:           make(map[string]imageDigestState),
+		workloadPods:           make(map[string]int),
+		NamespaceWatcher:       NewNamespaceWatcher(client),
+	}
+	r.filter.Store(filter)
+	return r
+}
+
+// SetFilter replaces the resource filter used by subsequent reconciles and watch events
+func (r *PodReconciler) SetFilter(filter *ResourceFilter) {
+	r.filter.Store(filter)
 }
 
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=pods/status,verbs=get
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
+	filter := r.filter.Load()
 
-	// Apply namespace filter
-	if r.filter != nil && !r.filter.ShouldWatchNamespace(req.Namespace) {
+	// Apply namespace filter. Pods tracked before a reload excluded their namespace are still
+	// looked up, so their deletion is reported and their state released.
+	_, tracked := r.podStates[req.String()]
+	excluded := filter != nil && !filter.ShouldWatchNamespace(req.Namespace)
+	if excluded && !tracked {
 		return ctrl.Result{}, nil
 	}
 
 	pod := &corev1.Pod{}
 	if err := r.Get(ctx, req.NamespacedName, pod); err != nil {
 		if apierrors.IsNotFound(err) {
-			// Pod was deleted
-			r.handleDeletion(ctx, req.Namespace, req.Name)
+			// Pod was deleted. Its namespace may be gone too, so the label filter only decides
+			// for pods never tracked, which it filtered out or the agent has not seen yet.
+			if tracked || filter == nil || !filter.HasNamespaceLabelFilters() {
+				r.handleDeletion(ctx, req.Namespace, req.Name)
+			}
+			return ctrl.Result{}, nil
+		}
+		return reconciler.HandleReconcileError(ctx, reconciler.NewReconcileError("get", req.String(), err))
+	}
+	if excluded {
+		return ctrl.Result{}, nil
+	}
+
+	// Apply namespace label filter
+	if filter != nil && filter.HasNamespaceLabelFilters() {
+		nsLabels, err := r.namespaceLabels(ctx, req.Namespace)
+		if err != nil {
+			return reconciler.HandleReconcileError(ctx, reconciler.NewReconcileError("get namespace", req.Namespace, err))
+		}
+		if !filter.ShouldWatchNamespaceByLabels(nsLabels) {
 			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, err
 	}
 
 	// Apply label filter
-	if r.filter != nil && !r.filter.ShouldWatchResource(pod.Labels) {
+	if filter != nil && !filter.ShouldWatchResource(pod.Labels) {
 		return ctrl.Result{}, nil
 	}
 
 	adapter := NewPodAdapter(pod)
-	log.V(1).Info("Reconciling Pod", "namespace", req.Namespace, "name", req.Name, "phase", adapter.GetPhase())
+	log = log.WithValues(reconciler.ResourceLogFields(adapter)...)
+	ctx = ctrl.LoggerInto(ctx, log)
+	log.V(1).Info("Reconciling Pod", "phase", adapter.GetPhase())
 
 	r.reconcilePod(ctx, adapter)
 
+	// Pending pods may not change again on their own, so come back to check the alert threshold
+	if adapter.GetPhase() == corev1.PodPending && r.PendingAlertThreshold > 0 && r.PendingCheckInterval > 0 {
+		return ctrl.Result{RequeueAfter: r.PendingCheckInterval}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -99,28 +237,338 @@ func (r *PodReconciler) reconcilePod(ctx context.Context, adapter *PodAdapter) {
 		nodeName:        adapter.GetNodeName(),
 		restartCount:    adapter.getTotalRestartCount(),
 		resourceVersion: adapter.Pod.ResourceVersion,
+		uid:             adapter.GetUID(),
+		workloadKey:     workloadKey(adapter),
 	}
 
+	r.reportInitContainerFailures(ctx, adapter)
+
 	// Check if this is a new pod or state changed
 	lastState, exists := r.podStates[podKey]
 	if !exists {
-		// New pod
+		// New pod; measure Pending from creation so an agent restart doesn't reset the clock
+		if currentState.phase == corev1.PodPending {
+			since := adapter.Pod.CreationTimestamp.Time
+			if since.IsZero() {
+				since = time.Now()
+			}
+			currentState.pendingSince = &since
+		}
 		r.publishEvent(adapter, model.ResourceEventKindCreated)
 		r.podStates[podKey] = currentState
-		log.V(1).Info("Pod created", "pod", podKey, "phase", currentState.phase)
+		if currentState.workloadKey != "" {
+			r.workloadPods[currentState.workloadKey]++
+		}
+		log.V(1).Info("Pod created", "phase", currentState.phase)
+		if currentState.phase == corev1.PodRunning {
+			r.checkImageDigests(ctx, adapter, false)
+		}
+		r.checkPending(ctx, adapter, podKey)
+		r.checkTopologyViolation(ctx, adapter, podKey)
 		return
 	}
 
+	// The pod is counted in workloadPods under the workload key first recorded for it
+	currentState.workloadKey = lastState.workloadKey
+	currentState.pendingSince = lastState.pendingSince
+	currentState.pendingAlerted = lastState.pendingAlerted
+	currentState.topologyViolationReported = lastState.topologyViolationReported
+	switch currentState.phase {
+	case corev1.PodPending:
+		if lastState.phase != corev1.PodPending || currentState.pendingSince == nil {
+			now := time.Now()
+			currentState.pendingSince = &now
+			currentState.pendingAlerted = false
+		}
+	default:
+		currentState.pendingSince = nil
+		currentState.pendingAlerted = false
+	}
+
+	r.checkRestartThresholds(ctx, adapter, lastState.restartCount, currentState.restartCount)
+	if currentState.phase == corev1.PodRunning && lastState.phase != corev1.PodRunning {
+		r.checkImageDigests(ctx, adapter, true)
+	}
+
 	// Check for meaningful state changes
 	if r.hasStateChanged(lastState, currentState) {
 		r.publishEvent(adapter, model.ResourceEventKindStatusChange)
-		r.podStates[podKey] = currentState
 		log.V(1).Info("Pod status changed",
-			"pod", podKey,
 			"phase", currentState.phase,
 			"ready", currentState.ready,
 			"restartCount", currentState.restartCount,
 		)
+	} else {
+		// Keep the last published state, but carry the pending tracking forward
+		lastState.pendingSince = currentState.pendingSince
+		lastState.pendingAlerted = currentState.pendingAlerted
+		currentState = lastState
+	}
+	r.podStates[podKey] = currentState
+
+	r.checkPending(ctx, adapter, podKey)
+	r.checkTopologyViolation(ctx, adapter, podKey)
+}
+
+// checkTopologyViolation emits a topology violation event once per stint of the pod being
+// unschedulable because of its topology spread constraints
+func (r *PodReconciler) checkTopologyViolation(ctx context.Context, adapter *PodAdapter, podKey string) {
+	state := r.podStates[podKey]
+	message, violated := adapter.GetTopologySpreadViolation()
+	if !violated {
+		if state.topologyViolationReported {
+			state.topologyViolationReported = false
+			r.podStates[podKey] = state
+		}
+		return
+	}
+	if state.topologyViolationReported {
+		return
+	}
+
+	state.topologyViolationReported = true
+	r.podStates[podKey] = state
+	topologyViolationsCounter.WithLabelValues(adapter.GetNamespace()).Inc()
+
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("Pod unschedulable due to topology spread constraints", "message", message)
+
+	event := model.NewPodEvent(
+		adapter.GetNamespace(),
+		adapter.GetName(),
+		adapter.GetUID(),
+		adapter.GetLabels(),
+		model.ResourceEventKindTopologyViolation,
+		adapter.GetState(),
+		r.extractPodMetadata(adapter),
+		r.clusterID,
+		r.agentVersion,
+	)
+	event.Metadata["scheduleMessage"] = message
+	event.Metadata["topologySpreadConstraints"] = adapter.Pod.Spec.TopologySpreadConstraints
+	if adapter.Pod.Spec.Affinity != nil {
+		event.Metadata["affinity"] = adapter.Pod.Spec.Affinity
+	}
+
+	event.SequenceNum = r.sequence.Add(1)
+
+	select {
+	case r.eventChan <- event:
+	default:
+		log.Error(nil, "Event channel full, dropping topology violation event")
+	}
+}
+
+// checkRestartThresholds emits a high restart count event for each alert threshold the pod's
+// total restart count reached since the last published state. Pods first seen with a high count,
+// e.g. after an agent restart, are not reported.
+func (r *PodReconciler) checkRestartThresholds(ctx context.Context, adapter *PodAdapter, last, current int32) {
+	log := ctrl.LoggerFrom(ctx)
+
+	for _, threshold := range r.RestartAlertThresholds {
+		if threshold <= 0 || current < threshold || last >= threshold {
+			continue
+		}
+
+		restartThresholdCounter.WithLabelValues(adapter.GetNamespace(), strconv.Itoa(int(threshold))).Inc()
+		log.Info("Pod restart count reached alert threshold", "restartCount", current, "threshold", threshold)
+
+		containerRestarts := make(map[string]int32, len(adapter.Pod.Status.ContainerStatuses))
+		for _, status := range adapter.Pod.Status.ContainerStatuses {
+			containerRestarts[status.Name] = status.RestartCount
+		}
+
+		event := model.NewPodEvent(
+			adapter.GetNamespace(),
+			adapter.GetName(),
+			adapter.GetUID(),
+			adapter.GetLabels(),
+			model.ResourceEventKindHighRestartCount,
+			adapter.GetState(),
+			r.extractPodMetadata(adapter),
+			r.clusterID,
+			r.agentVersion,
+		)
+		event.Metadata["restartCount"] = current
+		event.Metadata["restartThreshold"] = threshold
+		event.Metadata["containerRestarts"] = containerRestarts
+
+		event.SequenceNum = r.sequence.Add(1)
+
+		select {
+		case r.eventChan <- event:
+		default:
+			log.Error(nil, "Event channel full, dropping high restart count event", "threshold", threshold)
+		}
+	}
+}
+
+// checkImageDigests records the image digests of a pod that started running under its workload
+// and, if report is set, emits a digest changed event when a container now runs a different
+// digest while the version label stayed the same, i.e. a mutable tag was overwritten. Pods seen
+// running for the first time, e.g. after an agent restart, only seed digests not yet known.
+func (r *PodReconciler) checkImageDigests(ctx context.Context, adapter *PodAdapter, report bool) {
+	workload := adapter.GetWorkloadName()
+	digests := adapter.GetImageDigests()
+	if workload == "" || len(digests) == 0 {
+		return
+	}
+
+	key := workloadKey(adapter)
+	version := adapter.GetLabels()[versionLabel]
+	last, known := r.imageDigests[key]
+	if known && !report {
+		return
+	}
+	r.imageDigests[key] = imageDigestState{version: version, digests: digests}
+	if !known || last.version != version {
+		return
+	}
+
+	previous := make(map[string]string)
+	changed := make(map[string]string)
+	for container, digest := range digests {
+		if lastDigest, ok := last.digests[container]; ok && lastDigest != digest {
+			previous[container] = lastDigest
+			changed[container] = digest
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("Image digest changed without a version change", "workload", workload, "version", version, "digests", changed)
+
+	event := model.NewPodEvent(
+		adapter.GetNamespace(),
+		adapter.GetName(),
+		adapter.GetUID(),
+		adapter.GetLabels(),
+		model.ResourceEventKindDigestChanged,
+		adapter.GetState(),
+		r.extractPodMetadata(adapter),
+		r.clusterID,
+		r.agentVersion,
+	)
+	event.Metadata["workload"] = workload
+	event.Metadata["version"] = version
+	event.Metadata["previousDigests"] = previous
+	event.Metadata["digests"] = changed
+
+	event.SequenceNum = r.sequence.Add(1)
+
+	select {
+	case r.eventChan <- event:
+	default:
+		log.Error(nil, "Event channel full, dropping digest changed event")
+	}
+}
+
+// ParseRestartAlertThresholds parses a comma-separated list of restart counts, e.g. "25,50"
+func ParseRestartAlertThresholds(values []string) ([]int32, error) {
+	thresholds := make([]int32, 0, len(values))
+	for _, value := range values {
+		threshold, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid restart alert threshold %q: must be a positive integer", value)
+		}
+		thresholds = append(thresholds, int32(threshold))
+	}
+	return thresholds, nil
+}
+
+// checkPending emits a pending alert once per Pending stint when it exceeds the threshold
+func (r *PodReconciler) checkPending(ctx context.Context, adapter *PodAdapter, podKey string) {
+	state := r.podStates[podKey]
+	if state.pendingSince == nil || state.pendingAlerted || r.PendingAlertThreshold <= 0 {
+		return
+	}
+	pendingFor := time.Since(*state.pendingSince)
+	if pendingFor <= r.PendingAlertThreshold {
+		return
+	}
+
+	state.pendingAlerted = true
+	r.podStates[podKey] = state
+	pendingOverThresholdCounter.Inc()
+
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("Pod pending longer than threshold",
+		"pendingFor", pendingFor.Round(time.Second),
+		"threshold", r.PendingAlertThreshold,
+	)
+
+	event := model.NewPodEvent(
+		adapter.GetNamespace(),
+		adapter.GetName(),
+		adapter.GetUID(),
+		adapter.GetLabels(),
+		model.ResourceEventKindPendingAlert,
+		adapter.GetState(),
+		r.extractPodMetadata(adapter),
+		r.clusterID,
+		r.agentVersion,
+	)
+	event.Metadata["pendingSince"] = state.pendingSince.UTC()
+	event.Metadata["pendingSeconds"] = int64(pendingFor.Seconds())
+
+	event.SequenceNum = r.sequence.Add(1)
+
+	select {
+	case r.eventChan <- event:
+	default:
+		log.Error(nil, "Event channel full, dropping pending alert event")
+	}
+}
+
+// reportInitContainerFailures publishes an event for each init container failure not yet reported
+func (r *PodReconciler) reportInitContainerFailures(ctx context.Context, adapter *PodAdapter) {
+	log := ctrl.LoggerFrom(ctx)
+	podKey := adapter.GetNamespace() + "/" + adapter.GetName()
+
+	for _, failure := range adapter.GetInitContainerFailures() {
+		failureKey := fmt.Sprintf("%s/%s/%d", adapter.GetUID(), failure.containerName, failure.attempt)
+		reported := r.reportedInitFailures[podKey]
+		if _, ok := reported[failureKey]; ok {
+			continue
+		}
+		if reported == nil {
+			reported = make(map[string]struct{})
+			r.reportedInitFailures[podKey] = reported
+		}
+		reported[failureKey] = struct{}{}
+
+		initContainerFailuresCounter.Inc()
+		log.V(1).Info("Init container failed",
+			"container", failure.containerName,
+			"exitCode", failure.exitCode,
+			"reason", failure.reason,
+		)
+
+		event := model.NewPodEvent(
+			adapter.GetNamespace(),
+			adapter.GetName(),
+			adapter.GetUID(),
+			adapter.GetLabels(),
+			model.ResourceEventKindInitContainerFailed,
+			adapter.GetState(),
+			r.extractPodMetadata(adapter),
+			r.clusterID,
+			r.agentVersion,
+		)
+		event.Metadata["containerName"] = failure.containerName
+		event.Metadata["exitCode"] = failure.exitCode
+		event.Metadata["reason"] = failure.reason
+		event.Metadata["message"] = failure.message
+
+		event.SequenceNum = r.sequence.Add(1)
+
+		select {
+		case r.eventChan <- event:
+		default:
+			log.Error(nil, "Event channel full, dropping init container failure event", "container", failure.containerName)
+		}
 	}
 }
 
@@ -132,9 +580,9 @@ func (r *PodReconciler) hasStateChanged(last, current podState) bool {
 }
 
 func (r *PodReconciler) handleDeletion(ctx context.Context, namespace, name string) {
-	log := ctrl.LoggerFrom(ctx)
+	log := ctrl.LoggerFrom(ctx).WithValues("kind", "Pod")
 	podKey := namespace + "/" + name
-	log.V(1).Info("Pod deleted", "pod", podKey)
+	log.V(1).Info("Pod deleted")
 
 	// Send deletion event
 	event := model.NewResourceEventPayload(
@@ -143,6 +591,7 @@ func (r *PodReconciler) handleDeletion(ctx context.Context, namespace, name stri
 			Kind:      "Pod",
 			Name:      name,
 			Namespace: namespace,
+			UID:       r.podStates[podKey].uid,
 		},
 		nil,
 		model.ResourceEventKindDeleted,
@@ -152,17 +601,93 @@ func (r *PodReconciler) handleDeletion(ctx context.Context, namespace, name stri
 		r.agentVersion,
 	)
 
+	event.SequenceNum = r.sequence.Add(1)
+
 	select {
-	case r.eventChan <- event:
+	case deletionChannel(r.eventChan, r.DeletionChan) <- event:
 	default:
-		log.Error(nil, "Event channel full, dropping pod deletion event", "pod", podKey)
+		log.Error(nil, "Event channel full, dropping pod deletion event")
 	}
 
+	state, tracked := r.podStates[podKey]
 	delete(r.podStates, podKey)
+	delete(r.reportedInitFailures, podKey)
+	if tracked {
+		r.releaseWorkloadPod(state.workloadKey)
+	}
 }
 
-func (r *PodReconciler) publishEvent(adapter *PodAdapter, eventKind model.ResourceEventKind) {
-	event := model.NewPodEvent(
+// releaseWorkloadPod uncounts a deleted pod of a workload and forgets the workload's image
+// digests once none of its pods are tracked, so workloads that come and go, such as Jobs, do
+// not accumulate
+func (r *PodReconciler) releaseWorkloadPod(workload string) {
+	if workload == "" {
+		return
+	}
+	r.workloadPods[workload]--
+	if r.workloadPods[workload] > 0 {
+		return
+	}
+	delete(r.workloadPods, workload)
+	delete(r.imageDigests, workload)
+}
+
+// workloadKey returns the namespace/workload-name key of the pod's owning workload, empty when
+// the pod has no owner
+func workloadKey(adapter *PodAdapter) string {
+	workload := adapter.GetWorkloadName()
+	if workload == "" {
+		return ""
+	}
+	return adapter.GetNamespace() + "/" + workload
+}
+
+// deletionChannel returns the channel deletion events are sent on: deletionChan when set,
+// eventChan otherwise
+func deletionChannel(eventChan, deletionChan chan<- model.ResourceEventPayload) chan<- model.ResourceEventPayload {
+	if deletionChan != nil {
+		return deletionChan
+	}
+	return eventChan
+}
+
+// Snapshot returns the current state of every pod that passes the resource filter, for full-state syncs
+func (r *PodReconciler) Snapshot(ctx context.Context) ([]model.ResourceEventPayload, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	filter := r.filter.Load()
+	events := make([]model.ResourceEventPayload, 0, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if filter != nil {
+			if !filter.ShouldWatchNamespace(pod.Namespace) || !filter.ShouldWatchResource(pod.Labels) {
+				continue
+			}
+			if filter.HasNamespaceLabelFilters() {
+				nsLabels, err := r.namespaceLabels(ctx, pod.Namespace)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get namespace %s: %w", pod.Namespace, err)
+				}
+				if !filter.ShouldWatchNamespaceByLabels(nsLabels) {
+					continue
+				}
+			}
+		}
+		events = append(events, r.newEvent(NewPodAdapter(pod), model.ResourceEventKindUpdated))
+	}
+	return events, nil
+}
+
+// namespaceLabels returns the labels of a namespace for namespace label filtering
+func (r *PodReconciler) namespaceLabels(ctx context.Context, namespace string) (map[string]string, error) {
+	return r.NamespaceWatcher.Get(ctx, namespace)
+}
+
+func (r *PodReconciler) newEvent(adapter *PodAdapter, eventKind model.ResourceEventKind) model.ResourceEventPayload {
+	return model.NewPodEvent(
 		adapter.GetNamespace(),
 		adapter.GetName(),
 		adapter.GetUID(),
@@ -173,6 +698,11 @@ func (r *PodReconciler) publishEvent(adapter *PodAdapter, eventKind model.Resour
 		r.clusterID,
 		r.agentVersion,
 	)
+}
+
+func (r *PodReconciler) publishEvent(adapter *PodAdapter, eventKind model.ResourceEventKind) {
+	event := r.newEvent(adapter, eventKind)
+	event.SequenceNum = r.sequence.Add(1)
 
 	select {
 	case r.eventChan <- event:
@@ -197,5 +727,6 @@ func (r *PodReconciler) extractPodMetadata(adapter *PodAdapter) *model.PodMetada
 func (r *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Pod{}).
+		WithEventFilter(reconciler.NamespaceScopedPredicate(r.filter.Load)).
 		Complete(r)
 }
@@ -0,0 +1,555 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/apptrail-sh/agent/internal/reconciler"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func podWithInitStatus(status corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default", UID: "pod-uid"},
+		Status: corev1.PodStatus{
+			Phase:                 corev1.PodPending,
+			InitContainerStatuses: []corev1.ContainerStatus{status},
+		},
+	}
+}
+
+func TestPodReconciler_ReportInitContainerFailures(t *testing.T) {
+	events := make(chan model.ResourceEventPayload, 10)
+	r := NewPodReconciler(nil, nil, nil, events, "test-cluster", "v1.0.0", nil)
+	ctx := context.Background()
+
+	failed := corev1.ContainerStatus{
+		Name:         "migrate",
+		RestartCount: 0,
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 1,
+			Reason:   "Error",
+			Message:  "migration failed",
+		}},
+	}
+
+	// First failure is reported
+	r.reportInitContainerFailures(ctx, NewPodAdapter(podWithInitStatus(failed)))
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	event := <-events
+	if event.EventKind != model.ResourceEventKindInitContainerFailed {
+		t.Errorf("Expected INIT_CONTAINER_FAILED, got %s", event.EventKind)
+	}
+	if event.Metadata["containerName"] != "migrate" || event.Metadata["exitCode"] != int32(1) ||
+		event.Metadata["reason"] != "Error" || event.Metadata["message"] != "migration failed" {
+		t.Errorf("Unexpected metadata: %v", event.Metadata)
+	}
+
+	// The same run seen again, now as the last termination after a restart, is not reported twice
+	restarted := corev1.ContainerStatus{
+		Name:                 "migrate",
+		RestartCount:         1,
+		State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		LastTerminationState: failed.State,
+	}
+	r.reportInitContainerFailures(ctx, NewPodAdapter(podWithInitStatus(failed)))
+	r.reportInitContainerFailures(ctx, NewPodAdapter(podWithInitStatus(restarted)))
+	if len(events) != 0 {
+		t.Fatalf("Expected duplicate failure to be skipped, got %d events", len(events))
+	}
+
+	// A new failed run is reported
+	failedAgain := failed
+	failedAgain.RestartCount = 1
+	r.reportInitContainerFailures(ctx, NewPodAdapter(podWithInitStatus(failedAgain)))
+	if len(events) != 1 {
+		t.Fatalf("Expected new failure to be reported, got %d events", len(events))
+	}
+}
+
+func TestPodAdapter_GetInitContainerFailures_IgnoresSuccess(t *testing.T) {
+	succeeded := corev1.ContainerStatus{
+		Name: "init",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 0,
+			Reason:   "Completed",
+		}},
+	}
+
+	if failures := NewPodAdapter(podWithInitStatus(succeeded)).GetInitContainerFailures(); len(failures) != 0 {
+		t.Errorf("Expected no failures, got %v", failures)
+	}
+}
+
+func TestPodReconciler_PendingAlert(t *testing.T) {
+	events := make(chan model.ResourceEventPayload, 10)
+	r := NewPodReconciler(nil, nil, nil, events, "test-cluster", "v1.0.0", nil)
+	r.PendingAlertThreshold = time.Minute
+	ctx := context.Background()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "api-0",
+			Namespace:         "default",
+			UID:               "pod-uid",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-5 * time.Minute)),
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+
+	drain := func() []model.ResourceEventKind {
+		var kinds []model.ResourceEventKind
+		for len(events) > 0 {
+			kinds = append(kinds, (<-events).EventKind)
+		}
+		return kinds
+	}
+
+	// A new pod pending since creation, past the threshold, is alerted once
+	r.reconcilePod(ctx, NewPodAdapter(pod))
+	kinds := drain()
+	if len(kinds) != 2 || kinds[0] != model.ResourceEventKindCreated || kinds[1] != model.ResourceEventKindPendingAlert {
+		t.Fatalf("Expected CREATED then PENDING_ALERT, got %v", kinds)
+	}
+
+	r.reconcilePod(ctx, NewPodAdapter(pod))
+	if kinds := drain(); len(kinds) != 0 {
+		t.Fatalf("Expected no repeated alert, got %v", kinds)
+	}
+
+	// Running clears the pending clock
+	pod.Status.Phase = corev1.PodRunning
+	r.reconcilePod(ctx, NewPodAdapter(pod))
+	drain()
+	if r.podStates["default/api-0"].pendingSince != nil {
+		t.Error("Expected pendingSince to be cleared when Running")
+	}
+
+	// Back to Pending starts a fresh clock, so no immediate alert
+	pod.Status.Phase = corev1.PodPending
+	r.reconcilePod(ctx, NewPodAdapter(pod))
+	if kinds := drain(); len(kinds) != 1 || kinds[0] != model.ResourceEventKindStatusChange {
+		t.Fatalf("Expected only STATUS_CHANGE, got %v", kinds)
+	}
+	if r.podStates["default/api-0"].pendingSince == nil {
+		t.Error("Expected pendingSince to be set on transition to Pending")
+	}
+}
+
+func TestPodReconciler_PendingRequeue(t *testing.T) {
+	tests := []struct {
+		name      string
+		phase     corev1.PodPhase
+		threshold time.Duration
+		want      time.Duration
+	}{
+		{name: "pending pod is rechecked", phase: corev1.PodPending, threshold: time.Minute, want: DefaultPendingCheckInterval},
+		{name: "disabled alerting does not requeue", phase: corev1.PodPending, threshold: 0, want: 0},
+		{name: "negative threshold does not requeue", phase: corev1.PodPending, threshold: -time.Minute, want: 0},
+		{name: "running pod is not rechecked", phase: corev1.PodRunning, threshold: time.Minute, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default", UID: "pod-uid"},
+				Status:     corev1.PodStatus{Phase: tt.phase},
+			}
+			k8sClient := fake.NewClientBuilder().WithObjects(pod).Build()
+			events := make(chan model.ResourceEventPayload, 10)
+			r := NewPodReconciler(k8sClient, nil, nil, events, "test-cluster", "v1.0.0", nil)
+			r.PendingAlertThreshold = tt.threshold
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "api-0"}}
+			result, err := r.Reconcile(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if result != (ctrl.Result{RequeueAfter: tt.want}) {
+				t.Errorf("Expected RequeueAfter %v, got %+v", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestPodReconciler_TopologyViolation(t *testing.T) {
+	events := make(chan model.ResourceEventPayload, 10)
+	r := NewPodReconciler(nil, nil, nil, events, "test-cluster", "v1.0.0", nil)
+	r.PendingAlertThreshold = 0
+	ctx := context.Background()
+
+	message := "0/3 nodes are available: 3 node(s) didn't match pod topology spread constraints."
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default", UID: "pod-uid"},
+		Spec: corev1.PodSpec{
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+				MaxSkew:           1,
+				TopologyKey:       "topology.kubernetes.io/zone",
+				WhenUnsatisfiable: corev1.DoNotSchedule,
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: message,
+			}},
+		},
+	}
+
+	drain := func() []model.ResourceEventPayload {
+		var drained []model.ResourceEventPayload
+		for len(events) > 0 {
+			drained = append(drained, <-events)
+		}
+		return drained
+	}
+
+	r.reconcilePod(ctx, NewPodAdapter(pod))
+	drained := drain()
+	if len(drained) != 2 || drained[1].EventKind != model.ResourceEventKindTopologyViolation {
+		t.Fatalf("Expected CREATED then TOPOLOGY_VIOLATION, got %d events", len(drained))
+	}
+	violation := drained[1]
+	if violation.Metadata["scheduleMessage"] != message {
+		t.Errorf("Expected schedule message in metadata, got %v", violation.Metadata["scheduleMessage"])
+	}
+	if _, ok := violation.Metadata["topologySpreadConstraints"]; !ok {
+		t.Error("Expected topology spread constraints in metadata")
+	}
+
+	// Still unschedulable: reported once per stint
+	r.reconcilePod(ctx, NewPodAdapter(pod))
+	if drained := drain(); len(drained) != 0 {
+		t.Fatalf("Expected no repeated violation, got %d events", len(drained))
+	}
+
+	// Scheduled clears the stint, a new violation is reported again
+	pod.Status.Conditions[0].Status = corev1.ConditionTrue
+	r.reconcilePod(ctx, NewPodAdapter(pod))
+	pod.Status.Conditions[0].Status = corev1.ConditionFalse
+	r.reconcilePod(ctx, NewPodAdapter(pod))
+	drained = drain()
+	if len(drained) != 1 || drained[0].EventKind != model.ResourceEventKindTopologyViolation {
+		t.Fatalf("Expected a new TOPOLOGY_VIOLATION, got %d events", len(drained))
+	}
+}
+
+func TestPodAdapter_GetTopologySpreadViolation_OtherUnschedulable(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: "0/3 nodes are available: 3 Insufficient cpu.",
+	}}}}
+
+	if _, violated := NewPodAdapter(pod).GetTopologySpreadViolation(); violated {
+		t.Error("Expected insufficient resources not to count as a topology violation")
+	}
+}
+
+func TestPodReconciler_RestartThresholds(t *testing.T) {
+	events := make(chan model.ResourceEventPayload, 10)
+	r := NewPodReconciler(nil, nil, nil, events, "test-cluster", "v1.0.0", nil)
+	ctx := context.Background()
+
+	podWithRestarts := func(app, sidecar int32) *PodAdapter {
+		return NewPodAdapter(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default", UID: "pod-uid"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "app", RestartCount: app},
+					{Name: "sidecar", RestartCount: sidecar},
+				},
+			},
+		})
+	}
+	thresholdEvents := func() []model.ResourceEventPayload {
+		var found []model.ResourceEventPayload
+		for len(events) > 0 {
+			if event := <-events; event.EventKind == model.ResourceEventKindHighRestartCount {
+				found = append(found, event)
+			}
+		}
+		return found
+	}
+
+	// Pods first seen above a threshold are not reported
+	r.reconcilePod(ctx, podWithRestarts(11, 0))
+	if got := thresholdEvents(); len(got) != 0 {
+		t.Fatalf("Expected no threshold event for a new pod, got %d", len(got))
+	}
+	delete(r.podStates, "default/api-0")
+
+	r.reconcilePod(ctx, podWithRestarts(8, 1))
+	thresholdEvents()
+
+	r.reconcilePod(ctx, podWithRestarts(8, 2))
+	got := thresholdEvents()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 threshold event at 10 restarts, got %d", len(got))
+	}
+	if got[0].Metadata["restartThreshold"] != int32(10) || got[0].Metadata["restartCount"] != int32(10) {
+		t.Errorf("Unexpected metadata: %v", got[0].Metadata)
+	}
+	breakdown, ok := got[0].Metadata["containerRestarts"].(map[string]int32)
+	if !ok || breakdown["app"] != 8 || breakdown["sidecar"] != 2 {
+		t.Errorf("Expected per-container restart breakdown, got %v", got[0].Metadata["containerRestarts"])
+	}
+
+	// Staying above the threshold does not report again
+	r.reconcilePod(ctx, podWithRestarts(12, 2))
+	if got := thresholdEvents(); len(got) != 0 {
+		t.Errorf("Expected no event between thresholds, got %d", len(got))
+	}
+
+	// Crossing two thresholds at once reports both
+	r.reconcilePod(ctx, podWithRestarts(48, 2))
+	got = thresholdEvents()
+	if len(got) != 2 || got[0].Metadata["restartThreshold"] != int32(25) || got[1].Metadata["restartThreshold"] != int32(50) {
+		t.Errorf("Expected events for thresholds 25 and 50, got %v", got)
+	}
+}
+
+func TestParseRestartAlertThresholds(t *testing.T) {
+	got, err := ParseRestartAlertThresholds([]string{"25", "50"})
+	if err != nil || len(got) != 2 || got[0] != 25 || got[1] != 50 {
+		t.Errorf("ParseRestartAlertThresholds() = %v, %v", got, err)
+	}
+	for _, invalid := range []string{"0", "-5", "ten"} {
+		if _, err := ParseRestartAlertThresholds([]string{invalid}); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestPodReconciler_ImageDigests(t *testing.T) {
+	events := make(chan model.ResourceEventPayload, 10)
+	r := NewPodReconciler(nil, nil, nil, events, "test-cluster", "v1.0.0", nil)
+	ctx := context.Background()
+
+	pod := func(name string, phase corev1.PodPhase, version, digest string) *PodAdapter {
+		return NewPodAdapter(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{"pod-template-hash": "5d8f7", "app.kubernetes.io/version": version},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "ReplicaSet", Name: "api-5d8f7"},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: phase,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "app", ImageID: "docker.io/acme/api@" + digest},
+				},
+			},
+		})
+	}
+	digestEvents := func() []model.ResourceEventPayload {
+		var found []model.ResourceEventPayload
+		for len(events) > 0 {
+			if event := <-events; event.EventKind == model.ResourceEventKindDigestChanged {
+				found = append(found, event)
+			}
+		}
+		return found
+	}
+
+	r.reconcilePod(ctx, pod("api-a", corev1.PodRunning, "1.0", "sha256:aaa"))
+	if got := r.imageDigests["default/api"].digests["app"]; got != "sha256:aaa" {
+		t.Fatalf("Expected digest to be tracked under the deployment, got %q", got)
+	}
+
+	// Same version, new digest once the replacement pod starts running
+	r.reconcilePod(ctx, pod("api-b", corev1.PodPending, "1.0", "sha256:bbb"))
+	r.reconcilePod(ctx, pod("api-b", corev1.PodRunning, "1.0", "sha256:bbb"))
+	got := digestEvents()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 digest changed event, got %d", len(got))
+	}
+	if got[0].Metadata["workload"] != "api" || got[0].Metadata["digests"].(map[string]string)["app"] != "sha256:bbb" ||
+		got[0].Metadata["previousDigests"].(map[string]string)["app"] != "sha256:aaa" {
+		t.Errorf("Unexpected metadata: %v", got[0].Metadata)
+	}
+
+	// A version bump changes the digest legitimately
+	r.reconcilePod(ctx, pod("api-c", corev1.PodPending, "1.1", "sha256:ccc"))
+	r.reconcilePod(ctx, pod("api-c", corev1.PodRunning, "1.1", "sha256:ccc"))
+	if got := digestEvents(); len(got) != 0 {
+		t.Errorf("Expected no event when the version changed, got %d", len(got))
+	}
+
+	// Pods first seen running don't overwrite known digests
+	r.reconcilePod(ctx, pod("api-old", corev1.PodRunning, "1.1", "sha256:aaa"))
+	if got := r.imageDigests["default/api"].digests["app"]; got != "sha256:ccc" || len(digestEvents()) != 0 {
+		t.Errorf("Expected first-seen pod to leave digest sha256:ccc, got %q", got)
+	}
+
+	// Digests are forgotten once the workload's last pod is deleted
+	for _, name := range []string{"api-a", "api-b", "api-c"} {
+		r.handleDeletion(ctx, "default", name)
+	}
+	if _, ok := r.imageDigests["default/api"]; !ok {
+		t.Fatal("Expected digests to be kept while api-old is running")
+	}
+	r.handleDeletion(ctx, "default", "api-old")
+	if _, ok := r.imageDigests["default/api"]; ok {
+		t.Error("Expected digests to be removed with the workload's last pod")
+	}
+	if len(r.workloadPods) != 0 {
+		t.Errorf("Expected no pods counted once all are deleted, got %v", r.workloadPods)
+	}
+
+	// Deleting an untracked pod leaves other workloads' counts alone
+	r.reconcilePod(ctx, pod("api-d", corev1.PodRunning, "1.2", "sha256:ddd"))
+	r.handleDeletion(ctx, "default", "api-unknown")
+	if got := r.workloadPods["default/api"]; got != 1 {
+		t.Errorf("Expected 1 pod counted for default/api, got %d", got)
+	}
+}
+
+func TestImageDigest(t *testing.T) {
+	tests := map[string]string{
+		"docker.io/library/nginx@sha256:abc": "sha256:abc",
+		"docker-pullable://nginx@sha256:def": "sha256:def",
+		"sha256:0123456789abcdef":            "",
+		"":                                   "",
+	}
+	for imageID, want := range tests {
+		if got := imageDigest(imageID); got != want {
+			t.Errorf("imageDigest(%q) = %q, want %q", imageID, got, want)
+		}
+	}
+}
+
+func TestPodReconciler_DeletionChan(t *testing.T) {
+	// A full event channel does not hold up deletions
+	events := make(chan model.ResourceEventPayload)
+	deletions := make(chan model.ResourceEventPayload, 1)
+	r := NewPodReconciler(nil, nil, nil, events, "test-cluster", "v1.0.0", nil)
+	r.DeletionChan = deletions
+	r.podStates["default/api-0"] = podState{uid: "pod-uid"}
+
+	r.handleDeletion(context.Background(), "default", "api-0")
+
+	if len(deletions) != 1 {
+		t.Fatalf("Expected the deletion on the deletion channel, got %d events", len(deletions))
+	}
+	event := <-deletions
+	if event.EventKind != model.ResourceEventKindDeleted || event.Resource.UID != "pod-uid" {
+		t.Errorf("Expected DELETED event for pod-uid, got %s for %q", event.EventKind, event.Resource.UID)
+	}
+}
+
+func TestPodReconciler_DeletionAfterFilterReload(t *testing.T) {
+	ctx := context.Background()
+	tracked := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "shop", UID: "tracked-uid"}}
+	untracked := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "shop", UID: "untracked-uid"}}
+	k8sClient := fake.NewClientBuilder().WithObjects(tracked).Build()
+
+	events := make(chan model.ResourceEventPayload, 10)
+	r := NewPodReconciler(k8sClient, nil, nil, events, "test-cluster", "v1.0.0", nil)
+	reconcile := func(pod *corev1.Pod) {
+		t.Helper()
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}}
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	reconcile(tracked)
+	if event := <-events; event.EventKind != model.ResourceEventKindCreated {
+		t.Fatalf("Expected CREATED, got %s", event.EventKind)
+	}
+
+	// A reload excludes the namespace; the delete event still reaches the reconciler
+	r.SetFilter(NewResourceFilter(ResourceFilterConfig{TrackPods: true, ExcludeNamespaces: []string{"shop"}}))
+	if !reconciler.NamespaceScopedPredicate(r.filter.Load).Delete(event.DeleteEvent{Object: tracked}) {
+		t.Fatal("Expected the delete event to pass the predicate")
+	}
+
+	// Updates of the still existing pod are filtered out
+	reconcile(tracked)
+	if len(events) != 0 {
+		t.Fatalf("Expected no events for the excluded namespace, got %d", len(events))
+	}
+
+	if err := k8sClient.Delete(ctx, tracked); err != nil {
+		t.Fatalf("Failed to delete pod: %v", err)
+	}
+	reconcile(tracked)
+	reconcile(untracked)
+
+	if len(events) != 1 {
+		t.Fatalf("Expected only the tracked pod's deletion, got %d events", len(events))
+	}
+	if event := <-events; event.EventKind != model.ResourceEventKindDeleted || event.Resource.UID != "tracked-uid" {
+		t.Errorf("Expected DELETED for tracked-uid, got %s for %q", event.EventKind, event.Resource.UID)
+	}
+	if _, ok := r.podStates["shop/api-0"]; ok {
+		t.Error("Expected the deleted pod's state to be released")
+	}
+}
+
+func TestPodReconciler_NamespaceLabelFilter(t *testing.T) {
+	ctx := context.Background()
+	shop := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"team": "a"}}}
+	billing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "billing"}}
+	shopPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "shop", UID: "shop-uid"}}
+	billingPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "billing", UID: "billing-uid"}}
+	k8sClient := fake.NewClientBuilder().WithObjects(shop, billing, shopPod, billingPod).Build()
+
+	events := make(chan model.ResourceEventPayload, 10)
+	resourceFilter := NewResourceFilter(ResourceFilterConfig{TrackPods: true, WatchNamespaceLabels: []string{"team=a"}})
+	r := NewPodReconciler(k8sClient, nil, nil, events, "test-cluster", "v1.0.0", resourceFilter)
+
+	reconcile := func(pod *corev1.Pod) {
+		t.Helper()
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}}
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+	expectEvents := func(want ...model.ResourceEventKind) {
+		t.Helper()
+		if len(events) != len(want) {
+			t.Fatalf("Expected %d events, got %d", len(want), len(events))
+		}
+		for _, kind := range want {
+			if event := <-events; event.EventKind != kind {
+				t.Errorf("Expected %s, got %s", kind, event.EventKind)
+			}
+		}
+	}
+
+	reconcile(shopPod)
+	reconcile(billingPod)
+	expectEvents(model.ResourceEventKindCreated)
+
+	// Deleting the namespace removes its labels; the tracked pod's deletion is still reported
+	for _, obj := range []client.Object{shopPod, shop, billingPod} {
+		if err := k8sClient.Delete(ctx, obj); err != nil {
+			t.Fatalf("Failed to delete %s: %v", obj.GetName(), err)
+		}
+	}
+	reconcile(shopPod)
+	reconcile(billingPod)
+	expectEvents(model.ResourceEventKindDeleted)
+	if _, ok := r.podStates["shop/api-0"]; ok {
+		t.Error("Expected the deleted pod's state to be removed")
+	}
+}
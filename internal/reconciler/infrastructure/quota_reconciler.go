@@ -0,0 +1,255 @@
+package infrastructure
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/apptrail-sh/agent/internal/reconciler"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DefaultQuotaWarningThreshold is the used/hard fraction above which a quota warning is emitted
+const DefaultQuotaWarningThreshold = 0.8
+
+var (
+	quotaUtilizationGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "apptrail_namespace_quota_utilization",
+		Help: "Highest used/hard fraction across the ResourceQuotas of a namespace, per resource",
+	}, []string{"namespace", "resource"})
+
+	quotaMetricsRegistered = false
+)
+
+// ResourceQuotaReconciler reconciles ResourceQuota objects to warn before a namespace runs out of quota
+type ResourceQuotaReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	Recorder         record.EventRecorder
+	eventChan        chan<- model.ResourceEventPayload
+	clusterID        string
+	agentVersion     string
+	filter           atomic.Pointer[ResourceFilter] // Swapped on config reload
+	warningThreshold float64
+
+	// Track last known utilization per quota (namespace/name) to detect threshold crossings
+	quotaStates map[string]quotaState
+
+	// sequence numbers emitted events so same-quota events keep their order in a batch
+	sequence atomic.Uint64
+}
+
+type quotaState struct {
+	namespace string
+	// usedFraction is used/hard per resource name
+	usedFraction map[string]float64
+	// overThreshold holds the resources currently above the warning threshold
+	overThreshold map[string]struct{}
+}
+
+func NewResourceQuotaReconciler(
+	client client.Client,
+	scheme *runtime.Scheme,
+	recorder record.EventRecorder,
+	eventChan chan<- model.ResourceEventPayload,
+	clusterID, agentVersion string,
+	filter *ResourceFilter,
+	warningThreshold float64,
+) *ResourceQuotaReconciler {
+	// Register metrics only once
+	if !quotaMetricsRegistered {
+		metrics.Registry.MustRegister(quotaUtilizationGauge)
+		quotaMetricsRegistered = true
+	}
+
+	if warningThreshold <= 0 {
+		warningThreshold = DefaultQuotaWarningThreshold
+	}
+
+	r := &ResourceQuotaReconciler{
+		Client:           client,
+		Scheme:           scheme,
+		Recorder:         recorder,
+		eventChan:        eventChan,
+		clusterID:        clusterID,
+		agentVersion:     agentVersion,
+		warningThreshold: warningThreshold,
+		quotaStates:      make(map[string]quotaState),
+	}
+	r.filter.Store(filter)
+	return r
+}
+
+// SetFilter replaces the resource filter used by subsequent reconciles
+func (r *ResourceQuotaReconciler) SetFilter(filter *ResourceFilter) {
+	r.filter.Store(filter)
+}
+
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch
+
+func (r *ResourceQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// Apply namespace filter
+	if filter := r.filter.Load(); filter != nil && !filter.ShouldWatchNamespace(req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
+	quota := &corev1.ResourceQuota{}
+	if err := r.Get(ctx, req.NamespacedName, quota); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Quota was deleted
+			r.handleDeletion(req.Namespace, req.Name)
+			return ctrl.Result{}, nil
+		}
+		return reconciler.HandleReconcileError(ctx, reconciler.NewReconcileError("get", req.String(), err))
+	}
+
+	log.V(1).Info("Reconciling ResourceQuota", "namespace", req.Namespace, "name", req.Name)
+
+	r.reconcileQuota(ctx, quota)
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ResourceQuotaReconciler) reconcileQuota(ctx context.Context, quota *corev1.ResourceQuota) {
+	log := ctrl.LoggerFrom(ctx)
+	key := quota.Namespace + "/" + quota.Name
+
+	current := quotaState{
+		namespace:     quota.Namespace,
+		usedFraction:  usedFractions(quota),
+		overThreshold: make(map[string]struct{}),
+	}
+	for resource, fraction := range current.usedFraction {
+		if fraction >= r.warningThreshold {
+			current.overThreshold[resource] = struct{}{}
+		}
+	}
+
+	previous := r.quotaStates[key]
+	r.quotaStates[key] = current
+	r.updateGauges(quota.Namespace, previous, current)
+
+	// Warn only when a resource newly crosses the threshold, not on every status update
+	var crossed []string
+	for resource := range current.overThreshold {
+		if _, ok := previous.overThreshold[resource]; !ok {
+			crossed = append(crossed, resource)
+		}
+	}
+	if len(crossed) == 0 {
+		return
+	}
+	slices.Sort(crossed)
+
+	log.Info("ResourceQuota utilization above warning threshold",
+		"namespace", quota.Namespace,
+		"quota", quota.Name,
+		"resources", strings.Join(crossed, ","),
+		"threshold", r.warningThreshold,
+	)
+	r.publishWarning(quota, current, crossed)
+}
+
+// usedFractions returns used/hard for every resource with a non-zero hard limit
+func usedFractions(quota *corev1.ResourceQuota) map[string]float64 {
+	fractions := make(map[string]float64, len(quota.Status.Hard))
+	for name, hard := range quota.Status.Hard {
+		if hard.IsZero() {
+			continue
+		}
+		used := quota.Status.Used[name]
+		fractions[string(name)] = used.AsApproximateFloat64() / hard.AsApproximateFloat64()
+	}
+	return fractions
+}
+
+// updateGauges refreshes the utilization gauge for every resource touched by a quota change.
+// Several quotas can constrain the same resource in a namespace, so the highest fraction wins.
+func (r *ResourceQuotaReconciler) updateGauges(namespace string, previous, current quotaState) {
+	resources := maps.Clone(current.usedFraction)
+	if resources == nil {
+		resources = make(map[string]float64)
+	}
+	for resource := range previous.usedFraction {
+		resources[resource] = 0
+	}
+
+	for resource := range resources {
+		highest, found := 0.0, false
+		for _, state := range r.quotaStates {
+			if state.namespace != namespace {
+				continue
+			}
+			if fraction, ok := state.usedFraction[resource]; ok && (!found || fraction > highest) {
+				highest, found = fraction, true
+			}
+		}
+		if found {
+			quotaUtilizationGauge.WithLabelValues(namespace, resource).Set(highest)
+		} else {
+			quotaUtilizationGauge.DeleteLabelValues(namespace, resource)
+		}
+	}
+}
+
+func (r *ResourceQuotaReconciler) handleDeletion(namespace, name string) {
+	key := namespace + "/" + name
+	previous, ok := r.quotaStates[key]
+	if !ok {
+		return
+	}
+	delete(r.quotaStates, key)
+	r.updateGauges(namespace, previous, quotaState{namespace: namespace})
+}
+
+func (r *ResourceQuotaReconciler) publishWarning(quota *corev1.ResourceQuota, state quotaState, crossed []string) {
+	event := model.NewResourceEventPayload(
+		model.ResourceTypeResourceQuota,
+		model.ResourceRef{
+			Kind:      "ResourceQuota",
+			Name:      quota.Name,
+			Namespace: quota.Namespace,
+			UID:       string(quota.UID),
+		},
+		quota.Labels,
+		model.ResourceEventKindQuotaWarning,
+		nil,
+		map[string]any{
+			"usedFraction":      state.usedFraction,
+			"exceededResources": crossed,
+			"warningThreshold":  r.warningThreshold,
+		},
+		r.clusterID,
+		r.agentVersion,
+	)
+	event.SequenceNum = r.sequence.Add(1)
+
+	select {
+	case r.eventChan <- event:
+	default:
+		// Log if channel is full but don't block
+		ctrl.Log.Error(nil, "Event channel full, dropping quota warning event",
+			"namespace", quota.Namespace,
+			"quota", quota.Name,
+		)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *ResourceQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.ResourceQuota{}).
+		Complete(r)
+}
@@ -0,0 +1,77 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func quotaWithUsage(usedCPU, usedPods string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "team-a", UID: "quota-uid"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse("4"),
+				corev1.ResourcePods:        resource.MustParse("10"),
+			},
+			Used: corev1.ResourceList{
+				corev1.ResourceRequestsCPU: resource.MustParse(usedCPU),
+				corev1.ResourcePods:        resource.MustParse(usedPods),
+			},
+		},
+	}
+}
+
+func TestResourceQuotaReconciler_ReconcileQuota(t *testing.T) {
+	events := make(chan model.ResourceEventPayload, 10)
+	r := NewResourceQuotaReconciler(nil, nil, nil, events, "test-cluster", "v1.0.0", nil, 0.8)
+	ctx := context.Background()
+
+	// Below threshold: no warning, gauge set
+	r.reconcileQuota(ctx, quotaWithUsage("2", "5"))
+	if len(events) != 0 {
+		t.Fatalf("Expected no event below threshold, got %d", len(events))
+	}
+	if got := testutil.ToFloat64(quotaUtilizationGauge.WithLabelValues("team-a", "requests.cpu")); got != 0.5 {
+		t.Errorf("Expected cpu utilization 0.5, got %v", got)
+	}
+
+	// CPU crosses the threshold
+	r.reconcileQuota(ctx, quotaWithUsage("3500m", "5"))
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	event := <-events
+	if event.EventKind != model.ResourceEventKindQuotaWarning {
+		t.Errorf("Expected QUOTA_WARNING, got %s", event.EventKind)
+	}
+	fractions, ok := event.Metadata["usedFraction"].(map[string]float64)
+	if !ok || fractions["requests.cpu"] != 0.875 || fractions["pods"] != 0.5 {
+		t.Errorf("Unexpected usedFraction metadata: %v", event.Metadata["usedFraction"])
+	}
+
+	// Still above threshold: no repeated warning
+	r.reconcileQuota(ctx, quotaWithUsage("3600m", "5"))
+	if len(events) != 0 {
+		t.Fatalf("Expected no repeated warning, got %d", len(events))
+	}
+
+	// Drops below and crosses again: warns again
+	r.reconcileQuota(ctx, quotaWithUsage("1", "5"))
+	r.reconcileQuota(ctx, quotaWithUsage("4", "5"))
+	if len(events) != 1 {
+		t.Fatalf("Expected warning after re-crossing, got %d", len(events))
+	}
+	<-events
+
+	// Deletion removes the gauge
+	r.handleDeletion("team-a", "compute")
+	if n := testutil.CollectAndCount(quotaUtilizationGauge); n != 0 {
+		t.Errorf("Expected gauge series to be removed, got %d", n)
+	}
+}
@@ -0,0 +1,35 @@
+package reconciler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ResourceLogFields returns the standard key/value pairs added to a resource's log lines. The
+// namespace and name are left out, as controller-runtime already sets them on the reconcile logger.
+func ResourceLogFields(adapter ResourceAdapter) []any {
+	return []any{"kind", adapter.GetKind()}
+}
+
+// reconcilerLogFields extends ResourceLogFields with the workload version, phase and a
+// correlationID shared by every log line of the same rollout. Version and phase are
+// omitted until they are known.
+func reconcilerLogFields(adapter WorkloadResourceAdapter, version, phase string) []any {
+	fields := ResourceLogFields(adapter)
+	if version != "" {
+		fields = append(fields,
+			"version", version,
+			"correlationID", rolloutCorrelationID(adapter.GetNamespace(), adapter.GetName(), adapter.GetKind(), version))
+	}
+	if phase != "" {
+		fields = append(fields, "phase", phase)
+	}
+	return fields
+}
+
+// rolloutCorrelationID identifies a rollout of a workload to a version. It is derived rather
+// than stored so it stays the same across agent restarts and leader changes.
+func rolloutCorrelationID(namespace, name, kind, version string) string {
+	sum := sha256.Sum256([]byte(namespace + "/" + name + "/" + kind + "/" + version))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,60 @@
+package reconciler
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcilerLogFields(t *testing.T) {
+	workload := &DeploymentAdapter{Deployment: &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+	}}
+	correlationID := rolloutCorrelationID("default", "api", "Deployment", "1.2.0")
+
+	tests := []struct {
+		name    string
+		version string
+		phase   string
+		want    []any
+	}{
+		{
+			name: "version and phase unknown",
+			want: []any{"kind", "Deployment"},
+		},
+		{
+			name:    "version known",
+			version: "1.2.0",
+			want:    []any{"kind", "Deployment", "version", "1.2.0", "correlationID", correlationID},
+		},
+		{
+			name:    "version and phase known",
+			version: "1.2.0",
+			phase:   phaseRollingOut,
+			want:    []any{"kind", "Deployment", "version", "1.2.0", "correlationID", correlationID, "phase", phaseRollingOut},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reconcilerLogFields(workload, tt.version, tt.phase); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reconcilerLogFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolloutCorrelationID(t *testing.T) {
+	id := rolloutCorrelationID("default", "api", "Deployment", "1.2.0")
+	if len(id) != 16 {
+		t.Errorf("Expected 16 character correlation ID, got %q", id)
+	}
+	if again := rolloutCorrelationID("default", "api", "Deployment", "1.2.0"); again != id {
+		t.Errorf("Expected stable correlation ID, got %q and %q", id, again)
+	}
+	if next := rolloutCorrelationID("default", "api", "Deployment", "1.3.0"); next == id {
+		t.Error("Expected a new correlation ID for a new version")
+	}
+}
@@ -0,0 +1,67 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Outcomes of a workload reconcile, as reported in the reconcile metrics
+const (
+	ReconcileOutcomeSuccess        = "success"
+	ReconcileOutcomeNotFound       = "not_found"
+	ReconcileOutcomePhaseUnchanged = "phase_unchanged"
+	ReconcileOutcomeNoVersion      = "no_version"
+	ReconcileOutcomeError          = "error"
+)
+
+var (
+	reconcileDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "apptrail_reconcile_duration_seconds",
+		Help:    "Duration of workload reconciles by kind, namespace and outcome",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+	}, []string{"kind", "namespace", "outcome"})
+
+	reconcileTotalCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_reconcile_total",
+		Help: "Number of workload reconciles by kind and outcome",
+	}, []string{"kind", "outcome"})
+)
+
+// reconcileOutcomeKey carries the outcome recorder of a reconcile in its context
+type reconcileOutcomeKey struct{}
+
+// setReconcileOutcome records the outcome of the reconcile running with ctx, if it is measured
+func setReconcileOutcome(ctx context.Context, outcome string) {
+	if recorded, ok := ctx.Value(reconcileOutcomeKey{}).(*string); ok {
+		*recorded = outcome
+	}
+}
+
+// ReconcileMetricsMiddleware runs a workload reconcile and records its duration and outcome.
+// The outcome is set by the reconcile through its context; otherwise it is derived from the
+// returned error.
+func ReconcileMetricsMiddleware(
+	ctx context.Context,
+	kind string,
+	req ctrl.Request,
+	reconcile func(context.Context, ctrl.Request) (ctrl.Result, error),
+) (ctrl.Result, error) {
+	var outcome string
+	start := time.Now()
+
+	result, err := reconcile(context.WithValue(ctx, reconcileOutcomeKey{}, &outcome), req)
+
+	switch {
+	case err != nil:
+		outcome = ReconcileOutcomeError
+	case outcome == "":
+		outcome = ReconcileOutcomeSuccess
+	}
+	reconcileDurationHistogram.WithLabelValues(kind, req.Namespace, outcome).Observe(time.Since(start).Seconds())
+	reconcileTotalCounter.WithLabelValues(kind, outcome).Inc()
+
+	return result, err
+}
@@ -0,0 +1,73 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestReconcileMetricsMiddleware(t *testing.T) {
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "shop", Name: "api"}}
+	nonRetryable := &ReconcileError{Op: "get", Resource: "shop/api", Err: errors.New("forbidden")}
+
+	tests := []struct {
+		name      string
+		reconcile func(context.Context, ctrl.Request) (ctrl.Result, error)
+		outcome   string
+		wantErr   bool
+	}{
+		{
+			name: "success",
+			reconcile: func(context.Context, ctrl.Request) (ctrl.Result, error) {
+				return ctrl.Result{}, nil
+			},
+			outcome: ReconcileOutcomeSuccess,
+		},
+		{
+			name: "set by reconcile",
+			reconcile: func(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+				setReconcileOutcome(ctx, ReconcileOutcomePhaseUnchanged)
+				return ctrl.Result{}, nil
+			},
+			outcome: ReconcileOutcomePhaseUnchanged,
+		},
+		{
+			name: "returned error",
+			reconcile: func(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+				setReconcileOutcome(ctx, ReconcileOutcomeNotFound)
+				return ctrl.Result{}, errors.New("timeout")
+			},
+			outcome: ReconcileOutcomeError,
+			wantErr: true,
+		},
+		{
+			name: "dropped non-retryable error",
+			reconcile: func(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+				return HandleReconcileError(ctx, nonRetryable)
+			},
+			outcome: ReconcileOutcomeError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind := "Test" + tt.name
+			series := testutil.CollectAndCount(reconcileDurationHistogram)
+			_, err := ReconcileMetricsMiddleware(context.Background(), kind, req, tt.reconcile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconcileMetricsMiddleware() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := testutil.ToFloat64(reconcileTotalCounter.WithLabelValues(kind, tt.outcome)); got != 1 {
+				t.Errorf("apptrail_reconcile_total{outcome=%q} = %v, want 1", tt.outcome, got)
+			}
+			if got := testutil.CollectAndCount(reconcileDurationHistogram); got != series+1 {
+				t.Errorf("Expected one new duration series, got %d", got-series)
+			}
+		})
+	}
+}
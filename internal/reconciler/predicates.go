@@ -1,11 +1,105 @@
 package reconciler
 
 import (
+	"strings"
+
+	"github.com/apptrail-sh/agent/internal/filter"
+
 	v1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
+// NamespaceScopedPredicate drops events for objects in namespaces the filter excludes, so they
+// are never enqueued. The filter is looked up per event so reloaded filters apply immediately;
+// a nil filter allows everything. Deletes always pass: the object may have been tracked before
+// a reload excluded its namespace, and only the reconciler knows whether to clean it up.
+func NamespaceScopedPredicate(currentFilter func() *filter.ResourceFilter) predicate.Predicate {
+	allowed := func(obj client.Object) bool {
+		resourceFilter := currentFilter()
+		return resourceFilter == nil || obj == nil || resourceFilter.ShouldWatchNamespace(obj.GetNamespace())
+	}
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return allowed(e.Object) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return true },
+		GenericFunc: func(e event.GenericEvent) bool { return allowed(e.Object) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return allowed(e.ObjectNew) },
+	}
+}
+
+// versionLabelKey is the label holding the workload version
+const versionLabelKey = "app.kubernetes.io/version"
+
+// WorkloadLabelChangedPredicate allows creates and updates where the label key was added,
+// removed or changed its value
+func WorkloadLabelChangedPredicate(key string) predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return true },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return true
+			}
+			oldValue, oldOK := e.ObjectOld.GetLabels()[key]
+			newValue, newOK := e.ObjectNew.GetLabels()[key]
+			return oldOK != newOK || oldValue != newValue
+		},
+	}
+}
+
+// VersionLabelsChangedPredicate allows updates that change any of the version label keys;
+// no keys means the app.kubernetes.io/version label
+func VersionLabelsChangedPredicate(keys []string) predicate.Predicate {
+	if len(keys) == 0 {
+		return WorkloadLabelChangedPredicate(versionLabelKey)
+	}
+	predicates := make([]predicate.Predicate, 0, len(keys))
+	for _, key := range keys {
+		predicates = append(predicates, WorkloadLabelChangedPredicate(key))
+	}
+	return predicate.Or(predicates...)
+}
+
+// AnnotationChangedPredicate allows updates where any annotation with the given prefix was
+// added, removed or changed, so annotation-driven behavior applies without waiting for a status change.
+func AnnotationChangedPredicate(prefix string) predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return true },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return true
+			}
+			return prefixedAnnotationsChanged(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations(), prefix)
+		},
+	}
+}
+
+// prefixedAnnotationsChanged returns true if annotations matching prefix differ between old and new.
+func prefixedAnnotationsChanged(oldAnnotations, newAnnotations map[string]string, prefix string) bool {
+	for key, oldValue := range oldAnnotations {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if newValue, ok := newAnnotations[key]; !ok || newValue != oldValue {
+			return true
+		}
+	}
+	for key := range newAnnotations {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if _, ok := oldAnnotations[key]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
 // DeploymentStatusChangedPredicate allows generation changes and status changes
 // that affect rollout phase detection (replicas, conditions, observed generation).
 func DeploymentStatusChangedPredicate() predicate.Predicate {
@@ -113,6 +207,10 @@ func statefulSetStatusChanged(oldObj, newObj *v1.StatefulSet) bool {
 	if oldStatus.ObservedGeneration != newStatus.ObservedGeneration {
 		return true
 	}
+	// The current revision is the version of StatefulSets without a version label
+	if oldStatus.CurrentRevision != newStatus.CurrentRevision || oldStatus.UpdateRevision != newStatus.UpdateRevision {
+		return true
+	}
 
 	return false
 }
@@ -167,3 +265,28 @@ func daemonSetStatusChanged(oldObj, newObj *v1.DaemonSet) bool {
 
 	return false
 }
+
+// VirtualMachineStatusChangedPredicate allows generation changes, version label changes and
+// status changes that affect phase detection of KubeVirt VirtualMachines.
+func VirtualMachineStatusChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return true },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return true },
+		GenericFunc: func(e event.GenericEvent) bool { return true },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldObj, okOld := e.ObjectOld.(*unstructured.Unstructured)
+			newObj, okNew := e.ObjectNew.(*unstructured.Unstructured)
+			if !okOld || !okNew {
+				return true
+			}
+			if oldObj.GetGeneration() != newObj.GetGeneration() {
+				return true
+			}
+			oldVM := &VirtualMachineAdapter{VirtualMachine: oldObj}
+			newVM := &VirtualMachineAdapter{VirtualMachine: newObj}
+			return oldVM.GetVersion() != newVM.GetVersion() ||
+				oldVM.GetPrintableStatus() != newVM.GetPrintableStatus() ||
+				oldVM.readyCondition() != newVM.readyCondition()
+		},
+	}
+}
@@ -3,10 +3,13 @@ package reconciler
 import (
 	"testing"
 
+	"github.com/apptrail-sh/agent/internal/filter"
+
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 func TestDeploymentStatusChangedPredicate(t *testing.T) {
@@ -150,6 +153,201 @@ func TestDeploymentStatusChangedPredicate_OtherEvents(t *testing.T) {
 	}
 }
 
+func TestWorkloadLabelChangedPredicate(t *testing.T) {
+	pred := WorkloadLabelChangedPredicate("app.kubernetes.io/version")
+
+	statefulSet := func(labels map[string]string) *v1.StatefulSet {
+		return &v1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: labels}}
+	}
+
+	tests := []struct {
+		name     string
+		old, new map[string]string
+		expected bool
+	}{
+		{"label added", nil, map[string]string{"app.kubernetes.io/version": "1.0.0"}, true},
+		{"label removed", map[string]string{"app.kubernetes.io/version": "1.0.0"}, nil, true},
+		{"value changed", map[string]string{"app.kubernetes.io/version": "1.0.0"}, map[string]string{"app.kubernetes.io/version": "1.1.0"}, true},
+		{"empty value added", nil, map[string]string{"app.kubernetes.io/version": ""}, true},
+		{"value unchanged", map[string]string{"app.kubernetes.io/version": "1.0.0"}, map[string]string{"app.kubernetes.io/version": "1.0.0"}, false},
+		{"other label changed", map[string]string{"team": "a"}, map[string]string{"team": "b"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pred.Update(event.UpdateEvent{ObjectOld: statefulSet(tt.old), ObjectNew: statefulSet(tt.new)})
+			if got != tt.expected {
+				t.Errorf("Update() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVersionLabelsChangedPredicate(t *testing.T) {
+	daemonSet := func(labels map[string]string) *v1.DaemonSet {
+		return &v1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default", Labels: labels}}
+	}
+	old := daemonSet(map[string]string{"app.kubernetes.io/version": "1.0.0", "version": "1.0.0"})
+
+	tests := []struct {
+		name     string
+		keys     []string
+		new      map[string]string
+		expected bool
+	}{
+		{"default key changed", nil, map[string]string{"app.kubernetes.io/version": "1.1.0", "version": "1.0.0"}, true},
+		{"custom key ignored by default", nil, map[string]string{"app.kubernetes.io/version": "1.0.0", "version": "1.1.0"}, false},
+		{"second key changed", []string{"app.kubernetes.io/version", "version"}, map[string]string{"app.kubernetes.io/version": "1.0.0", "version": "1.1.0"}, true},
+		{"second key removed", []string{"app.kubernetes.io/version", "version"}, map[string]string{"app.kubernetes.io/version": "1.0.0"}, true},
+		{"no key changed", []string{"app.kubernetes.io/version", "version"}, map[string]string{"app.kubernetes.io/version": "1.0.0", "version": "1.0.0"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pred := predicate.Or(DaemonSetStatusChangedPredicate(), VersionLabelsChangedPredicate(tt.keys))
+			got := pred.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: daemonSet(tt.new)})
+			if got != tt.expected {
+				t.Errorf("Update() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeploymentPredicate_Combined(t *testing.T) {
+	pred := predicate.Or(DeploymentStatusChangedPredicate(), VersionLabelsChangedPredicate(nil))
+
+	base := func() *v1.Deployment {
+		return &v1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "test-deployment",
+				Generation: 1,
+				Labels:     map[string]string{"app.kubernetes.io/version": "1.0.0"},
+			},
+			Status: v1.DeploymentStatus{Replicas: 3, ReadyReplicas: 3},
+		}
+	}
+
+	// Version label edit alone doesn't bump generation or status but must reconcile
+	old, labelChanged := base(), base()
+	labelChanged.Labels["app.kubernetes.io/version"] = "1.1.0"
+	if !pred.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: labelChanged}) {
+		t.Error("Expected version label change to pass combined predicate")
+	}
+
+	// Unrelated metadata changes are filtered out
+	annotated := base()
+	annotated.Annotations = map[string]string{"note": "x"}
+	if pred.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: annotated}) {
+		t.Error("Expected unrelated change to be filtered by combined predicate")
+	}
+
+	if !pred.Delete(event.DeleteEvent{Object: old}) {
+		t.Error("Expected deletes to pass combined predicate")
+	}
+}
+
+func TestAnnotationChangedPredicate(t *testing.T) {
+	pred := AnnotationChangedPredicate("apptrail.sh/")
+
+	withAnnotations := func(annotations map[string]string) *v1.Deployment {
+		return &v1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Annotations: annotations},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		old, new map[string]string
+		expected bool
+	}{
+		{
+			name:     "prefixed annotation added",
+			old:      nil,
+			new:      map[string]string{"apptrail.sh/rollout-timeout": "45m"},
+			expected: true,
+		},
+		{
+			name:     "prefixed annotation changed",
+			old:      map[string]string{"apptrail.sh/rollout-timeout": "30m"},
+			new:      map[string]string{"apptrail.sh/rollout-timeout": "45m"},
+			expected: true,
+		},
+		{
+			name:     "prefixed annotation removed",
+			old:      map[string]string{"apptrail.sh/rollout-timeout": "30m"},
+			new:      map[string]string{},
+			expected: true,
+		},
+		{
+			name:     "other annotation changed",
+			old:      map[string]string{"fluxcd.io/sync": "a"},
+			new:      map[string]string{"fluxcd.io/sync": "b"},
+			expected: false,
+		},
+		{
+			name:     "unchanged",
+			old:      map[string]string{"apptrail.sh/rollout-timeout": "30m"},
+			new:      map[string]string{"apptrail.sh/rollout-timeout": "30m"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pred.Update(event.UpdateEvent{ObjectOld: withAnnotations(tt.old), ObjectNew: withAnnotations(tt.new)})
+			if got != tt.expected {
+				t.Errorf("Update() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNamespaceScopedPredicate(t *testing.T) {
+	resourceFilter := filter.NewResourceFilter(filter.ResourceFilterConfig{
+		ExcludeNamespaces: []string{"kube-*"},
+	})
+	pred := NamespaceScopedPredicate(func() *filter.ResourceFilter { return resourceFilter })
+
+	pod := func(namespace string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: namespace}}
+	}
+
+	tests := []struct {
+		namespace string
+		expected  bool
+	}{
+		{namespace: "default", expected: true},
+		{namespace: "kube-system", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			obj := pod(tt.namespace)
+			if got := pred.Create(event.CreateEvent{Object: obj}); got != tt.expected {
+				t.Errorf("Create() = %v, want %v", got, tt.expected)
+			}
+			if got := pred.Update(event.UpdateEvent{ObjectOld: obj, ObjectNew: obj}); got != tt.expected {
+				t.Errorf("Update() = %v, want %v", got, tt.expected)
+			}
+			// Deletes are left to the reconciler, which may still track the object
+			if !pred.Delete(event.DeleteEvent{Object: obj}) {
+				t.Error("Delete() = false, want true")
+			}
+		})
+	}
+
+	// A reloaded filter takes effect for the next event
+	resourceFilter = filter.NewResourceFilter(filter.ResourceFilterConfig{})
+	if !pred.Create(event.CreateEvent{Object: pod("kube-system")}) {
+		t.Error("Expected reloaded filter to allow kube-system")
+	}
+
+	resourceFilter = nil
+	if !pred.Create(event.CreateEvent{Object: pod("kube-system")}) {
+		t.Error("Expected nil filter to allow all namespaces")
+	}
+}
+
 func TestStatefulSetStatusChangedPredicate(t *testing.T) {
 	pred := StatefulSetStatusChangedPredicate()
 
@@ -183,6 +381,13 @@ func TestStatefulSetStatusChangedPredicate(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "current revision changed",
+			modify: func(old, new *v1.StatefulSet) {
+				new.Status.CurrentRevision = "db-7c9d"
+			},
+			expected: true,
+		},
 		{
 			name: "replicas changed",
 			modify: func(old, new *v1.StatefulSet) {
@@ -424,3 +629,38 @@ func TestPredicates_WrongType(t *testing.T) {
 		t.Error("DaemonSetStatusChangedPredicate should return true for wrong type")
 	}
 }
+
+func TestVirtualMachineStatusChangedPredicate(t *testing.T) {
+	virtualMachine := func(version, printableStatus, ready string) *VirtualMachineAdapter {
+		obj := NewVirtualMachineObject()
+		obj.SetName("db-vm")
+		obj.SetLabels(map[string]string{"app.kubernetes.io/version": version})
+		obj.Object["status"] = map[string]any{
+			"printableStatus": printableStatus,
+			"conditions":      []any{map[string]any{"type": "Ready", "status": ready}},
+		}
+		return &VirtualMachineAdapter{VirtualMachine: obj}
+	}
+
+	tests := []struct {
+		name     string
+		old      *VirtualMachineAdapter
+		new      *VirtualMachineAdapter
+		expected bool
+	}{
+		{"no change", virtualMachine("1.0", "Running", "True"), virtualMachine("1.0", "Running", "True"), false},
+		{"version label changed", virtualMachine("1.0", "Running", "True"), virtualMachine("1.1", "Running", "True"), true},
+		{"migration started", virtualMachine("1.0", "Running", "True"), virtualMachine("1.0", "Migrating", "True"), true},
+		{"ready condition changed", virtualMachine("1.0", "Running", "True"), virtualMachine("1.0", "Running", "False"), true},
+	}
+
+	pred := VirtualMachineStatusChangedPredicate()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pred.Update(event.UpdateEvent{ObjectOld: tt.old.VirtualMachine, ObjectNew: tt.new.VirtualMachine})
+			if got != tt.expected {
+				t.Errorf("Update() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package reconciler
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DefaultRateLimitBaseDelay is the requeue delay after a workload's first failed reconcile
+	DefaultRateLimitBaseDelay = 200 * time.Millisecond
+	// DefaultRateLimitMaxDelay caps the exponential requeue delay of failing workloads
+	DefaultRateLimitMaxDelay = 10 * time.Minute
+
+	// maxDebugQueueEntries is how many workloads the queue debug endpoint lists
+	maxDebugQueueEntries = 10
+)
+
+// QueueBackoff is the retry state of a workload that failed to reconcile
+type QueueBackoff struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Retries   int    `json:"retries"`
+	Backoff   string `json:"backoff"` // Delay before the workload is reconciled again
+}
+
+// backoffTracker is an exponential failure rate limiter that remembers the last delay of
+// every workload it is backing off, for the queue debug endpoint
+type backoffTracker struct {
+	workqueue.TypedRateLimiter[reconcile.Request]
+
+	mu       sync.Mutex
+	backoffs map[reconcile.Request]time.Duration
+}
+
+func newBackoffTracker(baseDelay, maxDelay time.Duration) *backoffTracker {
+	return &backoffTracker{
+		TypedRateLimiter: workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](baseDelay, maxDelay),
+		backoffs:         make(map[reconcile.Request]time.Duration),
+	}
+}
+
+func (t *backoffTracker) When(item reconcile.Request) time.Duration {
+	delay := t.TypedRateLimiter.When(item)
+	t.mu.Lock()
+	t.backoffs[item] = delay
+	t.mu.Unlock()
+	return delay
+}
+
+func (t *backoffTracker) Forget(item reconcile.Request) {
+	t.TypedRateLimiter.Forget(item)
+	t.mu.Lock()
+	delete(t.backoffs, item)
+	t.mu.Unlock()
+}
+
+// snapshot returns the retry state of every workload currently backing off
+func (t *backoffTracker) snapshot(kind string) []QueueBackoff {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]QueueBackoff, 0, len(t.backoffs))
+	for item, delay := range t.backoffs {
+		entries = append(entries, QueueBackoff{
+			Kind:      kind,
+			Namespace: item.Namespace,
+			Name:      item.Name,
+			Retries:   t.NumRequeues(item),
+			Backoff:   delay.String(),
+		})
+	}
+	return entries
+}
+
+// rateLimiter creates the work queue rate limiter of this reconciler's controller from
+// RateLimitBaseDelay and RateLimitMaxDelay
+func (wr *WorkloadReconciler) rateLimiter() workqueue.TypedRateLimiter[reconcile.Request] {
+	wr.backoffs = newBackoffTracker(wr.RateLimitBaseDelay, wr.RateLimitMaxDelay)
+	return wr.backoffs
+}
+
+// QueueDebugHandler serves the most-retried workloads of the given reconcilers and their
+// current backoff as a JSON array
+func QueueDebugHandler(reconcilers ...*WorkloadReconciler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		entries := []QueueBackoff{}
+		for _, wr := range reconcilers {
+			if wr.backoffs != nil {
+				entries = append(entries, wr.backoffs.snapshot(wr.kind)...)
+			}
+		}
+		slices.SortFunc(entries, func(a, b QueueBackoff) int {
+			return cmp.Or(
+				cmp.Compare(b.Retries, a.Retries),
+				cmp.Compare(a.Kind, b.Kind),
+				cmp.Compare(a.Namespace, b.Namespace),
+				cmp.Compare(a.Name, b.Name),
+			)
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries[:min(len(entries), maxDebugQueueEntries)])
+	})
+}
@@ -0,0 +1,74 @@
+package reconciler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestBackoffTracker(t *testing.T) {
+	tracker := newBackoffTracker(100*time.Millisecond, time.Second)
+	item := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "shop", Name: "api"}}
+
+	for _, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		if got := tracker.When(item); got != want {
+			t.Errorf("When() = %v, want %v", got, want)
+		}
+	}
+
+	entries := tracker.snapshot("Deployment")
+	if len(entries) != 1 || entries[0].Retries != 3 || entries[0].Backoff != "400ms" {
+		t.Fatalf("Expected one entry with 3 retries and 400ms backoff, got %+v", entries)
+	}
+
+	tracker.Forget(item)
+	if entries := tracker.snapshot("Deployment"); len(entries) != 0 {
+		t.Errorf("Expected forgotten workload to be dropped, got %+v", entries)
+	}
+}
+
+func TestQueueDebugHandler(t *testing.T) {
+	deployments := &WorkloadReconciler{kind: "Deployment", RateLimitBaseDelay: time.Millisecond, RateLimitMaxDelay: time.Second}
+	deployments.rateLimiter()
+	daemonSets := &WorkloadReconciler{kind: "DaemonSet"} // Not set up, no backoff state
+
+	// workload-N fails N times
+	for n := 1; n <= 12; n++ {
+		item := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "shop", Name: fmt.Sprintf("workload-%d", n)}}
+		for range n {
+			deployments.backoffs.When(item)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	QueueDebugHandler(deployments, daemonSets).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/queues", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var entries []QueueBackoff
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(entries) != maxDebugQueueEntries {
+		t.Fatalf("Expected %d entries, got %d", maxDebugQueueEntries, len(entries))
+	}
+	if entries[0].Name != "workload-12" || entries[0].Retries != 12 || entries[0].Kind != "Deployment" {
+		t.Errorf("Expected most-retried workload first, got %+v", entries[0])
+	}
+	if last := entries[len(entries)-1]; last.Name != "workload-3" {
+		t.Errorf("Expected least-retried listed workload to be workload-3, got %+v", last)
+	}
+
+	rec = httptest.NewRecorder()
+	QueueDebugHandler(deployments).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/queues", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", rec.Code)
+	}
+}
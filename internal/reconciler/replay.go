@@ -0,0 +1,108 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ReplaySource feeds workloads queued by Replay into the controller's work queue
+func (wr *WorkloadReconciler) ReplaySource() source.Source {
+	return source.Channel(wr.replayEvents, &handler.EnqueueRequestForObject{})
+}
+
+// Replay queues a reconcile for every watched workload of this reconciler's kind and marks
+// each one so its current state is published even if it was already sent. It returns the
+// number of workloads queued. The controller must be running, i.e. this agent is the leader.
+func (wr *WorkloadReconciler) Replay(ctx context.Context) (int, error) {
+	workloads, err := wr.listWorkloads(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resourceFilter := wr.filter.Load()
+	queued := 0
+	for _, workload := range workloads {
+		if resourceFilter != nil && !resourceFilter.ShouldWatchNamespace(workload.GetNamespace()) {
+			continue
+		}
+
+		wr.mu.Lock()
+		wr.replayPending[workload.GetNamespace()+"/"+workload.GetName()+"/"+wr.kind] = struct{}{}
+		wr.mu.Unlock()
+
+		if err := wr.enqueue(ctx, workload); err != nil {
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// enqueue queues a reconcile of object through ReplaySource, waiting for the controller to take it
+func (wr *WorkloadReconciler) enqueue(ctx context.Context, object client.Object) error {
+	select {
+	case wr.replayEvents <- event.GenericEvent{Object: object}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// takeReplay reports whether appkey was marked by Replay and clears the mark
+func (wr *WorkloadReconciler) takeReplay(appkey string) bool {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	_, ok := wr.replayPending[appkey]
+	delete(wr.replayPending, appkey)
+	return ok
+}
+
+// listWorkloads lists the live workloads of this reconciler's kind from the cache
+func (wr *WorkloadReconciler) listWorkloads(ctx context.Context) ([]client.Object, error) {
+	var workloads []client.Object
+	switch wr.kind {
+	case "Deployment":
+		list := &v1.DeploymentList{}
+		if err := wr.List(ctx, list); err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	case "StatefulSet":
+		list := &v1.StatefulSetList{}
+		if err := wr.List(ctx, list); err != nil {
+			return nil, fmt.Errorf("failed to list statefulsets: %w", err)
+		}
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	case "DaemonSet":
+		list := &v1.DaemonSetList{}
+		if err := wr.List(ctx, list); err != nil {
+			return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+		}
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	case "VirtualMachine":
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(VirtualMachineGVK.GroupVersion().WithKind("VirtualMachineList"))
+		if err := wr.List(ctx, list); err != nil {
+			return nil, fmt.Errorf("failed to list virtualmachines: %w", err)
+		}
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", wr.kind)
+	}
+	return workloads, nil
+}
@@ -1,7 +1,10 @@
 package reconciler
 
 import (
+	"time"
+
 	"github.com/apptrail-sh/agent/internal/model"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // ResourceAdapter is the base interface for all Kubernetes resource adapters
@@ -22,6 +25,15 @@ type WorkloadResourceAdapter interface {
 	// Version tracking
 	GetVersion() string // Gets app.kubernetes.io/version label
 
+	// Pod template containers, used to derive versions from image tags
+	GetContainers() []corev1.Container
+
+	// Per-workload configuration such as apptrail.sh/rollout-timeout
+	GetAnnotations() map[string]string
+
+	// Age tracking
+	GetCreationTimestamp() time.Time
+
 	// Replica status
 	GetTotalReplicas() int32
 	GetReadyReplicas() int32
@@ -31,6 +43,7 @@ type WorkloadResourceAdapter interface {
 	// Phase determination
 	IsRollingOut() bool
 	HasFailed() bool
+	IsManagedRollout() bool // False when pods are only updated on manual deletion (OnDelete)
 }
 
 // InfrastructureResourceAdapter extends ResourceAdapter for infrastructure resources
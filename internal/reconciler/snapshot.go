@@ -0,0 +1,76 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	v1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Snapshot returns the current state of every watched workload of this reconciler's kind, for
+// full-state syncs. Workloads without a version are skipped, as they are never tracked. The
+// reconciler does not know the cluster ID, so the event source is left to the caller.
+func (wr *WorkloadReconciler) Snapshot(ctx context.Context) ([]model.ResourceEventPayload, error) {
+	workloads, err := wr.listWorkloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceFilter := wr.filter.Load()
+	events := make([]model.ResourceEventPayload, 0, len(workloads))
+	for _, obj := range workloads {
+		if resourceFilter != nil && !resourceFilter.ShouldWatchNamespace(obj.GetNamespace()) {
+			continue
+		}
+
+		workload, err := newWorkloadAdapter(obj)
+		if err != nil {
+			return nil, err
+		}
+		version := wr.workloadVersion(workload)
+		if version == "" {
+			continue
+		}
+
+		wr.mu.RLock()
+		phase := wr.workloadPhases[workload.GetNamespace()+"/"+workload.GetName()+"/"+workload.GetKind()]
+		wr.mu.RUnlock()
+
+		events = append(events, model.NewWorkloadEvent(
+			workload.GetKind(),
+			workload.GetNamespace(),
+			workload.GetName(),
+			workload.GetUID(),
+			workload.GetLabels(),
+			model.ResourceEventKindUpdated,
+			&model.WorkloadMetadata{
+				Version:       version,
+				Phase:         phase,
+				Replicas:      workload.GetTotalReplicas(),
+				ReadyReplicas: workload.GetReadyReplicas(),
+			},
+			"",
+			"",
+		))
+	}
+	return events, nil
+}
+
+// newWorkloadAdapter wraps a listed workload in the adapter for its kind
+func newWorkloadAdapter(obj client.Object) (WorkloadAdapter, error) {
+	switch workload := obj.(type) {
+	case *v1.Deployment:
+		return &DeploymentAdapter{Deployment: workload}, nil
+	case *v1.StatefulSet:
+		return &StatefulSetAdapter{StatefulSet: workload}, nil
+	case *v1.DaemonSet:
+		return &DaemonSetAdapter{DaemonSet: workload}, nil
+	case *unstructured.Unstructured:
+		return &VirtualMachineAdapter{VirtualMachine: workload}, nil
+	default:
+		return nil, fmt.Errorf("unsupported workload type %T", obj)
+	}
+}
@@ -0,0 +1,58 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apptrail-sh/agent/internal/model"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSnapshot_ListsVersionedWorkloads(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	versioned := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "default",
+			UID:       "api-uid",
+			Labels:    map[string]string{"app.kubernetes.io/version": "1.2.0"},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 2},
+	}
+	unversioned := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "sidecar", Namespace: "default"},
+	}
+
+	wr := NewWorkloadReconciler(fake.NewClientBuilder().WithScheme(scheme).WithObjects(versioned, unversioned).Build(), scheme, nil,
+		make(chan model.WorkloadUpdate, 1), "apptrail-system", nil)
+	wr.kind = "Deployment"
+	wr.workloadPhases["default/api/Deployment"] = phaseRollingOut
+
+	events, err := wr.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+
+	event := events[0]
+	if event.ResourceType != model.ResourceTypeWorkload || event.Resource.Kind != "Deployment" || event.Resource.UID != "api-uid" {
+		t.Errorf("Unexpected resource: %s %+v", event.ResourceType, event.Resource)
+	}
+	metadata, ok := event.Metadata["workload"].(*model.WorkloadMetadata)
+	if !ok {
+		t.Fatalf("Expected workload metadata, got %T", event.Metadata["workload"])
+	}
+	expected := model.WorkloadMetadata{Version: "1.2.0", Phase: phaseRollingOut, Replicas: 3, ReadyReplicas: 2}
+	if *metadata != expected {
+		t.Errorf("Expected metadata %+v, got %+v", expected, *metadata)
+	}
+}
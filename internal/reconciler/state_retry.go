@@ -0,0 +1,59 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// maxStateAttempts limits how often a WorkloadRolloutState API call is tried
+const maxStateAttempts = 3
+
+// stateRetryBackoff spaces out attempts of WorkloadRolloutState API calls after transient errors
+var stateRetryBackoff = wait.Backoff{
+	Steps:    maxStateAttempts,
+	Duration: 200 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+}
+
+// isTransientAPIError reports whether the API server rejected a request only temporarily
+// (429 Too Many Requests, 503 Service Unavailable), so repeating it may succeed
+func isTransientAPIError(err error) bool {
+	return apierrors.IsTooManyRequests(err) || apierrors.IsServiceUnavailable(err)
+}
+
+// retryStateOperation runs fn, repeating it with exponential backoff while it fails with a transient
+// API error. The last error is returned once maxStateAttempts is reached.
+func retryStateOperation(ctx context.Context, op, stateName string, fn func() error) error {
+	attempt := 0
+	return retry.OnError(stateRetryBackoff, func(err error) bool {
+		if !isTransientAPIError(err) {
+			return false
+		}
+		if attempt < maxStateAttempts {
+			ctrl.LoggerFrom(ctx).Info("Retrying rollout state operation after transient API error",
+				"operation", op,
+				"stateName", stateName,
+				"attempt", attempt,
+				"reason", err.Error(),
+			)
+		}
+		return true
+	}, func() error {
+		attempt++
+		return fn()
+	})
+}
+
+// updateStateOnConflict runs update, re-running it when it lost an update conflict and repeating
+// transient API errors. update must re-read the state it modifies.
+func updateStateOnConflict(ctx context.Context, stateName string, update func() error) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		return retryStateOperation(ctx, "update", stateName, update)
+	})
+}
@@ -0,0 +1,140 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apptrailv1alpha1 "github.com/apptrail-sh/agent/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var rolloutStateResource = schema.GroupResource{Group: apptrailv1alpha1.GroupVersion.Group, Resource: "workloadrolloutstates"}
+
+// fastStateRetries shortens the rollout state backoff for the duration of a test
+func fastStateRetries(t *testing.T) {
+	t.Helper()
+	original := stateRetryBackoff
+	stateRetryBackoff.Duration = time.Millisecond
+	t.Cleanup(func() { stateRetryBackoff = original })
+}
+
+func TestRetryStateOperation(t *testing.T) {
+	fastStateRetries(t)
+
+	unavailable := apierrors.NewServiceUnavailable("etcd leader changed")
+	tests := []struct {
+		name         string
+		errs         []error // Returned by successive attempts, nil afterwards
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"success", nil, 1, false},
+		{"service unavailable", []error{unavailable}, 2, false},
+		{"too many requests", []error{apierrors.NewTooManyRequests("slow down", 1)}, 2, false},
+		{"exhausted", []error{unavailable, unavailable, unavailable, unavailable}, maxStateAttempts, true},
+		{"not retryable", []error{apierrors.NewBadRequest("invalid")}, 1, true},
+		{"already exists", []error{apierrors.NewAlreadyExists(rolloutStateResource, "state")}, 1, true},
+		{"plain error", []error{errors.New("boom")}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := retryStateOperation(context.Background(), "create", "state", func() error {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("retryStateOperation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRolloutStateCRD_RetriesTransientErrors(t *testing.T) {
+	fastStateRetries(t)
+
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	ctx := context.Background()
+
+	// Every rollout state call fails once: creates and deletes with 503, updates with a conflict
+	failed := map[string]bool{}
+	failOnce := func(op string, err error) error {
+		if failed[op] {
+			return nil
+		}
+		failed[op] = true
+		return err
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if err := failOnce("create", apierrors.NewServiceUnavailable("unavailable")); err != nil {
+				return err
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if err := failOnce("update", apierrors.NewConflict(rolloutStateResource, obj.GetName(), errors.New("modified"))); err != nil {
+				return err
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if err := failOnce("delete", apierrors.NewTooManyRequests("slow down", 1)); err != nil {
+				return err
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+	wr := NewDeploymentReconciler(k8sClient, scheme, nil, nil, "apptrail-system", nil)
+
+	getState := func() (*apptrailv1alpha1.WorkloadRolloutState, error) {
+		state := &apptrailv1alpha1.WorkloadRolloutState{}
+		err := k8sClient.Get(ctx, types.NamespacedName{
+			Name:      sanitizeStateName("default", "api", "Deployment"),
+			Namespace: "apptrail-system",
+		}, state)
+		return state, err
+	}
+
+	for _, phase := range []string{phaseRollingOut, phaseSuccess} {
+		if err := wr.saveFullRolloutStateToCRD(ctx, "default", "api", "Deployment", "1.0.0", time.Now(), "1.0.0", phase); err != nil {
+			t.Fatalf("Expected %s state to be saved despite transient errors, got: %v", phase, err)
+		}
+	}
+	state, err := getState()
+	if err != nil {
+		t.Fatalf("Failed to get rollout state: %v", err)
+	}
+	if state.Spec.LastSentPhase != phaseSuccess {
+		t.Errorf("Expected updated phase %s, got %s", phaseSuccess, state.Spec.LastSentPhase)
+	}
+
+	if err := wr.deleteRolloutStateFromCRD(ctx, "default", "api", "Deployment"); err != nil {
+		t.Fatalf("Expected state to be deleted despite transient errors, got: %v", err)
+	}
+	if _, err := getState(); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected rollout state to be removed, got: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package reconciler
+
+import (
+	"context"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// StateValidator cross-checks workload reconciler state against WorkloadRolloutState CRDs when
+// this agent becomes leader. A newly elected leader may have stale or empty maps, which would
+// otherwise produce false version-change events on its first reconciles.
+//
+// It is a leader-election runnable: the manager starts it from its OnStartedLeading callback.
+type StateValidator struct {
+	reconcilers []*WorkloadReconciler
+}
+
+// NewStateValidator creates a validator for the given workload reconcilers
+func NewStateValidator(reconcilers ...*WorkloadReconciler) *StateValidator {
+	return &StateValidator{reconcilers: reconcilers}
+}
+
+// Start validates state once after leadership is acquired
+func (v *StateValidator) Start(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx).WithName("state-validator")
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	for _, r := range v.reconcilers {
+		if err := r.ValidateState(ctx); err != nil {
+			// Not fatal: reconciles still work, they may just re-emit events
+			log.Error(err, "Failed to validate workload state", "kind", r.kind)
+		}
+	}
+	return nil
+}
+
+// NeedLeaderElection makes the manager run the validator only on the elected leader
+func (v *StateValidator) NeedLeaderElection() bool {
+	return true
+}
@@ -2,17 +2,16 @@ package reconciler
 
 import (
 	"context"
-	"time"
+	"sync"
 
 	v1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/apptrail-sh/agent/internal/filter"
 	"github.com/apptrail-sh/agent/internal/model"
@@ -21,51 +20,142 @@ import (
 // StatefulSetReconciler reconciles StatefulSet objects
 type StatefulSetReconciler struct {
 	*WorkloadReconciler
+
+	// ResourceEventChan receives the one-time STATUS_CHANGE event reporting that a StatefulSet
+	// uses the OnDelete update strategy; nil disables the event
+	ResourceEventChan chan<- model.ResourceEventPayload
+	// ClusterID and AgentVersion identify this agent in resource events
+	ClusterID    string
+	AgentVersion string
+
+	// Whether each StatefulSet was reported as using the OnDelete update strategy, keyed by
+	// namespace/name; caches the marker persisted in its WorkloadRolloutState
+	onDeleteMu       sync.Mutex
+	onDeleteNotified map[string]bool
 }
 
 func NewStatefulSetReconciler(client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, publisherChan chan<- model.WorkloadUpdate, controllerNamespace string, resourceFilter *filter.ResourceFilter) *StatefulSetReconciler {
+	wr := NewWorkloadReconciler(client, scheme, recorder, publisherChan, controllerNamespace, resourceFilter)
+	wr.kind = "StatefulSet"
 	return &StatefulSetReconciler{
-		WorkloadReconciler: NewWorkloadReconciler(client, scheme, recorder, publisherChan, controllerNamespace, resourceFilter),
+		WorkloadReconciler: wr,
+		onDeleteNotified:   make(map[string]bool),
 	}
 }
 
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=statefulsets/status,verbs=get
 // +kubebuilder:rbac:groups=apptrail.apptrail.sh,resources=workloadrolloutstates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (sr *StatefulSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return ReconcileMetricsMiddleware(ctx, "StatefulSet", req, sr.reconcile)
+}
+
+func (sr *StatefulSetReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	log.Info("Reconciling StatefulSet")
 
 	resource := &v1.StatefulSet{}
 	if err := sr.Get(ctx, req.NamespacedName, resource); err != nil {
 		if apierrors.IsNotFound(err) {
+			setReconcileOutcome(ctx, ReconcileOutcomeNotFound)
 			// StatefulSet was deleted, clean up state
 			_ = sr.HandleDeletion(ctx, req.Namespace, req.Name, "StatefulSet")
+			sr.onDeleteMu.Lock()
+			delete(sr.onDeleteNotified, req.String())
+			sr.onDeleteMu.Unlock()
 			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, err
+		return HandleReconcileError(ctx, NewReconcileError("get", req.String(), err))
 	}
 	log.Info("StatefulSet found", "StatefulSet", resource)
 
 	// Wrap the StatefulSet in an adapter
 	adapter := &StatefulSetAdapter{StatefulSet: resource}
+	sr.notifyOnDeleteStrategy(ctx, adapter)
 
 	// Use the shared reconciliation logic
 	return sr.ReconcileWorkload(ctx, req, adapter)
 }
 
+// notifyOnDeleteStrategy publishes a one-time STATUS_CHANGE resource event explaining that an
+// OnDelete StatefulSet is never reported as rolling out. The marker is persisted in the
+// StatefulSet's WorkloadRolloutState so restarts don't repeat the event, and cleared when the
+// strategy switches away from OnDelete so it is reported again if it switches back.
+func (sr *StatefulSetReconciler) notifyOnDeleteStrategy(ctx context.Context, adapter *StatefulSetAdapter) {
+	log := ctrl.LoggerFrom(ctx)
+	namespace, name := adapter.GetNamespace(), adapter.GetName()
+	key := namespace + "/" + name
+	onDelete := adapter.IsOnDelete()
+
+	sr.onDeleteMu.Lock()
+	notified, known := sr.onDeleteNotified[key]
+	sr.onDeleteMu.Unlock()
+	if !known {
+		state, err := sr.loadFullRolloutStateFromCRD(ctx, namespace, name, "StatefulSet")
+		if err != nil {
+			// Retried on the next reconcile
+			return
+		}
+		notified = state.OnDeleteNotified
+	}
+
+	if notified != onDelete {
+		if onDelete {
+			log.Info("StatefulSet uses OnDelete update strategy, rollout tracking disabled")
+			sr.publishOnDeleteEvent(ctx, adapter)
+		}
+		if err := sr.saveOnDeleteNotified(ctx, namespace, name, "StatefulSet", onDelete); err != nil {
+			log.Error(err, "Failed to persist OnDelete update strategy marker")
+		}
+	}
+
+	sr.onDeleteMu.Lock()
+	sr.onDeleteNotified[key] = onDelete
+	sr.onDeleteMu.Unlock()
+}
+
+// publishOnDeleteEvent sends the STATUS_CHANGE event reporting the OnDelete update strategy
+func (sr *StatefulSetReconciler) publishOnDeleteEvent(ctx context.Context, adapter *StatefulSetAdapter) {
+	if sr.ResourceEventChan == nil {
+		return
+	}
+
+	event := model.NewWorkloadEvent(
+		"StatefulSet",
+		adapter.GetNamespace(),
+		adapter.GetName(),
+		adapter.GetUID(),
+		adapter.GetLabels(),
+		model.ResourceEventKindStatusChange,
+		nil,
+		sr.ClusterID,
+		sr.AgentVersion,
+	)
+	event.Metadata["updateStrategy"] = string(v1.OnDeleteStatefulSetStrategyType)
+	event.Metadata["message"] = "Pods are updated only when deleted, so the StatefulSet is not reported as rolling out"
+
+	select {
+	case sr.ResourceEventChan <- event:
+	default:
+		// Log if channel is full but don't block
+		ctrl.LoggerFrom(ctx).Error(nil, "Event channel full, dropping OnDelete update strategy event")
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (sr *StatefulSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1.StatefulSet{}).
-		WithEventFilter(StatefulSetStatusChangedPredicate()).
+		WithEventFilter(predicate.Or(
+			StatefulSetStatusChangedPredicate(),
+			VersionLabelsChangedPredicate(sr.VersionLabels),
+		)).
+		WatchesRawSource(sr.ReplaySource()).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 5,
-			RateLimiter: workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](
-				200*time.Millisecond,
-				10*time.Minute,
-			),
+			RateLimiter:             sr.rateLimiter(),
 		}).
 		Complete(sr)
 }
@@ -0,0 +1,78 @@
+package reconciler
+
+import (
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// VersionExtractor derives the version of a workload. The default reads the
+// app.kubernetes.io/version label via GetVersion.
+type VersionExtractor func(adapter WorkloadResourceAdapter) string
+
+// LabelVersionExtractor reads the app.kubernetes.io/version label
+func LabelVersionExtractor(adapter WorkloadResourceAdapter) string {
+	return adapter.GetVersion()
+}
+
+// LabelKeysVersionExtractor reads the first of the label keys that is set, for teams that keep
+// the version in a label of their own, e.g. during a migration to app.kubernetes.io/version
+func LabelKeysVersionExtractor(keys []string) VersionExtractor {
+	return func(adapter WorkloadResourceAdapter) string {
+		labels := adapter.GetLabels()
+		for _, key := range keys {
+			if version := labels[key]; version != "" {
+				return version
+			}
+		}
+		return ""
+	}
+}
+
+// ImageTagVersionExtractor reads the image tag of the named container in the pod template,
+// for teams that version through image tags (myapp:1.2.3) rather than labels
+func ImageTagVersionExtractor(containerName string) VersionExtractor {
+	return func(adapter WorkloadResourceAdapter) string {
+		for _, container := range adapter.GetContainers() {
+			if container.Name == containerName {
+				return imageTag(container.Image)
+			}
+		}
+		return ""
+	}
+}
+
+// imageTag returns the tag of an image reference, ignoring any digest and registry port
+func imageTag(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	colon := strings.LastIndex(image, ":")
+	if colon < 0 || colon < strings.LastIndex(image, "/") {
+		return ""
+	}
+	return image[colon+1:]
+}
+
+// canonicalSemVer returns version with the "v" prefix semver expects and whether it is a valid
+// semantic version. Commit hashes and other custom labels are not.
+func canonicalSemVer(version string) (string, bool) {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version, semver.IsValid(version)
+}
+
+// isRollback reports whether current is a lower semantic version than previous.
+// It is false when either version is not a semantic version.
+func isRollback(previous, current string) bool {
+	previousSemVer, ok := canonicalSemVer(previous)
+	if !ok {
+		return false
+	}
+	currentSemVer, ok := canonicalSemVer(current)
+	if !ok {
+		return false
+	}
+	return semver.Compare(currentSemVer, previousSemVer) < 0
+}
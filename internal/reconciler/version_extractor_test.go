@@ -0,0 +1,131 @@
+package reconciler
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestVersionExtractors(t *testing.T) {
+	deployment := func(images ...string) *DeploymentAdapter {
+		containers := make([]corev1.Container, 0, len(images))
+		for i, image := range images {
+			name := "app"
+			if i > 0 {
+				name = "sidecar"
+			}
+			containers = append(containers, corev1.Container{Name: name, Image: image})
+		}
+		return &DeploymentAdapter{Deployment: &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "api",
+				Labels: map[string]string{"app.kubernetes.io/version": "1.0.0"},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: containers}},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		extractor VersionExtractor
+		workload  *DeploymentAdapter
+		expected  string
+	}{
+		{
+			name:      "label",
+			extractor: LabelVersionExtractor,
+			workload:  deployment("myapp:2.0.0"),
+			expected:  "1.0.0",
+		},
+		{
+			name:      "image tag",
+			extractor: ImageTagVersionExtractor("app"),
+			workload:  deployment("myapp:1.2.3", "envoy:1.30"),
+			expected:  "1.2.3",
+		},
+		{
+			name:      "image tag of named sidecar",
+			extractor: ImageTagVersionExtractor("sidecar"),
+			workload:  deployment("myapp:1.2.3", "envoy:1.30"),
+			expected:  "1.30",
+		},
+		{
+			name:      "registry port and digest",
+			extractor: ImageTagVersionExtractor("app"),
+			workload:  deployment("registry.local:5000/team/myapp:1.2.3@sha256:abc"),
+			expected:  "1.2.3",
+		},
+		{
+			name:      "untagged image",
+			extractor: ImageTagVersionExtractor("app"),
+			workload:  deployment("registry.local:5000/team/myapp"),
+			expected:  "",
+		},
+		{
+			name:      "first label key set",
+			extractor: LabelKeysVersionExtractor([]string{"version", "app.kubernetes.io/version"}),
+			workload:  deployment("myapp:2.0.0"),
+			expected:  "1.0.0",
+		},
+		{
+			name:      "no label key set",
+			extractor: LabelKeysVersionExtractor([]string{"version"}),
+			workload:  deployment("myapp:2.0.0"),
+			expected:  "",
+		},
+		{
+			name:      "missing container",
+			extractor: ImageTagVersionExtractor("worker"),
+			workload:  deployment("myapp:1.2.3"),
+			expected:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.extractor(tt.workload); got != tt.expected {
+				t.Errorf("extractor() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	// The reconciler falls back to the label without an extractor
+	wr := &WorkloadReconciler{}
+	if got := wr.workloadVersion(deployment("myapp:2.0.0")); got != "1.0.0" {
+		t.Errorf("workloadVersion() = %q, want %q", got, "1.0.0")
+	}
+	wr.VersionExtractor = ImageTagVersionExtractor("app")
+	if got := wr.workloadVersion(deployment("myapp:2.0.0")); got != "2.0.0" {
+		t.Errorf("workloadVersion() = %q, want %q", got, "2.0.0")
+	}
+}
+
+func TestIsRollback(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     bool
+	}{
+		{name: "upgrade", previous: "1.2.0", current: "1.3.0", want: false},
+		{name: "downgrade", previous: "1.3.0", current: "1.2.0", want: true},
+		{name: "downgrade with v prefix", previous: "v2.0.0", current: "1.9.9", want: true},
+		{name: "prerelease below release", previous: "1.3.0", current: "1.3.0-rc.1", want: true},
+		{name: "numeric not lexical order", previous: "1.10.0", current: "1.9.0", want: true},
+		{name: "first version", previous: "", current: "1.0.0", want: false},
+		{name: "commit hash", previous: "a1b2c3d", current: "1.0.0", want: false},
+		{name: "to custom label", previous: "1.0.0", current: "stable", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRollback(tt.previous, tt.current); got != tt.want {
+				t.Errorf("isRollback(%q, %q) = %v, want %v", tt.previous, tt.current, got, tt.want)
+			}
+		})
+	}
+}
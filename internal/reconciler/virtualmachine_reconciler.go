@@ -0,0 +1,75 @@
+package reconciler
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/apptrail-sh/agent/internal/filter"
+	"github.com/apptrail-sh/agent/internal/model"
+)
+
+// VirtualMachineReconciler reconciles KubeVirt VirtualMachine objects through the dynamic
+// informer of the manager cache, so the KubeVirt CRDs must be installed when it is enabled
+type VirtualMachineReconciler struct {
+	*WorkloadReconciler
+}
+
+func NewVirtualMachineReconciler(client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, publisherChan chan<- model.WorkloadUpdate, controllerNamespace string, resourceFilter *filter.ResourceFilter) *VirtualMachineReconciler {
+	wr := NewWorkloadReconciler(client, scheme, recorder, publisherChan, controllerNamespace, resourceFilter)
+	wr.kind = "VirtualMachine"
+	return &VirtualMachineReconciler{
+		WorkloadReconciler: wr,
+	}
+}
+
+// +kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachines,verbs=get;list;watch
+
+func (vmr *VirtualMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return ReconcileMetricsMiddleware(ctx, "VirtualMachine", req, vmr.reconcile)
+}
+
+func (vmr *VirtualMachineReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("Reconciling VirtualMachine")
+
+	resource := NewVirtualMachineObject()
+	if err := vmr.Get(ctx, req.NamespacedName, resource); err != nil {
+		if apierrors.IsNotFound(err) {
+			setReconcileOutcome(ctx, ReconcileOutcomeNotFound)
+			// VirtualMachine was deleted, clean up state
+			_ = vmr.HandleDeletion(ctx, req.Namespace, req.Name, "VirtualMachine")
+			return ctrl.Result{}, nil
+		}
+		return HandleReconcileError(ctx, NewReconcileError("get", req.String(), err))
+	}
+
+	// Wrap the VirtualMachine in an adapter
+	adapter := &VirtualMachineAdapter{VirtualMachine: resource}
+
+	// Use the shared reconciliation logic
+	return vmr.ReconcileWorkload(ctx, req, adapter)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (vmr *VirtualMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(NewVirtualMachineObject()).
+		Named("virtualmachine").
+		WithEventFilter(predicate.Or(
+			VirtualMachineStatusChangedPredicate(),
+			VersionLabelsChangedPredicate(vmr.VersionLabels),
+		)).
+		WatchesRawSource(vmr.ReplaySource()).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: 5,
+			RateLimiter:             vmr.rateLimiter(),
+		}).
+		Complete(vmr)
+}
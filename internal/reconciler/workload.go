@@ -1,14 +1,54 @@
 package reconciler
 
 import (
+	"time"
+
 	"github.com/apptrail-sh/agent/internal/model"
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // WorkloadAdapter abstracts the common operations across Deployments, StatefulSets, and DaemonSets
 // It implements WorkloadResourceAdapter interface
 type WorkloadAdapter interface {
 	WorkloadResourceAdapter
+
+	// GetObject returns the wrapped workload, e.g. as the involved object of Kubernetes Events
+	GetObject() client.Object
+}
+
+// Labels added to workload updates for StatefulSets so consumers can follow controller revisions
+const (
+	currentRevisionLabel = "apptrail.sh/current-revision"
+	updateRevisionLabel  = "apptrail.sh/update-revision"
+)
+
+// Annotations Helm sets on every resource a release manages
+const (
+	helmReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	helmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+)
+
+// helmReleaseLabels returns the update labels naming the Helm release that manages a workload,
+// or nil when it is not managed by Helm
+func helmReleaseLabels(annotations map[string]string) map[string]string {
+	release := annotations[helmReleaseNameAnnotation]
+	if release == "" {
+		return nil
+	}
+	labels := map[string]string{model.HelmReleaseLabel: release}
+	if namespace := annotations[helmReleaseNamespaceAnnotation]; namespace != "" {
+		labels[model.HelmReleaseNamespaceLabel] = namespace
+	}
+	return labels
+}
+
+// revisionLabeler is implemented by adapters that report controller revisions alongside the workload labels
+type revisionLabeler interface {
+	GetRevisionLabels() map[string]string
 }
 
 // DeploymentAdapter wraps a Deployment to implement WorkloadAdapter
@@ -28,6 +68,10 @@ func (d *DeploymentAdapter) GetKind() string {
 	return "Deployment"
 }
 
+func (d *DeploymentAdapter) GetObject() client.Object {
+	return d.Deployment
+}
+
 func (d *DeploymentAdapter) GetLabels() map[string]string {
 	return d.Deployment.Labels
 }
@@ -36,6 +80,18 @@ func (d *DeploymentAdapter) GetVersion() string {
 	return d.Deployment.Labels["app.kubernetes.io/version"]
 }
 
+func (d *DeploymentAdapter) GetContainers() []corev1.Container {
+	return d.Deployment.Spec.Template.Spec.Containers
+}
+
+func (d *DeploymentAdapter) GetAnnotations() map[string]string {
+	return d.Deployment.Annotations
+}
+
+func (d *DeploymentAdapter) GetCreationTimestamp() time.Time {
+	return d.Deployment.CreationTimestamp.Time
+}
+
 func (d *DeploymentAdapter) GetTotalReplicas() int32 {
 	return d.Deployment.Status.Replicas
 }
@@ -52,6 +108,11 @@ func (d *DeploymentAdapter) GetAvailableReplicas() int32 {
 	return d.Deployment.Status.AvailableReplicas
 }
 
+// IsManagedRollout is always true; Deployments have no OnDelete strategy
+func (d *DeploymentAdapter) IsManagedRollout() bool {
+	return true
+}
+
 func (d *DeploymentAdapter) IsRollingOut() bool {
 	return d.Deployment.Status.UpdatedReplicas < d.Deployment.Status.Replicas ||
 		d.Deployment.Status.ReadyReplicas < d.Deployment.Status.Replicas
@@ -80,6 +141,16 @@ func (d *DeploymentAdapter) GetResourceType() model.ResourceType {
 	return model.ResourceTypeWorkload
 }
 
+// argoManagedByAnnotation is set by Argo Rollouts on Deployments it scales down and manages via a Rollout
+const argoManagedByAnnotation = "argo-rollouts.argoproj.io/managed-by"
+
+// IsArgoManaged reports whether Argo Rollouts has taken over this Deployment's pods.
+// The Deployment is scaled to zero and the real rollout state lives in the Rollout CR.
+func (d *DeploymentAdapter) IsArgoManaged() bool {
+	replicas := d.Deployment.Spec.Replicas
+	return replicas != nil && *replicas == 0 && d.Deployment.Annotations[argoManagedByAnnotation] != ""
+}
+
 // StatefulSetAdapter wraps a StatefulSet to implement WorkloadAdapter
 type StatefulSetAdapter struct {
 	StatefulSet *v1.StatefulSet
@@ -97,12 +168,45 @@ func (s *StatefulSetAdapter) GetKind() string {
 	return "StatefulSet"
 }
 
+func (s *StatefulSetAdapter) GetObject() client.Object {
+	return s.StatefulSet
+}
+
 func (s *StatefulSetAdapter) GetLabels() map[string]string {
 	return s.StatefulSet.Labels
 }
 
+// GetVersion returns the version label, falling back to status.currentRevision so StatefulSets
+// managed by Helm or operators are tracked without a version label
 func (s *StatefulSetAdapter) GetVersion() string {
-	return s.StatefulSet.Labels["app.kubernetes.io/version"]
+	if version := s.StatefulSet.Labels["app.kubernetes.io/version"]; version != "" {
+		return version
+	}
+	return s.StatefulSet.Status.CurrentRevision
+}
+
+// GetRevisionLabels returns the current and update revisions as apptrail.sh/ labels
+func (s *StatefulSetAdapter) GetRevisionLabels() map[string]string {
+	labels := make(map[string]string, 2)
+	if s.StatefulSet.Status.CurrentRevision != "" {
+		labels[currentRevisionLabel] = s.StatefulSet.Status.CurrentRevision
+	}
+	if s.StatefulSet.Status.UpdateRevision != "" {
+		labels[updateRevisionLabel] = s.StatefulSet.Status.UpdateRevision
+	}
+	return labels
+}
+
+func (s *StatefulSetAdapter) GetContainers() []corev1.Container {
+	return s.StatefulSet.Spec.Template.Spec.Containers
+}
+
+func (s *StatefulSetAdapter) GetAnnotations() map[string]string {
+	return s.StatefulSet.Annotations
+}
+
+func (s *StatefulSetAdapter) GetCreationTimestamp() time.Time {
+	return s.StatefulSet.CreationTimestamp.Time
 }
 
 func (s *StatefulSetAdapter) GetTotalReplicas() int32 {
@@ -121,7 +225,23 @@ func (s *StatefulSetAdapter) GetAvailableReplicas() int32 {
 	return s.StatefulSet.Status.AvailableReplicas
 }
 
+// IsOnDelete reports whether pods are only updated when they are manually deleted
+func (s *StatefulSetAdapter) IsOnDelete() bool {
+	return s.StatefulSet.Spec.UpdateStrategy.Type == v1.OnDeleteStatefulSetStrategyType
+}
+
+// IsManagedRollout reports whether the controller drives the rollout, i.e. the strategy is not OnDelete
+func (s *StatefulSetAdapter) IsManagedRollout() bool {
+	return !s.IsOnDelete()
+}
+
 func (s *StatefulSetAdapter) IsRollingOut() bool {
+	// With OnDelete the user drives updates by deleting pods, so stale replicas are expected
+	// indefinitely and don't indicate a rollout in progress
+	if s.IsOnDelete() {
+		return false
+	}
+
 	// StatefulSet is rolling out if updated replicas don't match desired
 	// or if not all replicas are ready
 	if s.StatefulSet.Spec.Replicas == nil {
@@ -163,6 +283,10 @@ func (d *DaemonSetAdapter) GetKind() string {
 	return "DaemonSet"
 }
 
+func (d *DaemonSetAdapter) GetObject() client.Object {
+	return d.DaemonSet
+}
+
 func (d *DaemonSetAdapter) GetLabels() map[string]string {
 	return d.DaemonSet.Labels
 }
@@ -171,6 +295,18 @@ func (d *DaemonSetAdapter) GetVersion() string {
 	return d.DaemonSet.Labels["app.kubernetes.io/version"]
 }
 
+func (d *DaemonSetAdapter) GetContainers() []corev1.Container {
+	return d.DaemonSet.Spec.Template.Spec.Containers
+}
+
+func (d *DaemonSetAdapter) GetAnnotations() map[string]string {
+	return d.DaemonSet.Annotations
+}
+
+func (d *DaemonSetAdapter) GetCreationTimestamp() time.Time {
+	return d.DaemonSet.CreationTimestamp.Time
+}
+
 func (d *DaemonSetAdapter) GetTotalReplicas() int32 {
 	// DaemonSets use DesiredNumberScheduled instead of Replicas
 	return d.DaemonSet.Status.DesiredNumberScheduled
@@ -188,7 +324,23 @@ func (d *DaemonSetAdapter) GetAvailableReplicas() int32 {
 	return d.DaemonSet.Status.NumberAvailable
 }
 
+// IsOnDelete reports whether pods are only updated when they are manually deleted
+func (d *DaemonSetAdapter) IsOnDelete() bool {
+	return d.DaemonSet.Spec.UpdateStrategy.Type == v1.OnDeleteDaemonSetStrategyType
+}
+
+// IsManagedRollout reports whether the controller drives the rollout, i.e. the strategy is not OnDelete
+func (d *DaemonSetAdapter) IsManagedRollout() bool {
+	return !d.IsOnDelete()
+}
+
 func (d *DaemonSetAdapter) IsRollingOut() bool {
+	// With OnDelete, UpdatedNumberScheduled stays below DesiredNumberScheduled
+	// until the user deletes the old pods, which is not a rollout in progress
+	if d.IsOnDelete() {
+		return false
+	}
+
 	// DaemonSet is rolling out if not all scheduled pods are updated or ready
 	return d.DaemonSet.Status.UpdatedNumberScheduled < d.DaemonSet.Status.DesiredNumberScheduled ||
 		d.DaemonSet.Status.NumberReady < d.DaemonSet.Status.DesiredNumberScheduled
@@ -207,3 +359,143 @@ func (d *DaemonSetAdapter) GetUID() string {
 func (d *DaemonSetAdapter) GetResourceType() model.ResourceType {
 	return model.ResourceTypeWorkload
 }
+
+// VirtualMachineGVK identifies KubeVirt (and OpenShift Virtualization) VirtualMachines.
+// They are read as unstructured objects so the agent does not depend on the KubeVirt API module.
+var VirtualMachineGVK = schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "VirtualMachine"}
+
+// VirtualMachine printable statuses the adapter acts on
+const (
+	vmStatusStopped      = "Stopped"
+	vmStatusStopping     = "Stopping"
+	vmStatusStarting     = "Starting"
+	vmStatusProvisioning = "Provisioning"
+	vmStatusMigrating    = "Migrating"
+)
+
+// NewVirtualMachineObject returns an empty unstructured VirtualMachine to read or watch into
+func NewVirtualMachineObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(VirtualMachineGVK)
+	return obj
+}
+
+// VirtualMachineAdapter wraps a KubeVirt VirtualMachine to implement WorkloadAdapter.
+// A VirtualMachine counts as one replica while it is meant to be running.
+type VirtualMachineAdapter struct {
+	VirtualMachine *unstructured.Unstructured
+}
+
+func (vm *VirtualMachineAdapter) GetName() string {
+	return vm.VirtualMachine.GetName()
+}
+
+func (vm *VirtualMachineAdapter) GetNamespace() string {
+	return vm.VirtualMachine.GetNamespace()
+}
+
+func (vm *VirtualMachineAdapter) GetKind() string {
+	return "VirtualMachine"
+}
+
+func (vm *VirtualMachineAdapter) GetObject() client.Object {
+	return vm.VirtualMachine
+}
+
+func (vm *VirtualMachineAdapter) GetLabels() map[string]string {
+	return vm.VirtualMachine.GetLabels()
+}
+
+func (vm *VirtualMachineAdapter) GetVersion() string {
+	return vm.VirtualMachine.GetLabels()["app.kubernetes.io/version"]
+}
+
+// GetContainers returns nil, VirtualMachines have no pod template to read image tags from
+func (vm *VirtualMachineAdapter) GetContainers() []corev1.Container {
+	return nil
+}
+
+func (vm *VirtualMachineAdapter) GetAnnotations() map[string]string {
+	return vm.VirtualMachine.GetAnnotations()
+}
+
+func (vm *VirtualMachineAdapter) GetCreationTimestamp() time.Time {
+	return vm.VirtualMachine.GetCreationTimestamp().Time
+}
+
+// GetPrintableStatus returns status.printableStatus, e.g. Running, Stopped or Migrating
+func (vm *VirtualMachineAdapter) GetPrintableStatus() string {
+	status, _, _ := unstructured.NestedString(vm.VirtualMachine.Object, "status", "printableStatus")
+	return status
+}
+
+// readyCondition returns the status of the Ready condition, empty when it is not reported
+func (vm *VirtualMachineAdapter) readyCondition() corev1.ConditionStatus {
+	conditions, _, _ := unstructured.NestedSlice(vm.VirtualMachine.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		return corev1.ConditionStatus(status)
+	}
+	return ""
+}
+
+// isStopped reports whether the VirtualMachine is not meant to be running
+func (vm *VirtualMachineAdapter) isStopped() bool {
+	status := vm.GetPrintableStatus()
+	return status == vmStatusStopped || status == vmStatusStopping
+}
+
+func (vm *VirtualMachineAdapter) GetTotalReplicas() int32 {
+	if vm.isStopped() {
+		return 0
+	}
+	return 1
+}
+
+func (vm *VirtualMachineAdapter) GetReadyReplicas() int32 {
+	if vm.isStopped() || vm.readyCondition() != corev1.ConditionTrue {
+		return 0
+	}
+	return 1
+}
+
+// GetUpdatedReplicas matches GetTotalReplicas, KubeVirt reports no per-instance update progress
+func (vm *VirtualMachineAdapter) GetUpdatedReplicas() int32 {
+	return vm.GetTotalReplicas()
+}
+
+func (vm *VirtualMachineAdapter) GetAvailableReplicas() int32 {
+	return vm.GetReadyReplicas()
+}
+
+// IsManagedRollout is always true, a live migration is driven by KubeVirt
+func (vm *VirtualMachineAdapter) IsManagedRollout() bool {
+	return true
+}
+
+// IsRollingOut reports a live migration in progress
+func (vm *VirtualMachineAdapter) IsRollingOut() bool {
+	return vm.GetPrintableStatus() == vmStatusMigrating
+}
+
+// HasFailed reports a Ready=False condition on a VirtualMachine that should be running.
+// Starting, provisioning and migrating VMs are briefly not ready and are not failures.
+func (vm *VirtualMachineAdapter) HasFailed() bool {
+	switch vm.GetPrintableStatus() {
+	case vmStatusStopped, vmStatusStopping, vmStatusStarting, vmStatusProvisioning, vmStatusMigrating:
+		return false
+	}
+	return vm.readyCondition() == corev1.ConditionFalse
+}
+
+func (vm *VirtualMachineAdapter) GetUID() string {
+	return string(vm.VirtualMachine.GetUID())
+}
+
+func (vm *VirtualMachineAdapter) GetResourceType() model.ResourceType {
+	return model.ResourceTypeWorkload
+}
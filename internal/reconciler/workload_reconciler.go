@@ -2,9 +2,13 @@ package reconciler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"maps"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	apptrailv1alpha1 "github.com/apptrail-sh/agent/api/v1alpha1"
@@ -12,13 +16,16 @@ import (
 	"github.com/apptrail-sh/agent/internal/model"
 
 	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
@@ -30,6 +37,29 @@ const (
 	phaseFailed      = "failed"
 	phaseSuccess     = "success"
 	phaseProgressing = "progressing"
+	phaseScaling     = "scaling"
+	phaseDeletion    = "deleted"
+	phaseArgoManaged = "argo-managed"
+
+	// DefaultRolloutTimeout is how long a rollout may run before it is reported as failed.
+	// Longer than the Kubernetes default progress deadline to account for Flux/ArgoCD resets.
+	DefaultRolloutTimeout = 15 * time.Minute
+	// How long after a DaemonSet's desired pod count rises that lagging pods are put down to
+	// new nodes being scheduled rather than a rollout
+	defaultNodeScaleThreshold = 5 * time.Minute
+	// Annotation overriding the rollout timeout for a single workload (e.g. "45m")
+	rolloutTimeoutAnnotation = "apptrail.sh/rollout-timeout"
+	// Annotations linking a version to the commit and the CI run that produced it
+	sourceURLAnnotation = "apptrail.sh/source-url"
+	ciRunURLAnnotation  = "apptrail.sh/ci-run"
+
+	// Fraction of the rollout timeout after which the RolloutTimedOut condition reports TimeoutApproaching
+	timeoutWarningFraction = 0.8
+
+	// Kubernetes object names are DNS subdomains, limited to 253 characters
+	maxStateNameLength = 253
+	// Number of hex characters of the SHA256 hash kept when a state name is truncated
+	stateNameHashLength = 16
 )
 
 var (
@@ -45,14 +75,54 @@ var (
 		"last_updated",
 	})
 
+	scaleEventsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_workload_scale_events_total",
+		Help: "Number of workload scale events (replica count changes without a version change)",
+	}, []string{
+		"namespace",
+		"kind",
+		"direction",
+	})
+
+	rollbacksCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_rollback_deployments_total",
+		Help: "Number of version changes to a lower semantic version",
+	}, []string{
+		"namespace",
+		"kind",
+	})
+
+	rolloutOutcomesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "apptrail_rollout_outcomes_total",
+		Help: "Number of finished rollouts by outcome (success, failed, timed_out)",
+	}, []string{
+		"namespace",
+		"kind",
+		"outcome",
+	})
+
 	metricsRegistered = false
 )
 
+// AppVersionCollector returns the app version gauge so it can be exported outside the metrics endpoint
+func AppVersionCollector() prometheus.Collector {
+	return appVersionGauge
+}
+
 type AppVersion struct {
 	PreviousVersion string
 	CurrentVersion  string
 	LastUpdated     time.Time
-	RolloutStarted  time.Time // When rollout started
+	RolloutStarted  time.Time      // When rollout started
+	CustomTimeout   *time.Duration // Parsed apptrail.sh/rollout-timeout annotation, if valid
+
+	TimeoutApproaching bool // RolloutTimedOut condition was last reported as TimeoutApproaching
+}
+
+// nodeScaleUp records a rise in a DaemonSet's desired pod count, e.g. from cluster autoscaling
+type nodeScaleUp struct {
+	previousDesired int32 // DesiredNumberScheduled before the rise
+	at              time.Time
 }
 
 // WorkloadReconciler contains shared logic for reconciling workloads
@@ -60,45 +130,96 @@ type WorkloadReconciler struct {
 	client.Client
 	Scheme              *runtime.Scheme
 	Recorder            record.EventRecorder
-	mu                  sync.RWMutex // Protects workloadVersions and workloadPhases
+	mu                  sync.RWMutex // Protects workloadVersions, workloadPhases, replayPending, resyncPending and the DaemonSet scale maps
 	workloadVersions    map[string]AppVersion
 	workloadPhases      map[string]string // Track last sent phase
+	daemonSetDesired    map[string]int32  // Last seen DesiredNumberScheduled per DaemonSet
+	daemonSetScaleUps   map[string]nodeScaleUp
+	nodeScaleThreshold  time.Duration
+	replayPending       map[string]struct{}                                   // Workloads whose next reconcile republishes their state
+	resyncPending       map[string]*apptrailv1alpha1.WorkloadRolloutStateSpec // Workloads whose next reconcile re-syncs state, see ValidateState
+	replayEvents        chan event.GenericEvent
 	publisherChan       chan<- model.WorkloadUpdate
 	controllerNamespace string // Namespace where controller is running
-	filter              *filter.ResourceFilter
+	filter              atomic.Pointer[filter.ResourceFilter]
+
+	// RolloutTimeout applies to workloads without a valid apptrail.sh/rollout-timeout annotation
+	RolloutTimeout time.Duration
+
+	// VersionExtractor derives workload versions; nil means the app.kubernetes.io/version label
+	VersionExtractor VersionExtractor
+
+	// VersionLabels are the label keys whose changes trigger a reconcile; nil means the
+	// app.kubernetes.io/version label. They must be set before SetupWithManager.
+	VersionLabels []string
+
+	// PassthroughAnnotationPrefixes selects workload annotations copied into updates,
+	// e.g. "argocd.argoproj.io/" for the ArgoCD application name
+	PassthroughAnnotationPrefixes []string
+
+	// APIReader reads directly from the API server; used by WarmUp before the cache is started
+	APIReader client.Reader
+
+	// RateLimitBaseDelay and RateLimitMaxDelay bound the exponential requeue delay of workloads
+	// whose reconcile fails; they must be set before SetupWithManager
+	RateLimitBaseDelay time.Duration
+	RateLimitMaxDelay  time.Duration
+
+	kind     string          // Workload kind handled by this reconciler, used to scope WarmUp
+	backoffs *backoffTracker // Set by SetupWithManager, read by the queue debug endpoint
 }
 
 func NewWorkloadReconciler(client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, publisherChan chan<- model.WorkloadUpdate, controllerNamespace string, resourceFilter *filter.ResourceFilter) *WorkloadReconciler {
 	// Register metrics only once
 	if !metricsRegistered {
-		metrics.Registry.MustRegister(appVersionGauge)
+		metrics.Registry.MustRegister(appVersionGauge, scaleEventsCounter, rollbacksCounter, rolloutOutcomesCounter, reconcileErrorsCounter,
+			reconcileDurationHistogram, reconcileTotalCounter)
 		metricsRegistered = true
 	}
 
-	return &WorkloadReconciler{
+	wr := &WorkloadReconciler{
 		Client:              client,
 		Scheme:              scheme,
 		Recorder:            recorder,
 		workloadVersions:    make(map[string]AppVersion),
 		workloadPhases:      make(map[string]string),
+		daemonSetDesired:    make(map[string]int32),
+		daemonSetScaleUps:   make(map[string]nodeScaleUp),
+		nodeScaleThreshold:  defaultNodeScaleThreshold,
+		replayPending:       make(map[string]struct{}),
+		resyncPending:       make(map[string]*apptrailv1alpha1.WorkloadRolloutStateSpec),
+		replayEvents:        make(chan event.GenericEvent),
 		publisherChan:       publisherChan,
 		controllerNamespace: controllerNamespace,
-		filter:              resourceFilter,
+		RolloutTimeout:      DefaultRolloutTimeout,
+		RateLimitBaseDelay:  DefaultRateLimitBaseDelay,
+		RateLimitMaxDelay:   DefaultRateLimitMaxDelay,
 	}
+	wr.filter.Store(resourceFilter)
+	return wr
+}
+
+// SetFilter replaces the resource filter used by subsequent reconciles
+func (wr *WorkloadReconciler) SetFilter(resourceFilter *filter.ResourceFilter) {
+	wr.filter.Store(resourceFilter)
 }
 
 // ReconcileWorkload contains the shared reconciliation logic for all workload types
 func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Request, workload WorkloadAdapter) (ctrl.Result, error) {
-	log := ctrl.LoggerFrom(ctx)
+	baseLog := ctrl.LoggerFrom(ctx)
 
 	// Skip workloads in excluded namespaces
-	if wr.filter != nil && !wr.filter.ShouldWatchNamespace(req.Namespace) {
+	if resourceFilter := wr.filter.Load(); resourceFilter != nil && !resourceFilter.ShouldWatchNamespace(req.Namespace) {
 		return ctrl.Result{}, nil
 	}
 
-	log.Info("Reconciling workload", "kind", workload.GetKind(), "name", workload.GetName())
+	log := baseLog.WithValues(reconcilerLogFields(workload, "", "")...)
+	ctx = ctrl.LoggerInto(ctx, log)
+	log.Info("Reconciling workload")
 
 	appkey := workload.GetNamespace() + "/" + workload.GetName() + "/" + workload.GetKind()
+	replay := wr.takeReplay(appkey)
+	wr.takeResync(appkey)
 
 	// Read stored state under read lock
 	wr.mu.RLock()
@@ -106,13 +227,15 @@ func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Re
 	lastPhase := wr.workloadPhases[appkey]
 	wr.mu.RUnlock()
 
-	versionLabel := workload.GetVersion()
+	versionLabel := wr.workloadVersion(workload)
 	if versionLabel == "" {
-		log.Info("Workload version label not found",
-			"kind", workload.GetKind(),
-			"workload", fmt.Sprintf("%s/%s", workload.GetNamespace(), workload.GetName()))
+		// Nothing to track until the workload gets a version, which triggers a new reconcile
+		log.V(1).Info("Skipping workload without a version")
+		setReconcileOutcome(ctx, ReconcileOutcomeNoVersion)
 		return ctrl.Result{}, nil
 	}
+	log = baseLog.WithValues(reconcilerLogFields(workload, versionLabel, "")...)
+	ctx = ctrl.LoggerInto(ctx, log)
 
 	// Load persistent state from CRD if in-memory state is empty (e.g., after restart)
 	var crdState RolloutState
@@ -142,8 +265,32 @@ func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Re
 		}
 	}
 
+	// Cache the per-workload rollout timeout so determineWorkloadPhase can access it. Workloads
+	// not tracked yet have no rollout timer to check; they get it with their first version.
+	customTimeout, err := parseRolloutTimeout(workload)
+	if err != nil {
+		log.Info("Ignoring invalid rollout timeout annotation",
+			"annotation", rolloutTimeoutAnnotation,
+			"error", err.Error())
+	}
+	stored.CustomTimeout = customTimeout
+	wr.mu.Lock()
+	if cached, ok := wr.workloadVersions[appkey]; ok {
+		cached.CustomTimeout = customTimeout
+		wr.workloadVersions[appkey] = cached
+	}
+	wr.mu.Unlock()
+
 	// Determine current workload phase
 	currentPhase := wr.determineWorkloadPhase(workload, appkey)
+	log = baseLog.WithValues(reconcilerLogFields(workload, versionLabel, currentPhase)...)
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	// DaemonSets catching up with newly added nodes are not rolling out a change
+	nodeScaleLag := false
+	if daemonSet, ok := workload.(*DaemonSetAdapter); ok {
+		nodeScaleLag = wr.isNodeScaleLag(daemonSet, appkey)
+	}
 
 	// Send event if version changed OR phase changed
 	versionChanged := stored.CurrentVersion != versionLabel
@@ -151,13 +298,11 @@ func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Re
 
 	// Check for restart deduplication: if we have CRD state, verify this is a real change
 	// not just a re-reconciliation of the same state after restart
-	if crdState.LastSentVersion != "" {
+	if crdState.LastSentVersion != "" && !replay {
 		// We loaded state from CRD, check if current state matches what we last sent
 		if crdState.LastSentVersion == versionLabel && crdState.LastSentPhase == currentPhase {
-			log.Info("Skipping duplicate event after restart",
-				"workload", appkey,
-				"version", versionLabel,
-				"phase", currentPhase)
+			log.Info("Skipping duplicate event after restart")
+			setReconcileOutcome(ctx, ReconcileOutcomePhaseUnchanged)
 
 			// Refresh metrics from current state (decoupled from event publishing)
 			previousVersion := stored.PreviousVersion
@@ -186,20 +331,41 @@ func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Re
 	// Track rollout timing
 	// Set RolloutStarted when entering rolling_out phase (or on version change)
 	// Clear it when leaving rolling_out phase
+	// OnDelete workloads are never timed, their pods only change when the user deletes them
 	needsPersistence := false
-	if currentPhase == phaseRollingOut && stored.RolloutStarted.IsZero() {
+	if !workload.IsManagedRollout() {
+		if !stored.RolloutStarted.IsZero() {
+			stored.RolloutStarted = time.Time{}
+			// Clear the persisted timer too, or a restart would restore it
+			needsPersistence = true
+			log.Info("Rollout tracking disabled for OnDelete update strategy")
+		}
+	} else if currentPhase == phaseRollingOut && stored.RolloutStarted.IsZero() && nodeScaleLag {
+		log.V(1).Info("DaemonSet scheduling pods on added nodes, not starting rollout timer")
+	} else if currentPhase == phaseRollingOut && stored.RolloutStarted.IsZero() {
 		// Entering rolling_out phase for the first time
 		stored.RolloutStarted = time.Now()
 		needsPersistence = true
-		log.Info("Rollout started", "workload", appkey, "time", stored.RolloutStarted)
+		log.Info("Rollout started", "time", stored.RolloutStarted)
 	} else if currentPhase != phaseRollingOut && !stored.RolloutStarted.IsZero() {
 		// Left rolling_out phase, clear the in-memory timer
 		// Keep CRD for dedup and metrics refresh on restart
 		stored.RolloutStarted = time.Time{}
-		log.Info("Rollout completed", "workload", appkey)
+		log.Info("Rollout completed")
+	}
+	conditions := wr.rolloutConditions(workload, currentPhase, stored)
+	if approaching := timeoutApproaching(conditions); approaching != stored.TimeoutApproaching {
+		stored.TimeoutApproaching = approaching
+		wr.mu.Lock()
+		cached := wr.workloadVersions[appkey]
+		cached.TimeoutApproaching = approaching
+		wr.workloadVersions[appkey] = cached
+		wr.mu.Unlock()
+		// Surface a slow rollout on the CRD before it fails
+		needsPersistence = needsPersistence || approaching
 	}
 
-	if versionChanged || phaseChanged {
+	if versionChanged || phaseChanged || replay {
 		// Update version tracking if version changed
 		if versionChanged {
 			newAppVer := AppVersion{
@@ -207,6 +373,7 @@ func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Re
 				CurrentVersion:  versionLabel,
 				LastUpdated:     time.Now(),
 				RolloutStarted:  stored.RolloutStarted, // Preserve rollout timer
+				CustomTimeout:   stored.CustomTimeout,
 			}
 			wr.mu.Lock()
 			wr.workloadVersions[appkey] = newAppVer
@@ -214,7 +381,12 @@ func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Re
 			stored = newAppVer // Update local reference
 
 			wr.refreshWorkloadMetrics(workload, stored.PreviousVersion, versionLabel)
-			log.Info("Updated workload version metric", "workload", workload.GetName(), "kind", workload.GetKind())
+			log.Info("Updated workload version metric")
+
+			if isRollback(stored.PreviousVersion, versionLabel) {
+				rollbacksCounter.WithLabelValues(workload.GetNamespace(), workload.GetKind()).Inc()
+				log.Info("Workload rolled back to a lower version", "previousVersion", stored.PreviousVersion)
+			}
 		} else {
 			// Version didn't change but we might have updated RolloutStarted
 			wr.mu.Lock()
@@ -227,44 +399,67 @@ func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Re
 		wr.workloadPhases[appkey] = currentPhase
 		wr.mu.Unlock()
 
+		if phaseChanged && lastPhase != "" {
+			recordRolloutOutcome(workload, currentPhase)
+		}
+
 		// Persist state to CRD for deduplication after restart
 		// Always persist when we send an event, not just when rollout starts
-		err := wr.saveFullRolloutStateToCRD(ctx, workload.GetNamespace(), workload.GetName(), workload.GetKind(), versionLabel, stored.RolloutStarted, versionLabel, currentPhase)
+		err := wr.saveFullRolloutStateToCRD(ctx, workload.GetNamespace(), workload.GetName(), workload.GetKind(), versionLabel, stored.RolloutStarted, versionLabel, currentPhase, conditions...)
 		if err != nil {
 			log.Error(err, "Failed to persist rollout state to CRD")
 			// Don't fail the reconciliation, continue with in-memory state
 		}
 
-		// Send event with current state
-		wr.publisherChan <- model.WorkloadUpdate{
+		update := model.WorkloadUpdate{
 			Name:            workload.GetName(),
 			Namespace:       workload.GetNamespace(),
 			Kind:            workload.GetKind(),
 			PreviousVersion: stored.PreviousVersion,
 			CurrentVersion:  versionLabel,
-			Labels:          workload.GetLabels(),
+			Labels:          workloadLabels(workload),
+			Annotations:     wr.passthroughAnnotations(workload),
+			SourceURL:       workload.GetAnnotations()[sourceURLAnnotation],
+			CIRunURL:        workload.GetAnnotations()[ciRunURLAnnotation],
+			GitOps:          extractGitOpsRef(gitOpsMarkers(workload)),
+
+			WorkloadAgeSeconds: workloadAgeSeconds(workload),
+
+			// Phase updates after the version change still belong to the rollback
+			IsRollback: isRollback(stored.PreviousVersion, versionLabel),
 
 			// Workload status
 			DeploymentPhase: currentPhase,
 		}
+		if versionChanged && stored.PreviousVersion != "" {
+			wr.recordEvent(workload, ReasonVersionChanged, update)
+		}
+		if phaseChanged {
+			wr.recordPhaseEvent(workload, lastPhase, update)
+		}
+
+		// Send event with current state
+		wr.publisherChan <- update
 
 		if versionChanged {
-			log.Info("Workload version updated",
-				"kind", workload.GetKind(),
-				"workload", workload.GetName(),
-				"phase", currentPhase)
+			log.Info("Workload version updated", "previousVersion", stored.PreviousVersion)
+		} else if phaseChanged {
+			log.Info("Workload phase updated", "previousPhase", lastPhase)
 		} else {
-			log.Info("Workload phase updated",
-				"kind", workload.GetKind(),
-				"workload", workload.GetName(),
-				"previousPhase", lastPhase,
-				"currentPhase", currentPhase)
+			log.Info("Workload state replayed")
 		}
-	} else if needsPersistence {
+	} else {
+		setReconcileOutcome(ctx, ReconcileOutcomePhaseUnchanged)
+
 		// Even if no event to send, persist rollout start time if needed
-		err := wr.saveFullRolloutStateToCRD(ctx, workload.GetNamespace(), workload.GetName(), workload.GetKind(), versionLabel, stored.RolloutStarted, versionLabel, currentPhase)
-		if err != nil {
-			log.Error(err, "Failed to persist rollout state to CRD")
+		if needsPersistence {
+			wr.mu.Lock()
+			wr.workloadVersions[appkey] = stored
+			wr.mu.Unlock()
+			err := wr.saveFullRolloutStateToCRD(ctx, workload.GetNamespace(), workload.GetName(), workload.GetKind(), versionLabel, stored.RolloutStarted, versionLabel, currentPhase, conditions...)
+			if err != nil {
+				log.Error(err, "Failed to persist rollout state to CRD")
+			}
 		}
 	}
 
@@ -276,15 +471,337 @@ func (wr *WorkloadReconciler) ReconcileWorkload(ctx context.Context, req ctrl.Re
 	return ctrl.Result{}, nil
 }
 
+// WarmUp restores in-memory version and phase tracking from WorkloadRolloutState CRDs so that
+// the first reconcile after a restart does not re-emit events that were already sent
+func (wr *WorkloadReconciler) WarmUp(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	states, err := wr.listRolloutStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	restored := wr.restoreFromRolloutStates(states)
+
+	log.Info("Restored workload state from rollout state CRDs", "kind", wr.kind, "restored", restored)
+	return nil
+}
+
+// ValidateState checks in-memory state against WorkloadRolloutState CRDs after this agent
+// becomes leader. Workloads whose CRD is missing from or differs from memory, e.g. entries
+// restored by WarmUp that another leader has since updated, workloads deleted while no agent
+// was running, and rollouts tracked in memory without a CRD that have finished or whose
+// workload is gone, are queued for a reconcile that re-syncs them. The state itself is only changed by that reconcile, so validation never races
+// with a reconcile of the same workload.
+func (wr *WorkloadReconciler) ValidateState(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	states, err := wr.listRolloutStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	resync := make(map[string]*apptrailv1alpha1.WorkloadRolloutStateSpec)
+	persisted := make(map[string]struct{}, len(states))
+	var unverified []string
+	var matching []*apptrailv1alpha1.WorkloadRolloutStateSpec
+	wr.mu.RLock()
+	for i := range states {
+		spec := &states[i].Spec
+		appkey := spec.WorkloadNamespace + "/" + spec.WorkloadName + "/" + spec.WorkloadKind
+		persisted[appkey] = struct{}{}
+		if spec.LastSentVersion == "" {
+			continue
+		}
+		if wr.workloadVersions[appkey].CurrentVersion != spec.LastSentVersion || wr.workloadPhases[appkey] != spec.LastSentPhase {
+			resync[appkey] = spec
+		} else {
+			matching = append(matching, spec)
+		}
+	}
+	for appkey, phase := range wr.workloadPhases {
+		if _, ok := persisted[appkey]; !ok && phase == phaseRollingOut {
+			unverified = append(unverified, appkey)
+		}
+	}
+	wr.mu.RUnlock()
+
+	// No delete event is delivered for workloads removed while no agent was running, so the
+	// state and version metric restored for them would otherwise never be cleaned up
+	for _, spec := range matching {
+		_, err := wr.getWorkload(ctx, spec.WorkloadNamespace, spec.WorkloadName, spec.WorkloadKind)
+		if apierrors.IsNotFound(err) {
+			resync[spec.WorkloadNamespace+"/"+spec.WorkloadName+"/"+spec.WorkloadKind] = spec
+		} else if err != nil {
+			log.Error(err, "Failed to verify workload state", "workload", spec.WorkloadNamespace+"/"+spec.WorkloadName+"/"+spec.WorkloadKind)
+		}
+	}
+
+	for _, appkey := range unverified {
+		parts := strings.SplitN(appkey, "/", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		workload, err := wr.getWorkload(ctx, parts[0], parts[1], parts[2])
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to verify workload state", "workload", appkey)
+			continue
+		}
+		// A rollout that finished while another agent was leader, or a workload that is gone
+		if workload == nil || !workload.IsRollingOut() {
+			resync[appkey] = nil
+		}
+	}
+
+	queued := 0
+	for appkey, spec := range resync {
+		parts := strings.SplitN(appkey, "/", 3)
+		wr.mu.Lock()
+		wr.resyncPending[appkey] = spec
+		wr.mu.Unlock()
+
+		object := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: parts[0], Name: parts[1]}}
+		if err := wr.enqueue(ctx, object); err != nil {
+			return err
+		}
+		queued++
+	}
+
+	log.Info("Validated workload state after leader election",
+		"kind", wr.kind,
+		"resyncQueued", queued,
+	)
+	return nil
+}
+
+// takeResync applies the re-sync queued by ValidateState for appkey and clears it. State
+// backed by a CRD is replaced with the CRD's; otherwise the stale rolling_out phase is
+// forgotten so the reconcile publishes the actual outcome.
+func (wr *WorkloadReconciler) takeResync(appkey string) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	spec, ok := wr.resyncPending[appkey]
+	if !ok {
+		return
+	}
+	delete(wr.resyncPending, appkey)
+
+	delete(wr.workloadPhases, appkey)
+	if spec != nil {
+		delete(wr.workloadVersions, appkey)
+		wr.restoreRolloutState(spec)
+	}
+}
+
+// listRolloutStates lists the WorkloadRolloutState CRDs for this reconciler's kind
+func (wr *WorkloadReconciler) listRolloutStates(ctx context.Context) ([]apptrailv1alpha1.WorkloadRolloutState, error) {
+	reader := wr.APIReader
+	if reader == nil {
+		reader = wr.Client
+	}
+
+	states := &apptrailv1alpha1.WorkloadRolloutStateList{}
+	if err := reader.List(ctx, states, client.InNamespace(wr.controllerNamespace)); err != nil {
+		return nil, fmt.Errorf("failed to list rollout states: %w", err)
+	}
+
+	items := make([]apptrailv1alpha1.WorkloadRolloutState, 0, len(states.Items))
+	for _, state := range states.Items {
+		if wr.kind != "" && state.Spec.WorkloadKind != wr.kind {
+			continue
+		}
+		items = append(items, state)
+	}
+	return items, nil
+}
+
+// getWorkload fetches a live workload of the given kind wrapped in its adapter
+func (wr *WorkloadReconciler) getWorkload(ctx context.Context, namespace, name, kind string) (WorkloadAdapter, error) {
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	switch kind {
+	case "Deployment":
+		obj := &v1.Deployment{}
+		if err := wr.Get(ctx, key, obj); err != nil {
+			return nil, err
+		}
+		return &DeploymentAdapter{Deployment: obj}, nil
+	case "StatefulSet":
+		obj := &v1.StatefulSet{}
+		if err := wr.Get(ctx, key, obj); err != nil {
+			return nil, err
+		}
+		return &StatefulSetAdapter{StatefulSet: obj}, nil
+	case "DaemonSet":
+		obj := &v1.DaemonSet{}
+		if err := wr.Get(ctx, key, obj); err != nil {
+			return nil, err
+		}
+		return &DaemonSetAdapter{DaemonSet: obj}, nil
+	case "VirtualMachine":
+		obj := NewVirtualMachineObject()
+		if err := wr.Get(ctx, key, obj); err != nil {
+			return nil, err
+		}
+		return &VirtualMachineAdapter{VirtualMachine: obj}, nil
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+}
+
+// restoreFromRolloutStates fills in-memory state for workloads that have none and returns how many were restored
+func (wr *WorkloadReconciler) restoreFromRolloutStates(states []apptrailv1alpha1.WorkloadRolloutState) int {
+	restored := 0
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	for _, state := range states {
+		spec := state.Spec
+		if spec.LastSentVersion == "" {
+			continue
+		}
+
+		appkey := spec.WorkloadNamespace + "/" + spec.WorkloadName + "/" + spec.WorkloadKind
+		if _, ok := wr.workloadVersions[appkey]; ok {
+			continue
+		}
+		wr.restoreRolloutState(&spec)
+		restored++
+	}
+	return restored
+}
+
+// restoreRolloutState sets the in-memory state of a workload from its CRD spec. The caller must hold wr.mu.
+func (wr *WorkloadReconciler) restoreRolloutState(spec *apptrailv1alpha1.WorkloadRolloutStateSpec) {
+	appkey := spec.WorkloadNamespace + "/" + spec.WorkloadName + "/" + spec.WorkloadKind
+	appVer := AppVersion{
+		CurrentVersion: spec.LastSentVersion,
+		RolloutStarted: spec.RolloutStarted.Time,
+	}
+	if spec.LastSentAt != nil {
+		appVer.LastUpdated = spec.LastSentAt.Time
+	}
+	wr.workloadVersions[appkey] = appVer
+	if spec.LastSentPhase != "" {
+		wr.workloadPhases[appkey] = spec.LastSentPhase
+	}
+
+	// Export the version metric right away; unchanged workloads won't refresh it on reconcile.
+	// A series exported earlier for this workload would otherwise stay behind with stale labels.
+	deleteWorkloadMetrics(spec.WorkloadNamespace, spec.WorkloadName, spec.WorkloadKind)
+	appVersionGauge.WithLabelValues(
+		spec.WorkloadNamespace,
+		spec.WorkloadName,
+		spec.WorkloadKind,
+		spec.LastSentVersion,
+		spec.LastSentVersion,
+		appVer.LastUpdated.Format(time.RFC3339),
+	).Set(1)
+}
+
+// deleteWorkloadMetrics removes every version series exported for a workload. The series
+// carry the versions and update time as labels, so they are matched on the workload alone.
+func deleteWorkloadMetrics(namespace, name, kind string) {
+	appVersionGauge.DeletePartialMatch(map[string]string{
+		"namespace": namespace,
+		"workload":  name,
+		"kind":      kind,
+	})
+}
+
+// workloadVersion returns the version of a workload using the configured extractor
+func (wr *WorkloadReconciler) workloadVersion(workload WorkloadResourceAdapter) string {
+	if wr.VersionExtractor != nil {
+		return wr.VersionExtractor(workload)
+	}
+	return workload.GetVersion()
+}
+
+// workloadLabels returns the labels sent with a workload update, including controller
+// revisions for adapters that track them and the Helm release managing the workload.
+// The workload's own labels are not modified.
+func workloadLabels(workload WorkloadAdapter) map[string]string {
+	extra := helmReleaseLabels(workload.GetAnnotations())
+	if labeler, ok := workload.(revisionLabeler); ok {
+		revisions := labeler.GetRevisionLabels()
+		if extra == nil {
+			extra = revisions
+		} else {
+			maps.Copy(extra, revisions)
+		}
+	}
+	if len(extra) == 0 {
+		return workload.GetLabels()
+	}
+	labels := maps.Clone(workload.GetLabels())
+	if labels == nil {
+		labels = make(map[string]string, len(extra))
+	}
+	maps.Copy(labels, extra)
+	return labels
+}
+
+// passthroughAnnotations returns the workload annotations matching PassthroughAnnotationPrefixes,
+// or nil when none match
+func (wr *WorkloadReconciler) passthroughAnnotations(workload WorkloadResourceAdapter) map[string]string {
+	var annotations map[string]string
+	for key, value := range workload.GetAnnotations() {
+		for _, prefix := range wr.PassthroughAnnotationPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				if annotations == nil {
+					annotations = make(map[string]string)
+				}
+				annotations[key] = value
+				break
+			}
+		}
+	}
+	return annotations
+}
+
+// recordRolloutOutcome counts a rollout reaching a terminal phase. A failure is a Kubernetes
+// failure when the workload reports a failure condition, otherwise it was our rollout timeout.
+func recordRolloutOutcome(workload WorkloadAdapter, phase string) {
+	var outcome string
+	switch {
+	case phase == phaseSuccess:
+		outcome = "success"
+	case phase == phaseFailed && workload.HasFailed():
+		outcome = "failed"
+	case phase == phaseFailed:
+		outcome = "timed_out"
+	default:
+		return
+	}
+	rolloutOutcomesCounter.WithLabelValues(workload.GetNamespace(), workload.GetKind(), outcome).Inc()
+}
+
+// recordPhaseEvent emits a Kubernetes Event on the workload for rollout phase transitions so
+// they show up in kubectl describe. The first phase seen for a workload only emits RolloutStarted,
+// otherwise a restarted agent without stored state would report every settled workload.
+// Failures Kubernetes doesn't report itself come from the agent's rollout timeout.
+func (wr *WorkloadReconciler) recordPhaseEvent(workload WorkloadAdapter, lastPhase string, update model.WorkloadUpdate) {
+	phase := update.DeploymentPhase
+	if lastPhase == "" && phase != phaseRollingOut {
+		return
+	}
+
+	switch phase {
+	case phaseRollingOut:
+		wr.recordEvent(workload, ReasonRolloutStarted, update)
+	case phaseSuccess:
+		wr.recordEvent(workload, ReasonRolloutSucceeded, update)
+	case phaseFailed:
+		if workload.HasFailed() {
+			wr.recordEvent(workload, ReasonRolloutFailed, update)
+		} else {
+			wr.recordEvent(workload, ReasonRolloutTimedOut, update)
+		}
+	}
+}
+
 // refreshWorkloadMetrics updates the Prometheus gauge for a workload.
 // Called to ensure metrics reflect current state regardless of event publishing.
 func (wr *WorkloadReconciler) refreshWorkloadMetrics(workload WorkloadAdapter, previousVersion, currentVersion string) {
-	labelsToDelete := map[string]string{
-		"namespace": workload.GetNamespace(),
-		"workload":  workload.GetName(),
-		"kind":      workload.GetKind(),
-	}
-	appVersionGauge.DeletePartialMatch(labelsToDelete)
+	deleteWorkloadMetrics(workload.GetNamespace(), workload.GetName(), workload.GetKind())
 
 	appVersionGauge.WithLabelValues(
 		workload.GetNamespace(),
@@ -296,8 +813,22 @@ func (wr *WorkloadReconciler) refreshWorkloadMetrics(workload WorkloadAdapter, p
 	).Set(1)
 }
 
+// workloadAgeSeconds returns how long ago the workload was created, or 0 if unknown
+func workloadAgeSeconds(workload WorkloadAdapter) float64 {
+	created := workload.GetCreationTimestamp()
+	if created.IsZero() {
+		return 0
+	}
+	return time.Since(created).Seconds()
+}
+
 // determineWorkloadPhase determines the workload phase based on Kubernetes status
 func (wr *WorkloadReconciler) determineWorkloadPhase(workload WorkloadAdapter, appkey string) string {
+	// Argo Rollouts scales the Deployment to 0/0 replicas, which would otherwise look like success
+	if deployment, ok := workload.(*DeploymentAdapter); ok && deployment.IsArgoManaged() {
+		return phaseArgoManaged
+	}
+
 	// Check replica status to determine if rolling out
 	isRollingOut := workload.IsRollingOut()
 
@@ -315,8 +846,7 @@ func (wr *WorkloadReconciler) determineWorkloadPhase(workload WorkloadAdapter, a
 		wr.mu.RUnlock()
 		if !stored.RolloutStarted.IsZero() {
 			elapsed := time.Since(stored.RolloutStarted)
-			// Force failed after 15 minutes (longer than K8s default to account for resets)
-			if elapsed > 15*time.Minute {
+			if elapsed > wr.rolloutTimeout(stored) {
 				return phaseFailed
 			}
 		}
@@ -332,19 +862,161 @@ func (wr *WorkloadReconciler) determineWorkloadPhase(workload WorkloadAdapter, a
 	return phaseProgressing
 }
 
+// isNodeScaleLag reports whether a DaemonSet is only behind because nodes were added: its desired
+// pod count rose within nodeScaleThreshold and at least as many pods as were desired before are updated
+func (wr *WorkloadReconciler) isNodeScaleLag(daemonSet *DaemonSetAdapter, appkey string) bool {
+	desired := daemonSet.DaemonSet.Status.DesiredNumberScheduled
+	updated := daemonSet.DaemonSet.Status.UpdatedNumberScheduled
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	previous, seen := wr.daemonSetDesired[appkey]
+	wr.daemonSetDesired[appkey] = desired
+
+	scaleUp, scaling := wr.daemonSetScaleUps[appkey]
+	if seen && desired > previous && !scaling {
+		// Keep the baseline of the first rise so consecutive node additions share one window
+		scaleUp = nodeScaleUp{previousDesired: previous, at: time.Now()}
+		wr.daemonSetScaleUps[appkey] = scaleUp
+		scaling = true
+	}
+	if !scaling {
+		return false
+	}
+	if time.Since(scaleUp.at) > wr.nodeScaleThreshold {
+		delete(wr.daemonSetScaleUps, appkey)
+		return false
+	}
+	return updated >= scaleUp.previousDesired
+}
+
+// rolloutConditions describes the rollout as WorkloadRolloutState status conditions. Failures
+// that Kubernetes did not report come from the agent's own rollout timeout.
+func (wr *WorkloadReconciler) rolloutConditions(workload WorkloadAdapter, phase string, stored AppVersion) []metav1.Condition {
+	timedOut := metav1.Condition{
+		Type:    apptrailv1alpha1.ConditionRolloutTimedOut,
+		Status:  metav1.ConditionFalse,
+		Reason:  "WithinTimeout",
+		Message: "Rollout has not exceeded its timeout",
+	}
+	failed := metav1.Condition{
+		Type:    apptrailv1alpha1.ConditionRolloutFailed,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotFailed",
+		Message: fmt.Sprintf("Rollout phase is %s", phase),
+	}
+
+	timeout := wr.rolloutTimeout(stored)
+	switch {
+	case phase == phaseFailed && !workload.HasFailed():
+		timedOut.Status = metav1.ConditionTrue
+		timedOut.Reason = "TimeoutExceeded"
+		timedOut.Message = fmt.Sprintf("Rollout ran longer than its %s timeout", timeout)
+		failed.Status = metav1.ConditionTrue
+		failed.Reason = "TimeoutExceeded"
+		failed.Message = timedOut.Message
+	case phase == phaseFailed:
+		failed.Status = metav1.ConditionTrue
+		failed.Reason = "WorkloadFailed"
+		failed.Message = "Kubernetes reports the rollout as failed"
+	case phase == phaseRollingOut && !stored.RolloutStarted.IsZero():
+		elapsed := time.Since(stored.RolloutStarted)
+		if elapsed >= time.Duration(float64(timeout)*timeoutWarningFraction) {
+			timedOut.Reason = "TimeoutApproaching"
+			timedOut.Message = fmt.Sprintf("Rollout has been running for %s of its %s timeout",
+				elapsed.Truncate(time.Second), timeout)
+		}
+	}
+	return []metav1.Condition{timedOut, failed}
+}
+
+// timeoutApproaching reports whether the conditions warn that the rollout timeout is near
+func timeoutApproaching(conditions []metav1.Condition) bool {
+	condition := apimeta.FindStatusCondition(conditions, apptrailv1alpha1.ConditionRolloutTimedOut)
+	return condition != nil && condition.Reason == "TimeoutApproaching"
+}
+
+// rolloutTimeout returns the workload's annotated timeout, falling back to the global one
+func (wr *WorkloadReconciler) rolloutTimeout(stored AppVersion) time.Duration {
+	if stored.CustomTimeout != nil {
+		return *stored.CustomTimeout
+	}
+	if wr.RolloutTimeout > 0 {
+		return wr.RolloutTimeout
+	}
+	return DefaultRolloutTimeout
+}
+
+// parseRolloutTimeout reads the apptrail.sh/rollout-timeout annotation, returning nil when it is
+// absent and an error when it is not a positive duration
+func parseRolloutTimeout(workload WorkloadAdapter) (*time.Duration, error) {
+	value, ok := workload.GetAnnotations()[rolloutTimeoutAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("rollout timeout %q must be positive", value)
+	}
+	return &timeout, nil
+}
+
 // RolloutState contains the state loaded from the CRD
 type RolloutState struct {
-	RolloutStarted  time.Time
-	LastSentVersion string
-	LastSentPhase   string
-	LastSentAt      time.Time
+	RolloutStarted   time.Time
+	LastSentVersion  string
+	LastSentPhase    string
+	LastSentAt       time.Time
+	OnDeleteNotified bool
+}
+
+// rolloutStateName returns the WorkloadRolloutState name for a workload, logging when it had to be truncated
+func rolloutStateName(ctx context.Context, namespace, name, kind string) string {
+	stateName := sanitizeStateName(namespace, name, kind)
+	if len(namespace)+len(name)+len(kind)+2 > maxStateNameLength {
+		ctrl.LoggerFrom(ctx).Info("Rollout state name exceeds Kubernetes limit, truncating",
+			"namespace", namespace,
+			"name", name,
+			"kind", kind,
+			"stateName", stateName)
+	}
+	return stateName
+}
+
+// sanitizeStateName builds a valid Kubernetes object name from a workload identity.
+// Names longer than 253 characters are truncated and suffixed with a hash of the full name
+// so that distinct workloads keep distinct state objects.
+func sanitizeStateName(namespace, name, kind string) string {
+	full := strings.ToLower(fmt.Sprintf("%s-%s-%s", namespace, name, kind))
+
+	sanitized := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, full)
+	sanitized = strings.Trim(sanitized, "-.")
+
+	if len(sanitized) <= maxStateNameLength {
+		return sanitized
+	}
+
+	sum := sha256.Sum256([]byte(full))
+	suffix := hex.EncodeToString(sum[:])[:stateNameHashLength]
+	prefix := strings.TrimRight(sanitized[:maxStateNameLength-len(suffix)-1], "-.")
+	return prefix + "-" + suffix
 }
 
 // loadFullRolloutStateFromCRD loads the complete rollout state from the CRD including deduplication fields
 func (wr *WorkloadReconciler) loadFullRolloutStateFromCRD(ctx context.Context, namespace, name, kind string) (RolloutState, error) {
 	log := ctrl.LoggerFrom(ctx)
 
-	stateName := fmt.Sprintf("%s-%s-%s", namespace, name, strings.ToLower(kind))
+	stateName := rolloutStateName(ctx, namespace, name, kind)
 	state := &apptrailv1alpha1.WorkloadRolloutState{}
 
 	err := wr.Get(ctx, types.NamespacedName{
@@ -361,9 +1033,10 @@ func (wr *WorkloadReconciler) loadFullRolloutStateFromCRD(ctx context.Context, n
 	}
 
 	result := RolloutState{
-		RolloutStarted:  state.Spec.RolloutStarted.Time,
-		LastSentVersion: state.Spec.LastSentVersion,
-		LastSentPhase:   state.Spec.LastSentPhase,
+		RolloutStarted:   state.Spec.RolloutStarted.Time,
+		LastSentVersion:  state.Spec.LastSentVersion,
+		LastSentPhase:    state.Spec.LastSentPhase,
+		OnDeleteNotified: state.Spec.OnDeleteNotified,
 	}
 	if state.Spec.LastSentAt != nil {
 		result.LastSentAt = state.Spec.LastSentAt.Time
@@ -372,11 +1045,12 @@ func (wr *WorkloadReconciler) loadFullRolloutStateFromCRD(ctx context.Context, n
 	return result, nil
 }
 
-// saveFullRolloutStateToCRD saves the complete rollout state to a CRD including deduplication fields
-func (wr *WorkloadReconciler) saveFullRolloutStateToCRD(ctx context.Context, namespace, name, kind, version string, rolloutStarted time.Time, lastSentVersion, lastSentPhase string) error {
+// saveFullRolloutStateToCRD saves the complete rollout state to a CRD including deduplication fields,
+// along with the status phase and conditions
+func (wr *WorkloadReconciler) saveFullRolloutStateToCRD(ctx context.Context, namespace, name, kind, version string, rolloutStarted time.Time, lastSentVersion, lastSentPhase string, conditions ...metav1.Condition) error {
 	log := ctrl.LoggerFrom(ctx)
 
-	stateName := fmt.Sprintf("%s-%s-%s", namespace, name, strings.ToLower(kind))
+	stateName := rolloutStateName(ctx, namespace, name, kind)
 	now := metav1.Now()
 	state := &apptrailv1alpha1.WorkloadRolloutState{
 		ObjectMeta: metav1.ObjectMeta{
@@ -393,50 +1067,129 @@ func (wr *WorkloadReconciler) saveFullRolloutStateToCRD(ctx context.Context, nam
 			LastSentPhase:     lastSentPhase,
 			LastSentAt:        &now,
 		},
+		Status: apptrailv1alpha1.WorkloadRolloutStateStatus{
+			Phase: lastSentPhase,
+		},
+	}
+	for _, condition := range conditions {
+		apimeta.SetStatusCondition(&state.Status.Conditions, condition)
 	}
 
-	// Try to create, if it exists, update it
-	err := wr.Create(ctx, state)
-	if err != nil {
-		if apierrors.IsAlreadyExists(err) {
-			// Update existing state
-			existingState := &apptrailv1alpha1.WorkloadRolloutState{}
-			err = wr.Get(ctx, types.NamespacedName{
-				Name:      stateName,
-				Namespace: wr.controllerNamespace,
-			}, existingState)
-			if err != nil {
-				return err
-			}
-
-			existingState.Spec = state.Spec
-			err = wr.Update(ctx, existingState)
-			if err != nil {
-				log.Error(err, "Failed to update rollout state", "stateName", stateName)
-				return err
-			}
-		} else {
-			log.Error(err, "Failed to create rollout state", "stateName", stateName)
-			return err
-		}
+	// Try to create, if it exists, update it. AlreadyExists is not retried: it means the
+	// state was stored before and is updated below.
+	err := retryStateOperation(ctx, "create", stateName, func() error {
+		return wr.Create(ctx, state)
+	})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		log.Error(err, "Failed to create rollout state", "stateName", stateName)
+		return err
 	}
 
+	err = updateStateOnConflict(ctx, stateName, func() error {
+		return wr.updateRolloutState(ctx, state, conditions)
+	})
+	if err != nil {
+		log.Error(err, "Failed to update rollout state", "stateName", stateName)
+		return err
+	}
 	return nil
 }
 
-// deleteRolloutStateFromCRD deletes the rollout state CRD
-func (wr *WorkloadReconciler) deleteRolloutStateFromCRD(ctx context.Context, namespace, name, kind string) error {
-	log := ctrl.LoggerFrom(ctx)
+// updateRolloutState reads the stored rollout state and overwrites it with state
+func (wr *WorkloadReconciler) updateRolloutState(ctx context.Context, state *apptrailv1alpha1.WorkloadRolloutState, conditions []metav1.Condition) error {
+	existingState := &apptrailv1alpha1.WorkloadRolloutState{}
+	err := wr.Get(ctx, types.NamespacedName{
+		Name:      state.Name,
+		Namespace: wr.controllerNamespace,
+	}, existingState)
+	if err != nil {
+		return err
+	}
 
-	stateName := fmt.Sprintf("%s-%s-%s", namespace, name, strings.ToLower(kind))
+	// The OnDelete marker is kept by saveOnDeleteNotified, not by the rollout tracking
+	onDeleteNotified := existingState.Spec.OnDeleteNotified
+	existingState.Spec = state.Spec
+	existingState.Spec.OnDeleteNotified = onDeleteNotified
+	existingState.Status.Phase = state.Status.Phase
+	// Keep transition times of conditions whose status did not change
+	for _, condition := range conditions {
+		apimeta.SetStatusCondition(&existingState.Status.Conditions, condition)
+	}
+	return wr.Update(ctx, existingState)
+}
+
+// saveOnDeleteNotified stores whether the workload was reported as using the OnDelete update
+// strategy, creating its rollout state when none exists yet
+func (wr *WorkloadReconciler) saveOnDeleteNotified(ctx context.Context, namespace, name, kind string, notified bool) error {
+	stateName := rolloutStateName(ctx, namespace, name, kind)
 	state := &apptrailv1alpha1.WorkloadRolloutState{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      stateName,
 			Namespace: wr.controllerNamespace,
 		},
+		Spec: apptrailv1alpha1.WorkloadRolloutStateSpec{
+			WorkloadNamespace: namespace,
+			WorkloadName:      name,
+			WorkloadKind:      kind,
+			OnDeleteNotified:  notified,
+		},
+	}
+
+	err := retryStateOperation(ctx, "create", stateName, func() error {
+		return wr.Create(ctx, state)
+	})
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return updateStateOnConflict(ctx, stateName, func() error {
+		existingState := &apptrailv1alpha1.WorkloadRolloutState{}
+		if err := wr.Get(ctx, types.NamespacedName{Name: stateName, Namespace: wr.controllerNamespace}, existingState); err != nil {
+			return err
+		}
+		if existingState.Spec.OnDeleteNotified == notified {
+			return nil
+		}
+		existingState.Spec.OnDeleteNotified = notified
+		return wr.Update(ctx, existingState)
+	})
+}
+
+// deleteRolloutStateFromCRD deletes the rollout state CRD once the workload no longer exists or
+// is no longer rolling out
+func (wr *WorkloadReconciler) deleteRolloutStateFromCRD(ctx context.Context, namespace, name, kind string) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	stateName := rolloutStateName(ctx, namespace, name, kind)
+	stateKey := types.NamespacedName{Name: stateName, Namespace: wr.controllerNamespace}
+	state := &apptrailv1alpha1.WorkloadRolloutState{}
+	err := retryStateOperation(ctx, "get", stateName, func() error {
+		return wr.Get(ctx, stateKey, state)
+	})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "Failed to load rollout state", "stateName", stateName)
+		return err
+	}
+
+	workload, err := wr.getWorkload(ctx, namespace, name, kind)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to verify workload before deleting rollout state: %w", err)
+	}
+	if workload != nil && workload.IsRollingOut() {
+		// Still rolling out (e.g. the delete raced a recreate); keep the timing state
+		log.Info("Workload still rolling out, keeping rollout state", "stateName", stateName)
+		return nil
 	}
 
-	err := wr.Delete(ctx, state)
+	err = retryStateOperation(ctx, "delete", stateName, func() error {
+		return wr.Delete(ctx, state)
+	})
 	if err != nil && !apierrors.IsNotFound(err) {
 		log.Error(err, "Failed to delete rollout state", "stateName", stateName)
 		return err
@@ -445,9 +1198,51 @@ func (wr *WorkloadReconciler) deleteRolloutStateFromCRD(ctx context.Context, nam
 	return nil
 }
 
-// HandleDeletion handles cleanup when a workload is deleted
+// HandleDeletion publishes a deletion event and cleans up state when a workload is deleted
 func (wr *WorkloadReconciler) HandleDeletion(ctx context.Context, namespace, name, kind string) error {
-	log := ctrl.LoggerFrom(ctx)
-	log.Info("Workload deleted, cleaning up state", "kind", kind, "namespace", namespace, "name", name)
+	log := ctrl.LoggerFrom(ctx).WithValues("kind", kind)
+	ctx = ctrl.LoggerInto(ctx, log)
+	log.Info("Workload deleted, cleaning up state")
+
+	appkey := namespace + "/" + name + "/" + kind
+
+	wr.mu.Lock()
+	stored, tracked := wr.workloadVersions[appkey]
+	delete(wr.workloadVersions, appkey)
+	delete(wr.workloadPhases, appkey)
+	delete(wr.daemonSetDesired, appkey)
+	delete(wr.daemonSetScaleUps, appkey)
+	delete(wr.replayPending, appkey)
+	// State queued for re-sync by ValidateState is unverified; trust the CRD instead
+	if _, ok := wr.resyncPending[appkey]; ok {
+		delete(wr.resyncPending, appkey)
+		tracked = false
+	}
+	wr.mu.Unlock()
+
+	// After a restart the last known version only lives in the CRD
+	lastVersion := stored.CurrentVersion
+	if !tracked || lastVersion == "" {
+		crdState, err := wr.loadFullRolloutStateFromCRD(ctx, namespace, name, kind)
+		if err != nil {
+			log.Error(err, "Failed to load rollout state from CRD")
+		}
+		lastVersion = crdState.LastSentVersion
+	}
+
+	deleteWorkloadMetrics(namespace, name, kind)
+
+	// Only workloads we reported on get a deletion event
+	if lastVersion != "" {
+		wr.publisherChan <- model.WorkloadUpdate{
+			Name:            name,
+			Namespace:       namespace,
+			Kind:            kind,
+			PreviousVersion: lastVersion,
+			CurrentVersion:  lastVersion,
+			DeploymentPhase: phaseDeletion,
+		}
+	}
+
 	return wr.deleteRolloutStateFromCRD(ctx, namespace, name, kind)
 }
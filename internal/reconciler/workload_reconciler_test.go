@@ -0,0 +1,1513 @@
+package reconciler
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	apptrailv1alpha1 "github.com/apptrail-sh/agent/api/v1alpha1"
+	"github.com/apptrail-sh/agent/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var dnsSubdomainRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+
+func TestSanitizeStateName(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		workload  string
+		kind      string
+		expected  string
+	}{
+		{
+			name:      "simple name",
+			namespace: "default",
+			workload:  "api",
+			kind:      "Deployment",
+			expected:  "default-api-deployment",
+		},
+		{
+			name:      "uppercase and invalid characters",
+			namespace: "Team_A",
+			workload:  "My:App",
+			kind:      "StatefulSet",
+			expected:  "team-a-my-app-statefulset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeStateName(tt.namespace, tt.workload, tt.kind); got != tt.expected {
+				t.Errorf("sanitizeStateName() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSanitizeStateName_Truncation(t *testing.T) {
+	namespace := strings.Repeat("n", 200)
+	workload := strings.Repeat("w", 100)
+
+	got := sanitizeStateName(namespace, workload, "Deployment")
+
+	if len(got) > maxStateNameLength {
+		t.Errorf("Expected name of at most %d characters, got %d", maxStateNameLength, len(got))
+	}
+	if !dnsSubdomainRegexp.MatchString(got) {
+		t.Errorf("Expected a valid Kubernetes name, got %q", got)
+	}
+	if !strings.HasPrefix(got, namespace) {
+		t.Errorf("Expected truncated name to keep the namespace prefix, got %q", got)
+	}
+
+	// Workloads differing only beyond the truncation point must not collide
+	other := sanitizeStateName(namespace, strings.Repeat("w", 99)+"x", "Deployment")
+	if got == other {
+		t.Errorf("Expected distinct names for distinct workloads, both got %q", got)
+	}
+
+	// Truncation must be deterministic across calls
+	if again := sanitizeStateName(namespace, workload, "Deployment"); again != got {
+		t.Errorf("Expected deterministic name, got %q and %q", got, again)
+	}
+}
+
+func TestDetermineWorkloadPhase_RolloutTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		elapsed     time.Duration
+		expected    string
+		invalid     bool
+	}{
+		{
+			name:     "global timeout not reached",
+			elapsed:  10 * time.Minute,
+			expected: phaseRollingOut,
+		},
+		{
+			name:     "global timeout exceeded",
+			elapsed:  20 * time.Minute,
+			expected: phaseFailed,
+		},
+		{
+			name:        "annotation extends timeout",
+			annotations: map[string]string{rolloutTimeoutAnnotation: "45m"},
+			elapsed:     20 * time.Minute,
+			expected:    phaseRollingOut,
+		},
+		{
+			name:        "annotation shortens timeout",
+			annotations: map[string]string{rolloutTimeoutAnnotation: "5m"},
+			elapsed:     10 * time.Minute,
+			expected:    phaseFailed,
+		},
+		{
+			name:        "malformed annotation falls back to global timeout",
+			annotations: map[string]string{rolloutTimeoutAnnotation: "forever"},
+			elapsed:     20 * time.Minute,
+			expected:    phaseFailed,
+			invalid:     true,
+		},
+		{
+			name:        "negative annotation falls back to global timeout",
+			annotations: map[string]string{rolloutTimeoutAnnotation: "-5m"},
+			elapsed:     10 * time.Minute,
+			expected:    phaseRollingOut,
+			invalid:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "api",
+					Namespace:   "default",
+					Annotations: tt.annotations,
+				},
+				Status: appsv1.DeploymentStatus{
+					Replicas:        3,
+					UpdatedReplicas: 1,
+					ReadyReplicas:   3,
+				},
+			}}
+
+			wr := &WorkloadReconciler{
+				workloadVersions: map[string]AppVersion{},
+				RolloutTimeout:   DefaultRolloutTimeout,
+			}
+			customTimeout, err := parseRolloutTimeout(workload)
+			if (err != nil) != tt.invalid {
+				t.Fatalf("parseRolloutTimeout() error = %v, want invalid %v", err, tt.invalid)
+			}
+			appkey := "default/api/Deployment"
+			wr.workloadVersions[appkey] = AppVersion{
+				RolloutStarted: time.Now().Add(-tt.elapsed),
+				CustomTimeout:  customTimeout,
+			}
+
+			if got := wr.determineWorkloadPhase(workload, appkey); got != tt.expected {
+				t.Errorf("determineWorkloadPhase() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandleDeletion_PublishesDeletionEvent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		stored      map[string]AppVersion
+		expectEvent bool
+	}{
+		{
+			name:        "tracked workload",
+			stored:      map[string]AppVersion{"default/api/Deployment": {CurrentVersion: "1.2.0"}},
+			expectEvent: true,
+		},
+		{
+			name:        "untracked workload",
+			stored:      map[string]AppVersion{},
+			expectEvent: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates := make(chan model.WorkloadUpdate, 1)
+			wr := &WorkloadReconciler{
+				Client:              fake.NewClientBuilder().WithScheme(scheme).Build(),
+				workloadVersions:    tt.stored,
+				workloadPhases:      map[string]string{"default/api/Deployment": phaseSuccess},
+				publisherChan:       updates,
+				controllerNamespace: "apptrail-system",
+			}
+
+			if err := wr.HandleDeletion(context.Background(), "default", "api", "Deployment"); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if !tt.expectEvent {
+				if len(updates) != 0 {
+					t.Fatalf("Expected no deletion event, got %+v", <-updates)
+				}
+				return
+			}
+
+			select {
+			case update := <-updates:
+				if update.DeploymentPhase != phaseDeletion {
+					t.Errorf("Expected phase %q, got %q", phaseDeletion, update.DeploymentPhase)
+				}
+				if update.Name != "api" || update.Namespace != "default" || update.Kind != "Deployment" {
+					t.Errorf("Unexpected workload in deletion event: %+v", update)
+				}
+				if update.CurrentVersion != "1.2.0" {
+					t.Errorf("Expected last known version 1.2.0, got %q", update.CurrentVersion)
+				}
+			default:
+				t.Fatal("Expected a deletion event")
+			}
+
+			if _, ok := wr.workloadVersions["default/api/Deployment"]; ok {
+				t.Error("Expected in-memory state to be cleared")
+			}
+		})
+	}
+}
+
+func TestWarmUp_RestoresStateFromCRDs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	newState := func(name, kind, version, phase string) *apptrailv1alpha1.WorkloadRolloutState {
+		return &apptrailv1alpha1.WorkloadRolloutState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      sanitizeStateName("default", name, kind),
+				Namespace: "apptrail-system",
+			},
+			Spec: apptrailv1alpha1.WorkloadRolloutStateSpec{
+				WorkloadNamespace: "default",
+				WorkloadName:      name,
+				WorkloadKind:      kind,
+				LastSentVersion:   version,
+				LastSentPhase:     phase,
+			},
+		}
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newState("api", "Deployment", "1.2.0", phaseSuccess),
+		newState("web", "Deployment", "", ""), // never sent, nothing to restore
+		newState("db", "StatefulSet", "15.1", phaseSuccess),
+	).Build()
+
+	wr := NewDeploymentReconciler(k8sClient, scheme, nil, nil, "apptrail-system", nil)
+	if err := wr.WarmUp(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(wr.workloadVersions) != 1 {
+		t.Fatalf("Expected 1 restored workload, got %d: %v", len(wr.workloadVersions), wr.workloadVersions)
+	}
+	if got := wr.workloadVersions["default/api/Deployment"].CurrentVersion; got != "1.2.0" {
+		t.Errorf("Expected restored version 1.2.0, got %q", got)
+	}
+	if got := wr.workloadPhases["default/api/Deployment"]; got != phaseSuccess {
+		t.Errorf("Expected restored phase %q, got %q", phaseSuccess, got)
+	}
+}
+
+func TestDetermineWorkloadPhase_StatefulSetOnDelete(t *testing.T) {
+	replicas := int32(3)
+	workload := &StatefulSetAdapter{StatefulSet: &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:       &replicas,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{
+			Replicas:        3,
+			UpdatedReplicas: 1,
+			ReadyReplicas:   2,
+		},
+	}}
+
+	wr := &WorkloadReconciler{
+		workloadVersions: map[string]AppVersion{},
+		RolloutTimeout:   DefaultRolloutTimeout,
+	}
+	appkey := "default/db/StatefulSet"
+
+	if workload.IsRollingOut() {
+		t.Error("Expected OnDelete StatefulSet to never report rolling out")
+	}
+	if got := wr.determineWorkloadPhase(workload, appkey); got == phaseRollingOut {
+		t.Errorf("determineWorkloadPhase() = %q, expected OnDelete StatefulSet not to be rolling out", got)
+	}
+
+	// The same replica counts with RollingUpdate are a rollout in progress
+	workload.StatefulSet.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	if got := wr.determineWorkloadPhase(workload, appkey); got != phaseRollingOut {
+		t.Errorf("determineWorkloadPhase() = %q, want %q", got, phaseRollingOut)
+	}
+}
+
+func TestStatefulSetReconciler_NotifiesOnDeleteOnce(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	adapter := &StatefulSetAdapter{StatefulSet: &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+	}}
+	newReconciler := func(events chan model.ResourceEventPayload) *StatefulSetReconciler {
+		sr := NewStatefulSetReconciler(k8sClient, scheme, nil, nil, "apptrail-system", nil)
+		sr.ResourceEventChan = events
+		sr.ClusterID = "cluster"
+		return sr
+	}
+
+	events := make(chan model.ResourceEventPayload, 10)
+	sr := newReconciler(events)
+	sr.notifyOnDeleteStrategy(context.Background(), adapter)
+	sr.notifyOnDeleteStrategy(context.Background(), adapter)
+	if len(events) != 1 {
+		t.Fatalf("Expected one event, got %d", len(events))
+	}
+	event := <-events
+	if event.EventKind != model.ResourceEventKindStatusChange || event.Resource.Kind != "StatefulSet" ||
+		event.Metadata["updateStrategy"] != "OnDelete" {
+		t.Errorf("Expected STATUS_CHANGE event for the OnDelete strategy, got %+v", event)
+	}
+
+	// A restarted agent reads the persisted marker instead of reporting again
+	restarted := newReconciler(events)
+	restarted.notifyOnDeleteStrategy(context.Background(), adapter)
+	if len(events) != 0 {
+		t.Fatalf("Expected no event after restart, got %d", len(events))
+	}
+
+	// Switching away from OnDelete and back reports it again
+	adapter.StatefulSet.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	restarted.notifyOnDeleteStrategy(context.Background(), adapter)
+	adapter.StatefulSet.Spec.UpdateStrategy.Type = appsv1.OnDeleteStatefulSetStrategyType
+	restarted.notifyOnDeleteStrategy(context.Background(), adapter)
+	if len(events) != 1 {
+		t.Errorf("Expected the strategy to be reported again, got %d events", len(events))
+	}
+}
+
+func TestValidateState_ResyncsStaleState(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	newDeployment := func(name string, updated int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: appsv1.DeploymentStatus{
+				Replicas:        3,
+				UpdatedReplicas: updated,
+				ReadyReplicas:   3,
+			},
+		}
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&apptrailv1alpha1.WorkloadRolloutState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      sanitizeStateName("default", "api", "Deployment"),
+				Namespace: "apptrail-system",
+			},
+			Spec: apptrailv1alpha1.WorkloadRolloutStateSpec{
+				WorkloadNamespace: "default",
+				WorkloadName:      "api",
+				WorkloadKind:      "Deployment",
+				LastSentVersion:   "1.2.0",
+				LastSentPhase:     phaseSuccess,
+			},
+		},
+		newDeployment("finished", 3),
+		newDeployment("rolling", 1),
+	).Build()
+
+	wr := NewDeploymentReconciler(k8sClient, scheme, nil, nil, "apptrail-system", nil)
+	// api was restored by WarmUp before another leader sent 1.2.0
+	wr.workloadVersions["default/api/Deployment"] = AppVersion{CurrentVersion: "1.1.0"}
+	wr.workloadPhases["default/api/Deployment"] = phaseRollingOut
+	for _, name := range []string{"finished", "rolling", "gone"} {
+		appkey := "default/" + name + "/Deployment"
+		wr.workloadVersions[appkey] = AppVersion{CurrentVersion: "2.0.0"}
+		wr.workloadPhases[appkey] = phaseRollingOut
+	}
+
+	// The controller would drain the channel
+	var queued []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range wr.replayEvents {
+			queued = append(queued, e.Object.GetNamespace()+"/"+e.Object.GetName())
+		}
+	}()
+	err := wr.ValidateState(context.Background())
+	close(wr.replayEvents)
+	<-done
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// The rollout still in progress is left alone
+	slices.Sort(queued)
+	if want := []string{"default/api", "default/finished", "default/gone"}; !slices.Equal(queued, want) {
+		t.Fatalf("Expected %v to be queued, got %v", want, queued)
+	}
+	// Validation leaves the state to the reconciles
+	if got := wr.workloadVersions["default/api/Deployment"].CurrentVersion; got != "1.1.0" {
+		t.Errorf("Expected state unchanged until reconcile, got version %q", got)
+	}
+
+	for _, name := range []string{"api", "finished", "rolling"} {
+		wr.takeResync("default/" + name + "/Deployment")
+	}
+
+	// Stale state replaced with the CRD's
+	if got := wr.workloadVersions["default/api/Deployment"].CurrentVersion; got != "1.2.0" {
+		t.Errorf("Expected version re-synced to 1.2.0, got %q", got)
+	}
+	if got := wr.workloadPhases["default/api/Deployment"]; got != phaseSuccess {
+		t.Errorf("Expected phase re-synced to %q, got %q", phaseSuccess, got)
+	}
+	// Live rollout finished: stale phase forgotten, version kept
+	if _, ok := wr.workloadPhases["default/finished/Deployment"]; ok {
+		t.Error("Expected stale rolling_out phase to be dropped for finished workload")
+	}
+	if _, ok := wr.workloadVersions["default/finished/Deployment"]; !ok {
+		t.Error("Expected version of finished workload to be kept")
+	}
+	// Live rollout still in progress: untouched
+	if got := wr.workloadPhases["default/rolling/Deployment"]; got != phaseRollingOut {
+		t.Errorf("Expected rolling workload to stay %q, got %q", phaseRollingOut, got)
+	}
+
+	// The gone workload's reconcile finds it deleted; its unverified state publishes nothing
+	updates := make(chan model.WorkloadUpdate, 1)
+	wr.publisherChan = updates
+	if err := wr.HandleDeletion(context.Background(), "default", "gone", "Deployment"); err != nil {
+		t.Fatalf("HandleDeletion() error = %v", err)
+	}
+	if _, ok := wr.workloadVersions["default/gone/Deployment"]; ok || len(updates) != 0 {
+		t.Errorf("Expected state of deleted workload dropped without an event, got %d events", len(updates))
+	}
+}
+
+func TestValidateState_CleansUpVersionMetric(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	const namespace = "metrics-cleanup"
+	newState := func(name, version string) *apptrailv1alpha1.WorkloadRolloutState {
+		return &apptrailv1alpha1.WorkloadRolloutState{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      sanitizeStateName(namespace, name, "Deployment"),
+				Namespace: "apptrail-system",
+			},
+			Spec: apptrailv1alpha1.WorkloadRolloutStateSpec{
+				WorkloadNamespace: namespace,
+				WorkloadName:      name,
+				WorkloadKind:      "Deployment",
+				LastSentVersion:   version,
+				LastSentPhase:     phaseSuccess,
+			},
+		}
+	}
+	versionSeries := func(name string) []string {
+		ch := make(chan prometheus.Metric, 16)
+		appVersionGauge.Collect(ch)
+		close(ch)
+		var versions []string
+		for m := range ch {
+			metric := &dto.Metric{}
+			if err := m.Write(metric); err != nil {
+				t.Fatalf("Failed to read metric: %v", err)
+			}
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["namespace"] == namespace && labels["workload"] == name {
+				versions = append(versions, labels["current_version"])
+			}
+		}
+		return versions
+	}
+
+	api := newState("api", "1.1.0")
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		api,
+		newState("removed", "3.0.0"), // deleted while no agent was running
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace}},
+	).Build()
+
+	wr := NewDeploymentReconciler(k8sClient, scheme, nil, nil, "apptrail-system", nil)
+	ctx := context.Background()
+	if err := wr.WarmUp(ctx); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+
+	// Another leader sent 1.2.0 for api after the warm-up
+	api.Spec.LastSentVersion = "1.2.0"
+	if err := k8sClient.Update(ctx, api); err != nil {
+		t.Fatalf("Failed to update rollout state: %v", err)
+	}
+
+	var queued []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range wr.replayEvents {
+			queued = append(queued, e.Object.GetName())
+		}
+	}()
+	err := wr.ValidateState(ctx)
+	close(wr.replayEvents)
+	<-done
+	if err != nil {
+		t.Fatalf("ValidateState() error = %v", err)
+	}
+	slices.Sort(queued)
+	if want := []string{"api", "removed"}; !slices.Equal(queued, want) {
+		t.Fatalf("Expected %v to be queued, got %v", want, queued)
+	}
+
+	// The re-sync replaces the restored series instead of adding one
+	wr.takeResync(namespace + "/api/Deployment")
+	if got := versionSeries("api"); !slices.Equal(got, []string{"1.2.0"}) {
+		t.Errorf("Expected a single series for version 1.2.0, got %v", got)
+	}
+
+	// The reconcile of the removed workload finds it gone and drops its series
+	wr.publisherChan = make(chan model.WorkloadUpdate, 1)
+	if err := wr.HandleDeletion(ctx, namespace, "removed", "Deployment"); err != nil {
+		t.Fatalf("HandleDeletion() error = %v", err)
+	}
+	if got := versionSeries("removed"); len(got) != 0 {
+		t.Errorf("Expected series of removed workload to be deleted, got %v", got)
+	}
+}
+
+func TestRecordRolloutOutcome(t *testing.T) {
+	failedCondition := []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentProgressing,
+		Status: "False",
+		Reason: "ProgressDeadlineExceeded",
+	}}
+
+	tests := []struct {
+		name       string
+		phase      string
+		conditions []appsv1.DeploymentCondition
+		outcome    string
+	}{
+		{name: "success", phase: phaseSuccess, outcome: "success"},
+		{name: "kubernetes failure", phase: phaseFailed, conditions: failedCondition, outcome: "failed"},
+		{name: "timeout", phase: phaseFailed, outcome: "timed_out"},
+		{name: "non-terminal phase", phase: phaseRollingOut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := "outcome-" + strings.ReplaceAll(tt.name, " ", "-")
+			workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace},
+				Status:     appsv1.DeploymentStatus{Conditions: tt.conditions},
+			}}
+
+			recordRolloutOutcome(workload, tt.phase)
+
+			for _, outcome := range []string{"success", "failed", "timed_out"} {
+				expected := 0.0
+				if outcome == tt.outcome {
+					expected = 1
+				}
+				got := testutil.ToFloat64(rolloutOutcomesCounter.WithLabelValues(namespace, "Deployment", outcome))
+				if got != expected {
+					t.Errorf("outcome %q = %v, want %v", outcome, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRolloutStateLifecycle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	ctx := context.Background()
+
+	rolling := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rolling", Namespace: "default"},
+		Status:     appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 1, ReadyReplicas: 3},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rolling).Build()
+	wr := NewDeploymentReconciler(k8sClient, scheme, nil, nil, "apptrail-system", nil)
+
+	getState := func(name string) (*apptrailv1alpha1.WorkloadRolloutState, error) {
+		state := &apptrailv1alpha1.WorkloadRolloutState{}
+		err := k8sClient.Get(ctx, types.NamespacedName{
+			Name:      sanitizeStateName("default", name, "Deployment"),
+			Namespace: "apptrail-system",
+		}, state)
+		return state, err
+	}
+
+	for _, name := range []string{"rolling", "gone"} {
+		if err := wr.saveFullRolloutStateToCRD(ctx, "default", name, "Deployment", "1.0.0", time.Now(), "1.0.0", phaseRollingOut); err != nil {
+			t.Fatalf("Failed to save rollout state: %v", err)
+		}
+		state, err := getState(name)
+		if err != nil {
+			t.Fatalf("Failed to get rollout state: %v", err)
+		}
+		if len(state.Finalizers) != 0 {
+			t.Errorf("Expected no finalizer on %s rollout state, got %v", name, state.Finalizers)
+		}
+	}
+
+	// Workload still rolling out: state is kept
+	if err := wr.deleteRolloutStateFromCRD(ctx, "default", "rolling", "Deployment"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := getState("rolling"); err != nil {
+		t.Errorf("Expected rollout state of rolling workload to be kept, got: %v", err)
+	}
+
+	// Workload gone: state deleted
+	if err := wr.deleteRolloutStateFromCRD(ctx, "default", "gone", "Deployment"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if _, err := getState("gone"); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected rollout state of deleted workload to be removed, got: %v", err)
+	}
+}
+
+func TestDetermineWorkloadPhase_ArgoManaged(t *testing.T) {
+	zero, three := int32(0), int32(3)
+
+	tests := []struct {
+		name        string
+		replicas    *int32
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			name:        "scaled to zero by Argo Rollouts",
+			replicas:    &zero,
+			annotations: map[string]string{argoManagedByAnnotation: "rollout-api"},
+			expected:    phaseArgoManaged,
+		},
+		{
+			name:     "scaled to zero without Argo",
+			replicas: &zero,
+			expected: phaseSuccess,
+		},
+		{
+			name:        "annotated but still running replicas",
+			replicas:    &three,
+			annotations: map[string]string{argoManagedByAnnotation: "rollout-api"},
+			expected:    phaseSuccess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := appsv1.DeploymentStatus{}
+			if *tt.replicas > 0 {
+				status = appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3}
+			}
+			workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", Annotations: tt.annotations},
+				Spec:       appsv1.DeploymentSpec{Replicas: tt.replicas},
+				Status:     status,
+			}}
+
+			wr := &WorkloadReconciler{workloadVersions: map[string]AppVersion{}}
+			if got := wr.determineWorkloadPhase(workload, "default/api/Deployment"); got != tt.expected {
+				t.Errorf("determineWorkloadPhase() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDaemonSetAdapter_UpdateStrategy(t *testing.T) {
+	tests := []struct {
+		name          string
+		strategy      appsv1.DaemonSetUpdateStrategyType
+		expectManaged bool
+		expectRolling bool
+		expectedPhase string
+	}{
+		{
+			name:          "rolling update",
+			strategy:      appsv1.RollingUpdateDaemonSetStrategyType,
+			expectManaged: true,
+			expectRolling: true,
+			expectedPhase: phaseRollingOut,
+		},
+		{
+			name:          "on delete",
+			strategy:      appsv1.OnDeleteDaemonSetStrategyType,
+			expectManaged: false,
+			expectRolling: false,
+			expectedPhase: phaseProgressing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workload := &DaemonSetAdapter{DaemonSet: &appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "kube-system"},
+				Spec: appsv1.DaemonSetSpec{
+					UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: tt.strategy},
+				},
+				Status: appsv1.DaemonSetStatus{
+					DesiredNumberScheduled: 3,
+					UpdatedNumberScheduled: 1,
+					NumberReady:            3,
+				},
+			}}
+
+			if got := workload.IsManagedRollout(); got != tt.expectManaged {
+				t.Errorf("IsManagedRollout() = %v, want %v", got, tt.expectManaged)
+			}
+			if got := workload.IsRollingOut(); got != tt.expectRolling {
+				t.Errorf("IsRollingOut() = %v, want %v", got, tt.expectRolling)
+			}
+
+			wr := &WorkloadReconciler{
+				workloadVersions: map[string]AppVersion{},
+				RolloutTimeout:   DefaultRolloutTimeout,
+			}
+			if got := wr.determineWorkloadPhase(workload, "kube-system/agent/DaemonSet"); got != tt.expectedPhase {
+				t.Errorf("determineWorkloadPhase() = %q, want %q", got, tt.expectedPhase)
+			}
+		})
+	}
+}
+
+func TestReconcileWorkload_SkipsRolloutTimerForOnDelete(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	workload := &DaemonSetAdapter{DaemonSet: &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "agent",
+			Namespace: "kube-system",
+			Labels:    map[string]string{"app.kubernetes.io/version": "1.1.0"},
+		},
+		Spec: appsv1.DaemonSetSpec{
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType},
+		},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 1,
+			NumberReady:            3,
+		},
+	}}
+
+	appkey := "kube-system/agent/DaemonSet"
+	wr := NewWorkloadReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil,
+		make(chan model.WorkloadUpdate, 10), "apptrail-system", nil)
+	wr.kind = "DaemonSet"
+	// A timer left over from before the strategy switched to OnDelete
+	wr.workloadVersions[appkey] = AppVersion{
+		CurrentVersion: "1.0.0",
+		RolloutStarted: time.Now().Add(-time.Hour),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "agent"}}
+	result, err := wr.ReconcileWorkload(context.Background(), req, workload)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if started := wr.workloadVersions[appkey].RolloutStarted; !started.IsZero() {
+		t.Errorf("Expected no rollout timer for OnDelete DaemonSet, got %v", started)
+	}
+	if phase := wr.workloadPhases[appkey]; phase == phaseRollingOut || phase == phaseFailed {
+		t.Errorf("Expected OnDelete DaemonSet not to be rolling out or failed, got %q", phase)
+	}
+	if result.RequeueAfter != 0 {
+		t.Errorf("Expected no rollout requeue, got %v", result.RequeueAfter)
+	}
+}
+
+func TestReconcileWorkload_ClearsPersistedTimerForOnDelete(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	ctx := context.Background()
+
+	workload := &DaemonSetAdapter{DaemonSet: &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "agent",
+			Namespace: "kube-system",
+			Labels:    map[string]string{"app.kubernetes.io/version": "1.1.0"},
+		},
+		Spec: appsv1.DaemonSetSpec{
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType},
+		},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 1,
+			NumberReady:            3,
+		},
+	}}
+
+	appkey := "kube-system/agent/DaemonSet"
+	updates := make(chan model.WorkloadUpdate, 10)
+	wr := NewWorkloadReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil,
+		updates, "apptrail-system", nil)
+	wr.kind = "DaemonSet"
+
+	// A timer persisted before the strategy switched to OnDelete, with the phase already reported
+	started := time.Now().Add(-time.Hour)
+	wr.workloadVersions[appkey] = AppVersion{CurrentVersion: "1.1.0", RolloutStarted: started}
+	phase := wr.determineWorkloadPhase(workload, appkey)
+	wr.workloadPhases[appkey] = phase
+	if err := wr.saveFullRolloutStateToCRD(ctx, "kube-system", "agent", "DaemonSet", "1.1.0", started, "1.1.0", phase); err != nil {
+		t.Fatalf("Failed to save rollout state: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "kube-system", Name: "agent"}}
+	if _, err := wr.ReconcileWorkload(ctx, req, workload); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("Expected no event for an unchanged phase, got %d", len(updates))
+	}
+
+	if started := wr.workloadVersions[appkey].RolloutStarted; !started.IsZero() {
+		t.Errorf("Expected the in-memory timer to be cleared, got %v", started)
+	}
+	state, err := wr.loadFullRolloutStateFromCRD(ctx, "kube-system", "agent", "DaemonSet")
+	if err != nil {
+		t.Fatalf("Failed to load rollout state: %v", err)
+	}
+	if !state.RolloutStarted.IsZero() {
+		t.Errorf("Expected the persisted timer to be cleared, got %v", state.RolloutStarted)
+	}
+}
+
+func TestReconcileWorkload_SkipsWorkloadWithoutVersion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+	}}
+
+	updates := make(chan model.WorkloadUpdate, 10)
+	wr := NewWorkloadReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil,
+		updates, "apptrail-system", nil)
+	wr.kind = "Deployment"
+
+	beforeErrors := testutil.ToFloat64(reconcileErrorsCounter.WithLabelValues("false"))
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "shop", Name: "api"}}
+	result, err := wr.ReconcileWorkload(context.Background(), req, workload)
+	if err != nil || result != (ctrl.Result{}) {
+		t.Fatalf("Expected the workload to be skipped, got %v, %v", result, err)
+	}
+	if got := testutil.ToFloat64(reconcileErrorsCounter.WithLabelValues("false")) - beforeErrors; got != 0 {
+		t.Errorf("Expected no reconcile error to be counted, got %v", got)
+	}
+	if len(updates) != 0 {
+		t.Errorf("Expected no workload update, got %d", len(updates))
+	}
+	if _, ok := wr.workloadVersions["shop/api/Deployment"]; ok {
+		t.Error("Expected no state for a workload without a version")
+	}
+}
+
+func TestStatefulSetAdapter_RevisionFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		status      appsv1.StatefulSetStatus
+		wantVersion string
+		wantLabels  map[string]string
+	}{
+		{
+			name:        "version label preferred over revision",
+			labels:      map[string]string{"app.kubernetes.io/version": "1.2.0"},
+			status:      appsv1.StatefulSetStatus{CurrentRevision: "db-5f8b", UpdateRevision: "db-7c9d"},
+			wantVersion: "1.2.0",
+			wantLabels: map[string]string{
+				"app.kubernetes.io/version":    "1.2.0",
+				"apptrail.sh/current-revision": "db-5f8b",
+				"apptrail.sh/update-revision":  "db-7c9d",
+			},
+		},
+		{
+			name:        "current revision without version label",
+			status:      appsv1.StatefulSetStatus{CurrentRevision: "db-5f8b", UpdateRevision: "db-5f8b"},
+			wantVersion: "db-5f8b",
+			wantLabels: map[string]string{
+				"apptrail.sh/current-revision": "db-5f8b",
+				"apptrail.sh/update-revision":  "db-5f8b",
+			},
+		},
+		{
+			name:        "no revisions reported yet",
+			labels:      map[string]string{"app.kubernetes.io/version": "1.2.0"},
+			wantVersion: "1.2.0",
+			wantLabels:  map[string]string{"app.kubernetes.io/version": "1.2.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workload := &StatefulSetAdapter{StatefulSet: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: tt.labels},
+				Status:     tt.status,
+			}}
+
+			if got := workload.GetVersion(); got != tt.wantVersion {
+				t.Errorf("GetVersion() = %q, want %q", got, tt.wantVersion)
+			}
+			if got := workloadLabels(workload); !maps.Equal(got, tt.wantLabels) {
+				t.Errorf("workloadLabels() = %v, want %v", got, tt.wantLabels)
+			}
+			if _, ok := workload.StatefulSet.Labels["apptrail.sh/current-revision"]; ok {
+				t.Error("Expected the StatefulSet's own labels not to be modified")
+			}
+		})
+	}
+}
+
+func TestWorkloadLabels_HelmRelease(t *testing.T) {
+	workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "default",
+			Labels:    map[string]string{"helm.sh/chart": "api-1.4.0"},
+			Annotations: map[string]string{
+				"meta.helm.sh/release-name":      "api-prod",
+				"meta.helm.sh/release-namespace": "releases",
+			},
+		},
+	}}
+
+	want := map[string]string{
+		"helm.sh/chart":                      "api-1.4.0",
+		"apptrail.sh/helm-release":           "api-prod",
+		"apptrail.sh/helm-release-namespace": "releases",
+	}
+	if got := workloadLabels(workload); !maps.Equal(got, want) {
+		t.Errorf("workloadLabels() = %v, want %v", got, want)
+	}
+	if _, ok := workload.Deployment.Labels["apptrail.sh/helm-release"]; ok {
+		t.Error("Expected the Deployment's own labels not to be modified")
+	}
+}
+
+func TestReconcileWorkload_DetectsRollback(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	replicas := int32(2)
+	workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "rollback",
+			Labels:    map[string]string{"app.kubernetes.io/version": "1.2.0"},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          2,
+			UpdatedReplicas:   2,
+			ReadyReplicas:     2,
+			AvailableReplicas: 2,
+		},
+	}}
+
+	updates := make(chan model.WorkloadUpdate, 10)
+	wr := NewWorkloadReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil,
+		updates, "apptrail-system", nil)
+	wr.kind = "Deployment"
+	wr.workloadVersions["rollback/api/Deployment"] = AppVersion{CurrentVersion: "1.3.0"}
+	wr.workloadPhases["rollback/api/Deployment"] = phaseSuccess
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "rollback", Name: "api"}}
+	if _, err := wr.ReconcileWorkload(context.Background(), req, workload); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	select {
+	case update := <-updates:
+		if !update.IsRollback {
+			t.Errorf("Expected rollback from 1.3.0 to 1.2.0, got %+v", update)
+		}
+	default:
+		t.Fatal("Expected a version update event")
+	}
+	if got := testutil.ToFloat64(rollbacksCounter.WithLabelValues("rollback", "Deployment")); got != 1 {
+		t.Errorf("Expected 1 rollback counted, got %v", got)
+	}
+}
+
+func TestReconcileWorkload_DaemonSetNodeScaleUp(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	daemonSet := func(version string, desired, updated int32) *DaemonSetAdapter {
+		return &DaemonSetAdapter{DaemonSet: &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "node-exporter",
+				Namespace: "monitoring",
+				Labels:    map[string]string{"app.kubernetes.io/version": version},
+			},
+			Status: appsv1.DaemonSetStatus{
+				DesiredNumberScheduled: desired,
+				UpdatedNumberScheduled: updated,
+				NumberReady:            updated,
+			},
+		}}
+	}
+
+	appkey := "monitoring/node-exporter/DaemonSet"
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "monitoring", Name: "node-exporter"}}
+	wr := NewWorkloadReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil,
+		make(chan model.WorkloadUpdate, 10), "apptrail-system", nil)
+	wr.kind = "DaemonSet"
+
+	reconcile := func(workload *DaemonSetAdapter) {
+		t.Helper()
+		if _, err := wr.ReconcileWorkload(context.Background(), req, workload); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	reconcile(daemonSet("1.0.0", 3, 3))
+
+	// Two nodes join, pods for them are not scheduled yet
+	reconcile(daemonSet("1.0.0", 5, 3))
+	if phase := wr.workloadPhases[appkey]; phase != phaseRollingOut {
+		t.Fatalf("Expected phase %q, got %q", phaseRollingOut, phase)
+	}
+	if started := wr.workloadVersions[appkey].RolloutStarted; !started.IsZero() {
+		t.Errorf("Expected no rollout timer for node scale-up, got %v", started)
+	}
+
+	// A new version rolling out during the scale-up window still starts the timer
+	reconcile(daemonSet("1.1.0", 5, 1))
+	if started := wr.workloadVersions[appkey].RolloutStarted; started.IsZero() {
+		t.Error("Expected rollout timer for a new version")
+	}
+}
+
+func TestIsNodeScaleLag_ThresholdExpired(t *testing.T) {
+	wr := &WorkloadReconciler{
+		daemonSetDesired:   map[string]int32{"monitoring/node-exporter/DaemonSet": 5},
+		daemonSetScaleUps:  map[string]nodeScaleUp{"monitoring/node-exporter/DaemonSet": {previousDesired: 3, at: time.Now().Add(-10 * time.Minute)}},
+		nodeScaleThreshold: defaultNodeScaleThreshold,
+	}
+	workload := &DaemonSetAdapter{DaemonSet: &appsv1.DaemonSet{
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 5, UpdatedNumberScheduled: 3},
+	}}
+
+	if wr.isNodeScaleLag(workload, "monitoring/node-exporter/DaemonSet") {
+		t.Error("Expected lag past the node scale threshold to count as a rollout")
+	}
+	if _, ok := wr.daemonSetScaleUps["monitoring/node-exporter/DaemonSet"]; ok {
+		t.Error("Expected expired scale-up to be cleared")
+	}
+}
+
+func TestPassthroughAnnotations(t *testing.T) {
+	workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "api",
+			Annotations: map[string]string{
+				"argocd.argoproj.io/app-name":       "api-prod",
+				"kustomize.toolkit.fluxcd.io/name":  "apps",
+				"deployment.kubernetes.io/revision": "4",
+				"apptrail.sh/rollout-timeout":       "30m",
+			},
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		prefixes []string
+		want     map[string]string
+	}{
+		{name: "no prefixes", want: nil},
+		{name: "no match", prefixes: []string{"example.com/"}, want: nil},
+		{
+			name:     "argocd and flux",
+			prefixes: []string{"argocd.argoproj.io/", "kustomize.toolkit.fluxcd.io/"},
+			want: map[string]string{
+				"argocd.argoproj.io/app-name":      "api-prod",
+				"kustomize.toolkit.fluxcd.io/name": "apps",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wr := &WorkloadReconciler{PassthroughAnnotationPrefixes: tt.prefixes}
+			if got := wr.passthroughAnnotations(workload); !maps.Equal(got, tt.want) {
+				t.Errorf("passthroughAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVirtualMachineAdapter_Phase(t *testing.T) {
+	virtualMachine := func(printableStatus, ready string) *VirtualMachineAdapter {
+		obj := NewVirtualMachineObject()
+		obj.SetName("db-vm")
+		obj.SetNamespace("vms")
+		obj.SetLabels(map[string]string{"app.kubernetes.io/version": "2.1.0"})
+		status := map[string]any{"printableStatus": printableStatus}
+		if ready != "" {
+			status["conditions"] = []any{
+				map[string]any{"type": "Ready", "status": ready},
+			}
+		}
+		obj.Object["status"] = status
+		return &VirtualMachineAdapter{VirtualMachine: obj}
+	}
+
+	tests := []struct {
+		name          string
+		workload      *VirtualMachineAdapter
+		expectedPhase string
+	}{
+		{name: "running and ready", workload: virtualMachine("Running", "True"), expectedPhase: phaseSuccess},
+		{name: "migrating", workload: virtualMachine("Migrating", "True"), expectedPhase: phaseRollingOut},
+		{name: "not ready", workload: virtualMachine("Running", "False"), expectedPhase: phaseFailed},
+		{name: "crash looping", workload: virtualMachine("CrashLoopBackOff", "False"), expectedPhase: phaseFailed},
+		{name: "starting", workload: virtualMachine("Starting", "False"), expectedPhase: phaseProgressing},
+		{name: "stopped", workload: virtualMachine("Stopped", "False"), expectedPhase: phaseSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wr := &WorkloadReconciler{workloadVersions: map[string]AppVersion{}}
+			if got := wr.determineWorkloadPhase(tt.workload, "vms/db-vm/VirtualMachine"); got != tt.expectedPhase {
+				t.Errorf("determineWorkloadPhase() = %q, want %q", got, tt.expectedPhase)
+			}
+			if got := tt.workload.GetVersion(); got != "2.1.0" {
+				t.Errorf("GetVersion() = %q, want 2.1.0", got)
+			}
+		})
+	}
+}
+
+func TestReconcileWorkload_SourceLinks(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		sourceURL   string
+		ciRunURL    string
+	}{
+		{
+			name: "annotated",
+			annotations: map[string]string{
+				"apptrail.sh/source-url": "https://github.com/acme/api/commit/4f2a9c1",
+				"apptrail.sh/ci-run":     "https://github.com/acme/api/actions/runs/812",
+			},
+			sourceURL: "https://github.com/acme/api/commit/4f2a9c1",
+			ciRunURL:  "https://github.com/acme/api/actions/runs/812",
+		},
+		{name: "not annotated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "api",
+					Namespace:   "default",
+					Labels:      map[string]string{"app.kubernetes.io/version": "1.4.0"},
+					Annotations: tt.annotations,
+				},
+			}}
+
+			updates := make(chan model.WorkloadUpdate, 1)
+			wr := NewWorkloadReconciler(fake.NewClientBuilder().WithScheme(scheme).Build(), scheme, nil,
+				updates, "apptrail-system", nil)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "api"}}
+			if _, err := wr.ReconcileWorkload(context.Background(), req, workload); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			select {
+			case update := <-updates:
+				if update.SourceURL != tt.sourceURL {
+					t.Errorf("SourceURL = %q, want %q", update.SourceURL, tt.sourceURL)
+				}
+				if update.CIRunURL != tt.ciRunURL {
+					t.Errorf("CIRunURL = %q, want %q", update.CIRunURL, tt.ciRunURL)
+				}
+			default:
+				t.Fatal("Expected a version update event")
+			}
+		})
+	}
+}
+
+func TestReplay_RepublishesUnchangedState(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "default",
+			Labels:    map[string]string{"app.kubernetes.io/version": "1.0.0"},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          2,
+			UpdatedReplicas:   2,
+			ReadyReplicas:     2,
+			AvailableReplicas: 2,
+		},
+	}
+
+	updates := make(chan model.WorkloadUpdate, 10)
+	wr := NewWorkloadReconciler(fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build(), scheme, nil,
+		updates, "apptrail-system", nil)
+	wr.kind = "Deployment"
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "api"}}
+	reconcile := func() {
+		t.Helper()
+		if _, err := wr.ReconcileWorkload(ctx, req, &DeploymentAdapter{Deployment: deployment}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	reconcile()
+	<-updates
+	reconcile()
+	if len(updates) != 0 {
+		t.Fatalf("Expected no event for unchanged state, got %d", len(updates))
+	}
+
+	// Replay queues every deployment; the controller would drain the channel
+	var queued []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range wr.replayEvents {
+			queued = append(queued, e.Object.GetNamespace()+"/"+e.Object.GetName())
+		}
+	}()
+	n, err := wr.Replay(ctx)
+	close(wr.replayEvents)
+	<-done
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if n != 1 || len(queued) != 1 || queued[0] != "default/api" {
+		t.Fatalf("Expected default/api to be queued once, got %d %v", n, queued)
+	}
+
+	reconcile()
+	select {
+	case update := <-updates:
+		if update.CurrentVersion != "1.0.0" || update.DeploymentPhase != phaseSuccess {
+			t.Errorf("Expected replayed 1.0.0 success event, got %+v", update)
+		}
+	default:
+		t.Fatal("Expected the replayed state to be published")
+	}
+
+	// The replay mark is consumed by one reconcile
+	reconcile()
+	if len(updates) != 0 {
+		t.Errorf("Expected no event after the replay, got %d", len(updates))
+	}
+}
+
+func TestRecordPhaseEvent(t *testing.T) {
+	progressDeadlineExceeded := []appsv1.DeploymentCondition{
+		{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+	}
+
+	tests := []struct {
+		name       string
+		lastPhase  string
+		phase      string
+		conditions []appsv1.DeploymentCondition
+		want       string
+	}{
+		{name: "rollout started", lastPhase: phaseSuccess, phase: phaseRollingOut,
+			want: "Normal AppTrailRolloutStarted Deployment rollout started (version 1.2.0)"},
+		{name: "new workload rolling out", lastPhase: "", phase: phaseRollingOut,
+			want: "Normal AppTrailRolloutStarted Deployment rollout started (version 1.2.0)"},
+		{name: "rollout succeeded", lastPhase: phaseRollingOut, phase: phaseSuccess,
+			want: "Normal AppTrailRolloutSucceeded Deployment rollout succeeded (version 1.2.0)"},
+		{name: "rollout failed", lastPhase: phaseRollingOut, phase: phaseFailed, conditions: progressDeadlineExceeded,
+			want: "Warning AppTrailRolloutFailed Deployment rollout failed (version 1.2.0)"},
+		{name: "rollout timed out", lastPhase: phaseRollingOut, phase: phaseFailed,
+			want: "Warning AppTrailRolloutTimedOut Deployment rollout exceeded the rollout timeout (version 1.2.0)"},
+		{name: "first seen settled workload", lastPhase: "", phase: phaseSuccess},
+		{name: "other phase", lastPhase: phaseSuccess, phase: phaseScaling},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			wr := &WorkloadReconciler{Recorder: recorder}
+			workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+				Status:     appsv1.DeploymentStatus{Conditions: tt.conditions},
+			}}
+
+			wr.recordPhaseEvent(workload, tt.lastPhase, model.WorkloadUpdate{
+				Kind:            "Deployment",
+				CurrentVersion:  "1.2.0",
+				DeploymentPhase: tt.phase,
+			})
+
+			select {
+			case got := <-recorder.Events:
+				if got != tt.want {
+					t.Errorf("Expected event %q, got %q", tt.want, got)
+				}
+			default:
+				if tt.want != "" {
+					t.Errorf("Expected event %q, got none", tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordEvent_VersionChanged(t *testing.T) {
+	recorder := record.NewFakeRecorder(1)
+	wr := &WorkloadReconciler{Recorder: recorder}
+	workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+	}}
+
+	wr.recordEvent(workload, ReasonVersionChanged, model.WorkloadUpdate{
+		Kind:            "Deployment",
+		PreviousVersion: "1.1.0",
+		CurrentVersion:  "1.2.0",
+	})
+
+	want := "Normal AppTrailVersionChanged Deployment version changed from 1.1.0 to 1.2.0"
+	if got := <-recorder.Events; got != want {
+		t.Errorf("Expected event %q, got %q", want, got)
+	}
+}
+
+func TestRolloutConditions(t *testing.T) {
+	wr := &WorkloadReconciler{RolloutTimeout: 10 * time.Minute}
+	rolling := &DeploymentAdapter{Deployment: &appsv1.Deployment{}}
+	failing := &DeploymentAdapter{Deployment: &appsv1.Deployment{
+		Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentProgressing,
+			Status: corev1.ConditionFalse,
+			Reason: "ProgressDeadlineExceeded",
+		}}},
+	}}
+
+	tests := []struct {
+		name           string
+		workload       WorkloadAdapter
+		phase          string
+		elapsed        time.Duration
+		timedOutReason string
+		failedReason   string
+	}{
+		{"rolling out early", rolling, phaseRollingOut, time.Minute, "WithinTimeout", "NotFailed"},
+		{"rolling out near timeout", rolling, phaseRollingOut, 9 * time.Minute, "TimeoutApproaching", "NotFailed"},
+		{"timed out", rolling, phaseFailed, 0, "TimeoutExceeded", "TimeoutExceeded"},
+		{"failed by kubernetes", failing, phaseFailed, 0, "WithinTimeout", "WorkloadFailed"},
+		{"succeeded", rolling, phaseSuccess, 0, "WithinTimeout", "NotFailed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := AppVersion{}
+			if tt.elapsed > 0 {
+				stored.RolloutStarted = time.Now().Add(-tt.elapsed)
+			}
+			conditions := wr.rolloutConditions(tt.workload, tt.phase, stored)
+
+			timedOut := apimeta.FindStatusCondition(conditions, apptrailv1alpha1.ConditionRolloutTimedOut)
+			failed := apimeta.FindStatusCondition(conditions, apptrailv1alpha1.ConditionRolloutFailed)
+			if timedOut == nil || failed == nil {
+				t.Fatalf("Expected both conditions, got %v", conditions)
+			}
+			if timedOut.Reason != tt.timedOutReason {
+				t.Errorf("Expected RolloutTimedOut reason %s, got %s", tt.timedOutReason, timedOut.Reason)
+			}
+			if failed.Reason != tt.failedReason {
+				t.Errorf("Expected RolloutFailed reason %s, got %s", tt.failedReason, failed.Reason)
+			}
+			if (failed.Status == metav1.ConditionTrue) != (tt.phase == phaseFailed) {
+				t.Errorf("Expected RolloutFailed to be True only for failed rollouts, got %s", failed.Status)
+			}
+			if got := timeoutApproaching(conditions); got != (tt.timedOutReason == "TimeoutApproaching") {
+				t.Errorf("timeoutApproaching() = %v", got)
+			}
+		})
+	}
+}
+
+func TestSaveFullRolloutStateToCRD_Conditions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := apptrailv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	ctx := context.Background()
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	wr := NewWorkloadReconciler(k8sClient, scheme, nil, nil, "apptrail-system", nil)
+	workload := &DeploymentAdapter{Deployment: &appsv1.Deployment{}}
+
+	save := func(phase string) *apptrailv1alpha1.WorkloadRolloutState {
+		t.Helper()
+		conditions := wr.rolloutConditions(workload, phase, AppVersion{})
+		if err := wr.saveFullRolloutStateToCRD(ctx, "default", "api", "Deployment", "1.0.0", time.Time{}, "1.0.0", phase, conditions...); err != nil {
+			t.Fatalf("Failed to save rollout state: %v", err)
+		}
+		state := &apptrailv1alpha1.WorkloadRolloutState{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{
+			Name:      sanitizeStateName("default", "api", "Deployment"),
+			Namespace: "apptrail-system",
+		}, state); err != nil {
+			t.Fatalf("Failed to get rollout state: %v", err)
+		}
+		return state
+	}
+
+	state := save(phaseRollingOut)
+	if state.Status.Phase != phaseRollingOut {
+		t.Errorf("Expected phase %s, got %s", phaseRollingOut, state.Status.Phase)
+	}
+	if !apimeta.IsStatusConditionFalse(state.Status.Conditions, apptrailv1alpha1.ConditionRolloutFailed) {
+		t.Errorf("Expected RolloutFailed to be False, got %v", state.Status.Conditions)
+	}
+
+	state = save(phaseFailed)
+	if state.Status.Phase != phaseFailed {
+		t.Errorf("Expected phase %s, got %s", phaseFailed, state.Status.Phase)
+	}
+	if !apimeta.IsStatusConditionTrue(state.Status.Conditions, apptrailv1alpha1.ConditionRolloutFailed) ||
+		!apimeta.IsStatusConditionTrue(state.Status.Conditions, apptrailv1alpha1.ConditionRolloutTimedOut) {
+		t.Errorf("Expected RolloutFailed and RolloutTimedOut to be True, got %v", state.Status.Conditions)
+	}
+}
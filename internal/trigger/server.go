@@ -0,0 +1,194 @@
+package trigger
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// ReconcilePath is the endpoint that triggers a full reconcile
+	ReconcilePath = "/api/v1/reconcile"
+
+	// RecentEventsPath is the endpoint listing recently published workload events
+	RecentEventsPath = "/api/v1/events/recent"
+
+	// DebugQueuesPath is the endpoint listing the most-retried workloads and their backoff
+	DebugQueuesPath = "/debug/queues"
+
+	// TokenHeader carries the shared secret configured with --trigger-token
+	TokenHeader = "X-Trigger-Token"
+
+	// minTriggerInterval is the minimum time between two full reconciles
+	minTriggerInterval = time.Minute
+
+	// shutdownTimeout bounds how long in-flight requests may take when the manager stops
+	shutdownTimeout = 5 * time.Second
+)
+
+// Replayer republishes the current state of every workload it tracks
+type Replayer interface {
+	Replay(ctx context.Context) (int, error)
+}
+
+// Server serves the full reconcile trigger. It runs on every replica, but only the elected
+// leader accepts triggers since the workload controllers only run there.
+type Server struct {
+	addr      string
+	token     string
+	elected   <-chan struct{}
+	replayers []Replayer
+	handlers  map[string]http.Handler
+
+	mu          sync.Mutex
+	lastTrigger time.Time
+	now         func() time.Time
+}
+
+// NewServer creates a trigger server listening on addr. Requests must carry token in the
+// X-Trigger-Token header; elected is closed once this replica becomes leader.
+func NewServer(addr, token string, elected <-chan struct{}, replayers ...Replayer) *Server {
+	return &Server{
+		addr:      addr,
+		token:     token,
+		elected:   elected,
+		replayers: replayers,
+		handlers:  make(map[string]http.Handler),
+		now:       time.Now,
+	}
+}
+
+// Handle serves an additional endpoint at path, protected by the same token as the trigger.
+// It must be called before the server starts.
+func (s *Server) Handle(path string, handler http.Handler) {
+	s.handlers[path] = handler
+}
+
+// Handler returns the HTTP handler serving the trigger endpoint and any additional endpoints
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ReconcilePath, s.handleReconcile)
+	for path, handler := range s.handlers {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if s.authorize(w, r) {
+				handler.ServeHTTP(w, r)
+			}
+		})
+	}
+	return mux
+}
+
+// authorize checks the request token, answering 401 and returning false if it doesn't match
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(TokenHeader)), []byte(s.token)) != 1 {
+		ctrl.LoggerFrom(r.Context()).Info("Rejected request with invalid token", "path", r.URL.Path, "remoteAddr", r.RemoteAddr)
+		http.Error(w, "invalid trigger token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleReconcile queues a reconcile of every workload so their current state is republished
+func (s *Server) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	logger := ctrl.LoggerFrom(r.Context()).WithValues("remoteAddr", r.RemoteAddr)
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+
+	select {
+	case <-s.elected:
+	default:
+		http.Error(w, "not the leader, workload controllers are not running on this replica", http.StatusServiceUnavailable)
+		return
+	}
+
+	if wait := s.reserve(); wait > 0 {
+		logger.Info("Rejected full reconcile trigger, rate limited", "retryAfter", wait)
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Round(time.Second).Seconds())))
+		http.Error(w, "a full reconcile was triggered less than a minute ago", http.StatusTooManyRequests)
+		return
+	}
+
+	logger.Info("Full reconcile triggered")
+	queued := 0
+	var errs []error
+	for _, replayer := range s.replayers {
+		n, err := replayer.Replay(r.Context())
+		queued += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		logger.Error(err, "Failed to queue all workloads for reconcile", "queued", queued)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info("Queued workloads for full reconcile", "queued", queued)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]int{"queued": queued})
+}
+
+// reserve records a trigger and returns zero, or how long to wait if the last one was too recent
+func (s *Server) reserve() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.lastTrigger.IsZero() {
+		if wait := minTriggerInterval - now.Sub(s.lastTrigger); wait > 0 {
+			return wait
+		}
+	}
+	s.lastTrigger = now
+	return 0
+}
+
+// Start serves the trigger endpoint until ctx is cancelled.
+// Implements manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	logger := ctrl.LoggerFrom(ctx).WithName("reconcile-trigger")
+
+	server := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctrl.LoggerInto(context.Background(), logger)
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("Serving full reconcile trigger", "addr", s.addr, "path", ReconcilePath)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection makes the manager run the server on every replica
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,136 @@
+package trigger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeReplayer struct {
+	calls int
+}
+
+func (f *fakeReplayer) Replay(context.Context) (int, error) {
+	f.calls++
+	return 2, nil
+}
+
+func TestServer_HandleReconcile(t *testing.T) {
+	elected := make(chan struct{})
+	close(elected)
+
+	tests := []struct {
+		name       string
+		method     string
+		token      string
+		elected    <-chan struct{}
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "triggers replay", method: http.MethodPost, token: "s3cret", elected: elected,
+			wantStatus: http.StatusAccepted, wantCalls: 1},
+		{name: "wrong token", method: http.MethodPost, token: "guess", elected: elected,
+			wantStatus: http.StatusUnauthorized},
+		{name: "missing token", method: http.MethodPost, elected: elected,
+			wantStatus: http.StatusUnauthorized},
+		{name: "GET not allowed", method: http.MethodGet, token: "s3cret", elected: elected,
+			wantStatus: http.StatusMethodNotAllowed},
+		{name: "not leader", method: http.MethodPost, token: "s3cret", elected: make(chan struct{}),
+			wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayer := &fakeReplayer{}
+			server := NewServer(":0", "s3cret", tt.elected, replayer)
+
+			req := httptest.NewRequest(tt.method, ReconcilePath, nil)
+			if tt.token != "" {
+				req.Header.Set(TokenHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+			if replayer.calls != tt.wantCalls {
+				t.Errorf("Expected %d replays, got %d", tt.wantCalls, replayer.calls)
+			}
+			if tt.wantStatus == http.StatusAccepted && !strings.Contains(rec.Body.String(), `"queued":2`) {
+				t.Errorf("Expected queued count in response, got %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	elected := make(chan struct{})
+	close(elected)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	replayer := &fakeReplayer{}
+	server := NewServer(":0", "s3cret", elected, replayer)
+	server.now = func() time.Time { return now }
+
+	trigger := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, ReconcilePath, nil)
+		req.Header.Set(TokenHeader, "s3cret")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := trigger(); rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected first trigger to be accepted, got %d", rec.Code)
+	}
+
+	now = now.Add(20 * time.Second)
+	rec := trigger()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected second trigger within a minute to be rate limited, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "40" {
+		t.Errorf("Expected Retry-After 40, got %q", got)
+	}
+
+	now = now.Add(40 * time.Second)
+	if rec := trigger(); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected trigger after a minute to be accepted, got %d", rec.Code)
+	}
+	if replayer.calls != 2 {
+		t.Errorf("Expected 2 replays, got %d", replayer.calls)
+	}
+}
+
+func TestServer_HandleRequiresToken(t *testing.T) {
+	server := NewServer(":0", "s3cret", make(chan struct{}))
+	server.Handle(RecentEventsPath, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	}))
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "valid token", token: "s3cret", wantStatus: http.StatusOK},
+		{name: "wrong token", token: "guess", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, RecentEventsPath, nil)
+			req.Header.Set(TokenHeader, tt.token)
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}